@@ -0,0 +1,173 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// watchConns tracks the watch WebSocket connections of each session,
+// so an evicted session loses its watches.
+type watchConns struct {
+	mu    sync.Mutex
+	conns map[string]map[*watchSession]context.CancelFunc
+}
+
+var globalWatchConns = &watchConns{conns: make(map[string]map[*watchSession]context.CancelFunc)}
+
+func (wc *watchConns) add(id string, ws *watchSession, cancel context.CancelFunc) {
+	wc.mu.Lock()
+	if wc.conns[id] == nil {
+		wc.conns[id] = make(map[*watchSession]context.CancelFunc)
+	}
+	wc.conns[id][ws] = cancel
+	wc.mu.Unlock()
+}
+
+func (wc *watchConns) remove(id string, ws *watchSession) {
+	wc.mu.Lock()
+	delete(wc.conns[id], ws)
+	if len(wc.conns[id]) == 0 {
+		delete(wc.conns, id)
+	}
+	wc.mu.Unlock()
+}
+
+// count returns the number of connections and watches of the session.
+func (wc *watchConns) count(id string) (conns, watches int) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	for ws := range wc.conns[id] {
+		ws.mu.Lock()
+		watches += len(ws.cancels)
+		ws.mu.Unlock()
+	}
+	return len(wc.conns[id]), watches
+}
+
+// cancel cancels all watches of the session.
+func (wc *watchConns) cancel(id string) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	for _, cancel := range wc.conns[id] {
+		cancel()
+	}
+}
+
+// AdminSession describes an active visitor session.
+type AdminSession struct {
+	ID         string
+	User       string
+	Created    time.Time
+	LastActive time.Time
+	Age        string
+	Idle       string
+
+	Requests      int64
+	WatchConns    int
+	Watches       int
+	ClusterSize   int
+	ClusterActive int
+}
+
+// AdminSessionsResult contains the active sessions. All sessions share
+// the cluster of the backend, whose size and running nodes are included.
+type AdminSessionsResult struct {
+	Success  bool
+	Result   string
+	Sessions []AdminSession
+}
+
+// isAdminRequest returns true if the request comes from this host,
+// not through the reverse proxy, which sets the forwarding headers.
+func isAdminRequest(req *http.Request) bool {
+	if getRealIP(req) != "" || req.Header.Get("X-Real-IP") != "" {
+		return false
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// adminSessionsHandler lists the sessions (GET), or evicts the session
+// of the 'id' query (DELETE), canceling its watches.
+func adminSessionsHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	if !isAdminRequest(req) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		now := time.Now()
+		size, active := globalCluster.Size(), globalCluster.ActiveNodeN()
+
+		ss := globalSessions.Store().List()
+		aresp := AdminSessionsResult{Success: true, Result: fmt.Sprintf("%d sessions", len(ss))}
+		for _, s := range ss {
+			conns, watches := globalWatchConns.count(s.ID)
+			user := s.Get("user")
+			if len(user) > 8 {
+				user = maskUserID(user)
+			}
+			aresp.Sessions = append(aresp.Sessions, AdminSession{
+				ID:            s.ID,
+				User:          user,
+				Created:       s.Created,
+				LastActive:    s.LastActive(),
+				Age:           roundDownDuration(now.Sub(s.Created), time.Second).String(),
+				Idle:          roundDownDuration(now.Sub(s.LastActive()), time.Second).String(),
+				Requests:      s.Requests(),
+				WatchConns:    conns,
+				Watches:       watches,
+				ClusterSize:   size,
+				ClusterActive: active,
+			})
+		}
+		return json.NewEncoder(w).Encode(aresp)
+
+	case http.MethodDelete:
+		id := req.URL.Query().Get("id")
+		s, ok := globalSessions.Store().Get(id)
+		if !ok {
+			return json.NewEncoder(w).Encode(AdminSessionsResult{Success: false, Result: fmt.Sprintf("unknown session %q", id)})
+		}
+		globalWatchConns.cancel(id)
+		globalSessions.Delete(id)
+
+		globalUserCacheLock.Lock()
+		delete(globalUserCache, s.Get("user"))
+		globalUserCacheLock.Unlock()
+
+		glog.Infof("evicted session %q", id)
+		return json.NewEncoder(w).Encode(AdminSessionsResult{Success: true, Result: fmt.Sprintf("evicted session %q", id)})
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
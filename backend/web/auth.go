@@ -0,0 +1,167 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+
+	"github.com/golang/glog"
+)
+
+// AuthRequest defines auth playground requests.
+type AuthRequest struct {
+	// Action is one of 'enable', 'disable', 'user-add', 'user-delete', 'user-grant-role',
+	// 'role-add', 'role-grant-permission', 'authenticate', 'put-as', 'get-as', 'info',
+	// 'issue-client-cert', 'revoke-client-cert'.
+	Action   string
+	Endpoint string
+
+	User     string
+	Password string // never echoed back
+	Role     string
+
+	Permission cluster.Permission // 'role-grant-permission'
+	Prefix     bool               // 'role-grant-permission', 'get-as'
+
+	Key   string // 'put-as', 'get-as'
+	Value string // 'put-as'
+}
+
+// AuthResult contains the auth playground response.
+type AuthResult struct {
+	AuthRequest AuthRequest
+	Success     bool
+	Result      string
+	Info        cluster.AuthInfo
+	Response    cluster.KVResponse
+}
+
+// adminAuthActions change the auth state of the cluster, which is shared
+// by all visitors, so they are only allowed from this host (see isAdminRequest).
+var adminAuthActions = map[string]bool{
+	"enable":                true,
+	"disable":               true,
+	"user-add":              true,
+	"user-delete":           true,
+	"user-grant-role":       true,
+	"role-add":              true,
+	"role-grant-permission": true,
+	"issue-client-cert":     true,
+	"revoke-client-cert":    true,
+}
+
+// authHandler handles auth enable/disable, users, roles, permissions and authenticated requests.
+func authHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodPost:
+		aresp := AuthResult{Success: true}
+		defer func() {
+			glog.Info(aresp.Result)
+		}()
+		if rmsg, ok := globalClientRequestLimiter.Check(); !ok {
+			aresp.Success = false
+			aresp.Result = "auth request " + rmsg
+			return json.NewEncoder(w).Encode(aresp)
+		}
+		globalClientRequestLimiter.Advance()
+
+		areq := AuthRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&areq); err != nil {
+			aresp.Success = false
+			aresp.Result = err.Error()
+			return json.NewEncoder(w).Encode(aresp)
+		}
+		defer req.Body.Close()
+
+		password := areq.Password
+		areq.Password = ""
+		aresp.AuthRequest = areq
+		aresp.AuthRequest.User = template.HTMLEscapeString(areq.User)
+		aresp.AuthRequest.Role = template.HTMLEscapeString(areq.Role)
+		aresp.AuthRequest.Permission.Key = template.HTMLEscapeString(areq.Permission.Key)
+		aresp.AuthRequest.Permission.RangeEnd = template.HTMLEscapeString(areq.Permission.RangeEnd)
+		aresp.AuthRequest.Key = template.HTMLEscapeString(areq.Key)
+		aresp.AuthRequest.Value = template.HTMLEscapeString(areq.Value)
+
+		if adminAuthActions[areq.Action] && !isAdminRequest(req) {
+			aresp.Success = false
+			aresp.Result = fmt.Sprintf("'%s' is only allowed to the administrator", areq.Action)
+			return json.NewEncoder(w).Encode(aresp)
+		}
+
+		idx := globalCluster.FindIndex(areq.Endpoint)
+		if idx == -1 {
+			aresp.Success = false
+			aresp.Result = fmt.Sprintf("wrong endpoint is given (%s)", areq.Endpoint)
+			return json.NewEncoder(w).Encode(aresp)
+		}
+
+		cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
+		defer ccancel()
+
+		var err error
+		switch areq.Action {
+		case "enable":
+			err = globalCluster.AuthEnable(cctx, idx, password)
+		case "disable":
+			err = globalCluster.AuthDisable(cctx, idx)
+		case "user-add":
+			err = globalCluster.UserAdd(cctx, idx, areq.User, password)
+		case "user-delete":
+			err = globalCluster.UserDelete(cctx, idx, areq.User)
+		case "user-grant-role":
+			err = globalCluster.UserGrantRole(cctx, idx, areq.User, areq.Role)
+		case "role-add":
+			err = globalCluster.RoleAdd(cctx, idx, areq.Role)
+		case "role-grant-permission":
+			err = globalCluster.RoleGrantPermission(cctx, idx, areq.Role, areq.Permission, areq.Prefix)
+		case "authenticate":
+			err = globalCluster.Authenticate(idx, areq.User, password)
+		case "put-as":
+			aresp.Response, err = globalCluster.PutAs(cctx, idx, areq.User, password, areq.Key, areq.Value)
+		case "get-as":
+			aresp.Response, err = globalCluster.GetAs(cctx, idx, areq.User, password, areq.Key, areq.Prefix)
+		case "issue-client-cert":
+			_, err = globalCluster.IssueClientCert(areq.User)
+		case "revoke-client-cert":
+			err = globalCluster.RevokeClientCert(areq.User)
+		case "info":
+		default:
+			err = fmt.Errorf("unknown action %q", areq.Action)
+		}
+		if err == nil {
+			aresp.Info, err = globalCluster.AuthInfo(cctx, idx)
+		}
+		if err != nil {
+			aresp.Success = false
+			aresp.Result = fmt.Sprintf("'%s' error %v", areq.Action, err)
+		} else {
+			aresp.Result = fmt.Sprintf("'%s' success", areq.Action)
+		}
+		return json.NewEncoder(w).Encode(aresp)
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+	"github.com/coreos/etcdlabs/pkg/websocket"
+
+	"github.com/golang/glog"
+)
+
+// ElectionRequest defines leader election playground requests.
+type ElectionRequest struct {
+	Action   string // 'campaign', 'resign', 'leader'
+	Endpoint string
+	Session  string // name of the candidate session
+	Name     string // name of the election
+	Value    string // 'campaign'
+}
+
+// ElectionResult contains the leader election playground response.
+type ElectionResult struct {
+	ElectionRequest ElectionRequest
+	Success         bool
+	Result          string
+	State           cluster.ElectionState
+}
+
+// electionHandler handles election campaign, resign and leader queries.
+func electionHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodPost:
+		eresp := ElectionResult{Success: true}
+		defer func() {
+			glog.Info(eresp.Result)
+		}()
+		if rmsg, ok := globalClientRequestLimiter.Check(); !ok {
+			eresp.Success = false
+			eresp.Result = "election request " + rmsg
+			return json.NewEncoder(w).Encode(eresp)
+		}
+		globalClientRequestLimiter.Advance()
+
+		ereq := ElectionRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&ereq); err != nil {
+			eresp.Success = false
+			eresp.Result = err.Error()
+			return json.NewEncoder(w).Encode(eresp)
+		}
+		defer req.Body.Close()
+
+		eresp.ElectionRequest = ereq
+		eresp.ElectionRequest.Session = template.HTMLEscapeString(ereq.Session)
+		eresp.ElectionRequest.Name = template.HTMLEscapeString(ereq.Name)
+		eresp.ElectionRequest.Value = template.HTMLEscapeString(ereq.Value)
+
+		idx := globalCluster.FindIndex(ereq.Endpoint)
+		if idx == -1 {
+			eresp.Success = false
+			eresp.Result = fmt.Sprintf("wrong endpoint is given (%s)", ereq.Endpoint)
+			return json.NewEncoder(w).Encode(eresp)
+		}
+
+		cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
+		defer ccancel()
+
+		var err error
+		switch ereq.Action {
+		case "campaign":
+			err = globalCluster.Campaign(idx, ereq.Session, ereq.Name, ereq.Value)
+		case "resign":
+			err = globalCluster.Resign(cctx, ereq.Session, ereq.Name)
+		case "leader":
+		default:
+			err = fmt.Errorf("unknown action %q", ereq.Action)
+		}
+		if err == nil {
+			eresp.State, err = globalCluster.ElectionLeader(cctx, idx, ereq.Name)
+		}
+		if err != nil {
+			eresp.Success = false
+			eresp.Result = fmt.Sprintf("'%s' error %v", ereq.Action, err)
+		} else {
+			eresp.Result = fmt.Sprintf("'%s' success", ereq.Action)
+		}
+		return json.NewEncoder(w).Encode(eresp)
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
+
+// ElectionObservation is pushed to the frontend over the WebSocket.
+type ElectionObservation struct {
+	Type  string // 'state', 'error'
+	State *cluster.ElectionState
+	Error string
+}
+
+// electionObserveHandler streams election state changes to the frontend over WebSocket.
+// The election and endpoint are given as 'name' and 'endpoint' query parameters.
+func electionObserveHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	name := req.URL.Query().Get("name")
+	idx := globalCluster.FindIndex(req.URL.Query().Get("endpoint"))
+
+	conn, err := websocket.Upgrade(w, req)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	send := func(msg ElectionObservation) error {
+		b, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return conn.WriteText(b)
+	}
+	if idx == -1 {
+		return send(ElectionObservation{Type: "error", Error: fmt.Sprintf("wrong endpoint is given (%s)", req.URL.Query().Get("endpoint"))})
+	}
+
+	octx, ocancel := context.WithCancel(ctx)
+	defer ocancel()
+
+	// close the observation when the frontend disconnects
+	go func() {
+		defer ocancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	sch, err := globalCluster.ElectionObserve(octx, idx, name)
+	if err != nil {
+		return send(ElectionObservation{Type: "error", Error: err.Error()})
+	}
+	for st := range sch {
+		st := st
+		if err = send(ElectionObservation{Type: "state", State: &st}); err != nil {
+			glog.Warningf("failed to send election state (%v)", err)
+			return nil
+		}
+	}
+	glog.Infof("election observation of %q closed", name)
+	return nil
+}
@@ -21,10 +21,13 @@ import (
 	"html/template"
 	"net/http"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/coreos/etcdlabs/cluster"
 	"github.com/coreos/etcdlabs/cluster/clusterpb"
+	"github.com/coreos/etcdlabs/pkg/session"
 
 	"github.com/coreos/etcd/clientv3"
 	humanize "github.com/dustin/go-humanize"
@@ -44,14 +47,12 @@ var (
 	globalUserCache     = make(map[string]userData)
 )
 
-var globalStatusInterval = time.Second
-
 func updateClusterStatus(stopc <-chan struct{}) {
 	for {
 		select {
 		case <-stopc:
 			return
-		case <-time.After(globalStatusInterval):
+		case <-time.After(globalCluster.StatusInterval()):
 		}
 
 		if len(globalUserCache) == 0 {
@@ -79,6 +80,10 @@ func cleanCache(stopc <-chan struct{}) {
 			}
 		}
 		globalUserCacheLock.Unlock()
+
+		if n := globalSessions.Sweep(); n > 0 {
+			glog.Infof("removed %d expired sessions", n)
+		}
 	}
 }
 
@@ -88,6 +93,12 @@ func withCache(h ContextHandler) ContextHandler {
 		globalServerVisits.Insert([]byte(userID + time.Now().String()[:10]))
 		ctx = context.WithValue(ctx, userKey, &userID)
 
+		sess, err := globalSessions.Start(w, req)
+		if err != nil {
+			return err
+		}
+		ctx = session.NewContext(ctx, sess)
+
 		globalUserCacheLock.Lock()
 		if _, ok := globalUserCache[userID]; !ok { // if user visits first time, create user cache
 			glog.Infof("just created user %q", userID)
@@ -95,15 +106,26 @@ func withCache(h ContextHandler) ContextHandler {
 		}
 		globalUserCacheLock.Unlock()
 
-		return h.ServeHTTPContext(ctx, w, req)
+		err = h.ServeHTTPContext(ctx, w, req)
+		// the session is kept only once the handler has written state to it
+		if sess.Stored() && sess.Get("user") != userID {
+			sess.Set("user", userID)
+		}
+		return err
 	})
 }
 
-// Connect contains initial server state.
+// Connect contains initial server state, and the state saved in the session.
 type Connect struct {
 	WebPort int
 	User    string
 	Deleted bool
+
+	// Endpoint is the endpoint the session last sent a request to.
+	Endpoint string
+	// Watches are re-opened when the session connects to the watch WebSocket.
+	Watches []WatchRequest
+	Control ControlState
 }
 
 func connectHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
@@ -113,6 +135,11 @@ func connectHandler(ctx context.Context, w http.ResponseWriter, req *http.Reques
 	switch req.Method {
 	case http.MethodGet:
 		resp := Connect{WebPort: globalWebserverPort, User: userID, Deleted: false}
+		if sess := session.FromContext(ctx); sess != nil {
+			resp.Endpoint = sess.Get(endpointSessionKey)
+			resp.Watches = loadWatches(sess)
+			loadJSON(sess, controlSessionKey, &resp.Control)
+		}
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			return err
 		}
@@ -156,6 +183,9 @@ type ServerStatus struct {
 
 	// MemberStatuses contains all node statuses.
 	MemberStatuses []clusterpb.MemberStatus
+
+	// Tolerance is how many more node failures the cluster can survive.
+	Tolerance cluster.FailureTolerance
 }
 
 func getUserIDs() []string {
@@ -201,6 +231,7 @@ func serverStatusHandler(ctx context.Context, w http.ResponseWriter, req *http.R
 			UserN:            getUserIDsN(),
 			Users:            getUserIDs(),
 			MemberStatuses:   globalCluster.AllMemberStatus(),
+			Tolerance:        globalCluster.FailureTolerance(),
 		}
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			return err
@@ -213,6 +244,50 @@ func serverStatusHandler(ctx context.Context, w http.ResponseWriter, req *http.R
 	return nil
 }
 
+// NodeLogs contains the most recent log lines of a node.
+type NodeLogs struct {
+	Name  string
+	Lines []cluster.LogLine
+}
+
+var defaultLogLines = 100
+
+// logsHandler tails the captured logs of a node.
+// Query parameters are 'index' (0-based node index) and 'lines'.
+func logsHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodGet:
+		idx, err := strconv.Atoi(req.URL.Query().Get("index"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid node index %q", req.URL.Query().Get("index")), http.StatusBadRequest)
+			return nil
+		}
+		n := defaultLogLines
+		if s := req.URL.Query().Get("lines"); s != "" {
+			if n, err = strconv.Atoi(s); err != nil {
+				http.Error(w, fmt.Sprintf("invalid lines %q", s), http.StatusBadRequest)
+				return nil
+			}
+		}
+		// both reads are bounds-checked, since nodes can be removed meanwhile
+		ss := globalCluster.AllMemberStatus()
+		lines, err := globalCluster.Logs(idx, n)
+		if err != nil || idx >= len(ss) {
+			http.Error(w, fmt.Sprintf("invalid node index %q", req.URL.Query().Get("index")), http.StatusBadRequest)
+			return nil
+		}
+		resp := NodeLogs{Name: ss[idx].Name, Lines: lines}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			return err
+		}
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
+
 // KeyValue defines key-value pair.
 type KeyValue struct {
 	Key   string
@@ -236,6 +311,11 @@ type ClientResponse struct {
 	KeyValues     []KeyValue
 }
 
+// escapeKeyValue escapes the key-value pair for display.
+func escapeKeyValue(kv KeyValue) KeyValue {
+	return KeyValue{Key: template.HTMLEscapeString(kv.Key), Value: template.HTMLEscapeString(kv.Value)}
+}
+
 var (
 	minScaleToDisplay = time.Millisecond
 	// ErrNoEndpoint is returned when client request has no target endpoint.
@@ -267,14 +347,9 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 		}
 		defer req.Body.Close()
 
-		if creq.KeyValue.Key != "" {
-			creq.KeyValue.Key = template.HTMLEscapeString(creq.KeyValue.Key)
-		}
-		if creq.KeyValue.Value != "" {
-			creq.KeyValue.Value = template.HTMLEscapeString(creq.KeyValue.Value)
-		}
-
+		// keys and values are written as given, and escaped for display
 		cresp.ClientRequest = creq
+		cresp.ClientRequest.KeyValue = escapeKeyValue(creq.KeyValue)
 
 		if len(creq.Endpoints) == 0 {
 			cresp.Success = false
@@ -290,6 +365,7 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 			cresp.ResultLines = []string{cresp.Result}
 			return json.NewEncoder(w).Encode(cresp)
 		}
+		saveEndpoint(ctx, creq.Endpoints[0])
 
 		cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
 		defer ccancel()
@@ -305,7 +381,7 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 				return json.NewEncoder(w).Encode(cresp)
 			}
 
-			cli, _, err := globalCluster.Client(creq.Endpoints...)
+			cli, _, err := globalCluster.Client(ctx, creq.Endpoints...)
 			if err != nil {
 				cresp.Success = false
 				cresp.Result = fmt.Sprintf("client error %v (took %v)", err, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
@@ -314,24 +390,25 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 			}
 			defer cli.Close()
 
-			cresp.KeyValues = []KeyValue{creq.KeyValue}
-			if _, err := cli.Put(cctx, creq.KeyValue.Key, creq.KeyValue.Value); err != nil {
+			cresp.KeyValues = []KeyValue{escapeKeyValue(creq.KeyValue)}
+			if presp, err := cli.Put(cctx, creq.KeyValue.Key, creq.KeyValue.Value); err != nil {
 				cresp.Success = false
 				cresp.Result = err.Error()
 				cresp.ResultLines = []string{cresp.Result}
 			} else {
+				recordPut("put", presp.Header.Revision, time.Since(reqStart))
 				cresp.Success = true
 				cresp.Result = fmt.Sprintf("'write' success (took %v)", roundDownDuration(time.Since(reqStart), minScaleToDisplay))
 				lines := make([]string, 1)
 				for i := range lines {
-					ks, vs := cresp.KeyValues[i].Key, cresp.KeyValues[i].Value
+					ks, vs := creq.KeyValue.Key, creq.KeyValue.Value
 					if len(ks) > 7 {
 						ks = ks[:7] + "..."
 					}
 					if len(vs) > 7 {
 						vs = vs[:7] + "..."
 					}
-					lines[i] = fmt.Sprintf("'write' success (key: %s, value: %s)", ks, vs)
+					lines[i] = fmt.Sprintf("'write' success (key: %s, value: %s)", template.HTMLEscapeString(ks), template.HTMLEscapeString(vs))
 				}
 				cresp.ResultLines = lines
 			}
@@ -340,7 +417,7 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 			}
 
 		case "stress":
-			cli, _, err := globalCluster.Client(creq.Endpoints...)
+			cli, _, err := globalCluster.Client(ctx, creq.Endpoints...)
 			if err != nil {
 				cresp.Success = false
 				cresp.Result = fmt.Sprintf("client error %v (took %v)", err, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
@@ -349,14 +426,17 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 			}
 			defer cli.Close()
 
-			cresp.KeyValues = multiRandKeyValues("foo", "bar", 3, 3)
+			cresp.KeyValues = multiRandKeyValues(globalCluster.NewRand(), "foo", "bar", 3, 3)
 			for _, kv := range cresp.KeyValues {
-				if _, err := cli.Put(cctx, kv.Key, kv.Value); err != nil {
+				putStart := time.Now()
+				presp, err := cli.Put(cctx, kv.Key, kv.Value)
+				if err != nil {
 					cresp.Success = false
 					cresp.Result = fmt.Sprintf("client error %v (took %v)", err, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
 					cresp.ResultLines = []string{cresp.Result}
 					break
 				}
+				recordPut("put", presp.Header.Revision, time.Since(putStart))
 			}
 
 			if cresp.Success {
@@ -386,7 +466,7 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 				return json.NewEncoder(w).Encode(cresp)
 			}
 
-			cli, _, err := globalCluster.Client(creq.Endpoints...)
+			cli, _, err := globalCluster.Client(ctx, creq.Endpoints...)
 			if err != nil {
 				cresp.Success = false
 				cresp.Result = fmt.Sprintf("client error %v (took %v)", err, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
@@ -406,11 +486,12 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 			}
 			kvs := make([]KeyValue, len(dresp.PrevKvs))
 			for i := range dresp.PrevKvs {
-				kvs[i] = KeyValue{Key: string(dresp.PrevKvs[i].Key), Value: string(dresp.PrevKvs[i].Value)}
+				kvs[i] = escapeKeyValue(KeyValue{Key: string(dresp.PrevKvs[i].Key), Value: string(dresp.PrevKvs[i].Value)})
 			}
 			cresp.KeyValues = kvs
 
 			if cresp.Success {
+				globalOpStats.record("delete", time.Since(reqStart))
 				cresp.Result = fmt.Sprintf("'delete' success (took %v)", roundDownDuration(time.Since(reqStart), minScaleToDisplay))
 				lines := make([]string, len(cresp.KeyValues))
 				for i := range lines {
@@ -432,7 +513,7 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 
 			// TODO: get all keys and by prefix
 
-			cli, _, err := globalCluster.Client(creq.Endpoints...)
+			cli, _, err := globalCluster.Client(ctx, creq.Endpoints...)
 			if err != nil {
 				cresp.Success = false
 				cresp.Result = fmt.Sprintf("client error %v (took %v)", err, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
@@ -453,18 +534,19 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 			}
 			kvs := make([]KeyValue, len(gresp.Kvs))
 			for i := range gresp.Kvs {
-				kvs[i] = KeyValue{Key: string(gresp.Kvs[i].Key), Value: string(gresp.Kvs[i].Value)}
+				kvs[i] = escapeKeyValue(KeyValue{Key: string(gresp.Kvs[i].Key), Value: string(gresp.Kvs[i].Value)})
 			}
 			cresp.KeyValues = kvs
 
 			if err == nil {
+				globalOpStats.record("get", time.Since(reqStart))
 				cresp.Result = fmt.Sprintf("'get' success (took %v)", roundDownDuration(time.Since(reqStart), minScaleToDisplay))
 				lines := make([]string, len(cresp.KeyValues))
 				for i := range lines {
 					lines[i] = fmt.Sprintf("'get' success (key: %s, value: %s)", cresp.KeyValues[i].Key, cresp.KeyValues[i].Value)
 				}
 				if len(lines) == 0 {
-					lines = append(lines, fmt.Sprintf("key %q does not exist", creq.KeyValue.Key))
+					lines = append(lines, fmt.Sprintf("key %q does not exist", template.HTMLEscapeString(creq.KeyValue.Key)))
 				}
 				cresp.ResultLines = lines
 			}
@@ -474,13 +556,12 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 			}
 
 		case "stop-node":
-			if rmsg, ok := globalStopRestartLimiter.Check(); !ok {
+			if rmsg, ok := allowControl(ctx, req); !ok {
 				cresp.Success = false
 				cresp.Result = "'stop-node' request " + rmsg
 				cresp.ResultLines = []string{cresp.Result}
 				return json.NewEncoder(w).Encode(cresp)
 			}
-			globalStopRestartLimiter.Advance()
 
 			if globalCluster.ActiveNodeN() < globalCluster.Quorum() {
 				cresp.Success = false
@@ -489,49 +570,67 @@ func clientRequestHandler(ctx context.Context, w http.ResponseWriter, req *http.
 				return json.NewEncoder(w).Encode(cresp)
 			}
 
-			glog.Infof("starting 'stop-node' on %q(%s)", globalCluster.MemberStatus(idx).Name, globalCluster.MemberStatus(idx).ID)
-			if globalCluster.IsStopped(idx) {
+			st, serr := globalCluster.MemberStatus(idx)
+			if serr != nil {
 				cresp.Success = false
-				cresp.Result = fmt.Sprintf("%s is already stopped (took %v)", globalCluster.MemberStatus(idx).Name, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
+				cresp.Result = serr.Error()
 				cresp.ResultLines = []string{cresp.Result}
 				return json.NewEncoder(w).Encode(cresp)
 			}
-			globalCluster.Stop(idx)
-			glog.Infof("finished 'stop-node' on %q(%s)", globalCluster.MemberStatus(idx).Name, globalCluster.MemberStatus(idx).ID)
+			glog.Infof("starting 'stop-node' on %q(%s)", st.Name, st.ID)
+			if st.State == clusterpb.StoppedMemberStatus {
+				cresp.Success = false
+				cresp.Result = fmt.Sprintf("%s is already stopped (took %v)", st.Name, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
+				cresp.ResultLines = []string{cresp.Result}
+				return json.NewEncoder(w).Encode(cresp)
+			}
+			if serr = globalCluster.StopByName(st.Name); serr != nil {
+				glog.Warningf("'stop-node' error %v", serr)
+				cresp.Success = false
+				cresp.Result = serr.Error()
+			} else {
+				cresp.Result = fmt.Sprintf("stopped %s (took %v)", st.Name, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
+			}
+			glog.Infof("finished 'stop-node' on %q(%s)", st.Name, st.ID)
 
-			cresp.Result = fmt.Sprintf("stopped %s (took %v)", globalCluster.MemberStatus(idx).Name, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
 			cresp.ResultLines = []string{cresp.Result}
 			if err := json.NewEncoder(w).Encode(cresp); err != nil {
 				return err
 			}
 
 		case "restart-node":
-			if rmsg, ok := globalStopRestartLimiter.Check(); !ok {
+			if rmsg, ok := allowControl(ctx, req); !ok {
 				cresp.Success = false
 				cresp.Result = "'restart-node' request " + rmsg
 				cresp.ResultLines = []string{cresp.Result}
 				return json.NewEncoder(w).Encode(cresp)
 			}
-			globalStopRestartLimiter.Advance()
 
-			glog.Infof("starting 'restart-node' on %q(%s)", globalCluster.MemberStatus(idx).Name, globalCluster.MemberStatus(idx).ID)
-			if !globalCluster.IsStopped(idx) {
+			st, serr := globalCluster.MemberStatus(idx)
+			if serr != nil {
+				cresp.Success = false
+				cresp.Result = serr.Error()
+				cresp.ResultLines = []string{cresp.Result}
+				return json.NewEncoder(w).Encode(cresp)
+			}
+			glog.Infof("starting 'restart-node' on %q(%s)", st.Name, st.ID)
+			if st.State != clusterpb.StoppedMemberStatus {
 				cresp.Success = false
-				cresp.Result = fmt.Sprintf("%s is already started (took %v)", globalCluster.MemberStatus(idx).Name, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
+				cresp.Result = fmt.Sprintf("%s is already started (took %v)", st.Name, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
 				cresp.ResultLines = []string{cresp.Result}
 				glog.Warningf("'restart-node' %s", cresp.Result)
 				return json.NewEncoder(w).Encode(cresp)
 			}
 
-			if rerr := globalCluster.Restart(idx); rerr != nil {
+			if rerr := globalCluster.RestartByName(st.Name); rerr != nil {
 				glog.Warningf("'restart-node' error %v", rerr)
 				cresp.Success = false
 				cresp.Result = rerr.Error()
 			} else {
 				cresp.Success = true
-				cresp.Result = fmt.Sprintf("restarted %s (took %v)", globalCluster.MemberStatus(idx).Name, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
+				cresp.Result = fmt.Sprintf("restarted %s (took %v)", st.Name, roundDownDuration(time.Since(reqStart), minScaleToDisplay))
 			}
-			glog.Infof("finished 'restart-node' on %q(%s)", globalCluster.MemberStatus(idx).Name, globalCluster.MemberStatus(idx).ID)
+			glog.Infof("finished 'restart-node' on %q(%s)", st.Name, st.ID)
 
 			cresp.ResultLines = []string{cresp.Result}
 			if err := json.NewEncoder(w).Encode(cresp); err != nil {
@@ -0,0 +1,144 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+
+	"github.com/golang/glog"
+)
+
+// KVRequest defines key-value console requests.
+type KVRequest struct {
+	Action   string // 'put', 'get', 'delete', 'range', 'history'
+	Endpoint string
+	Key      string
+	Value    string
+	Prefix   bool // 'get', 'delete', 'range'
+
+	// Range defines the query options of 'range'.
+	// 'Key' and 'Prefix' are copied from the request.
+	Range cluster.RangeRequest
+}
+
+// KVResult contains the key-value console response with etcd header metadata.
+type KVResult struct {
+	KVRequest KVRequest
+	Success   bool
+	Result    string
+	Response  cluster.KVResponse
+
+	// RangeResponse is set on 'range'.
+	RangeResponse cluster.RangeResponse
+
+	// History is set on 'history'.
+	History cluster.KeyHistory
+}
+
+// kvHandler handles key-value console operations against a chosen endpoint.
+func kvHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodPost:
+		kresp := KVResult{Success: true}
+		defer func() {
+			glog.Info(kresp.Result)
+		}()
+		if rmsg, ok := globalClientRequestLimiter.Check(); !ok {
+			kresp.Success = false
+			kresp.Result = "kv request " + rmsg
+			return json.NewEncoder(w).Encode(kresp)
+		}
+		globalClientRequestLimiter.Advance()
+
+		kreq := KVRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&kreq); err != nil {
+			kresp.Success = false
+			kresp.Result = err.Error()
+			return json.NewEncoder(w).Encode(kresp)
+		}
+		defer req.Body.Close()
+
+		kresp.KVRequest = kreq
+		kresp.KVRequest.Key = template.HTMLEscapeString(kreq.Key)
+		kresp.KVRequest.Value = template.HTMLEscapeString(kreq.Value)
+
+		if kreq.Endpoint == "" {
+			kresp.Success = false
+			kresp.Result = ErrNoEndpoint
+			return json.NewEncoder(w).Encode(kresp)
+		}
+		idx := globalCluster.FindIndex(kreq.Endpoint)
+		if idx == -1 {
+			kresp.Success = false
+			kresp.Result = fmt.Sprintf("wrong endpoint is given (%s)", kreq.Endpoint)
+			return json.NewEncoder(w).Encode(kresp)
+		}
+		if kreq.Key == "" {
+			kresp.Success = false
+			kresp.Result = fmt.Sprintf("'%s' request got empty key", kreq.Action)
+			return json.NewEncoder(w).Encode(kresp)
+		}
+
+		cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
+		defer ccancel()
+
+		var err error
+		switch kreq.Action {
+		case "put":
+			kresp.Response, err = globalCluster.Put(cctx, idx, kreq.Key, kreq.Value)
+		case "get":
+			kresp.Response, err = globalCluster.Get(cctx, idx, kreq.Key, kreq.Prefix)
+		case "delete":
+			kresp.Response, err = globalCluster.Delete(cctx, idx, kreq.Key, kreq.Prefix)
+		case "range":
+			rr := kreq.Range
+			rr.Key, rr.Prefix = kreq.Key, kreq.Prefix
+			kresp.RangeResponse, err = globalCluster.Range(cctx, idx, rr)
+			kresp.Response.Header, kresp.Response.Took = kresp.RangeResponse.Header, kresp.RangeResponse.Took
+		case "history":
+			kresp.History, err = globalCluster.KeyHistory(cctx, idx, kreq.Key, 0)
+			kresp.Response.Header.Revision = kresp.History.Revision
+		default:
+			kresp.Success = false
+			kresp.Result = fmt.Sprintf("unknown action %q", kreq.Action)
+			return json.NewEncoder(w).Encode(kresp)
+		}
+		if err != nil {
+			kresp.Success = false
+			kresp.Result = fmt.Sprintf("'%s' error %v", kreq.Action, err)
+		} else {
+			switch kreq.Action {
+			case "put":
+				recordPut("kv-put", kresp.Response.Header.Revision, kresp.Response.Took)
+			case "get", "delete", "range":
+				globalOpStats.record("kv-"+kreq.Action, kresp.Response.Took)
+			}
+			kresp.Result = fmt.Sprintf("'%s' success at revision %d (took %v)", kreq.Action, kresp.Response.Header.Revision, roundDownDuration(kresp.Response.Took, minScaleToDisplay))
+		}
+		return json.NewEncoder(w).Encode(kresp)
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+
+	"github.com/golang/glog"
+)
+
+// LeaseRequest defines lease playground requests.
+type LeaseRequest struct {
+	Action   string // 'grant', 'keep-alive', 'keep-alive-stop', 'ttl', 'revoke', 'put', 'list'
+	Endpoint string
+	TTL      int64  // 'grant'
+	LeaseID  string // hexadecimal lease ID
+	Key      string // 'put'
+	Value    string // 'put'
+}
+
+// LeaseResult contains the lease playground response.
+type LeaseResult struct {
+	LeaseRequest LeaseRequest
+	Success      bool
+	Result       string
+	Lease        cluster.LeaseInfo
+	Response     cluster.KVResponse
+	// Leases are the active leases, for 'list'.
+	Leases []cluster.LeaseInfo
+}
+
+// leaseHandler handles lease grant, keep-alive, time-to-live, revoke, attaching keys
+// and listing the active leases.
+func leaseHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodPost:
+		lresp := LeaseResult{Success: true}
+		defer func() {
+			glog.Info(lresp.Result)
+		}()
+		if rmsg, ok := globalClientRequestLimiter.Check(); !ok {
+			lresp.Success = false
+			lresp.Result = "lease request " + rmsg
+			return json.NewEncoder(w).Encode(lresp)
+		}
+		globalClientRequestLimiter.Advance()
+
+		lreq := LeaseRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&lreq); err != nil {
+			lresp.Success = false
+			lresp.Result = err.Error()
+			return json.NewEncoder(w).Encode(lresp)
+		}
+		defer req.Body.Close()
+
+		lresp.LeaseRequest = lreq
+		lresp.LeaseRequest.Key = template.HTMLEscapeString(lreq.Key)
+		lresp.LeaseRequest.Value = template.HTMLEscapeString(lreq.Value)
+
+		idx := globalCluster.FindIndex(lreq.Endpoint)
+		if idx == -1 {
+			lresp.Success = false
+			lresp.Result = fmt.Sprintf("wrong endpoint is given (%s)", lreq.Endpoint)
+			return json.NewEncoder(w).Encode(lresp)
+		}
+
+		var (
+			id  int64
+			err error
+		)
+		if lreq.Action != "grant" && lreq.Action != "list" {
+			if id, err = cluster.ParseLeaseID(lreq.LeaseID); err != nil {
+				lresp.Success = false
+				lresp.Result = err.Error()
+				return json.NewEncoder(w).Encode(lresp)
+			}
+		}
+
+		cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
+		defer ccancel()
+
+		switch lreq.Action {
+		case "grant":
+			lresp.Lease, err = globalCluster.LeaseGrant(cctx, idx, lreq.TTL)
+		case "keep-alive":
+			if err = globalCluster.LeaseKeepAlive(idx, id); err == nil {
+				lresp.Lease, err = globalCluster.LeaseTimeToLive(cctx, idx, id, true)
+			}
+		case "keep-alive-stop":
+			if !globalCluster.LeaseKeepAliveStop(id) {
+				err = fmt.Errorf("lease %s is not kept alive", lreq.LeaseID)
+			}
+		case "ttl":
+			lresp.Lease, err = globalCluster.LeaseTimeToLive(cctx, idx, id, true)
+		case "revoke":
+			err = globalCluster.LeaseRevoke(cctx, idx, id)
+		case "list":
+			lresp.Leases, err = globalCluster.Leases(cctx, idx)
+		case "put":
+			if lreq.Key == "" {
+				err = fmt.Errorf("'put' request got empty key")
+				break
+			}
+			lresp.Response, err = globalCluster.PutWithLease(cctx, idx, lreq.Key, lreq.Value, id)
+		default:
+			err = fmt.Errorf("unknown action %q", lreq.Action)
+		}
+		if err != nil {
+			lresp.Success = false
+			lresp.Result = fmt.Sprintf("'%s' error %v", lreq.Action, err)
+		} else {
+			lresp.Result = fmt.Sprintf("'%s' success", lreq.Action)
+		}
+		return json.NewEncoder(w).Encode(lresp)
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
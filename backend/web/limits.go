@@ -0,0 +1,94 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+
+	"github.com/golang/glog"
+)
+
+// RequestLimitRequest defines request size limit demo requests.
+type RequestLimitRequest struct {
+	Endpoint string
+}
+
+// RequestLimitResult contains the request size limit demo response.
+type RequestLimitResult struct {
+	RequestLimitRequest RequestLimitRequest
+	Success             bool
+	Result              string
+	Response            cluster.RequestLimitResponse
+}
+
+// requestLimitHandler sends a request over the size limit and reports the error.
+func requestLimitHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodPost:
+		lresp := RequestLimitResult{Success: true}
+		defer func() {
+			glog.Info(lresp.Result)
+		}()
+		if rmsg, ok := globalClientRequestLimiter.Check(); !ok {
+			lresp.Success = false
+			lresp.Result = "request-limit request " + rmsg
+			return json.NewEncoder(w).Encode(lresp)
+		}
+		globalClientRequestLimiter.Advance()
+
+		lreq := RequestLimitRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&lreq); err != nil {
+			lresp.Success = false
+			lresp.Result = err.Error()
+			return json.NewEncoder(w).Encode(lresp)
+		}
+		defer req.Body.Close()
+		lresp.RequestLimitRequest = lreq
+
+		idx := globalCluster.FindIndex(lreq.Endpoint)
+		if idx == -1 {
+			lresp.Success = false
+			lresp.Result = fmt.Sprintf("wrong endpoint is given (%s)", lreq.Endpoint)
+			return json.NewEncoder(w).Encode(lresp)
+		}
+
+		cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
+		defer ccancel()
+
+		var err error
+		lresp.Response, err = globalCluster.RequestLimit(cctx, idx, "request-limit-demo")
+		switch {
+		case err != nil:
+			lresp.Success = false
+			lresp.Result = fmt.Sprintf("'request-limit' error %v", err)
+		case lresp.Response.Rejected:
+			lresp.Result = fmt.Sprintf("%d-byte request was rejected (limit %d bytes): %s", lresp.Response.RequestBytes, lresp.Response.MaxRequestBytes, lresp.Response.Error)
+		default:
+			lresp.Result = fmt.Sprintf("%d-byte request was accepted (limit %d bytes)", lresp.Response.RequestBytes, lresp.Response.MaxRequestBytes)
+		}
+		return json.NewEncoder(w).Encode(lresp)
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
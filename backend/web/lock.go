@@ -0,0 +1,111 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+
+	"github.com/golang/glog"
+)
+
+// LockRequest defines distributed lock playground requests.
+type LockRequest struct {
+	Action   string // 'acquire', 'release', 'state', 'close-session'
+	Endpoint string
+	Session  string // name of the client session
+	Name     string // name of the lock
+}
+
+// LockResult contains the distributed lock playground response.
+type LockResult struct {
+	LockRequest LockRequest
+	Success     bool
+	Result      string
+	State       cluster.LockState
+}
+
+// lockHandler handles distributed lock acquire and release with named sessions.
+func lockHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodPost:
+		lresp := LockResult{Success: true}
+		defer func() {
+			glog.Info(lresp.Result)
+		}()
+		if rmsg, ok := globalClientRequestLimiter.Check(); !ok {
+			lresp.Success = false
+			lresp.Result = "lock request " + rmsg
+			return json.NewEncoder(w).Encode(lresp)
+		}
+		globalClientRequestLimiter.Advance()
+
+		lreq := LockRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&lreq); err != nil {
+			lresp.Success = false
+			lresp.Result = err.Error()
+			return json.NewEncoder(w).Encode(lresp)
+		}
+		defer req.Body.Close()
+
+		lresp.LockRequest = lreq
+		lresp.LockRequest.Session = template.HTMLEscapeString(lreq.Session)
+		lresp.LockRequest.Name = template.HTMLEscapeString(lreq.Name)
+
+		idx := globalCluster.FindIndex(lreq.Endpoint)
+		if idx == -1 {
+			lresp.Success = false
+			lresp.Result = fmt.Sprintf("wrong endpoint is given (%s)", lreq.Endpoint)
+			return json.NewEncoder(w).Encode(lresp)
+		}
+
+		cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
+		defer ccancel()
+
+		var err error
+		switch lreq.Action {
+		case "acquire":
+			err = globalCluster.LockAcquire(idx, lreq.Session, lreq.Name)
+		case "release":
+			err = globalCluster.LockRelease(cctx, lreq.Session, lreq.Name)
+		case "state":
+		case "close-session":
+			err = globalCluster.CloseSession(lreq.Session)
+		default:
+			err = fmt.Errorf("unknown action %q", lreq.Action)
+		}
+		if err == nil && lreq.Name != "" {
+			lresp.State, err = globalCluster.LockState(cctx, idx, lreq.Name)
+		}
+		if err != nil {
+			lresp.Success = false
+			lresp.Result = fmt.Sprintf("'%s' error %v", lreq.Action, err)
+		} else {
+			lresp.Result = fmt.Sprintf("'%s' success", lreq.Action)
+		}
+		return json.NewEncoder(w).Encode(lresp)
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
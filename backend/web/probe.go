@@ -0,0 +1,47 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// healthzHandler reports that the backend serves requests,
+// for liveness probes (e.g. Kubernetes).
+func healthzHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+	return nil
+}
+
+// readyzHandler reports whether a quorum of the cluster members is healthy,
+// for readiness probes. It is distinct from the health of a single member.
+func readyzHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	clus := globalCluster
+	if clus == nil {
+		http.Error(w, "cluster is not started", http.StatusServiceUnavailable)
+		return nil
+	}
+	healthy, quorum := clus.HealthyNodeN(), clus.Quorum()
+	if healthy < quorum {
+		http.Error(w, fmt.Sprintf("no quorum (%d healthy members, quorum %d)", healthy, quorum), http.StatusServiceUnavailable)
+		return nil
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "OK (%d healthy members, quorum %d)", healthy, quorum)
+	return nil
+}
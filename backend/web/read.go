@@ -0,0 +1,124 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+
+	"github.com/golang/glog"
+)
+
+// ReadRequest defines read consistency demo requests.
+type ReadRequest struct {
+	Endpoint string
+	Key      string
+	Prefix   bool
+	// Consistency is 'linearizable' (default) or 'serializable'.
+	Consistency string
+}
+
+// ReadResult contains the read response, with the latency
+// of linearizable and serializable reads so far.
+type ReadResult struct {
+	ReadRequest ReadRequest
+	Success     bool
+	Result      string
+	Response    cluster.ReadResponse
+	Stats       []OpStats
+}
+
+// readStats returns the latency stats of reads per consistency level.
+func readStats() []OpStats {
+	var ss []OpStats
+	for _, s := range globalOpStats.summaries() {
+		if strings.HasPrefix(s.Op, "read-") {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// readHandler reads with the chosen consistency, to compare the staleness
+// and latency of serializable reads with linearizable reads.
+func readHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodPost:
+		rresp := ReadResult{Success: true}
+		defer func() {
+			glog.Info(rresp.Result)
+		}()
+		if rmsg, ok := globalClientRequestLimiter.Check(); !ok {
+			rresp.Success = false
+			rresp.Result = "read request " + rmsg
+			return json.NewEncoder(w).Encode(rresp)
+		}
+		globalClientRequestLimiter.Advance()
+
+		rreq := ReadRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&rreq); err != nil {
+			rresp.Success = false
+			rresp.Result = err.Error()
+			return json.NewEncoder(w).Encode(rresp)
+		}
+		defer req.Body.Close()
+
+		if rreq.Consistency == "" {
+			rreq.Consistency = cluster.ReadLinearizable
+		}
+		rresp.ReadRequest = rreq
+		rresp.ReadRequest.Key = template.HTMLEscapeString(rreq.Key)
+
+		idx := globalCluster.FindIndex(rreq.Endpoint)
+		if idx == -1 {
+			rresp.Success = false
+			rresp.Result = fmt.Sprintf("wrong endpoint is given (%s)", rreq.Endpoint)
+			return json.NewEncoder(w).Encode(rresp)
+		}
+		if rreq.Key == "" {
+			rresp.Success = false
+			rresp.Result = "'read' request got empty key"
+			return json.NewEncoder(w).Encode(rresp)
+		}
+
+		cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
+		defer ccancel()
+
+		var err error
+		rresp.Response, err = globalCluster.Read(cctx, idx, rreq.Key, rreq.Prefix, rreq.Consistency)
+		if err != nil {
+			rresp.Success = false
+			rresp.Result = fmt.Sprintf("'read' error %v", err)
+		} else {
+			globalOpStats.record("read-"+rreq.Consistency, rresp.Response.Took)
+			rresp.Result = fmt.Sprintf("%s read at revision %d, %d revision(s) behind (took %v)",
+				rreq.Consistency, rresp.Response.Header.Revision, rresp.Response.RevisionLag, roundDownDuration(rresp.Response.Took, minScaleToDisplay))
+		}
+		rresp.Stats = readStats()
+		return json.NewEncoder(w).Encode(rresp)
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+
+	"github.com/golang/glog"
+)
+
+// RevisionRequest defines revision playground requests.
+type RevisionRequest struct {
+	Action   string // 'read-at', 'compact'
+	Endpoint string
+	Key      string // 'read-at'
+	Prefix   bool   // 'read-at'
+	Revision int64
+	Physical bool // 'compact'
+}
+
+// RevisionResult contains the revision playground response.
+type RevisionResult struct {
+	RevisionRequest RevisionRequest
+	Success         bool
+	Result          string
+
+	// ReadResponse is set on 'read-at'.
+	ReadResponse cluster.TimeTravelResponse
+	// CompactResponse is set on 'compact'.
+	CompactResponse cluster.CompactResponse
+}
+
+// revisionHandler handles reads at historical revisions and compaction.
+func revisionHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodPost:
+		rresp := RevisionResult{Success: true}
+		defer func() {
+			glog.Info(rresp.Result)
+		}()
+		if rmsg, ok := globalClientRequestLimiter.Check(); !ok {
+			rresp.Success = false
+			rresp.Result = "revision request " + rmsg
+			return json.NewEncoder(w).Encode(rresp)
+		}
+		globalClientRequestLimiter.Advance()
+
+		rreq := RevisionRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&rreq); err != nil {
+			rresp.Success = false
+			rresp.Result = err.Error()
+			return json.NewEncoder(w).Encode(rresp)
+		}
+		defer req.Body.Close()
+
+		rresp.RevisionRequest = rreq
+		rresp.RevisionRequest.Key = template.HTMLEscapeString(rreq.Key)
+
+		idx := globalCluster.FindIndex(rreq.Endpoint)
+		if idx == -1 {
+			rresp.Success = false
+			rresp.Result = fmt.Sprintf("wrong endpoint is given (%s)", rreq.Endpoint)
+			return json.NewEncoder(w).Encode(rresp)
+		}
+
+		cctx, ccancel := context.WithTimeout(ctx, 3*time.Second)
+		defer ccancel()
+
+		var err error
+		switch rreq.Action {
+		case "read-at":
+			if rreq.Key == "" {
+				err = fmt.Errorf("'read-at' request got empty key")
+				break
+			}
+			rresp.ReadResponse, err = globalCluster.ReadAt(cctx, idx, rreq.Key, rreq.Prefix, rreq.Revision)
+			if err == nil && rresp.ReadResponse.Compacted {
+				rresp.Success = false
+				rresp.Result = fmt.Sprintf("revision %d is compacted (oldest readable revision is %d)", rreq.Revision, rresp.ReadResponse.CompactRevision)
+				return json.NewEncoder(w).Encode(rresp)
+			}
+		case "compact":
+			rresp.CompactResponse, err = globalCluster.Compact(cctx, idx, rreq.Revision, rreq.Physical)
+		default:
+			err = fmt.Errorf("unknown action %q", rreq.Action)
+		}
+		if err != nil {
+			rresp.Success = false
+			rresp.Result = fmt.Sprintf("'%s' error %v", rreq.Action, err)
+		} else {
+			rresp.Result = fmt.Sprintf("'%s' success", rreq.Action)
+		}
+		return json.NewEncoder(w).Encode(rresp)
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
@@ -26,6 +26,8 @@ import (
 
 	"github.com/coreos/etcdlabs/cluster"
 	"github.com/coreos/etcdlabs/pkg/ratelimit"
+	"github.com/coreos/etcdlabs/pkg/session"
+	"github.com/coreos/etcdlabs/tutorial"
 
 	"github.com/axiomhq/hyperloglog"
 	"github.com/golang/glog"
@@ -82,8 +84,37 @@ var (
 
 	globalStopRestartIntervalLimit = 5 * time.Second
 	globalStopRestartLimiter       ratelimit.RequestLimiter
+
+	// per-IP limit of stop/restart requests, on top of the node-wide limit,
+	// so one visitor cannot take all stop/restart slots
+	globalControlIPInterval = 30 * time.Second
+	globalControlIPBurst    = 3
+	globalControlIPLimiter  *ratelimit.IPLimiter
+
+	// reverse proxies whose 'X-Forwarded-For' is trusted (see SetTrustedProxies)
+	globalTrustedProxies ratelimit.TrustedProxies
+
+	// sessions keep per-visitor state across page reloads
+	globalSessionIdleTTL     = 15 * time.Minute
+	globalSessionAbsoluteTTL = 24 * time.Hour
+	globalSessions           *session.Manager
+
+	// guided lessons, with the progress kept in sessions
+	globalTutorials *tutorial.Engine
 )
 
+// SetTrustedProxies sets the IP addresses or CIDR networks of the reverse
+// proxies in front of the backend, so that per-IP limits apply to the
+// clients behind them. Call it before StartServer.
+func SetTrustedProxies(ss []string) error {
+	tp, err := ratelimit.ParseTrustedProxies(ss)
+	if err != nil {
+		return err
+	}
+	globalTrustedProxies = tp
+	return nil
+}
+
 // StartServer starts a backend webserver with stoppable listener.
 func StartServer(port int) (*Server, error) {
 	globalWebserverPort = port
@@ -101,7 +132,23 @@ func StartServer(port int) (*Server, error) {
 	// rate-limit more strictly for every 3 second
 	globalStopRestartLimiter = ratelimit.NewRequestLimiter(rootCtx, globalStopRestartIntervalLimit)
 
+	globalControlIPLimiter = ratelimit.NewIPLimiter(globalControlIPInterval, globalControlIPBurst)
+
+	globalSessions = session.NewManager(session.Config{
+		CookieName:  "etcdlabs-session",
+		IdleTTL:     globalSessionIdleTTL,
+		AbsoluteTTL: globalSessionAbsoluteTTL,
+	})
+
+	if globalTutorials, err = tutorial.NewEngine(); err != nil {
+		return nil, err
+	}
+
 	mux := http.NewServeMux()
+	mux.Handle("/admin/sessions", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: ContextHandlerFunc(adminSessionsHandler),
+	})
 	mux.Handle("/health", &ContextAdapter{
 		ctx: rootCtx,
 		handler: ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
@@ -110,6 +157,14 @@ func StartServer(port int) (*Server, error) {
 			return nil
 		}),
 	})
+	mux.Handle("/healthz", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: ContextHandlerFunc(healthzHandler),
+	})
+	mux.Handle("/readyz", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: ContextHandlerFunc(readyzHandler),
+	})
 	mux.Handle("/conn", &ContextAdapter{
 		ctx:     rootCtx,
 		handler: withCache(ContextHandlerFunc(connectHandler)),
@@ -122,6 +177,62 @@ func StartServer(port int) (*Server, error) {
 		ctx:     rootCtx,
 		handler: withCache(ContextHandlerFunc(clientRequestHandler)),
 	})
+	mux.Handle("/auth", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(authHandler)),
+	})
+	mux.Handle("/kv", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(kvHandler)),
+	})
+	mux.Handle("/lease", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(leaseHandler)),
+	})
+	mux.Handle("/lock", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(lockHandler)),
+	})
+	mux.Handle("/election", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(electionHandler)),
+	})
+	mux.Handle("/election-observe", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(electionObserveHandler)),
+	})
+	mux.Handle("/read", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(readHandler)),
+	})
+	mux.Handle("/request-limit", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(requestLimitHandler)),
+	})
+	mux.Handle("/revision", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(revisionHandler)),
+	})
+	mux.Handle("/stats", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(statsHandler)),
+	})
+	mux.Handle("/stm", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(stmHandler)),
+	})
+	mux.Handle("/watch", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(watchHandler)),
+	})
+	mux.Handle("/tutorial", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(tutorialHandler)),
+	})
+	mux.Handle("/logs", &ContextAdapter{
+		ctx:     rootCtx,
+		handler: withCache(ContextHandlerFunc(logsHandler)),
+	})
 
 	stopc := make(chan struct{})
 	addrURL := url.URL{Scheme: "http", Host: fmt.Sprintf("localhost:%d", port)}
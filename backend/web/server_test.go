@@ -49,6 +49,13 @@ func TestServer(t *testing.T) {
 
 	tu := srv.addrURL
 	tu.Path = "/client-request"
+	endpoints := func(i int, scheme bool) []string {
+		eps, err := globalCluster.Endpoints(i, scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return eps
+	}
 
 	time.Sleep(7 * time.Second)
 	glog.Info("getting server status update...")
@@ -80,7 +87,7 @@ func TestServer(t *testing.T) {
 	func() {
 		req := ClientRequest{
 			Action:    "stress",
-			Endpoints: globalCluster.Endpoints(0, true),
+			Endpoints: endpoints(0, true),
 		}
 		data, err := json.Marshal(req)
 		if err != nil {
@@ -114,7 +121,7 @@ func TestServer(t *testing.T) {
 	func() {
 		req := ClientRequest{
 			Action:    "stress",
-			Endpoints: globalCluster.Endpoints(0, false),
+			Endpoints: endpoints(0, false),
 		}
 		data, err := json.Marshal(req)
 		if err != nil {
@@ -177,7 +184,7 @@ func TestServer(t *testing.T) {
 	func() {
 		req := ClientRequest{
 			Action:    "write",
-			Endpoints: globalCluster.Endpoints(1, true),
+			Endpoints: endpoints(1, true),
 			KeyValue:  KeyValue{Key: "foo", Value: "bar"},
 		}
 		data, err := json.Marshal(req)
@@ -215,7 +222,7 @@ func TestServer(t *testing.T) {
 		req := ClientRequest{
 			Action:      "get",
 			RangePrefix: true,
-			Endpoints:   globalCluster.Endpoints(2, true),
+			Endpoints:   endpoints(2, true),
 			KeyValue:    KeyValue{Key: "foo"},
 		}
 		data, err := json.Marshal(req)
@@ -250,7 +257,7 @@ func TestServer(t *testing.T) {
 		req := ClientRequest{
 			Action:      "delete",
 			RangePrefix: true,
-			Endpoints:   globalCluster.Endpoints(3, true),
+			Endpoints:   endpoints(3, true),
 			KeyValue:    KeyValue{Key: "foo"},
 		}
 		data, err := json.Marshal(req)
@@ -281,7 +288,7 @@ func TestServer(t *testing.T) {
 	func() {
 		req := ClientRequest{
 			Action:    "get",
-			Endpoints: globalCluster.Endpoints(4, true),
+			Endpoints: endpoints(4, true),
 			KeyValue:  KeyValue{Key: "foo"},
 		}
 		data, err := json.Marshal(req)
@@ -312,7 +319,7 @@ func TestServer(t *testing.T) {
 	func() {
 		req := ClientRequest{
 			Action:    "stop-node",
-			Endpoints: globalCluster.Endpoints(0, true),
+			Endpoints: endpoints(0, true),
 		}
 		data, err := json.Marshal(req)
 		if err != nil {
@@ -344,7 +351,7 @@ func TestServer(t *testing.T) {
 	func() {
 		req := ClientRequest{
 			Action:    "stop-node",
-			Endpoints: globalCluster.Endpoints(0, true),
+			Endpoints: endpoints(0, true),
 		}
 		data, err := json.Marshal(req)
 		if err != nil {
@@ -377,7 +384,7 @@ func TestServer(t *testing.T) {
 	func() {
 		req := ClientRequest{
 			Action:    "stress",
-			Endpoints: globalCluster.Endpoints(0, false),
+			Endpoints: endpoints(0, false),
 		}
 		data, err := json.Marshal(req)
 		if err != nil {
@@ -410,7 +417,7 @@ func TestServer(t *testing.T) {
 	func() {
 		req := ClientRequest{
 			Action:    "restart-node",
-			Endpoints: globalCluster.Endpoints(0, true),
+			Endpoints: endpoints(0, true),
 		}
 		data, err := json.Marshal(req)
 		if err != nil {
@@ -439,7 +446,7 @@ func TestServer(t *testing.T) {
 	func() {
 		req := ClientRequest{
 			Action:    "restart-node",
-			Endpoints: globalCluster.Endpoints(0, true),
+			Endpoints: endpoints(0, true),
 		}
 		data, err := json.Marshal(req)
 		if err != nil {
@@ -472,7 +479,7 @@ func TestServer(t *testing.T) {
 	func() {
 		req := ClientRequest{
 			Action:    "restart-node",
-			Endpoints: globalCluster.Endpoints(0, true),
+			Endpoints: endpoints(0, true),
 		}
 		data, err := json.Marshal(req)
 		if err != nil {
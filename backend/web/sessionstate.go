@@ -0,0 +1,127 @@
+// Copyright 2017 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/coreos/etcdlabs/pkg/session"
+
+	"github.com/golang/glog"
+)
+
+const (
+	endpointSessionKey = "endpoint"
+	watchesSessionKey  = "watches"
+	controlSessionKey  = "control"
+)
+
+// ControlState counts the 'stop-node' and 'restart-node' requests of a session.
+type ControlState struct {
+	Requests    int
+	RateLimited int
+	// LastLimited is the time of the last rate-limited request.
+	LastLimited time.Time
+}
+
+// saveEndpoint remembers the endpoint the session last sent a request to.
+func saveEndpoint(ctx context.Context, ep string) {
+	if sess := session.FromContext(ctx); sess != nil {
+		sess.Set(endpointSessionKey, ep)
+	}
+}
+
+func loadJSON(sess *session.Session, key string, v interface{}) bool {
+	s := sess.Get(key)
+	if s == "" {
+		return false
+	}
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		glog.Warningf("invalid session value %q for %q (%v)", s, key, err)
+		return false
+	}
+	return true
+}
+
+func saveJSON(sess *session.Session, key string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		glog.Warningf("failed to encode session value for %q (%v)", key, err)
+		return
+	}
+	sess.Set(key, string(b))
+}
+
+// loadWatches returns the watches saved in the session.
+func loadWatches(sess *session.Session) []WatchRequest {
+	var ws []WatchRequest
+	loadJSON(sess, watchesSessionKey, &ws)
+	return ws
+}
+
+// saveWatch adds the watch to the session, so that it is re-opened
+// when the session connects again.
+func saveWatch(sess *session.Session, wreq WatchRequest) {
+	ws := loadWatches(sess)
+	for _, w := range ws {
+		if w.Endpoint == wreq.Endpoint && w.Key == wreq.Key && w.Prefix == wreq.Prefix {
+			return
+		}
+	}
+	ws = append(ws, WatchRequest{Action: "watch", Endpoint: wreq.Endpoint, Key: wreq.Key, Prefix: wreq.Prefix})
+	saveJSON(sess, watchesSessionKey, ws)
+}
+
+// removeWatch removes the watch from the session.
+func removeWatch(sess *session.Session, wreq WatchRequest) {
+	ws := loadWatches(sess)
+	for i, w := range ws {
+		if w.Endpoint == wreq.Endpoint && w.Key == wreq.Key && w.Prefix == wreq.Prefix {
+			ws = append(ws[:i], ws[i+1:]...)
+			if len(ws) == 0 {
+				sess.Delete(watchesSessionKey)
+			} else {
+				saveJSON(sess, watchesSessionKey, ws)
+			}
+			return
+		}
+	}
+}
+
+// allowControl checks the per-IP and global limits of 'stop-node' and
+// 'restart-node' requests, and counts the request in the session.
+func allowControl(ctx context.Context, req *http.Request) (string, bool) {
+	rmsg, ok := globalControlIPLimiter.Allow(globalTrustedProxies.ClientIP(req))
+	if ok {
+		if rmsg, ok = globalStopRestartLimiter.Check(); ok {
+			globalStopRestartLimiter.Advance()
+		}
+	}
+
+	if sess := session.FromContext(ctx); sess != nil {
+		var cs ControlState
+		loadJSON(sess, controlSessionKey, &cs)
+		cs.Requests++
+		if !ok {
+			cs.RateLimited++
+			cs.LastLimited = time.Now()
+		}
+		saveJSON(sess, controlSessionKey, cs)
+	}
+	return rmsg, ok
+}
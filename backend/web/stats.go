@@ -0,0 +1,223 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+	"github.com/coreos/etcdlabs/pkg/histogram"
+)
+
+// opStats records latency histograms per client operation.
+type opStats struct {
+	mu    sync.Mutex
+	hists map[string]*histogram.Histogram
+}
+
+var globalOpStats = &opStats{hists: make(map[string]*histogram.Histogram)}
+
+func (s *opStats) record(op string, d time.Duration) {
+	s.mu.Lock()
+	h, ok := s.hists[op]
+	if !ok {
+		h = histogram.New()
+		s.hists[op] = h
+	}
+	s.mu.Unlock()
+	h.Record(d)
+}
+
+// OpStats is the latency summary of a client operation.
+type OpStats struct {
+	Op string
+	histogram.Summary
+}
+
+func (s *opStats) summaries() []OpStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss := make([]OpStats, 0, len(s.hists))
+	for op, h := range s.hists {
+		ss = append(ss, OpStats{Op: op, Summary: h.Summary()})
+	}
+	sort.Slice(ss, func(i, j int) bool { return ss[i].Op < ss[j].Op })
+	return ss
+}
+
+func (s *opStats) reset() {
+	s.mu.Lock()
+	s.hists = make(map[string]*histogram.Histogram)
+	s.mu.Unlock()
+}
+
+// globalPutTimesLimit is the number of recent writes whose
+// time is kept to measure watch event delivery.
+var globalPutTimesLimit = 1024
+
+// putTimes maps revisions of writes through the backend to their
+// completion time, so watch event delivery lag can be measured.
+type putTimes struct {
+	mu    sync.Mutex
+	times map[int64]time.Time
+	revs  []int64
+}
+
+var globalPutTimes = &putTimes{times: make(map[int64]time.Time)}
+
+func (pt *putTimes) record(rev int64, t time.Time) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	if _, ok := pt.times[rev]; ok {
+		return
+	}
+	pt.times[rev] = t
+	pt.revs = append(pt.revs, rev)
+	if len(pt.revs) > globalPutTimesLimit {
+		delete(pt.times, pt.revs[0])
+		pt.revs = pt.revs[1:]
+	}
+}
+
+func (pt *putTimes) get(rev int64) (time.Time, bool) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	t, ok := pt.times[rev]
+	return t, ok
+}
+
+// recordPut records the latency of a write and its revision.
+func recordPut(op string, rev int64, took time.Duration) {
+	globalOpStats.record(op, took)
+	globalPutTimes.record(rev, time.Now())
+}
+
+// watchKey identifies a watched key, or prefix.
+type watchKey struct {
+	key    string
+	prefix bool
+}
+
+// watchStats records the watch traffic per watched key or prefix,
+// to find the noisy ones.
+type watchStats struct {
+	mu      sync.Mutex
+	traffic map[watchKey]*WatchTraffic
+}
+
+var globalWatchStats = &watchStats{traffic: make(map[watchKey]*WatchTraffic)}
+
+// WatchTraffic is the watch traffic of a watched key or prefix.
+type WatchTraffic struct {
+	Key    string
+	Prefix bool
+	// Watches is the number of watches opened on the key.
+	Watches int
+	Events  int64
+	// Bytes is the size of the keys and values of the events,
+	// including the previous values.
+	Bytes int64
+}
+
+func (s *watchStats) get(key string, prefix bool) *WatchTraffic {
+	wk := watchKey{key: key, prefix: prefix}
+	t, ok := s.traffic[wk]
+	if !ok {
+		t = &WatchTraffic{Key: key, Prefix: prefix}
+		s.traffic[wk] = t
+	}
+	return t
+}
+
+func (s *watchStats) opened(key string, prefix bool) {
+	s.mu.Lock()
+	s.get(key, prefix).Watches++
+	s.mu.Unlock()
+}
+
+func (s *watchStats) record(key string, prefix bool, wr cluster.WatchResponse) {
+	var n int64
+	for _, ev := range wr.Events {
+		n += int64(len(ev.KeyValue.Key) + len(ev.KeyValue.Value))
+		if ev.PrevKeyValue != nil {
+			n += int64(len(ev.PrevKeyValue.Key) + len(ev.PrevKeyValue.Value))
+		}
+	}
+	s.mu.Lock()
+	t := s.get(key, prefix)
+	t.Events += int64(len(wr.Events))
+	t.Bytes += n
+	s.mu.Unlock()
+}
+
+// summaries returns the traffic per watched key, the most bytes first.
+func (s *watchStats) summaries() []WatchTraffic {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts := make([]WatchTraffic, 0, len(s.traffic))
+	for _, t := range s.traffic {
+		ts = append(ts, *t)
+	}
+	sort.Slice(ts, func(i, j int) bool {
+		if ts[i].Bytes != ts[j].Bytes {
+			return ts[i].Bytes > ts[j].Bytes
+		}
+		if ts[i].Key != ts[j].Key {
+			return ts[i].Key < ts[j].Key
+		}
+		return ts[i].Prefix && !ts[j].Prefix
+	})
+	return ts
+}
+
+func (s *watchStats) reset() {
+	s.mu.Lock()
+	s.traffic = make(map[watchKey]*WatchTraffic)
+	s.mu.Unlock()
+}
+
+// StatsResult contains the latency percentiles of client operations,
+// and the watch traffic per watched key or prefix.
+type StatsResult struct {
+	Success      bool
+	Result       string
+	Stats        []OpStats
+	WatchTraffic []WatchTraffic
+}
+
+// statsHandler returns the latency percentiles of client operations
+// proxied by the backend and the watch traffic on GET, and resets them
+// on DELETE.
+func statsHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodGet:
+		return json.NewEncoder(w).Encode(StatsResult{Success: true, Stats: globalOpStats.summaries(), WatchTraffic: globalWatchStats.summaries()})
+
+	case http.MethodDelete:
+		globalOpStats.reset()
+		globalWatchStats.reset()
+		return json.NewEncoder(w).Encode(StatsResult{Success: true, Result: "stats reset"})
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+
+	"github.com/golang/glog"
+)
+
+var (
+	// globalSTMWriterLimit is the maximum number of concurrent STM writers per request.
+	globalSTMWriterLimit = 10
+	// globalSTMIterationLimit is the maximum number of increments per STM writer.
+	globalSTMIterationLimit = 50
+)
+
+// STMRequest defines software transactional memory playground requests.
+type STMRequest struct {
+	Endpoint string
+	Request  cluster.STMRequest
+}
+
+// STMResult contains the software transactional memory playground response.
+type STMResult struct {
+	STMRequest STMRequest
+	Success    bool
+	Result     string
+	Response   cluster.STMResponse
+}
+
+// stmHandler runs a read-modify-write loop with concurrent STM writers.
+func stmHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodPost:
+		sresp := STMResult{Success: true}
+		defer func() {
+			glog.Info(sresp.Result)
+		}()
+		if rmsg, ok := globalClientRequestLimiter.Check(); !ok {
+			sresp.Success = false
+			sresp.Result = "STM request " + rmsg
+			return json.NewEncoder(w).Encode(sresp)
+		}
+		globalClientRequestLimiter.Advance()
+
+		sreq := STMRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&sreq); err != nil {
+			sresp.Success = false
+			sresp.Result = err.Error()
+			return json.NewEncoder(w).Encode(sresp)
+		}
+		defer req.Body.Close()
+
+		sresp.STMRequest = sreq
+		sresp.STMRequest.Request.Key = template.HTMLEscapeString(sreq.Request.Key)
+
+		idx := globalCluster.FindIndex(sreq.Endpoint)
+		if idx == -1 {
+			sresp.Success = false
+			sresp.Result = fmt.Sprintf("wrong endpoint is given (%s)", sreq.Endpoint)
+			return json.NewEncoder(w).Encode(sresp)
+		}
+		if sreq.Request.Writers > globalSTMWriterLimit || sreq.Request.Iterations > globalSTMIterationLimit {
+			sresp.Success = false
+			sresp.Result = fmt.Sprintf("too many writers or iterations (maximum %d writers, %d iterations)", globalSTMWriterLimit, globalSTMIterationLimit)
+			return json.NewEncoder(w).Encode(sresp)
+		}
+
+		cctx, ccancel := context.WithTimeout(ctx, 10*time.Second)
+		defer ccancel()
+
+		var err error
+		sresp.Response, err = globalCluster.RunSTM(cctx, idx, sreq.Request)
+		if err != nil {
+			sresp.Success = false
+			sresp.Result = fmt.Sprintf("STM error %v", err)
+		} else {
+			sresp.Result = fmt.Sprintf("STM success (%d retries in %v)", sresp.Response.Retries, sresp.Response.Took)
+		}
+		return json.NewEncoder(w).Encode(sresp)
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/coreos/etcdlabs/pkg/session"
+	"github.com/coreos/etcdlabs/tutorial"
+
+	"github.com/golang/glog"
+)
+
+// tutorialSessionKey keeps the tutorial progress of each session.
+const tutorialSessionKey = "tutorial"
+
+// TutorialRequest starts a lesson, or verifies the current step.
+type TutorialRequest struct {
+	// Action is 'start' or 'verify'.
+	Action string
+	Lesson string
+}
+
+// TutorialResult contains the lessons, or the result of the request.
+type TutorialResult struct {
+	Success bool
+	Result  string
+	Lessons []tutorial.Lesson `json:",omitempty"`
+	// Step is the result of starting a lesson or verifying a step.
+	Step *tutorial.Result `json:",omitempty"`
+}
+
+func loadProgress(sess *session.Session) (tutorial.Progress, bool) {
+	var p tutorial.Progress
+	v := sess.Get(tutorialSessionKey)
+	if v == "" {
+		return p, false
+	}
+	if err := json.Unmarshal([]byte(v), &p); err != nil {
+		glog.Warningf("invalid tutorial progress %q (%v)", v, err)
+		return p, false
+	}
+	return p, true
+}
+
+func saveProgress(sess *session.Session, p tutorial.Progress) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	sess.Set(tutorialSessionKey, string(b))
+	return nil
+}
+
+// tutorialHandler lists the lessons on GET. On POST, it starts a lesson,
+// or verifies the current step of the session against the cluster state.
+func tutorialHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	switch req.Method {
+	case http.MethodGet:
+		return json.NewEncoder(w).Encode(TutorialResult{Success: true, Lessons: globalTutorials.Lessons()})
+
+	case http.MethodPost:
+		tresp := TutorialResult{Success: true}
+		sess := session.FromContext(ctx)
+		if sess == nil {
+			tresp.Success = false
+			tresp.Result = "tutorial needs a session"
+			return json.NewEncoder(w).Encode(tresp)
+		}
+
+		treq := TutorialRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&treq); err != nil {
+			tresp.Success = false
+			tresp.Result = err.Error()
+			return json.NewEncoder(w).Encode(tresp)
+		}
+		defer req.Body.Close()
+
+		now := tutorial.NewState(globalCluster.AllMemberStatus())
+		var (
+			r   tutorial.Result
+			err error
+		)
+		switch treq.Action {
+		case "start":
+			r, err = globalTutorials.Start(treq.Lesson, now)
+		case "verify":
+			p, ok := loadProgress(sess)
+			if !ok {
+				err = fmt.Errorf("no lesson is started")
+				break
+			}
+			r, err = globalTutorials.Verify(p, now)
+		default:
+			err = fmt.Errorf("unknown tutorial action %q", treq.Action)
+		}
+		if err == nil {
+			err = saveProgress(sess, r.Progress)
+		}
+		if err != nil {
+			tresp.Success = false
+			tresp.Result = fmt.Sprintf("'%s' error %v", treq.Action, err)
+			return json.NewEncoder(w).Encode(tresp)
+		}
+		tresp.Result = r.Message
+		tresp.Step = &r
+		return json.NewEncoder(w).Encode(tresp)
+
+	default:
+		http.Error(w, "Method Not Allowed", 405)
+	}
+
+	return nil
+}
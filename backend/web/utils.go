@@ -27,8 +27,7 @@ const (
 	letterIdxMax  = 63 / letterIdxBits   // # of letter indices fitting in 63 bits
 )
 
-func randBytes(bytesN int) []byte {
-	src := rand.NewSource(time.Now().UnixNano())
+func randBytes(src *rand.Rand, bytesN int) []byte {
 	b := make([]byte, bytesN)
 	for i, cache, remain := bytesN-1, src.Int63(), letterIdxMax; i >= 0; {
 		if remain == 0 {
@@ -44,11 +43,11 @@ func randBytes(bytesN int) []byte {
 	return b
 }
 
-func multiRandStrings(bytesN, sliceN int, prefix string) []string {
+func multiRandStrings(src *rand.Rand, bytesN, sliceN int, prefix string) []string {
 	m := make(map[string]struct{})
 	rs := make([]string, 0, sliceN)
 	for len(rs) != sliceN {
-		b := randBytes(bytesN)
+		b := randBytes(src, bytesN)
 		s := fmt.Sprintf("%s%s%d", prefix, b, len(rs)+1)
 		if _, ok := m[s]; !ok {
 			rs = append(rs, s)
@@ -58,8 +57,10 @@ func multiRandStrings(bytesN, sliceN int, prefix string) []string {
 	return rs
 }
 
-func multiRandKeyValues(keyPrefix, valPrefix string, bytesN, sliceN int) []KeyValue {
-	keys, vals := multiRandStrings(bytesN, sliceN, keyPrefix), multiRandStrings(bytesN, sliceN, valPrefix)
+// multiRandKeyValues generates key-values from the random generator
+// (e.g. cluster.Cluster.NewRand), so they are reproducible from its seed.
+func multiRandKeyValues(src *rand.Rand, keyPrefix, valPrefix string, bytesN, sliceN int) []KeyValue {
+	keys, vals := multiRandStrings(src, bytesN, sliceN, keyPrefix), multiRandStrings(src, bytesN, sliceN, valPrefix)
 	kvs := make([]KeyValue, sliceN)
 	for i := range kvs {
 		kvs[i].Key = keys[i]
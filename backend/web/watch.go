@@ -0,0 +1,218 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+	"github.com/coreos/etcdlabs/pkg/session"
+	"github.com/coreos/etcdlabs/pkg/websocket"
+
+	"github.com/golang/glog"
+)
+
+// globalWatchLimit is the maximum number of watches per WebSocket session.
+var globalWatchLimit = 5
+
+// WatchRequest is sent by the frontend over the WebSocket.
+type WatchRequest struct {
+	Action   string // 'watch', 'cancel'
+	WatchID  int    // 'cancel'
+	Endpoint string
+	Key      string
+	Prefix   bool
+	Revision int64
+}
+
+// WatchMessage is pushed to the frontend over the WebSocket.
+type WatchMessage struct {
+	Type     string // 'created', 'event', 'canceled', 'error'
+	WatchID  int
+	Key      string
+	Prefix   bool
+	Response *cluster.WatchResponse
+	Error    string
+}
+
+// watchSession tracks the watches opened by a single WebSocket connection.
+type watchSession struct {
+	ctx  context.Context
+	conn *websocket.Conn
+	// sess keeps the watches to re-open on the next connection, if not nil.
+	sess *session.Session
+
+	mu      sync.Mutex
+	nextID  int
+	cancels map[int]context.CancelFunc
+	reqs    map[int]WatchRequest
+}
+
+func (ws *watchSession) send(msg WatchMessage) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return ws.conn.WriteText(b)
+}
+
+func (ws *watchSession) watch(wreq WatchRequest) error {
+	if wreq.Key == "" {
+		return fmt.Errorf("'watch' request got empty key")
+	}
+	idx := globalCluster.FindIndex(wreq.Endpoint)
+	if idx == -1 {
+		return fmt.Errorf("wrong endpoint is given (%s)", wreq.Endpoint)
+	}
+
+	ws.mu.Lock()
+	if len(ws.cancels) >= globalWatchLimit {
+		ws.mu.Unlock()
+		return fmt.Errorf("watch limit exceeded (maximum %d watches per session)", globalWatchLimit)
+	}
+	ws.nextID++
+	id := ws.nextID
+	wctx, wcancel := context.WithCancel(ws.ctx)
+	ws.cancels[id] = wcancel
+	ws.reqs[id] = wreq
+	ws.mu.Unlock()
+
+	// the key is watched as given, and escaped for display
+	key := template.HTMLEscapeString(wreq.Key)
+	rch, err := globalCluster.Watch(wctx, idx, wreq.Key, wreq.Prefix, wreq.Revision)
+	if err != nil {
+		ws.cancel(id)
+		return err
+	}
+	if err = ws.send(WatchMessage{Type: "created", WatchID: id, Key: key, Prefix: wreq.Prefix}); err != nil {
+		ws.cancel(id)
+		return err
+	}
+	globalWatchStats.opened(wreq.Key, wreq.Prefix)
+	if ws.sess != nil {
+		ws.mu.Lock()
+		saveWatch(ws.sess, wreq)
+		ws.mu.Unlock()
+	}
+
+	go func() {
+		defer ws.cancel(id)
+		for wr := range rch {
+			wr := wr
+			if err := ws.send(WatchMessage{Type: "event", WatchID: id, Key: key, Prefix: wreq.Prefix, Response: &wr}); err != nil {
+				glog.Warningf("failed to send watch event (%v)", err)
+				return
+			}
+			globalWatchStats.record(wreq.Key, wreq.Prefix, wr)
+			// delivery lag from the write through the backend to the frontend
+			for _, ev := range wr.Events {
+				if t, ok := globalPutTimes.get(ev.KeyValue.ModRevision); ok {
+					globalOpStats.record("watch-event", time.Since(t))
+				}
+			}
+		}
+		ws.send(WatchMessage{Type: "canceled", WatchID: id, Key: key, Prefix: wreq.Prefix})
+	}()
+	return nil
+}
+
+func (ws *watchSession) cancel(id int) bool {
+	ws.mu.Lock()
+	wcancel, ok := ws.cancels[id]
+	delete(ws.cancels, id)
+	delete(ws.reqs, id)
+	ws.mu.Unlock()
+	if ok {
+		wcancel()
+	}
+	return ok
+}
+
+// forget cancels the watch, and removes it from the session.
+func (ws *watchSession) forget(id int) bool {
+	ws.mu.Lock()
+	wreq, ok := ws.reqs[id]
+	if ok && ws.sess != nil {
+		removeWatch(ws.sess, wreq)
+	}
+	ws.mu.Unlock()
+	return ws.cancel(id)
+}
+
+// watchHandler bridges etcd watches to the frontend over WebSocket.
+// All watches of the connection are canceled when it closes, and the watches
+// saved in the session are re-opened when it connects again.
+func watchHandler(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	conn, err := websocket.Upgrade(w, req)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	sctx, scancel := context.WithCancel(ctx)
+	defer scancel() // cancels all watches on disconnect
+
+	ws := &watchSession{
+		ctx:     sctx,
+		conn:    conn,
+		sess:    session.FromContext(ctx),
+		cancels: make(map[int]context.CancelFunc),
+		reqs:    make(map[int]WatchRequest),
+	}
+	if ws.sess != nil {
+		globalWatchConns.add(ws.sess.ID, ws, scancel)
+		defer globalWatchConns.remove(ws.sess.ID, ws)
+
+		// re-open the watches saved in the session
+		for _, wreq := range loadWatches(ws.sess) {
+			if err = ws.watch(wreq); err != nil {
+				removeWatch(ws.sess, wreq)
+				ws.send(WatchMessage{Type: "error", Key: template.HTMLEscapeString(wreq.Key), Prefix: wreq.Prefix, Error: err.Error()})
+			}
+		}
+	}
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			glog.Infof("watch session closed (%v)", err)
+			return nil
+		}
+
+		wreq := WatchRequest{}
+		if err = json.Unmarshal(data, &wreq); err != nil {
+			ws.send(WatchMessage{Type: "error", Error: err.Error()})
+			continue
+		}
+
+		switch wreq.Action {
+		case "watch":
+			if err = ws.watch(wreq); err != nil {
+				ws.send(WatchMessage{Type: "error", Key: template.HTMLEscapeString(wreq.Key), Prefix: wreq.Prefix, Error: err.Error()})
+			}
+		case "cancel":
+			if !ws.forget(wreq.WatchID) {
+				ws.send(WatchMessage{Type: "error", WatchID: wreq.WatchID, Error: fmt.Sprintf("unknown watch ID %d", wreq.WatchID)})
+			}
+		default:
+			ws.send(WatchMessage{Type: "error", Error: fmt.Sprintf("unknown action %q", wreq.Action)})
+		}
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcdlabs/pkg/workload"
+
+	"github.com/coreos/etcd/clientv3"
+	"golang.org/x/time/rate"
+)
+
+// Workloads.
+const (
+	Put   = "put"
+	Range = "range"
+	Txn   = "txn"
+	// Watch opens Watchers watchers on a hot prefix and puts keys under
+	// it, measuring the event delivery lag.
+	Watch = "watch"
+)
+
+// Spec defines a benchmark workload.
+type Spec struct {
+	// Workload is one of Put, Range and Txn.
+	Workload string
+	// KeySize and ValueSize are in bytes. If ValueSizeMax is greater,
+	// value sizes are uniform between ValueSize and ValueSizeMax.
+	KeySize      int
+	ValueSize    int
+	ValueSizeMax int
+	// KeySpace is the number of distinct keys (Total if zero).
+	KeySpace int
+	// Distribution is the key distribution (see package workload):
+	// "uniform" (if empty), "zipfian" (with the exponent ZipfS) or
+	// "sequential", in which the clients write consecutive ranges.
+	Distribution string
+	ZipfS        float64
+	// Clients is the number of concurrent clients.
+	Clients int
+	// Total is the number of requests.
+	Total int
+	// QPS is the target requests per second (unlimited if zero).
+	QPS int
+	// Serializable makes Range requests serializable.
+	Serializable bool
+	// Watchers is the number of watchers of the Watch workload,
+	// spread over the clients.
+	Watchers int
+	// Seed seeds the key choice of the clients, so a run can be
+	// reproduced. Defaults to the current time, and is set in the
+	// result spec.
+	Seed int64
+}
+
+// FsyncSpec is a write workload whose latency is dominated by fsync,
+// with sequential small puts from a few clients, to compare clusters
+// with and without fsync.
+var FsyncSpec = Spec{
+	Workload:  Put,
+	KeySize:   8,
+	ValueSize: 256,
+	Clients:   4,
+	Total:     2000,
+}
+
+// Percentiles are request latencies.
+type Percentiles struct {
+	Min time.Duration
+	Avg time.Duration
+	P50 time.Duration
+	P90 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// Result is the result of a benchmark run.
+type Result struct {
+	Spec     Spec
+	Requests int
+	Errors   int
+	// FirstError is the first request error, if any.
+	FirstError string
+	Took       time.Duration
+	// Throughput is the successful requests per second.
+	Throughput float64
+	Latency    Percentiles
+
+	// Events is the number of events delivered to the watchers
+	// of the Watch workload, and WatchLag their delivery lag.
+	Events   int
+	WatchLag Percentiles
+}
+
+const (
+	maxKeySize   = 1024
+	maxValueSize = 1024 * 1024
+	maxClients   = 1000
+	maxWatchers  = 10000
+)
+
+func (s *Spec) validate() error {
+	switch s.Workload {
+	case Put, Range, Txn:
+	case Watch:
+		if s.Watchers <= 0 || s.Watchers > maxWatchers {
+			return fmt.Errorf("watchers must be in (0, %d]", maxWatchers)
+		}
+	default:
+		return fmt.Errorf("unknown workload %q", s.Workload)
+	}
+	if s.KeySize <= 0 || s.KeySize > maxKeySize {
+		return fmt.Errorf("key size must be in (0, %d]", maxKeySize)
+	}
+	if s.ValueSize < 0 || s.ValueSize > maxValueSize {
+		return fmt.Errorf("value size must be in [0, %d]", maxValueSize)
+	}
+	if s.ValueSizeMax > maxValueSize {
+		return fmt.Errorf("maximum value size must be at most %d", maxValueSize)
+	}
+	if s.Clients <= 0 || s.Clients > maxClients {
+		return fmt.Errorf("clients must be in (0, %d]", maxClients)
+	}
+	if s.Total <= 0 {
+		return fmt.Errorf("total must be positive")
+	}
+	if s.QPS < 0 {
+		return fmt.Errorf("QPS cannot be negative")
+	}
+	if s.KeySpace <= 0 {
+		s.KeySpace = s.Total
+	}
+	if s.Seed == 0 {
+		s.Seed = time.Now().UnixNano()
+	}
+	return s.workload(0).Validate()
+}
+
+// workload returns the key and value spec of the client.
+func (s Spec) workload(client int) workload.Spec {
+	return workload.Spec{
+		Distribution: s.Distribution,
+		KeySpace:     s.KeySpace,
+		KeySize:      s.KeySize,
+		Offset:       client * s.KeySpace / s.Clients,
+		ZipfS:        s.ZipfS,
+		ValueSize:    s.ValueSize,
+		ValueSizeMax: s.ValueSizeMax,
+	}
+}
+
+// key returns the n-th key of the key space, padded to the key size.
+var key = workload.Key
+
+// Run runs the workload, spreading the requests over the clients
+// in round-robin. Requests are not retried.
+func Run(ctx context.Context, clis []*clientv3.Client, spec Spec) (Result, error) {
+	if err := spec.validate(); err != nil {
+		return Result{}, err
+	}
+	if len(clis) == 0 {
+		return Result{}, fmt.Errorf("no client is given")
+	}
+
+	var limiter *rate.Limiter
+	if spec.QPS > 0 {
+		limiter = rate.NewLimiter(rate.Limit(spec.QPS), 1)
+	}
+	gens := make([]*workload.Generator, spec.Clients)
+	for c := range gens {
+		var err error
+		if gens[c], err = workload.New(spec.workload(c), rand.New(rand.NewSource(spec.Seed+int64(c)))); err != nil {
+			return Result{}, err
+		}
+	}
+
+	var ws *watchers
+	if spec.Workload == Watch {
+		var err error
+		if ws, err = startWatchers(ctx, clis, spec.Watchers); err != nil {
+			return Result{}, err
+		}
+		defer ws.stop()
+	}
+
+	reqs := make(chan int, spec.Total)
+	for i := 0; i < spec.Total; i++ {
+		reqs <- i
+	}
+	close(reqs)
+
+	var (
+		mu       sync.Mutex
+		lats     = make([]time.Duration, 0, spec.Total)
+		errs     int
+		firstErr error
+	)
+	var wg sync.WaitGroup
+	wg.Add(spec.Clients)
+	now := time.Now()
+	for c := 0; c < spec.Clients; c++ {
+		go func(cli *clientv3.Client, gen *workload.Generator) {
+			defer wg.Done()
+			for range reqs {
+				if limiter != nil {
+					if err := limiter.Wait(ctx); err != nil {
+						return
+					}
+				}
+				k, value := gen.Next()
+
+				start := time.Now()
+				var err error
+				switch spec.Workload {
+				case Put:
+					_, err = cli.Put(ctx, k, value)
+				case Range:
+					var opts []clientv3.OpOption
+					if spec.Serializable {
+						opts = append(opts, clientv3.WithSerializable())
+					}
+					_, err = cli.Get(ctx, k, opts...)
+				case Txn:
+					_, err = cli.Txn(ctx).
+						If(clientv3.Compare(clientv3.Version(k), ">=", 0)).
+						Then(clientv3.OpPut(k, value)).
+						Commit()
+				case Watch:
+					_, err = cli.Put(ctx, WatchPrefix+k, stampValue(spec.ValueSize))
+				}
+				took := time.Since(start)
+
+				mu.Lock()
+				if err != nil {
+					errs++
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					lats = append(lats, took)
+				}
+				mu.Unlock()
+			}
+		}(clis[c%len(clis)], gens[c])
+	}
+	wg.Wait()
+
+	r := Result{
+		Spec:     spec,
+		Requests: len(lats) + errs,
+		Errors:   errs,
+		Took:     time.Since(now),
+		Latency:  percentiles(lats),
+	}
+	if firstErr != nil {
+		r.FirstError = firstErr.Error()
+	}
+	if r.Took > 0 {
+		r.Throughput = float64(len(lats)) / r.Took.Seconds()
+	}
+	if ws != nil {
+		r.Events, r.WatchLag = ws.wait(ctx, len(lats)*spec.Watchers)
+	}
+	return r, ctx.Err()
+}
+
+// percentiles returns the latency percentiles, sorting the latencies.
+func percentiles(lats []time.Duration) (p Percentiles) {
+	if len(lats) == 0 {
+		return p
+	}
+	sort.Slice(lats, func(i, j int) bool { return lats[i] < lats[j] })
+
+	var sum time.Duration
+	for _, l := range lats {
+		sum += l
+	}
+	at := func(q float64) time.Duration {
+		i := int(q*float64(len(lats))+0.5) - 1
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(lats) {
+			i = len(lats) - 1
+		}
+		return lats[i]
+	}
+	return Percentiles{
+		Min: lats[0],
+		Avg: sum / time.Duration(len(lats)),
+		P50: at(0.50),
+		P90: at(0.90),
+		P95: at(0.95),
+		P99: at(0.99),
+		Max: lats[len(lats)-1],
+	}
+}
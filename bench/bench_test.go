@@ -0,0 +1,97 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentiles(t *testing.T) {
+	var lats []time.Duration
+	for i := 100; i >= 1; i-- {
+		lats = append(lats, time.Duration(i)*time.Millisecond)
+	}
+	p := percentiles(lats)
+	exp := Percentiles{
+		Min: time.Millisecond,
+		Avg: 50500 * time.Microsecond,
+		P50: 50 * time.Millisecond,
+		P90: 90 * time.Millisecond,
+		P95: 95 * time.Millisecond,
+		P99: 99 * time.Millisecond,
+		Max: 100 * time.Millisecond,
+	}
+	if p != exp {
+		t.Fatalf("expected %+v, got %+v", exp, p)
+	}
+
+	if p = percentiles(nil); p != (Percentiles{}) {
+		t.Fatalf("expected empty percentiles, got %+v", p)
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		n, size int
+		exp     string
+	}{
+		{1, 4, "0001"},
+		{12345, 3, "345"},
+	}
+	for i, tt := range tests {
+		if k := key(tt.n, tt.size); k != tt.exp {
+			t.Errorf("#%d: expected %q, got %q", i, tt.exp, k)
+		}
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	s := Spec{Workload: Put, KeySize: 8, ValueSize: 8, Clients: 1, Total: 10}
+	if err := s.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if s.KeySpace != 10 {
+		t.Fatalf("expected key space 10, got %d", s.KeySpace)
+	}
+	s.Workload = "delete"
+	if err := s.validate(); err == nil {
+		t.Fatal("expected error on unknown workload")
+	}
+	s.Workload = Watch
+	if err := s.validate(); err == nil {
+		t.Fatal("expected error on watch workload without watchers")
+	}
+	s.Watchers = 10
+	if err := s.validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStamp(t *testing.T) {
+	for _, size := range []int{0, 64} {
+		v := stampValue(size)
+		if size > 0 && len(v) != size {
+			t.Fatalf("expected %d bytes, got %d", size, len(v))
+		}
+		ts, err := parseStamp([]byte(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := time.Since(ts); d < 0 || d > time.Minute {
+			t.Fatalf("unexpected stamp %v", ts)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is a persisted benchmark run.
+type Record struct {
+	ID   string
+	Time time.Time
+	// Labels describe the setup (e.g. "size": "5", "client-tls": "true").
+	Labels map[string]string
+	Result Result
+}
+
+// Store persists runs as JSON files in a directory.
+type Store struct {
+	dir string
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewStore returns a Store in the directory, creating it if needed.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+func validRunID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, `/\.`)
+}
+
+// Save persists the result and returns the run with its ID.
+func (s *Store) Save(labels map[string]string, r Result) (Record, error) {
+	s.mu.Lock()
+	now := time.Now()
+	if !now.After(s.last) {
+		now = s.last.Add(time.Nanosecond)
+	}
+	s.last = now
+	s.mu.Unlock()
+
+	run := Record{ID: fmt.Sprintf("%d", now.UnixNano()), Time: now, Labels: labels, Result: r}
+	b, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return Record{}, err
+	}
+	return run, ioutil.WriteFile(filepath.Join(s.dir, run.ID+".json"), b, 0600)
+}
+
+// Load returns the run.
+func (s *Store) Load(id string) (Record, error) {
+	if !validRunID(id) {
+		return Record{}, fmt.Errorf("invalid run ID %q", id)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, id+".json"))
+	if err != nil {
+		return Record{}, err
+	}
+	var run Record
+	err = json.Unmarshal(b, &run)
+	return run, err
+}
+
+// List returns all runs, oldest first.
+func (s *Store) List() ([]Record, error) {
+	fs, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]Record, 0, len(fs))
+	for _, f := range fs {
+		run, err := s.Load(strings.TrimSuffix(filepath.Base(f), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Time.Before(runs[j].Time) })
+	return runs, nil
+}
+
+// Delta is the change of a metric from run A to run B.
+type Delta struct {
+	Metric string
+	A      float64
+	B      float64
+	// Change is (B-A)/A in percent, or zero if A is zero.
+	Change float64
+}
+
+// Comparison is the difference between two runs.
+type Comparison struct {
+	A, B Record
+	// SpecDiffers is true if the runs have different workloads,
+	// so the comparison is not apples to apples.
+	SpecDiffers bool
+	// Labels are the labels that differ, as "key: A -> B".
+	Labels []string
+	Deltas []Delta
+}
+
+func delta(metric string, a, b float64) Delta {
+	d := Delta{Metric: metric, A: a, B: b}
+	if a != 0 {
+		d.Change = (b - a) / a * 100
+	}
+	return d
+}
+
+// Compare returns the difference from run 'a' to run 'b'.
+// Latencies are in milliseconds.
+func Compare(a, b Record) Comparison {
+	c := Comparison{A: a, B: b}
+
+	// runs of the same workload may have different seeds
+	sa, sb := a.Result.Spec, b.Result.Spec
+	sa.Seed, sb.Seed = 0, 0
+	c.SpecDiffers = sa != sb
+
+	keys := make(map[string]struct{})
+	for k := range a.Labels {
+		keys[k] = struct{}{}
+	}
+	for k := range b.Labels {
+		keys[k] = struct{}{}
+	}
+	for k := range keys {
+		if a.Labels[k] != b.Labels[k] {
+			c.Labels = append(c.Labels, fmt.Sprintf("%s: %q -> %q", k, a.Labels[k], b.Labels[k]))
+		}
+	}
+	sort.Strings(c.Labels)
+
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	la, lb := a.Result.Latency, b.Result.Latency
+	c.Deltas = []Delta{
+		delta("throughput", a.Result.Throughput, b.Result.Throughput),
+		delta("errors", float64(a.Result.Errors), float64(b.Result.Errors)),
+		delta("latency-avg", ms(la.Avg), ms(lb.Avg)),
+		delta("latency-p50", ms(la.P50), ms(lb.P50)),
+		delta("latency-p90", ms(la.P90), ms(lb.P90)),
+		delta("latency-p95", ms(la.P95), ms(lb.P95)),
+		delta("latency-p99", ms(la.P99), ms(lb.P99)),
+		delta("latency-max", ms(la.Max), ms(lb.Max)),
+	}
+	if sa.Workload == Watch || sb.Workload == Watch {
+		wa, wb := a.Result.WatchLag, b.Result.WatchLag
+		c.Deltas = append(c.Deltas,
+			delta("watch-events", float64(a.Result.Events), float64(b.Result.Events)),
+			delta("watch-lag-p50", ms(wa.P50), ms(wb.P50)),
+			delta("watch-lag-p99", ms(wa.P99), ms(wb.P99)),
+			delta("watch-lag-max", ms(wa.Max), ms(wb.Max)),
+		)
+	}
+	return c
+}
+
+// Compare loads and compares two runs.
+func (s *Store) Compare(idA, idB string) (Comparison, error) {
+	a, err := s.Load(idA)
+	if err != nil {
+		return Comparison{}, err
+	}
+	b, err := s.Load(idB)
+	if err != nil {
+		return Comparison{}, err
+	}
+	return Compare(a, b), nil
+}
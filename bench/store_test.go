@@ -0,0 +1,79 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "bench-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := Spec{Workload: Put, KeySize: 8, ValueSize: 8, Clients: 1, Total: 10, KeySpace: 10}
+	a, err := s.Save(map[string]string{"size": "3"}, Result{Spec: spec, Throughput: 100, Latency: Percentiles{P99: 10 * time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.Save(map[string]string{"size": "5"}, Result{Spec: spec, Throughput: 80, Latency: Percentiles{P99: 15 * time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].ID != a.ID || runs[1].ID != b.ID {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+
+	c, err := s.Compare(a.ID, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.SpecDiffers {
+		t.Fatal("expected same spec")
+	}
+	if len(c.Labels) != 1 || c.Labels[0] != `size: "3" -> "5"` {
+		t.Fatalf("unexpected label diff %v", c.Labels)
+	}
+	for _, d := range c.Deltas {
+		switch d.Metric {
+		case "throughput":
+			if d.Change != -20 {
+				t.Fatalf("expected -20%% throughput, got %v", d.Change)
+			}
+		case "latency-p99":
+			if d.Change != 50 {
+				t.Fatalf("expected +50%% p99, got %v", d.Change)
+			}
+		}
+	}
+
+	if _, err = s.Load("../x"); err == nil {
+		t.Fatal("expected error on invalid run ID")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+)
+
+// WatchPrefix is the hot prefix of the Watch workload.
+const WatchPrefix = "/bench/watch/"
+
+// watchDrainTimeout is how long to wait for the events
+// after the last write of the Watch workload.
+var watchDrainTimeout = 10 * time.Second
+
+// stampValue returns a value holding the current time. The value is
+// padded to 'size' bytes, or longer if the time does not fit.
+func stampValue(size int) string {
+	v := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if len(v) < size {
+		v += string(make([]byte, size-len(v)))
+	}
+	return v
+}
+
+// parseStamp returns the time held by a value from stampValue.
+func parseStamp(v []byte) (time.Time, error) {
+	ns, err := strconv.ParseInt(strings.TrimRight(string(v), "\x00"), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, ns), nil
+}
+
+// watchers receive the events of the Watch workload.
+type watchers struct {
+	cancel func()
+	wg     sync.WaitGroup
+	events int64
+
+	mu   sync.Mutex
+	lags []time.Duration
+}
+
+// startWatchers opens 'n' watchers on WatchPrefix spread over the
+// clients, and waits until all of them are created.
+func startWatchers(ctx context.Context, clis []*clientv3.Client, n int) (*watchers, error) {
+	wctx, cancel := context.WithCancel(ctx)
+	ws := &watchers{cancel: cancel}
+
+	created := make(chan error, n)
+	ws.wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(cli *clientv3.Client) {
+			defer ws.wg.Done()
+			wch := cli.Watch(wctx, WatchPrefix, clientv3.WithPrefix(), clientv3.WithCreatedNotify())
+			wr, ok := <-wch
+			if !ok || wr.Err() != nil {
+				created <- fmt.Errorf("failed to create watcher (%v)", wr.Err())
+				return
+			}
+			created <- nil
+
+			var lags []time.Duration
+			defer func() {
+				ws.mu.Lock()
+				ws.lags = append(ws.lags, lags...)
+				ws.mu.Unlock()
+			}()
+			for wr := range wch {
+				now := time.Now()
+				for _, ev := range wr.Events {
+					if t, err := parseStamp(ev.Kv.Value); err == nil {
+						lags = append(lags, now.Sub(t))
+					}
+				}
+				atomic.AddInt64(&ws.events, int64(len(wr.Events)))
+			}
+		}(clis[i%len(clis)])
+	}
+
+	for i := 0; i < n; i++ {
+		if err := <-created; err != nil {
+			ws.stop()
+			return nil, err
+		}
+	}
+	return ws, nil
+}
+
+// wait waits until the watchers receive 'expected' events, or until
+// watchDrainTimeout, and returns the events and their delivery lag.
+func (ws *watchers) wait(ctx context.Context, expected int) (int, Percentiles) {
+	deadline := time.After(watchDrainTimeout)
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+loop:
+	for atomic.LoadInt64(&ws.events) < int64(expected) {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			break loop
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	ws.stop()
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return int(atomic.LoadInt64(&ws.events)), percentiles(ws.lags)
+}
+
+// stop closes the watchers and waits for them to exit.
+func (ws *watchers) stop() {
+	ws.cancel()
+	ws.wg.Wait()
+}
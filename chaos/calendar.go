@@ -0,0 +1,444 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+	"github.com/coreos/etcdlabs/nemesis"
+	"github.com/coreos/etcdlabs/pkg/cron"
+	"github.com/coreos/etcdlabs/scenario"
+
+	"github.com/golang/glog"
+)
+
+// ActionRestart restarts a stopped node. Other actions are nemeses
+// (see package nemesis).
+const ActionRestart = "restart"
+
+// Targets of calendar entries, other than node names.
+const (
+	TargetLeader   = "leader"
+	TargetFollower = "follower"
+	TargetRandom   = "random"
+	TargetStopped  = "stopped"
+)
+
+// requestTimeout bounds the status refresh, injections and recoveries.
+var requestTimeout = 5 * time.Second
+
+// Entry is a recurring failure injection.
+type Entry struct {
+	Name string `json:"name"`
+	// Schedule is a cron expression (e.g. "*/5 * * * *" or "@every 5m").
+	Schedule string `json:"schedule"`
+	// Action is "restart" or the name of a registered nemesis
+	// (e.g. "stop" or "kill").
+	Action string `json:"action"`
+	// Target is a node name, "leader", "follower", "random" or "stopped".
+	Target string `json:"target"`
+	// Recover recovers the node from the nemesis after the duration.
+	// The node is not recovered if it is zero.
+	Recover scenario.Duration `json:"recover,omitempty"`
+}
+
+// Validate checks the entry.
+func (e Entry) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("entry has no name")
+	}
+	if _, err := cron.Parse(e.Schedule); err != nil {
+		return err
+	}
+	if e.Action == ActionRestart {
+		switch e.Target {
+		case TargetLeader, TargetFollower, TargetRandom:
+			return fmt.Errorf("cannot restart %q, which is started", e.Target)
+		}
+		if e.Recover != 0 {
+			return fmt.Errorf("restarts do not recover")
+		}
+	} else {
+		if _, err := nemesis.Get(e.Action); err != nil {
+			return err
+		}
+		if e.Target == TargetStopped {
+			return fmt.Errorf("cannot %s a stopped node", e.Action)
+		}
+	}
+	if e.Target == "" {
+		return fmt.Errorf("entry %q has no target", e.Name)
+	}
+	if e.Recover < 0 {
+		return fmt.Errorf("negative recovery delay %v", time.Duration(e.Recover))
+	}
+	return nil
+}
+
+// Status is the state of a calendar entry.
+type Status struct {
+	Entry
+	Next time.Time
+	Runs int
+	// LastRun, LastNode and LastError describe the last injection.
+	LastRun   time.Time
+	LastNode  string
+	LastError string
+}
+
+type entry struct {
+	schedule cron.Schedule
+	status   Status
+}
+
+// Calendar runs the entries against a cluster.
+type Calendar struct {
+	clus *cluster.Cluster
+	path string
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	entries map[string]*entry
+
+	updatec   chan struct{}
+	stopc     chan struct{}
+	donec     chan struct{}
+	closeOnce sync.Once
+	recovery  sync.WaitGroup
+}
+
+// Open loads the entries saved in the file, if it exists, and starts
+// running them against the cluster. Entries are kept in memory if the
+// path is empty.
+func Open(clus *cluster.Cluster, path string) (*Calendar, error) {
+	c := &Calendar{
+		clus:    clus,
+		path:    path,
+		rand:    clus.NewRand(),
+		entries: make(map[string]*entry),
+		updatec: make(chan struct{}, 1),
+		stopc:   make(chan struct{}),
+		donec:   make(chan struct{}),
+	}
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, err
+		default:
+			var es []Entry
+			if err = json.Unmarshal(b, &es); err != nil {
+				return nil, fmt.Errorf("invalid calendar %q (%v)", path, err)
+			}
+			now := time.Now()
+			for _, e := range es {
+				if err = c.add(e, now); err != nil {
+					return nil, fmt.Errorf("invalid calendar %q (%v)", path, err)
+				}
+			}
+		}
+	}
+	go c.run()
+	return c, nil
+}
+
+// Add adds the entry, replacing any entry of the same name.
+func (c *Calendar) Add(e Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.add(e, time.Now()); err != nil {
+		return err
+	}
+	c.notify()
+	return c.save()
+}
+
+func (c *Calendar) add(e Entry, now time.Time) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	s, err := cron.Parse(e.Schedule)
+	if err != nil {
+		return err
+	}
+	c.entries[e.Name] = &entry{schedule: s, status: Status{Entry: e, Next: s.Next(now)}}
+	return nil
+}
+
+// Remove removes the entry.
+func (c *Calendar) Remove(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[name]; !ok {
+		return fmt.Errorf("unknown entry %q", name)
+	}
+	delete(c.entries, name)
+	c.notify()
+	return c.save()
+}
+
+// Entries returns the status of the entries, sorted by name.
+func (c *Calendar) Entries() []Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ss := make([]Status, 0, len(c.entries))
+	for _, e := range c.entries {
+		ss = append(ss, e.status)
+	}
+	sort.Slice(ss, func(i, j int) bool { return ss[i].Name < ss[j].Name })
+	return ss
+}
+
+// Close stops running the entries, and restarts the nodes
+// waiting for recovery.
+func (c *Calendar) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stopc)
+		<-c.donec
+		c.recovery.Wait()
+	})
+}
+
+// notify wakes up the run loop to reschedule.
+func (c *Calendar) notify() {
+	select {
+	case c.updatec <- struct{}{}:
+	default:
+	}
+}
+
+// save writes the entries to the calendar file, if any.
+func (c *Calendar) save() error {
+	if c.path == "" {
+		return nil
+	}
+	es := make([]Entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		es = append(es, e.status.Entry)
+	}
+	sort.Slice(es, func(i, j int) bool { return es[i].Name < es[j].Name })
+	b, err := json.MarshalIndent(es, "", "\t")
+	if err != nil {
+		return err
+	}
+	// write and rename, so the file is always a complete calendar
+	tmp := c.path + ".tmp"
+	if err = ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, c.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (c *Calendar) run() {
+	defer close(c.donec)
+	for {
+		var (
+			t     *time.Timer
+			timer <-chan time.Time
+		)
+		if next := c.next(); !next.IsZero() {
+			t = time.NewTimer(next.Sub(time.Now()))
+			timer = t.C
+		}
+		select {
+		case now := <-timer:
+			for _, e := range c.due(now) {
+				c.inject(e)
+			}
+		case <-c.updatec:
+		case <-c.stopc:
+		case <-c.clus.StopNotify():
+		}
+		if t != nil {
+			t.Stop()
+		}
+		select {
+		case <-c.stopc:
+			return
+		case <-c.clus.StopNotify():
+			return
+		default:
+		}
+	}
+}
+
+// next returns the earliest activation time of the entries.
+func (c *Calendar) next() (next time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.entries {
+		if n := e.status.Next; !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
+		}
+	}
+	return next
+}
+
+// due returns the entries due at the time, and schedules their next run.
+func (c *Calendar) due(now time.Time) []*entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var es []*entry
+	for _, e := range c.entries {
+		if n := e.status.Next; !n.IsZero() && !n.After(now) {
+			e.status.Next = e.schedule.Next(now)
+			es = append(es, e)
+		}
+	}
+	sort.Slice(es, func(i, j int) bool { return es[i].status.Name < es[j].status.Name })
+	return es
+}
+
+// inject runs the entry once, and records the result.
+func (c *Calendar) inject(e *entry) {
+	c.mu.Lock()
+	en := e.status.Entry
+	c.mu.Unlock()
+
+	now := time.Now()
+	name, err := c.apply(en)
+	if err != nil {
+		glog.Warningf("chaos %q: %s %s failed (%v)", en.Name, en.Action, en.Target, err)
+	} else {
+		glog.Infof("chaos %q: %s %q", en.Name, en.Action, name)
+	}
+
+	c.mu.Lock()
+	e.status.Runs++
+	e.status.LastRun, e.status.LastNode, e.status.LastError = now, name, ""
+	if err != nil {
+		e.status.LastError = err.Error()
+	}
+	c.mu.Unlock()
+}
+
+// apply injects the failure, and returns the name of the target node.
+func (c *Calendar) apply(e Entry) (string, error) {
+	idx, err := c.target(e.Target)
+	if err != nil {
+		return "", err
+	}
+	st, err := c.clus.MemberStatus(idx)
+	if err != nil {
+		return "", err
+	}
+	name := st.Name
+	stopped := st.State == clusterpb.StoppedMemberStatus
+
+	if e.Action == ActionRestart {
+		if !stopped {
+			return name, fmt.Errorf("%q is already started", name)
+		}
+		return name, c.clus.Restart(idx)
+	}
+
+	n, err := nemesis.Get(e.Action)
+	if err != nil {
+		return name, err
+	}
+	if stopped {
+		return name, fmt.Errorf("%q is already stopped", name)
+	}
+	if c.clus.ActiveNodeN() <= c.clus.Quorum() {
+		return name, fmt.Errorf("skipped %s of %q, which would lose quorum", e.Action, name)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	err = n.Inject(ctx, c.clus, idx)
+	cancel()
+	if err != nil {
+		return name, err
+	}
+	if e.Recover > 0 {
+		c.recovery.Add(1)
+		go c.recover(n, name, time.Duration(e.Recover))
+	}
+	return name, nil
+}
+
+// recover recovers the node after the delay, or when the calendar closes.
+func (c *Calendar) recover(n nemesis.Nemesis, name string, d time.Duration) {
+	defer c.recovery.Done()
+	select {
+	case <-time.After(d):
+	case <-c.stopc:
+	case <-c.clus.StopNotify():
+		return
+	}
+	idx := c.clus.FindIndexByName(name)
+	if idx < 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	if err := n.Recover(ctx, c.clus, idx); err != nil {
+		glog.Warningf("chaos: failed to recover %q (%v)", name, err)
+		return
+	}
+	glog.Infof("chaos: recovered %q", name)
+}
+
+// target returns the index of the target node.
+func (c *Calendar) target(target string) (int, error) {
+	switch target {
+	case TargetLeader, TargetFollower, TargetRandom, TargetStopped:
+	default:
+		idx := c.clus.FindIndexByName(target)
+		if idx < 0 {
+			return -1, fmt.Errorf("unknown node %q", target)
+		}
+		return idx, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	err := c.clus.RefreshStatus(ctx)
+	cancel()
+	if err != nil {
+		return -1, err
+	}
+
+	var idxs []int
+	for i, st := range c.clus.AllMemberStatus() {
+		started := st.State != clusterpb.StoppedMemberStatus
+		switch {
+		case target == TargetLeader && started && st.IsLeader,
+			target == TargetFollower && started && !st.IsLeader,
+			target == TargetRandom && started,
+			target == TargetStopped && !started:
+			idxs = append(idxs, i)
+		}
+	}
+	if len(idxs) == 0 {
+		return -1, fmt.Errorf("no %s node", target)
+	}
+	c.mu.Lock()
+	i := idxs[c.rand.Intn(len(idxs))]
+	c.mu.Unlock()
+	return i, nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chaos injects recurring failures into a cluster on a schedule,
+// for long-running demo and soak deployments.
+//
+// A Calendar holds entries with a cron expression (see package cron), an
+// action and a target, such as "kill a random follower every 5 minutes":
+//
+//	name: kill-follower
+//	schedule: "*/5 * * * *"
+//	action: kill
+//	target: follower
+//	recover: 30s
+//
+// Actions are "restart" and the registered nemeses, such as "stop" and
+// "kill" (see package nemesis). Targets are a node name, "leader",
+// "follower" (a random follower), "random" (a random started node) and, to
+// restart, "stopped" (a random stopped node). Nodes are recovered from the
+// nemesis after 'recover', if set.
+//
+// Nemeses that would lose quorum are skipped, so the cluster stays
+// available however the entries overlap.
+//
+// Entries are saved to the calendar file, if any, and loaded again when
+// the calendar is opened, so schedules survive restarts of the backend.
+package chaos
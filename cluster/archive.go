@@ -0,0 +1,139 @@
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// writeArchive writes a gzipped tarball of the root directory (the data
+// directories, and the certificates and process logs if any) under
+// 'data/', the captured logs of each member as 'logs/<name>.log', and the
+// event log as 'events.jsonl'. Sockets and other special files are skipped.
+// The members must be stopped, so that the data files are consistent.
+func (clus *Cluster) writeArchive(path string) error {
+	if err := mkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+	tmp := path + ".part"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	err = clus.archiveFiles(tw)
+	if cerr := tw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := gw.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+func (clus *Cluster) archiveFiles(tw *tar.Writer) error {
+	now := time.Now()
+	root := clus.rootDir
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() && !fi.IsDir() {
+			return nil
+		}
+		hdr, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(filepath.Join("data", rel))
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if err = tw.WriteHeader(hdr); err != nil || fi.IsDir() {
+			return err
+		}
+		rf, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer rf.Close()
+		_, err = io.CopyN(tw, rf, fi.Size())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, m := range clus.Members {
+		var buf bytes.Buffer
+		for _, l := range m.logs.last(0) {
+			fmt.Fprintf(&buf, "%s %s %s: %s\n", l.Time.Format(time.RFC3339Nano), l.Level, l.Package, l.Text)
+		}
+		if err = writeArchiveFile(tw, "logs/"+m.cfg.Name+".log", buf.Bytes(), now); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, ev := range clus.events.last(0) {
+		if err = enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+	return writeArchiveFile(tw, "events.jsonl", buf.Bytes(), now)
+}
+
+// validateShutdownArchive returns an error if the archive would be
+// deleted with the root directory.
+func (c Config) validateShutdownArchive() error {
+	if c.ShutdownArchive == "" {
+		return nil
+	}
+	root, err := filepath.Abs(c.RootDir)
+	if err != nil {
+		return err
+	}
+	path, err := filepath.Abs(c.ShutdownArchive)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("shutdown archive %q cannot be under root directory %q", c.ShutdownArchive, c.RootDir)
+	}
+	return nil
+}
+
+func writeArchiveFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modTime}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
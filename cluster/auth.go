@@ -0,0 +1,308 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+)
+
+// ErrAuthEmbeddedClient is returned when auth is used with embedded clients,
+// which cannot carry credentials.
+var ErrAuthEmbeddedClient = errors.New("auth is not supported with embedded clients")
+
+// rootUser is the user with full access, required to enable auth.
+const rootUser = "root"
+
+// Permission is a key range permission of a role.
+type Permission struct {
+	// Type is one of "READ", "WRITE" and "READWRITE".
+	Type     string
+	Key      string
+	RangeEnd string
+}
+
+// AuthRole is a role with its permissions.
+type AuthRole struct {
+	Name        string
+	Permissions []Permission
+}
+
+// AuthUser is a user with its granted roles.
+type AuthUser struct {
+	Name  string
+	Roles []string
+}
+
+// AuthInfo describes the auth state of the cluster.
+type AuthInfo struct {
+	Enabled bool
+	// ClientCertAuth is true if users are identified by client certificate CN.
+	ClientCertAuth bool
+
+	Users []AuthUser
+	Roles []AuthRole
+}
+
+// rootCredentials returns the root user name and password
+// if auth is enabled, or empty strings otherwise.
+func (clus *Cluster) rootCredentials() (string, string) {
+	clus.authMu.RLock()
+	defer clus.authMu.RUnlock()
+	if clus.rootPassword == "" {
+		return "", ""
+	}
+	return rootUser, clus.rootPassword
+}
+
+// AuthEnabled returns true if auth is enabled.
+func (clus *Cluster) AuthEnabled() bool {
+	clus.authMu.RLock()
+	defer clus.authMu.RUnlock()
+	return clus.rootPassword != ""
+}
+
+// AuthEnable creates the root user with the password and enables auth through node 'i'.
+// Internal clients authenticate as root afterwards.
+func (clus *Cluster) AuthEnable(ctx context.Context, i int, rootPassword string) error {
+	if clus.embeddedClient {
+		return ErrAuthEmbeddedClient
+	}
+	if rootPassword == "" {
+		return fmt.Errorf("root password is empty")
+	}
+	if clus.AuthEnabled() {
+		return fmt.Errorf("auth is already enabled")
+	}
+
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return err
+	}
+
+	if _, err = cli.UserAdd(ctx, rootUser, rootPassword); err != nil && err != rpctypes.ErrUserAlreadyExist {
+		return err
+	}
+	if err == rpctypes.ErrUserAlreadyExist {
+		if _, err = cli.UserChangePassword(ctx, rootUser, rootPassword); err != nil {
+			return err
+		}
+	}
+	if _, err = cli.UserGrantRole(ctx, rootUser, rootUser); err != nil {
+		return err
+	}
+	if _, err = cli.AuthEnable(ctx); err != nil {
+		return err
+	}
+
+	clus.authMu.Lock()
+	clus.rootPassword = rootPassword
+	clus.authMu.Unlock()
+
+	clus.closeSharedClients()
+	return nil
+}
+
+// AuthDisable disables auth through node 'i'.
+func (clus *Cluster) AuthDisable(ctx context.Context, i int) error {
+	if !clus.AuthEnabled() {
+		return fmt.Errorf("auth is not enabled")
+	}
+
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return err
+	}
+
+	if _, err = cli.AuthDisable(ctx); err != nil {
+		return err
+	}
+
+	clus.authMu.Lock()
+	clus.rootPassword = ""
+	clus.authMu.Unlock()
+
+	clus.closeSharedClients()
+	return nil
+}
+
+// UserAdd creates a user through node 'i'.
+func (clus *Cluster) UserAdd(ctx context.Context, i int, user, password string) error {
+	if user == "" {
+		return fmt.Errorf("user name is empty")
+	}
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.UserAdd(ctx, user, password)
+	return err
+}
+
+// UserDelete deletes a user through node 'i'.
+func (clus *Cluster) UserDelete(ctx context.Context, i int, user string) error {
+	if user == rootUser && clus.AuthEnabled() {
+		return fmt.Errorf("cannot delete root user while auth is enabled")
+	}
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.UserDelete(ctx, user)
+	return err
+}
+
+// UserGrantRole grants the role to the user through node 'i'.
+func (clus *Cluster) UserGrantRole(ctx context.Context, i int, user, role string) error {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.UserGrantRole(ctx, user, role)
+	return err
+}
+
+// RoleAdd creates a role through node 'i'.
+func (clus *Cluster) RoleAdd(ctx context.Context, i int, role string) error {
+	if role == "" {
+		return fmt.Errorf("role name is empty")
+	}
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.RoleAdd(ctx, role)
+	return err
+}
+
+// RoleGrantPermission grants the role access to the key range through node 'i'.
+// If 'prefix' is true, the permission covers all keys with the prefix 'Key'.
+func (clus *Cluster) RoleGrantPermission(ctx context.Context, i int, role string, perm Permission, prefix bool) error {
+	pt, err := clientv3.StrToPermissionType(strings.ToUpper(perm.Type))
+	if err != nil {
+		return err
+	}
+	if prefix && perm.RangeEnd != "" {
+		return fmt.Errorf("prefix and range end cannot be set together")
+	}
+	rangeEnd := perm.RangeEnd
+	if prefix {
+		rangeEnd = clientv3.GetPrefixRangeEnd(perm.Key)
+	}
+
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.RoleGrantPermission(ctx, role, perm.Key, rangeEnd, pt)
+	return err
+}
+
+// AuthInfo returns the users, roles and permissions through node 'i'.
+func (clus *Cluster) AuthInfo(ctx context.Context, i int) (info AuthInfo, err error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return info, err
+	}
+
+	info.Enabled = clus.AuthEnabled()
+	info.ClientCertAuth = clus.ccfg.ClientCertAuth
+
+	uresp, err := cli.UserList(ctx)
+	if err != nil {
+		return info, err
+	}
+	for _, name := range uresp.Users {
+		gresp, err := cli.UserGet(ctx, name)
+		if err != nil {
+			return info, err
+		}
+		info.Users = append(info.Users, AuthUser{Name: name, Roles: gresp.Roles})
+	}
+
+	rresp, err := cli.RoleList(ctx)
+	if err != nil {
+		return info, err
+	}
+	for _, name := range rresp.Roles {
+		gresp, err := cli.RoleGet(ctx, name)
+		if err != nil {
+			return info, err
+		}
+		role := AuthRole{Name: name}
+		for _, p := range gresp.Perm {
+			role.Permissions = append(role.Permissions, Permission{
+				Type:     p.PermType.String(),
+				Key:      string(p.Key),
+				RangeEnd: string(p.RangeEnd),
+			})
+		}
+		info.Roles = append(info.Roles, role)
+	}
+	return info, nil
+}
+
+// userClient returns a client of node 'i' authenticated as the user.
+func (clus *Cluster) userClient(i int, user, password string) (*clientv3.Client, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return nil, err
+	}
+	return m.UserClient(user, password)
+}
+
+// Authenticate checks the user credentials through node 'i'.
+func (clus *Cluster) Authenticate(i int, user, password string) error {
+	cli, err := clus.userClient(i, user, password)
+	if err != nil {
+		return err
+	}
+	return cli.Close()
+}
+
+// PutAs writes a key-value pair through node 'i' as the user,
+// subject to the permissions of the user's roles.
+func (clus *Cluster) PutAs(ctx context.Context, i int, user, password, key, val string) (resp KVResponse, err error) {
+	cli, err := clus.userClient(i, user, password)
+	if err != nil {
+		return resp, err
+	}
+	defer cli.Close()
+
+	presp, err := cli.Put(ctx, key, val)
+	if err != nil {
+		return resp, err
+	}
+	resp.Header = toResponseHeader(presp.Header)
+	return resp, nil
+}
+
+// GetAs reads a key (or all keys with the prefix) through node 'i' as the user,
+// subject to the permissions of the user's roles.
+func (clus *Cluster) GetAs(ctx context.Context, i int, user, password, key string, prefix bool) (resp KVResponse, err error) {
+	cli, err := clus.userClient(i, user, password)
+	if err != nil {
+		return resp, err
+	}
+	defer cli.Close()
+
+	var opts []clientv3.OpOption
+	if prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	gresp, err := cli.Get(ctx, key, opts...)
+	if err != nil {
+		return resp, err
+	}
+	resp.Header = toResponseHeader(gresp.Header)
+	resp.KeyValues = toKeyValues(gresp.Kvs)
+	return resp, nil
+}
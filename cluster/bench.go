@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcdlabs/bench"
+)
+
+// Bench runs the benchmark workload against the cluster, with the
+// clients connected to the members in round-robin. If Config.BenchDir
+// is set, the run is persisted with labels describing the cluster.
+func (clus *Cluster) Bench(ctx context.Context, spec bench.Spec) (bench.Result, error) {
+	rec, err := clus.bench(ctx, spec, "members", func(m *Member) (*clientv3.Client, error) {
+		cli, _, err := m.Client(ctx, false)
+		return cli, err
+	})
+	return rec.Result, err
+}
+
+// BenchGRPCProxy runs the benchmark workload with the clients connected
+// through the gRPC proxy, e.g. to compare the watch fan-out with Bench,
+// since the proxy coalesces the watchers of the same key range.
+func (clus *Cluster) BenchGRPCProxy(ctx context.Context, spec bench.Spec) (bench.Result, error) {
+	rec, err := clus.bench(ctx, spec, "grpc-proxy", func(*Member) (*clientv3.Client, error) {
+		cli, _, err := clus.GRPCProxyClient()
+		return cli, err
+	})
+	return rec.Result, err
+}
+
+// CompareFsync runs the benchmark workload on a cluster started from the
+// configuration with fsync, and then on another one without fsync (see
+// Config.UnsafeNoFsync), and compares the runs. The clusters are shut
+// down after their run. bench.FsyncSpec is a workload for the comparison.
+func CompareFsync(ctx context.Context, ccfg Config, spec bench.Spec) (bench.Comparison, error) {
+	var recs [2]bench.Record
+	for i, noFsync := range []bool{false, true} {
+		cfg := ccfg
+		cfg.UnsafeNoFsync = noFsync
+		cfg.RootCtx, cfg.RootCancel = context.WithCancel(ctx)
+
+		clus, err := Start(cfg)
+		if err != nil {
+			cfg.RootCancel()
+			return bench.Comparison{}, err
+		}
+		recs[i], err = clus.bench(ctx, spec, "members", func(m *Member) (*clientv3.Client, error) {
+			cli, _, err := m.Client(ctx, false)
+			return cli, err
+		})
+		clus.Shutdown()
+		if err != nil {
+			return bench.Comparison{}, err
+		}
+	}
+	return bench.Compare(recs[0], recs[1]), nil
+}
+
+// BenchStore returns the store of persisted runs, or nil if
+// Config.BenchDir is not set. Use it to list and compare runs.
+func (clus *Cluster) BenchStore() *bench.Store {
+	return clus.benchStore
+}
+
+// benchLabels describes the cluster setup of a benchmark run.
+func (clus *Cluster) benchLabels() map[string]string {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+
+	ls := map[string]string{
+		"size":            fmt.Sprint(clus.size),
+		"mode":            clus.ccfg.Mode,
+		"embedded-client": fmt.Sprint(clus.embeddedClient),
+		"unsafe-no-fsync": fmt.Sprint(clus.ccfg.UnsafeNoFsync),
+	}
+	if len(clus.Members) > 0 {
+		ls["client-scheme"] = clus.Members[0].cfg.LCUrls[0].Scheme
+		ls["peer-scheme"] = clus.Members[0].cfg.LPUrls[0].Scheme
+		ls["heartbeat-ms"] = fmt.Sprint(clus.Members[0].cfg.TickMs)
+		ls["election-ms"] = fmt.Sprint(clus.Members[0].cfg.ElectionMs)
+		ls["snapshot-count"] = fmt.Sprint(clus.Members[0].cfg.SnapCount)
+	}
+	return ls
+}
+
+func (clus *Cluster) bench(ctx context.Context, spec bench.Spec, via string, newClient func(*Member) (*clientv3.Client, error)) (bench.Record, error) {
+	clus.mmu.RLock()
+	members := append([]*Member{}, clus.Members...)
+	clus.mmu.RUnlock()
+
+	n := spec.Clients
+	if n > len(members) {
+		n = len(members)
+	}
+	var clis []*clientv3.Client
+	defer func() {
+		for _, cli := range clis {
+			cli.Close()
+		}
+	}()
+	for i := 0; i < n; i++ {
+		cli, err := newClient(members[i])
+		if err != nil {
+			return bench.Record{}, err
+		}
+		clis = append(clis, cli)
+	}
+
+	if spec.Seed == 0 {
+		spec.Seed = clus.NextSeed()
+	}
+	r, err := bench.Run(ctx, clis, spec)
+	ls := clus.benchLabels()
+	ls["via"] = via
+	rec := bench.Record{Time: time.Now(), Labels: ls, Result: r}
+	if err != nil || clus.benchStore == nil {
+		return rec, err
+	}
+	return clus.benchStore.Save(ls, r)
+}
@@ -0,0 +1,182 @@
+package cluster
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/golang/glog"
+)
+
+const (
+	// CatchUpLog means the restarted member replayed the missing entries
+	// from the leader's log.
+	CatchUpLog = "log"
+	// CatchUpSnapshot means the leader had compacted the missing entries,
+	// so the restarted member received a database snapshot instead.
+	CatchUpSnapshot = "snapshot"
+)
+
+var (
+	catchUpTimeout      = time.Minute
+	catchUpPollInterval = 100 * time.Millisecond
+)
+
+// CatchUp is how a restarted member caught up with the leader.
+// Lower snapshot counts make the leader compact its log sooner,
+// so followers down for longer catch up by snapshot.
+type CatchUp struct {
+	Name      string
+	ID        string
+	Restarted time.Time
+
+	// TargetIndex is the highest raft index of the other members at restart.
+	TargetIndex uint64
+	// Done is false if the member did not reach 'TargetIndex' in time.
+	Done bool
+	// Duration is from the restart until the member reached 'TargetIndex'.
+	Duration time.Duration
+
+	Method string
+
+	SnapshotIndex uint64
+	// SnapshotBytes is the size of the member database once the snapshot
+	// is applied, which is the size of the transferred snapshot.
+	SnapshotBytes    uint64
+	SnapshotBytesTxt string
+	// SnapshotDuration is the transfer time, as logged by the leader.
+	SnapshotDuration time.Duration
+}
+
+var (
+	snapSendStartRegex = regexp.MustCompile(`start to send database snapshot \[index: (\d+), to ([0-9a-f]+)\]`)
+	snapSendDoneRegex  = regexp.MustCompile(`database snapshot \[index: (\d+), to: ([0-9a-f]+)\] sent out successfully`)
+	snapRestoreRegex   = regexp.MustCompile(`^([0-9a-f]+) \[commit: \d+\] restored snapshot \[index: (\d+)`)
+)
+
+// catchUpTarget returns the highest raft index of the members other than 'm'.
+func (clus *Cluster) catchUpTarget(m *Member) (target uint64) {
+	for _, o := range clus.Members {
+		if o == m {
+			continue
+		}
+		o.statusLock.RLock()
+		if o.status.RaftIndex > target {
+			target = o.status.RaftIndex
+		}
+		o.statusLock.RUnlock()
+	}
+	return target
+}
+
+// observeCatchUp waits until the restarted member reaches the target index,
+// and finds any snapshot sent to it in the captured logs.
+func (clus *Cluster) observeCatchUp(m *Member, restarted time.Time, target uint64) {
+	cu := CatchUp{
+		Name:        m.cfg.Name,
+		ID:          m.ID().String(),
+		Restarted:   restarted,
+		TargetIndex: target,
+		Method:      CatchUpLog,
+	}
+
+	ctx, cancel := context.WithTimeout(clus.rootCtx, catchUpTimeout)
+	defer cancel()
+
+	var dbSize uint64
+	for !cu.Done {
+		select {
+		case <-ctx.Done():
+			glog.Warningf("%q did not catch up to index %d (%v)", cu.Name, target, ctx.Err())
+			clus.setCatchUp(cu)
+			return
+		case <-time.After(catchUpPollInterval):
+		}
+
+		m.statusLock.RLock()
+		stopped := m.status.State == clusterpb.StoppedMemberStatus
+		m.statusLock.RUnlock()
+		if stopped {
+			return
+		}
+
+		cli, err := m.sharedClient()
+		if err != nil {
+			continue
+		}
+		sctx, scancel := context.WithTimeout(ctx, time.Second)
+		resp, err := cli.Status(sctx, m.clientEndpoint())
+		scancel()
+		if err != nil {
+			continue
+		}
+		if resp.RaftIndex >= target {
+			cu.Done, cu.Duration, dbSize = true, time.Since(restarted), uint64(resp.DbSize)
+		}
+	}
+
+	// embedded servers share one logger, so the lines may be
+	// attributed to any member
+	var sendStart, sendDone time.Time
+	for _, o := range clus.Members {
+		for _, l := range o.logs.last(0) {
+			if l.Time.Before(restarted) {
+				continue
+			}
+			if ms := snapSendStartRegex.FindStringSubmatch(l.Text); ms != nil && ms[2] == cu.ID {
+				sendStart = l.Time
+			}
+			if ms := snapSendDoneRegex.FindStringSubmatch(l.Text); ms != nil && ms[2] == cu.ID {
+				sendDone = l.Time
+				cu.Method = CatchUpSnapshot
+				cu.SnapshotIndex, _ = strconv.ParseUint(ms[1], 10, 64)
+			}
+			if ms := snapRestoreRegex.FindStringSubmatch(l.Text); ms != nil && ms[1] == cu.ID {
+				cu.Method = CatchUpSnapshot
+				cu.SnapshotIndex, _ = strconv.ParseUint(ms[2], 10, 64)
+			}
+		}
+	}
+	if cu.Method == CatchUpSnapshot {
+		cu.SnapshotBytes, cu.SnapshotBytesTxt = dbSize, humanize.Bytes(dbSize)
+		if !sendStart.IsZero() && sendDone.After(sendStart) {
+			cu.SnapshotDuration = sendDone.Sub(sendStart)
+		}
+	}
+
+	clus.setCatchUp(cu)
+	if cu.Method == CatchUpSnapshot {
+		clus.recordEvent("member-catch-up", cu.Name, "%q caught up to index %d in %v by snapshot at index %d (%s)", cu.Name, target, cu.Duration, cu.SnapshotIndex, cu.SnapshotBytesTxt)
+	} else {
+		clus.recordEvent("member-catch-up", cu.Name, "%q caught up to index %d in %v by log replay", cu.Name, target, cu.Duration)
+	}
+}
+
+func (clus *Cluster) setCatchUp(cu CatchUp) {
+	clus.catchUpMu.Lock()
+	clus.catchUps[cu.Name] = cu
+	clus.catchUpMu.Unlock()
+}
+
+// CatchUp returns how the node caught up with the leader after its
+// last restart. It returns false if the node has not been restarted,
+// or is still catching up.
+func (clus *Cluster) CatchUp(i int) (CatchUp, bool) {
+	m, err := clus.member(i)
+	if err != nil {
+		return CatchUp{}, false
+	}
+	return clus.CatchUpByName(m.cfg.Name)
+}
+
+// CatchUpByName is CatchUp of the node with the name.
+func (clus *Cluster) CatchUpByName(name string) (CatchUp, bool) {
+	clus.catchUpMu.Lock()
+	defer clus.catchUpMu.Unlock()
+	cu, ok := clus.catchUps[name]
+	return cu, ok
+}
@@ -0,0 +1,234 @@
+package cluster
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/coreos/etcdlabs/pkg/certs"
+
+	"github.com/coreos/etcd/embed"
+	"github.com/coreos/etcd/pkg/transport"
+	"github.com/golang/glog"
+)
+
+// certsDir returns the directory of generated certificates.
+func (clus *Cluster) certsDir() string {
+	return filepath.Join(clus.rootDir, "certs")
+}
+
+// generateCA creates the cluster certificate authority.
+func (clus *Cluster) generateCA() (err error) {
+	clus.ca, err = certs.NewCA(clus.certsDir(), 0)
+	if err != nil {
+		return err
+	}
+	glog.Infof("generated CA %q", clus.ca.CertFile)
+
+	if clus.ccfg.ClientCertAuth {
+		clus.rootClientTLSInfo, err = clus.IssueClientCert(rootUser)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// issueMemberCerts generates the client-facing server certificate and the peer
+// certificate of the member, signed by the cluster CA, and sets them in 'cfg'.
+func (clus *Cluster) issueMemberCerts(cfg *embed.Config) error {
+	hosts := []string{"127.0.0.1"}
+	seen := make(map[string]bool)
+	for _, u := range append(append([]url.URL{}, cfg.LCUrls...), cfg.LPUrls...) {
+		if h := u.Hostname(); !seen[h] {
+			seen[h] = true
+			hosts = append(hosts, h)
+		}
+	}
+
+	validFor := clus.CertValidFor()
+
+	// server certificates also authenticate internal clients
+	srv, err := clus.ca.Issue(clus.certsDir(), cfg.Name, certs.Request{
+		CommonName: cfg.Name,
+		Hosts:      hosts,
+		ValidFor:   validFor,
+		Server:     true,
+		Client:     true,
+	})
+	if err != nil {
+		return err
+	}
+	peer, err := clus.ca.Issue(clus.certsDir(), cfg.Name+"-peer", certs.Request{
+		CommonName: cfg.Name,
+		Hosts:      hosts,
+		ValidFor:   validFor,
+		Server:     true,
+		Client:     true,
+	})
+	if err != nil {
+		return err
+	}
+	peer.ClientCertAuth = true
+	srv.ClientCertAuth = clus.ccfg.ClientCertAuth
+	srv.CRLFile, peer.CRLFile = clus.ca.CRLFile, clus.ca.CRLFile
+
+	cfg.ClientTLSInfo, cfg.ClientAutoTLS = srv, false
+	cfg.PeerTLSInfo, cfg.PeerAutoTLS = peer, false
+	glog.Infof("%q is set up with generated certificates %q, %q", cfg.Name, srv.CertFile, peer.CertFile)
+	return nil
+}
+
+// IssueClientCert generates a client certificate whose CN is the user name.
+// In client certificate auth mode, etcd authenticates the client as that user.
+func (clus *Cluster) IssueClientCert(user string) (transport.TLSInfo, error) {
+	if clus.ca == nil {
+		return transport.TLSInfo{}, fmt.Errorf("certificates are not generated by the cluster")
+	}
+	if !validCertName(user) {
+		return transport.TLSInfo{}, fmt.Errorf("invalid user name %q for certificate", user)
+	}
+	return clus.ca.Issue(clus.certsDir(), "client-"+user, certs.Request{
+		CommonName: user,
+		ValidFor:   clus.CertValidFor(),
+		Client:     true,
+	})
+}
+
+// clientCert returns the existing client certificate of the user,
+// issuing one if none exists, so that revoked certificates stay in use.
+func (clus *Cluster) clientCert(user string) (transport.TLSInfo, error) {
+	if clus.ca == nil || !validCertName(user) {
+		return clus.IssueClientCert(user)
+	}
+	info := transport.TLSInfo{
+		CertFile:      filepath.Join(clus.certsDir(), "client-"+user+".pem"),
+		KeyFile:       filepath.Join(clus.certsDir(), "client-"+user+"-key.pem"),
+		TrustedCAFile: clus.ca.CertFile,
+	}
+	if existFileOrDir(info.CertFile) && existFileOrDir(info.KeyFile) {
+		return info, nil
+	}
+	return clus.IssueClientCert(user)
+}
+
+// RevokeClientCert adds the client certificate of the user to the revocation list.
+// Members reject connections with the certificate from then on; issue a new
+// certificate with IssueClientCert to restore access.
+func (clus *Cluster) RevokeClientCert(user string) error {
+	if clus.ca == nil {
+		return fmt.Errorf("certificates are not generated by the cluster")
+	}
+	if !validCertName(user) {
+		return fmt.Errorf("invalid user name %q for certificate", user)
+	}
+	if err := clus.ca.Revoke(filepath.Join(clus.certsDir(), "client-"+user+".pem")); err != nil {
+		return err
+	}
+	glog.Infof("revoked client certificate of %q", user)
+	return nil
+}
+
+// validCertName returns true if the name is safe to use in certificate file names.
+func validCertName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// CertValidFor returns the lifetime of newly issued member certificates.
+func (clus *Cluster) CertValidFor() time.Duration {
+	clus.certMu.Lock()
+	defer clus.certMu.Unlock()
+	return clus.certValidFor
+}
+
+// SetCertValidFor sets the lifetime of certificates issued by following rotations.
+// Issue short-lived certificates to watch what breaks when they expire,
+// then rotate with a longer lifetime to recover.
+func (clus *Cluster) SetCertValidFor(d time.Duration) {
+	clus.certMu.Lock()
+	clus.certValidFor = d
+	clus.certMu.Unlock()
+}
+
+// updateCertExpiry reads the expiry of the member certificates into its status.
+func (m *Member) updateCertExpiry() {
+	if m.clus.ca == nil {
+		return
+	}
+	var cexp, pexp int64
+	if t, err := certs.Expiry(m.cfg.ClientTLSInfo.CertFile); err == nil {
+		cexp = t.Unix()
+	}
+	if t, err := certs.Expiry(m.cfg.PeerTLSInfo.CertFile); err == nil {
+		pexp = t.Unix()
+	}
+	m.statusLock.Lock()
+	m.status.ClientCertExpiry, m.status.PeerCertExpiry = cexp, pexp
+	m.statusLock.Unlock()
+}
+
+// RotateCerts regenerates the server and peer certificates of all members,
+// and restarts the members one at a time, waiting for the cluster to be
+// healthy between restarts so that quorum is kept.
+// Certificate files are reloaded on every handshake, so all members are
+// reissued before any restart; this also recovers a cluster whose
+// certificates have already expired.
+func (clus *Cluster) RotateCerts() error {
+	if clus.ca == nil {
+		return fmt.Errorf("certificates are not generated by the cluster")
+	}
+
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+
+	clus.mmu.RLock()
+	members := append([]*Member{}, clus.Members...)
+	clus.mmu.RUnlock()
+
+	if !clus.rootClientTLSInfo.Empty() {
+		if _, err := clus.IssueClientCert(rootUser); err != nil {
+			return err
+		}
+	}
+	for _, m := range members {
+		if err := clus.issueMemberCerts(m.cfg); err != nil {
+			return err
+		}
+		clus.applyNodeTLS(m.cfg)
+		m.updateCertExpiry()
+	}
+
+	for _, m := range members {
+		if m.stopped() {
+			// picks up new certificates on next restart
+			continue
+		}
+
+		if err := clus.stop(m); err != nil {
+			return fmt.Errorf("failed to stop %q to rotate certificates (%v)", m.cfg.Name, err)
+		}
+		if err := clus.restart(m); err != nil {
+			return fmt.Errorf("failed to restart %q with rotated certificates (%v)", m.cfg.Name, err)
+		}
+		if err := m.waitReady(nodeReadyTimeout); err != nil {
+			return fmt.Errorf("certificate rotation failed (%v)", err)
+		}
+		// keep quorum before restarting the next member
+		if err := clus.waitHealthy(upgradeHealthTimeout); err != nil {
+			return fmt.Errorf("cluster is not healthy after rotating certificates of %q (%v)", m.cfg.Name, err)
+		}
+		glog.Infof("rotated certificates of %q", m.cfg.Name)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"github.com/coreos/etcd/clientv3"
+	"github.com/golang/glog"
+)
+
+// SharedClient returns the long-lived client of node 'i', created on
+// first use. Callers must not close it; it is closed when the node stops,
+// when auth is toggled, and when the cluster shuts down.
+// It returns UnknownNodeError if there is no node 'i'.
+func (clus *Cluster) SharedClient(i int) (*clientv3.Client, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return nil, err
+	}
+	return m.sharedClient()
+}
+
+func (m *Member) sharedClient() (*clientv3.Client, error) {
+	m.sharedMu.Lock()
+	defer m.sharedMu.Unlock()
+
+	if m.shared != nil {
+		return m.shared, nil
+	}
+	cli, _, err := m.Client(m.clus.rootCtx, false)
+	if err != nil {
+		return nil, err
+	}
+	m.shared = cli
+	return cli, nil
+}
+
+// closeSharedClient closes the shared client, if any,
+// so that the next one connects with the current server and credentials.
+func (m *Member) closeSharedClient() {
+	m.sharedMu.Lock()
+	defer m.sharedMu.Unlock()
+
+	if m.shared == nil {
+		return
+	}
+	if err := m.shared.Close(); err != nil {
+		glog.Warningf("failed to close shared client of %q (%v)", m.cfg.Name, err)
+	}
+	m.shared = nil
+}
+
+func (clus *Cluster) closeSharedClients() {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+
+	for _, m := range clus.Members {
+		m.closeSharedClient()
+	}
+}
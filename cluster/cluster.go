@@ -5,21 +5,29 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/coreos/etcdlabs/bench"
 	"github.com/coreos/etcdlabs/cluster/clusterpb"
+	"github.com/coreos/etcdlabs/pkg/certs"
+	"github.com/coreos/etcdlabs/pkg/peertrace"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/compactor"
 	"github.com/coreos/etcd/embed"
 	"github.com/coreos/etcd/pkg/netutil"
 	"github.com/coreos/etcd/pkg/transport"
+	"github.com/coreos/etcd/pkg/types"
+	"github.com/coreos/pkg/capnslog"
 	"github.com/golang/glog"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
 )
 
 // Cluster contains all embedded etcd Members in the same cluster.
@@ -41,12 +49,63 @@ type Cluster struct {
 
 	stopc chan struct{} // to signal UpdateMemberStatus
 
+	leaderHistory *leaderHistory
+	events        *eventLog
+	statusPool    *workerPool
+	gateway       *gateway
+	benchStore    *bench.Store
+	grpcProxy     *grpcProxy
+	seeder        *seeder
+	histories     histories
+
+	toleranceMu sync.RWMutex
+	tolerance   FailureTolerance
+
+	connectivityMu sync.RWMutex
+	connectivity   Connectivity
+
+	lifecycleLimiter *rate.Limiter // nil if LifecycleInterval is zero
+
+	observers observers
+
+	catchUpMu sync.Mutex
+	catchUps  map[string]CatchUp // by member name
+
+	leaseMu         sync.Mutex
+	leaseKeepAlives map[int64]context.CancelFunc
+
+	authMu       sync.RWMutex
+	rootPassword string // non-empty when auth is enabled
+
+	sessions  sessions
+	locks     locks
+	elections elections
+
 	rootCtx    context.Context
 	rootCancel func()
 
-	basePort int
-	rootDir  string
-	ccfg     Config
+	ports   PortAllocator
+	rootDir string
+	ccfg    Config
+
+	ca *certs.CA // set if certificates are generated
+
+	metricsPort int // next metrics port
+
+	peerTracer    *peertrace.Tracer // set if the peer proxy is enabled
+	peerProxyPort int               // next peer proxy port
+	peerLimitMu   sync.Mutex
+	peerLimits    map[types.ID]*rate.Limiter // by member, see SlowPeer
+
+	docker *dockerClient // set in docker mode
+	kube   *kubeClient   // set in kubernetes mode
+
+	certMu       sync.Mutex
+	certValidFor time.Duration
+
+	// rootClientTLSInfo is the root user certificate of internal clients,
+	// set in client certificate auth mode.
+	rootClientTLSInfo transport.TLSInfo
 }
 
 // Config defines etcd local cluster Configuration.
@@ -55,44 +114,425 @@ type Config struct {
 	RootDir  string
 	RootPort int
 
+	// PortAllocator assigns client and peer ports to nodes.
+	// Defaults to consecutive ports from RootPort.
+	PortAllocator PortAllocator
+	// NodeNameTemplate is the fmt template of node names, with one '%d'
+	// for the node number starting from 1. Defaults to "node%d".
+	NodeNameTemplate string
+
 	EmbeddedClient bool
-	PeerTLSInfo    transport.TLSInfo
-	PeerAutoTLS    bool
-	ClientTLSInfo  transport.TLSInfo
-	ClientAutoTLS  bool
+
+	// ClientHost and PeerHost are the IP addresses of client and peer URLs
+	// (e.g. "::1", or a LAN address to expose the cluster). Domain names
+	// other than "localhost" cannot be bound. Default to "localhost".
+	ClientHost string
+	PeerHost   string
+
+	// HeartbeatInterval and ElectionTimeout are the raft timing of all
+	// members (100ms and 1s if zero). The election timeout must be at
+	// least 5 times the heartbeat interval.
+	HeartbeatInterval time.Duration
+	ElectionTimeout   time.Duration
+
+	// PreVote enables raft pre-vote, so a rejoining member with a higher
+	// term does not disrupt the leader. DisableInitialElectionTickAdvance
+	// makes a restarted member wait a full election timeout before
+	// campaigning. Both require ModeSubprocess or ModeDocker with an etcd
+	// release that supports them (v3.4+).
+	PreVote                           bool
+	DisableInitialElectionTickAdvance bool
+
+	// AutoCompactionMode is "periodic" (default), "revision" or "disabled".
+	// AutoCompactionRetention is the number of hours to keep in periodic
+	// mode (1 if zero), or the number of revisions to keep in revision
+	// mode (e.g. 10, to see compaction in action).
+	AutoCompactionMode      string
+	AutoCompactionRetention int
+
+	// MaxRequestBytes is the request size limit (1.5 MiB if zero).
+	// MaxConcurrentStreams limits the gRPC streams per client connection,
+	// and requires ModeSubprocess or ModeDocker with etcd v3.4+.
+	MaxRequestBytes      uint
+	MaxConcurrentStreams uint32
+
+	// UnsafeNoFsync disables fsync of the WAL and the backend, UNSAFE:
+	// a crash or power loss can lose committed writes and corrupt the
+	// data. It is meant to measure the cost of durability (see
+	// CompareFsync), and requires ModeSubprocess or ModeDocker with
+	// etcd v3.5+.
+	UnsafeNoFsync bool
+
+	// SnapshotCount is the number of committed entries between snapshots,
+	// so a small value (e.g. 100) makes lagging members catch up from a
+	// snapshot. MaxSnapFiles and MaxWalFiles are the number of snapshot
+	// and WAL files to retain. etcd defaults are used if zero.
+	SnapshotCount uint64
+	MaxSnapFiles  uint
+	MaxWalFiles   uint
+
+	// CORS is the origins allowed for cross-origin requests to the client
+	// endpoints (e.g. "http://localhost:4200"), so browser clients can hit
+	// them directly. "*" allows any origin.
+	CORS []string
+	// HostWhitelist is the hosts allowed in the 'Host' header of client
+	// HTTP requests, against DNS rebinding. It requires ModeSubprocess or
+	// ModeDocker with etcd v3.3+.
+	HostWhitelist []string
+
+	// UnixSockets serves client and peer traffic on unix domain sockets
+	// ('unix://' URLs) instead of TCP ports, to avoid port conflicts.
+	// Sockets are created in the working directory.
+	UnixSockets bool
+
+	PeerTLSInfo   transport.TLSInfo
+	PeerAutoTLS   bool
+	ClientTLSInfo transport.TLSInfo
+	ClientAutoTLS bool
+
+	// NodeTLS overrides the TLS settings per node, keyed by node name (e.g. "node1").
+	NodeTLS map[string]NodeTLS
+
+	// GenerateCerts generates a CA and per-node server and peer certificates
+	// into RootDir, instead of using manual or auto TLS.
+	GenerateCerts bool
+	// ClientCertAuth requires client certificates signed by the generated CA.
+	// Once auth is enabled, the certificate CN is the user name.
+	ClientCertAuth bool
+
+	// CertValidFor is the lifetime of generated member certificates
+	// (1 year if zero). Set it to a few minutes to simulate expiry.
+	CertValidFor time.Duration
+
+	// MetricsRootPort is the port of the first node's '/metrics' and '/health'
+	// endpoints; following nodes use consecutive ports, which must not
+	// overlap the client, peer or peer proxy ports. Disabled if zero.
+	MetricsRootPort int
+	// MetricsTLSInfo serves the metrics endpoints over TLS,
+	// independent of the client TLS configuration.
+	MetricsTLSInfo transport.TLSInfo
+
+	// PeerProxyRootPort is the port of the first node's peer proxy, which
+	// is advertised as its peer URL, forwards the peer traffic and traces
+	// the raft messages (see PeerTraffic); following nodes use consecutive
+	// ports. It requires plain HTTP peers over TCP. Disabled if zero.
+	PeerProxyRootPort int
+
+	// GatewayPort is the port of the gateway, a TCP proxy in front of
+	// the client endpoints (see GatewayEndpoint). Disabled if zero.
+	GatewayPort int
+	// GRPCProxyPort is the port of 'etcd grpc-proxy' in front of the
+	// cluster, run with EtcdBinary (see GRPCProxyEndpoint). Disabled if zero.
+	GRPCProxyPort int
+
+	// Mode is one of ModeEmbedded (default), ModeSubprocess, ModeDocker,
+	// ModeKubernetes and ModeSSH.
+	Mode string
+	// EtcdBinary is the etcd binary run in ModeSubprocess, or uploaded
+	// and run in ModeSSH. Defaults to "etcd" in PATH.
+	EtcdBinary string
+	// EtcdBinaries maps etcd versions (e.g. "3.2.0") to binaries,
+	// to run different versions per node in ModeSubprocess and ModeSSH.
+	EtcdBinaries map[string]string
+	// EtcdctlBinary is the etcdctl binary used for the downgrade
+	// workflow in ModeSubprocess. Defaults to "etcdctl" in PATH.
+	EtcdctlBinary string
+	// NodeVersions pins the etcd version per node name (e.g. "node1").
+	// In ModeDocker and ModeKubernetes, the version selects the image
+	// tag "v<version>".
+	NodeVersions map[string]string
+	// DockerHost is the Docker Engine address in ModeDocker.
+	// Defaults to "unix:///var/run/docker.sock".
+	DockerHost string
+	// DockerImage is the etcd image run in ModeDocker and ModeKubernetes.
+	// Defaults to "quay.io/coreos/etcd:v3.2.0".
+	DockerImage string
+
+	// KubeAPIServer is the Kubernetes API server in ModeKubernetes.
+	// Defaults to the service account inside a pod, and to 'kubectl proxy'
+	// ("http://127.0.0.1:8001") otherwise. KubeNamespace is the namespace
+	// of the pods, defaulting to the one of the service account or
+	// "default". The pods run on the host network and mount RootDir from
+	// their node, so the node must be the host of the cluster: KubeNode
+	// pins the pods to it unless the Kubernetes cluster has a single node.
+	KubeAPIServer string
+	KubeNamespace string
+	KubeNode      string
+
+	// SSHHosts maps node names (e.g. "node1") to the SSH destinations
+	// ("user@host") of the nodes in ModeSSH. The host is also the host of
+	// the client and peer URLs of the node, so it must be an IP address
+	// of the remote machine. The binary and data live under RootDir on the
+	// hosts, and each node runs as the systemd unit 'etcdlabs-<name>'.
+	// SSHIdentityFile is the private key of 'ssh', if not the default one.
+	// SSHSudo runs systemctl and journalctl with 'sudo -n', for SSH users
+	// other than root.
+	SSHHosts        map[string]string
+	SSHIdentityFile string
+	SSHSudo         bool
 
 	RootCtx     context.Context
 	RootCancel  func()
 	DialTimeout time.Duration // for client requests
+
+	// BenchDir persists benchmark runs (see Bench), to compare runs
+	// across clusters. Runs are not persisted if empty.
+	BenchDir string
+
+	// ShutdownArchive is the path of a gzipped tarball that Shutdown
+	// writes with the data directories, the captured member logs and the
+	// event log before deleting RootDir, for post-mortem analysis.
+	// Nothing is archived if empty.
+	ShutdownArchive string
+
+	// Seed seeds the random generators of the cluster: the bench workloads
+	// without a seed (see bench.Spec.Seed) and NewRand, in the order they
+	// are used. Defaults to the current time, and is logged and returned by
+	// Seed, so that randomized runs can be reproduced.
+	Seed int64
+
+	// Faults are injected into nodes after the cluster starts.
+	Faults []Fault
+
+	// Fixture is a fixture file (see LoadFixture) loaded after
+	// the cluster elects a leader, if not empty.
+	Fixture string
+
+	// LogBufferSize is the number of log lines to keep per node.
+	// Defaults to 1000 if zero.
+	LogBufferSize int
+
+	// EventLogSize is the number of events to keep.
+	// Defaults to 512 if zero.
+	EventLogSize int
+
+	// LeaderHistorySize is the number of leader changes to keep.
+	// Defaults to 128 if zero.
+	LeaderHistorySize int
+
+	// StatusInterval is how often the node statuses should be polled
+	// (see StatusInterval). Defaults to 1 second if zero.
+	StatusInterval time.Duration
+
+	// LifecycleInterval is the minimum interval between the stops and
+	// restarts of StopCtx, RestartCtx, UpgradeNode and SetNodeVersion.
+	// They are not limited if zero.
+	LifecycleInterval time.Duration
+
+	// TimeScale scales the raft timing (heartbeat and election), the
+	// snapshot count, the status polling interval and the fault delays,
+	// starting from etcd defaults if they are not set, so that scenarios
+	// run faster (e.g. 0.1 for 10 times faster) in CI. The scenario package
+	// scales its waits by the same factor. Timing is not scaled if zero.
+	TimeScale float64
+
+	// StatusWorkers is the number of nodes whose status is
+	// fetched concurrently. Defaults to 8 if zero.
+	StatusWorkers int
+
+	// TraceExporter receives spans of client and status operations.
+	// Tracing is disabled if nil.
+	TraceExporter SpanExporter
+
+	// timeScaled is set once the timing is scaled, so that the Config of
+	// a running cluster can be started again (see Fork).
+	timeScaled bool
 }
 
 // PeerScheme returns the peer scheme.
-// TODO: support unix
+// Unix socket schemes are applied per node (see UnixSockets).
 func (c Config) PeerScheme() string {
 	scheme := "https"
-	if c.PeerTLSInfo.Empty() && !c.PeerAutoTLS {
+	if c.PeerTLSInfo.Empty() && !c.PeerAutoTLS && !c.GenerateCerts {
 		scheme = "http"
 	}
 	return scheme
 }
 
 // ClientScheme returns the client scheme.
-// TODO: support unix
+// Unix socket schemes are applied per node (see UnixSockets).
 func (c Config) ClientScheme() string {
 	scheme := "https"
-	if c.ClientTLSInfo.Empty() && !c.ClientAutoTLS {
+	if c.ClientTLSInfo.Empty() && !c.ClientAutoTLS && !c.GenerateCerts {
 		scheme = "http"
 	}
 	return scheme
 }
 
-var defaultDialTimeout = time.Second
+func (c Config) clientHost() string {
+	if c.ClientHost == "" {
+		return "localhost"
+	}
+	return c.ClientHost
+}
+
+func (c Config) peerHost() string {
+	if c.PeerHost == "" {
+		return "localhost"
+	}
+	return c.PeerHost
+}
+
+// applyRaftTiming sets the configured raft timing of the member, if any.
+func (c Config) applyRaftTiming(cfg *embed.Config) {
+	if c.HeartbeatInterval > 0 {
+		cfg.TickMs = uint(c.HeartbeatInterval / time.Millisecond)
+	}
+	if c.ElectionTimeout > 0 {
+		cfg.ElectionMs = uint(c.ElectionTimeout / time.Millisecond)
+	}
+}
+
+// applySnapshot sets the configured snapshot and WAL retention,
+// and the request size limit of the member, if any.
+func (c Config) applySnapshot(cfg *embed.Config) {
+	if c.SnapshotCount > 0 {
+		cfg.SnapCount = c.SnapshotCount
+	}
+	if c.MaxRequestBytes > 0 {
+		cfg.MaxRequestBytes = c.MaxRequestBytes
+	}
+	if c.MaxSnapFiles > 0 {
+		cfg.MaxSnapFiles = c.MaxSnapFiles
+	}
+	if c.MaxWalFiles > 0 {
+		cfg.MaxWalFiles = c.MaxWalFiles
+	}
+}
+
+// AutoCompactionDisabled disables auto-compaction (see Config.AutoCompactionMode).
+const AutoCompactionDisabled = "disabled"
+
+func (c Config) validateAutoCompaction() error {
+	if c.AutoCompactionRetention < 0 {
+		return fmt.Errorf("auto-compaction retention cannot be negative")
+	}
+	switch c.AutoCompactionMode {
+	case "", compactor.ModePeriodic, AutoCompactionDisabled:
+	case compactor.ModeRevision:
+		if c.AutoCompactionRetention == 0 {
+			return fmt.Errorf("revision auto-compaction requires retention")
+		}
+	default:
+		return fmt.Errorf("unknown auto-compaction mode %q", c.AutoCompactionMode)
+	}
+	return nil
+}
+
+// applyAutoCompaction sets the auto-compaction policy of the member.
+func (c Config) applyAutoCompaction(cfg *embed.Config) {
+	switch c.AutoCompactionMode {
+	case AutoCompactionDisabled:
+		cfg.AutoCompactionMode, cfg.AutoCompactionRetention = compactor.ModePeriodic, 0
+	case compactor.ModeRevision:
+		cfg.AutoCompactionMode, cfg.AutoCompactionRetention = compactor.ModeRevision, c.AutoCompactionRetention
+	default:
+		// auto-compaction every hour by default
+		cfg.AutoCompactionMode, cfg.AutoCompactionRetention = compactor.ModePeriodic, 1
+		if c.AutoCompactionRetention > 0 {
+			cfg.AutoCompactionRetention = c.AutoCompactionRetention
+		}
+	}
+}
+
+func (c Config) validateCORS() error {
+	for _, o := range c.CORS {
+		if o == "*" {
+			continue
+		}
+		u, err := url.Parse(o)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("CORS origin %q must be \"*\" or an http(s) origin", o)
+		}
+	}
+	for _, h := range c.HostWhitelist {
+		if h == "" || strings.Contains(h, "/") {
+			return fmt.Errorf("invalid host %q in host whitelist", h)
+		}
+	}
+	return nil
+}
+
+// applyCORS sets the configured CORS origins of the member, if any.
+func (c Config) applyCORS(cfg *embed.Config) {
+	for _, o := range c.CORS {
+		(*cfg.CorsInfo)[o] = true
+	}
+}
+
+var (
+	defaultDialTimeout    = time.Second
+	defaultStatusInterval = time.Second
+
+	// startLeaderTimeout bounds how long Start waits for a leader.
+	startLeaderTimeout = time.Minute
+)
 
 // Start starts embedded etcd cluster.
 func Start(ccfg Config) (clus *Cluster, err error) {
 	if ccfg.Size > 7 {
 		return nil, fmt.Errorf("max cluster size is 7, got %d", ccfg.Size)
 	}
+	switch ccfg.Mode {
+	case "":
+		ccfg.Mode = ModeEmbedded
+	case ModeEmbedded:
+	case ModeSubprocess, ModeDocker, ModeKubernetes, ModeSSH:
+		if ccfg.EmbeddedClient {
+			return nil, fmt.Errorf("embedded client cannot be used in %s mode", ccfg.Mode)
+		}
+		if !ccfg.MetricsTLSInfo.Empty() {
+			return nil, fmt.Errorf("metrics TLS cannot be used in %s mode", ccfg.Mode)
+		}
+		if ccfg.Mode != ModeSubprocess && ccfg.UnixSockets {
+			return nil, fmt.Errorf("unix sockets cannot be used in %s mode", ccfg.Mode)
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode %q", ccfg.Mode)
+	}
+	if fs := ccfg.externalFlags(); ccfg.Mode == ModeEmbedded && len(fs) > 0 {
+		return nil, fmt.Errorf("%v cannot be set in %s mode", fs, ModeEmbedded)
+	}
+	if ccfg.UnsafeNoFsync {
+		glog.Warning("fsync is disabled; committed writes can be lost on a crash")
+	}
+
+	if ccfg.HeartbeatInterval < 0 || ccfg.ElectionTimeout < 0 {
+		return nil, fmt.Errorf("raft timing cannot be negative")
+	}
+	if err = ccfg.applyTimeScale(); err != nil {
+		return nil, err
+	}
+	tcfg := embed.NewConfig()
+	ccfg.applyRaftTiming(tcfg)
+	if 5*tcfg.TickMs > tcfg.ElectionMs {
+		return nil, fmt.Errorf("election timeout %dms must be at least 5 times heartbeat interval %dms", tcfg.ElectionMs, tcfg.TickMs)
+	}
+
+	if err = ccfg.validateNodeNameTemplate(); err != nil {
+		return nil, err
+	}
+	if err = ccfg.validateAutoCompaction(); err != nil {
+		return nil, err
+	}
+	if err = ccfg.validateCORS(); err != nil {
+		return nil, err
+	}
+	if err = ccfg.validatePeerProxy(); err != nil {
+		return nil, err
+	}
+	if err = ccfg.validateMetricsPorts(); err != nil {
+		return nil, err
+	}
+	if err = ccfg.validateShutdownArchive(); err != nil {
+		return nil, err
+	}
+	if err = ccfg.validateSSH(); err != nil {
+		return nil, err
+	}
+	if ccfg.PortAllocator == nil {
+		ccfg.PortAllocator = NewSequentialPortAllocator(ccfg.RootPort)
+	}
 
 	glog.Infof("starting %d Members (root directory %q, root port :%d)", ccfg.Size, ccfg.RootDir, ccfg.RootPort)
 
@@ -109,12 +549,53 @@ func Start(ccfg Config) (clus *Cluster, err error) {
 		clientHostToIndex: make(map[string]int, ccfg.Size),
 		clientDialTimeout: dt,
 		stopc:             make(chan struct{}),
-		rootCtx:           ccfg.RootCtx,
-		rootCancel:        ccfg.RootCancel,
+		leaderHistory:     newLeaderHistory(ccfg.LeaderHistorySize),
+		events:            newEventLog(ccfg.EventLogSize),
+		catchUps:          make(map[string]CatchUp),
+		leaseKeepAlives:   make(map[int64]context.CancelFunc),
+		sessions: sessions{
+			byName: make(map[string]*namedSession),
+			byID:   make(map[clientv3.LeaseID]string),
+		},
+		locks:      locks{handles: make(map[string]*lockHandle)},
+		elections:  elections{handles: make(map[string]*electionHandle)},
+		rootCtx:    ccfg.RootCtx,
+		rootCancel: ccfg.RootCancel,
+
+		ports:   ccfg.PortAllocator,
+		rootDir: ccfg.RootDir,
+		ccfg:    ccfg,
+
+		certValidFor: ccfg.CertValidFor,
+		metricsPort:  ccfg.MetricsRootPort,
+
+		peerProxyPort: ccfg.PeerProxyRootPort,
+
+		seeder: newSeeder(ccfg.Seed),
+	}
 
-		basePort: ccfg.RootPort,
-		rootDir:  ccfg.RootDir,
-		ccfg:     ccfg,
+	if ccfg.PeerProxyRootPort > 0 {
+		clus.peerTracer = peertrace.New(peerTraceSamples)
+		clus.peerLimits = make(map[types.ID]*rate.Limiter)
+	}
+	if ccfg.LifecycleInterval > 0 {
+		clus.lifecycleLimiter = rate.NewLimiter(rate.Every(ccfg.LifecycleInterval), 1)
+	}
+	if ccfg.BenchDir != "" {
+		if clus.benchStore, err = bench.NewStore(ccfg.BenchDir); err != nil {
+			return nil, err
+		}
+	}
+
+	switch ccfg.Mode {
+	case ModeDocker:
+		if clus.docker, err = newDockerClient(ccfg.DockerHost); err != nil {
+			return nil, err
+		}
+	case ModeKubernetes:
+		if clus.kube, err = newKubeClient(ccfg.KubeAPIServer, ccfg.KubeNamespace); err != nil {
+			return nil, err
+		}
 	}
 
 	if !existFileOrDir(ccfg.RootDir) {
@@ -142,14 +623,24 @@ func Start(ccfg Config) (clus *Cluster, err error) {
 	if !ccfg.ClientTLSInfo.Empty() && ccfg.ClientAutoTLS {
 		return nil, fmt.Errorf("choose either auto client TLS or manual client TLS")
 	}
+	if ccfg.GenerateCerts {
+		if !ccfg.PeerTLSInfo.Empty() || ccfg.PeerAutoTLS || !ccfg.ClientTLSInfo.Empty() || ccfg.ClientAutoTLS {
+			return nil, fmt.Errorf("generated certificates cannot be used with auto or manual TLS")
+		}
+		if err = clus.generateCA(); err != nil {
+			return nil, err
+		}
+	}
+	if ccfg.ClientCertAuth && !ccfg.GenerateCerts {
+		return nil, fmt.Errorf("client certificate auth requires generated certificates")
+	}
 
-	startPort := ccfg.RootPort
 	for i := 0; i < ccfg.Size; i++ {
 		cfg := embed.NewConfig()
 
 		cfg.ClusterState = embed.ClusterStateFlagNew
 
-		cfg.Name = fmt.Sprintf("node%d", i+1)
+		cfg.Name = ccfg.nodeName(i + 1)
 		cfg.Dir = filepath.Join(ccfg.RootDir, cfg.Name+".data-dir-etcd")
 		cfg.WalDir = filepath.Join(ccfg.RootDir, cfg.Name+".data-dir-etcd", "wal")
 
@@ -159,18 +650,25 @@ func Start(ccfg Config) (clus *Cluster, err error) {
 		os.RemoveAll(cfg.WalDir)
 		glog.Infof("removed %q", cfg.WalDir)
 
-		curl := url.URL{Scheme: ccfg.ClientScheme(), Host: fmt.Sprintf("localhost:%d", startPort)}
+		cport, pport, perr := clus.ports.Allocate(cfg.Name)
+		if perr != nil {
+			clus.abortStart()
+			return nil, perr
+		}
+		cscheme, pscheme := clus.nodeSchemes(cfg.Name)
+		chost := ccfg.nodeClientHost(cfg.Name)
+		curl := clus.listenURL(cscheme, chost, cport)
 		cfg.ACUrls = []url.URL{curl}
 		cfg.LCUrls = []url.URL{curl}
-		if dhost != "localhost" {
+		if dhost != "localhost" && chost == "localhost" && !ccfg.UnixSockets {
 			// expose default host to other machines in listen address (e.g. Prometheus dashboard)
-			curl2 := url.URL{Scheme: ccfg.ClientScheme(), Host: fmt.Sprintf("%s:%d", dhost, startPort)}
+			curl2 := url.URL{Scheme: cscheme, Host: net.JoinHostPort(dhost, fmt.Sprint(cport))}
 			cfg.LCUrls = append(cfg.LCUrls, curl2)
 			glog.Infof("%q is set up to listen on client url %q (default host)", cfg.Name, curl2.String())
 		}
 		glog.Infof("%q is set up to listen on client url %q", cfg.Name, curl.String())
 
-		purl := url.URL{Scheme: ccfg.PeerScheme(), Host: fmt.Sprintf("localhost:%d", startPort+1)}
+		purl := clus.listenURL(pscheme, ccfg.nodePeerHost(cfg.Name), pport)
 		cfg.APUrls = []url.URL{purl}
 		cfg.LPUrls = []url.URL{purl}
 		glog.Infof("%q is set up to listen on peer url %q", cfg.Name, purl.String())
@@ -179,10 +677,18 @@ func Start(ccfg Config) (clus *Cluster, err error) {
 		cfg.ClientTLSInfo = ccfg.ClientTLSInfo
 		cfg.PeerAutoTLS = ccfg.PeerAutoTLS
 		cfg.PeerTLSInfo = ccfg.PeerTLSInfo
+		if ccfg.GenerateCerts {
+			if err = clus.issueMemberCerts(cfg); err != nil {
+				clus.abortStart()
+				return nil, err
+			}
+		}
+		clus.applyNodeTLS(cfg)
+		ccfg.applyRaftTiming(cfg)
+		ccfg.applySnapshot(cfg)
 
-		// auto-compaction every hour
-		cfg.AutoCompactionMode = compactor.ModePeriodic
-		cfg.AutoCompactionRetention = 1
+		ccfg.applyAutoCompaction(cfg)
+		ccfg.applyCORS(cfg)
 
 		clus.Members[i] = &Member{
 			clus: clus,
@@ -192,14 +698,29 @@ func Start(ccfg Config) (clus *Cluster, err error) {
 				Endpoint: curl.String(),
 				IsLeader: false,
 				State:    clusterpb.StoppedMemberStatus,
+
+				HeartbeatIntervalMs: uint64(cfg.TickMs),
+				ElectionTimeoutMs:   uint64(cfg.ElectionMs),
+				LogLevel:            capnslog.INFO.String(),
 			},
+			logs:       newLogBuffer(ccfg.LogBufferSize),
+			logLevel:   capnslog.INFO,
+			metricsURL: clus.nextMetricsURL(),
+		}
+		clus.initNode(clus.Members[i])
+		if err = clus.initNodeVersion(clus.Members[i]); err != nil {
+			clus.abortStart()
+			return nil, err
+		}
+		clus.Members[i].setLogTokens()
+		registerMemberLogs(clus.Members[i])
+		if err = clus.startPeerProxy(clus.Members[i]); err != nil {
+			clus.abortStart()
+			return nil, err
 		}
 
 		clus.clientHostToIndex[curl.Host] = i
-
-		startPort += 2
 	}
-	clus.basePort = startPort
 
 	for i := 0; i < clus.size; i++ {
 		clus.Members[i].cfg.InitialCluster = clus.initialCluster()
@@ -211,12 +732,36 @@ func Start(ccfg Config) (clus *Cluster, err error) {
 		g.Go(func() error { return clus.Members[idx].Start() })
 	}
 	if gerr := g.Wait(); gerr != nil {
+		clus.abortStart()
 		return nil, gerr
 	}
 
-	time.Sleep(time.Second)
+	if err = clus.startGateway(); err != nil {
+		clus.abortStart()
+		return nil, err
+	}
+	if err = clus.startGRPCProxy(); err != nil {
+		clus.abortStart()
+		return nil, err
+	}
+	if err = clus.armFaults(); err != nil {
+		clus.abortStart()
+		return nil, err
+	}
+	clus.statusPool = newWorkerPool(ccfg.StatusWorkers)
 
-	return clus, clus.WaitForLeader()
+	ctx, cancel := context.WithTimeout(clus.rootCtx, startLeaderTimeout)
+	_, err = clus.WaitLeader(ctx)
+	cancel()
+	if err != nil {
+		return clus, err
+	}
+	if ccfg.Fixture != "" {
+		ctx, cancel := context.WithTimeout(clus.rootCtx, fixtureTimeout)
+		_, err = clus.LoadFixture(ctx, ccfg.Fixture)
+		cancel()
+	}
+	return clus, err
 }
 
 // StopNotify returns receive-only stop channel to notify the cluster has stopped.
@@ -225,21 +770,65 @@ func (clus *Cluster) StopNotify() <-chan struct{} {
 }
 
 // Stop stops a node.
-func (clus *Cluster) Stop(i int) {
+func (clus *Cluster) Stop(i int) error {
 	clus.opLock.Lock()
 	defer clus.opLock.Unlock()
-	clus.Members[i].Stop()
+	m, err := clus.member(i)
+	if err != nil {
+		return err
+	}
+	return clus.stop(m)
+}
+
+// stop stops the member. Must be called with 'opLock' held.
+func (clus *Cluster) stop(m *Member) error {
+	wasStopped := m.stopped()
+	if err := m.Stop(); err != nil {
+		return err
+	}
+	clus.recordEvent("member-stop", m.cfg.Name, "stopped %q", m.cfg.Name)
+	if !wasStopped {
+		clus.notifyStopped(m.cfg.Name)
+	}
+	return nil
 }
 
 // Restart restarts a node.
 func (clus *Cluster) Restart(i int) error {
 	clus.opLock.Lock()
 	defer clus.opLock.Unlock()
-	return clus.Members[i].Restart()
+	m, err := clus.member(i)
+	if err != nil {
+		return err
+	}
+	return clus.restart(m)
+}
+
+// restart restarts the member, and observes how it catches up.
+// Must be called with 'opLock' held.
+func (clus *Cluster) restart(m *Member) error {
+	m.statusLock.RLock()
+	stopped := m.status.State == clusterpb.StoppedMemberStatus
+	m.statusLock.RUnlock()
+	target := clus.catchUpTarget(m)
+	if err := m.Restart(); err != nil {
+		return err
+	}
+	clus.recordEvent("member-restart", m.cfg.Name, "restarted %q", m.cfg.Name)
+	if !stopped {
+		return nil
+	}
+	clus.notifyStarted(m.cfg.Name)
+
+	clus.catchUpMu.Lock()
+	delete(clus.catchUps, m.cfg.Name)
+	clus.catchUpMu.Unlock()
+	go clus.observeCatchUp(m, m.StoppedStartedAt(), target)
+	return nil
 }
 
-// Add adds one member.
-func (clus *Cluster) Add() error {
+// Add adds one member. The context parents the spans of the request.
+func (clus *Cluster) Add(ctx context.Context) error {
 	glog.Infof("getting default host")
 	dhost, err := netutil.GetDefaultHost()
 	if err != nil {
@@ -259,7 +848,10 @@ func (clus *Cluster) Add() error {
 
 	cfg.ClusterState = embed.ClusterStateFlagExisting
 
-	cfg.Name = fmt.Sprintf("node%d", clus.size+1)
+	cfg.Name = clus.ccfg.nodeName(clus.size + 1)
+	if _, ok := clus.ccfg.SSHHosts[cfg.Name]; clus.ccfg.Mode == ModeSSH && !ok {
+		return fmt.Errorf("no SSH host for %q", cfg.Name)
+	}
 	cfg.Dir = filepath.Join(clus.rootDir, cfg.Name+".data-dir-etcd")
 	cfg.WalDir = filepath.Join(clus.rootDir, cfg.Name+".data-dir-etcd", "wal")
 
@@ -269,32 +861,44 @@ func (clus *Cluster) Add() error {
 	os.RemoveAll(cfg.WalDir)
 	glog.Infof("removed %q", cfg.WalDir)
 
-	curl := url.URL{Scheme: clus.ccfg.ClientScheme(), Host: fmt.Sprintf("localhost:%d", clus.basePort)}
+	cport, pport, err := clus.ports.Allocate(cfg.Name)
+	if err != nil {
+		return err
+	}
+	cscheme, pscheme := clus.nodeSchemes(cfg.Name)
+	chost := clus.ccfg.nodeClientHost(cfg.Name)
+	curl := clus.listenURL(cscheme, chost, cport)
 	cfg.ACUrls = []url.URL{curl}
 	cfg.LCUrls = []url.URL{curl}
-	if dhost != "localhost" {
+	if dhost != "localhost" && chost == "localhost" && !clus.ccfg.UnixSockets {
 		// expose default host to other machines in listen address (e.g. Prometheus dashboard)
-		curl2 := url.URL{Scheme: clus.ccfg.ClientScheme(), Host: fmt.Sprintf("%s:%d", dhost, clus.basePort)}
+		curl2 := url.URL{Scheme: cscheme, Host: net.JoinHostPort(dhost, fmt.Sprint(cport))}
 		cfg.LCUrls = append(cfg.LCUrls, curl2)
 		glog.Infof("%q is set up to listen on client url %q (default host)", cfg.Name, curl2.String())
 	}
 	glog.Infof("%q is set up to listen on client url %q", cfg.Name, curl.String())
 
-	purl := url.URL{Scheme: clus.ccfg.PeerScheme(), Host: fmt.Sprintf("localhost:%d", clus.basePort+1)}
+	purl := clus.listenURL(pscheme, clus.ccfg.nodePeerHost(cfg.Name), pport)
 	cfg.APUrls = []url.URL{purl}
 	cfg.LPUrls = []url.URL{purl}
 
 	clus.size++
-	clus.basePort += 2
 
 	cfg.ClientAutoTLS = clus.ccfg.ClientAutoTLS
 	cfg.ClientTLSInfo = clus.ccfg.ClientTLSInfo
 	cfg.PeerAutoTLS = clus.ccfg.PeerAutoTLS
 	cfg.PeerTLSInfo = clus.ccfg.PeerTLSInfo
+	if clus.ca != nil {
+		if err = clus.issueMemberCerts(cfg); err != nil {
+			return err
+		}
+	}
+	clus.applyNodeTLS(cfg)
+	clus.ccfg.applyRaftTiming(cfg)
+	clus.ccfg.applySnapshot(cfg)
 
-	// auto-compaction every hour
-	cfg.AutoCompactionMode = compactor.ModePeriodic
-	cfg.AutoCompactionRetention = 1
+	clus.ccfg.applyAutoCompaction(cfg)
+	clus.ccfg.applyCORS(cfg)
 
 	clus.Members = append(clus.Members, &Member{
 		clus: clus,
@@ -304,9 +908,25 @@ func (clus *Cluster) Add() error {
 			Endpoint: curl.String(),
 			IsLeader: false,
 			State:    clusterpb.StoppedMemberStatus,
+
+			HeartbeatIntervalMs: uint64(cfg.TickMs),
+			ElectionTimeoutMs:   uint64(cfg.ElectionMs),
+			LogLevel:            capnslog.INFO.String(),
 		},
+		logs:       newLogBuffer(clus.ccfg.LogBufferSize),
+		logLevel:   capnslog.INFO,
+		metricsURL: clus.nextMetricsURL(),
 	})
 	idx := len(clus.Members) - 1
+	clus.initNode(clus.Members[idx])
+	if err = clus.initNodeVersion(clus.Members[idx]); err != nil {
+		return err
+	}
+	clus.Members[idx].setLogTokens()
+	registerMemberLogs(clus.Members[idx])
+	if err = clus.startPeerProxy(clus.Members[idx]); err != nil {
+		return err
+	}
 	clus.clientHostToIndex[curl.Host] = idx
 
 	for i := 0; i < clus.size; i++ {
@@ -314,13 +934,16 @@ func (clus *Cluster) Add() error {
 	}
 
 	glog.Infof("adding member %q", clus.Members[idx].cfg.Name)
-	cli, _, err := clus.Members[0].Client(false)
+	cli, err := clus.Members[0].sharedClient()
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(clus.rootCtx, 3*time.Second)
-	_, err = cli.MemberAdd(ctx, []string{clus.Members[idx].cfg.APUrls[0].String()})
+	tctx, sp := clus.startSpan(ctx, "cluster.MemberAdd")
+	sp.setAttribute("member", clus.Members[idx].cfg.Name)
+	cctx, cancel := context.WithTimeout(tctx, 3*time.Second)
+	_, err = cli.MemberAdd(cctx, []string{clus.Members[idx].cfg.APUrls[0].String()})
 	cancel()
+	sp.end(err)
 	if err != nil {
 		return err
 	}
@@ -331,31 +954,62 @@ func (clus *Cluster) Add() error {
 		return serr
 	}
 	glog.Infof("started member %q", clus.Members[idx].cfg.Name)
+	clus.notifyStarted(clus.Members[idx].cfg.Name)
 
 	return nil
 }
 
-// Remove removes the member and its data.
-func (clus *Cluster) Remove(i int) error {
+// Remove removes the member and its data. The context parents the
+// spans of the request.
+func (clus *Cluster) Remove(ctx context.Context, i int) error {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+
+	clus.mmu.Lock()
+	defer clus.mmu.Unlock()
+
+	if i < 0 || i >= len(clus.Members) {
+		return &UnknownNodeError{Node: fmt.Sprint(i)}
+	}
+	return clus.remove(ctx, i)
+}
+
+// RemoveByName removes the node with the name and its data.
+func (clus *Cluster) RemoveByName(ctx context.Context, name string) error {
 	clus.opLock.Lock()
 	defer clus.opLock.Unlock()
 
 	clus.mmu.Lock()
 	defer clus.mmu.Unlock()
 
+	for i, m := range clus.Members {
+		if m.cfg.Name == name {
+			return clus.remove(ctx, i)
+		}
+	}
+	return &UnknownNodeError{Node: name}
+}
+
+// remove removes the i-th member. Must be called with 'opLock' and 'mmu' held.
+func (clus *Cluster) remove(ctx context.Context, i int) error {
 	idx := (i + 1) % clus.size
 	glog.Infof("removing member %q", clus.Members[i].cfg.Name)
-	cli, _, err := clus.Members[idx].Client(false)
+	cli, err := clus.Members[idx].sharedClient()
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(clus.rootCtx, 3*time.Second)
-	_, err = cli.MemberRemove(ctx, uint64(clus.Members[i].srv.Server.ID()))
+	tctx, sp := clus.startSpan(ctx, "cluster.MemberRemove")
+	sp.setAttribute("member", clus.Members[i].cfg.Name)
+	cctx, cancel := context.WithTimeout(tctx, 3*time.Second)
+	_, err = cli.MemberRemove(cctx, uint64(clus.Members[i].ID()))
 	cancel()
+	sp.end(err)
 	if err != nil {
 		return err
 	}
-	glog.Infof("removed member %q", clus.Members[idx].cfg.Name)
+	glog.Infof("removed member %q", clus.Members[i].cfg.Name)
+
+	rm := clus.Members[i]
 
 	clus.size--
 	var newms []*Member
@@ -366,29 +1020,69 @@ func (clus *Cluster) Remove(i int) error {
 		newms = append(newms, clus.Members[j])
 	}
 	clus.Members = newms
+	delete(clus.clientHostToIndex, rm.cfg.LCUrls[0].Host)
 	for j, m := range clus.Members {
 		clus.clientHostToIndex[m.cfg.LCUrls[0].Host] = j
 	}
 
-	clus.Members[i].Stop()
+	rm.Stop()
+	unregisterMemberLogs(rm)
+	if rm.peerProxy != nil {
+		rm.peerProxy.stop()
+	}
+	clus.ports.Release(rm.cfg.Name)
 
-	os.RemoveAll(clus.Members[i].cfg.Dir)
-	glog.Infof("removed %q", clus.Members[i].cfg.Dir)
+	os.RemoveAll(rm.cfg.Dir)
+	glog.Infof("removed %q", rm.cfg.Dir)
 
-	os.RemoveAll(clus.Members[i].cfg.WalDir)
-	glog.Infof("removed %q", clus.Members[i].cfg.WalDir)
+	os.RemoveAll(rm.cfg.WalDir)
+	glog.Infof("removed %q", rm.cfg.WalDir)
 
 	return nil
 }
 
+// abortStart stops the gateway, the gRPC proxy, the members and their
+// peer proxies that Start started before failing, and releases their
+// ports, so that no process or listener outlives the cluster. The data
+// directories are kept.
+func (clus *Cluster) abortStart() {
+	if clus.gateway != nil {
+		clus.gateway.stop()
+	}
+	if clus.grpcProxy != nil {
+		clus.grpcProxy.stop()
+	}
+	clus.closeSharedClients()
+	for i, m := range clus.Members {
+		clus.ports.Release(clus.ccfg.nodeName(i + 1))
+		if m == nil {
+			continue
+		}
+		m.Stop()
+		unregisterMemberLogs(m)
+		if m.peerProxy != nil {
+			m.peerProxy.stop()
+		}
+	}
+}
+
 // Shutdown stops all Members and deletes all data directories.
 func (clus *Cluster) Shutdown() {
 	clus.rootCancel()
 	close(clus.stopc) // stopping UpdateMemberStatus
+	clus.statusPool.stop()
 
 	clus.opLock.Lock()
 	defer clus.opLock.Unlock()
 
+	if clus.gateway != nil {
+		clus.gateway.stop()
+	}
+	if clus.grpcProxy != nil {
+		clus.grpcProxy.stop()
+	}
+	clus.closeSharedClients()
+
 	glog.Info("shutting down all Members")
 	var wg sync.WaitGroup
 	wg.Add(clus.size)
@@ -396,10 +1090,23 @@ func (clus *Cluster) Shutdown() {
 		go func(i int) {
 			defer wg.Done()
 			clus.Members[i].Stop()
+			unregisterMemberLogs(clus.Members[i])
+			if clus.Members[i].peerProxy != nil {
+				clus.Members[i].peerProxy.stop()
+			}
 		}(i)
 	}
 	wg.Wait()
 
+	if path := clus.ccfg.ShutdownArchive; path != "" {
+		if err := clus.writeArchive(path); err != nil {
+			// keeps the data for post-mortem
+			glog.Warningf("failed to archive %q to %q, not deleting it (%v)", clus.rootDir, path, err)
+			return
+		}
+		glog.Infof("archived %q to %q", clus.rootDir, path)
+	}
+
 	os.RemoveAll(clus.rootDir)
 	glog.Infof("successfully shutdown cluster (deleted %q)", clus.rootDir)
 }
@@ -424,20 +1131,24 @@ func (clus *Cluster) WaitForLeader() error {
 
 	found := false
 	for i, m := range clus.Members {
-		if m.status.IsLeader {
+		if m.statusCopy().IsLeader {
 			if found {
-				return fmt.Errorf("duplicate leader? %q(%s) claims to be the leader", clus.Members[clus.LeadIdx].cfg.Name, clus.Members[clus.LeadIdx].srv.Server.ID())
+				return fmt.Errorf("duplicate leader? %q(%s) claims to be the leader", clus.Members[clus.LeadIdx].cfg.Name, clus.Members[clus.LeadIdx].ID())
 			}
 			clus.LeadIdx = i
-			glog.Infof("%q(%s) is the leader", m.cfg.Name, m.srv.Server.ID())
+			glog.Infof("%q(%s) is the leader", m.cfg.Name, m.ID())
 			found = true
 		}
 	}
+	clus.recordLeader()
+	clus.recordTolerance()
+	clus.recordConnectivity()
+	clus.notifyStatus()
 	return nil
 }
 
-// Client creates the client.
-func (clus *Cluster) Client(eps ...string) (*clientv3.Client, *tls.Config, error) {
+// Client creates the client. The context parents the spans of the request.
+func (clus *Cluster) Client(ctx context.Context, eps ...string) (*clientv3.Client, *tls.Config, error) {
 	if len(eps) == 0 {
 		return nil, nil, errors.New("no endpoint is given")
 	}
@@ -445,45 +1156,64 @@ func (clus *Cluster) Client(eps ...string) (*clientv3.Client, *tls.Config, error
 	if !ok {
 		return nil, nil, fmt.Errorf("cannot find node with endpoint %s", eps[0])
 	}
-	return clus.Members[idx].Client(false, eps...)
+	return clus.Members[idx].Client(ctx, false, eps...)
 }
 
-// UpdateMemberStatus updates node statuses.
+// UpdateMemberStatus updates node statuses, on at most
+// Config.StatusWorkers nodes at a time.
 func (clus *Cluster) UpdateMemberStatus() {
+	clus.updateMemberStatus(clus.rootCtx)
+}
+
+// RefreshStatus updates node statuses immediately, and returns
+// when all of them are updated or 'ctx' is done.
+func (clus *Cluster) RefreshStatus(ctx context.Context) error {
+	return clus.updateMemberStatus(ctx)
+}
+
+// StatusInterval returns how often the node statuses should be polled.
+func (clus *Cluster) StatusInterval() time.Duration {
+	if clus.ccfg.StatusInterval > 0 {
+		return clus.ccfg.StatusInterval
+	}
+	return defaultStatusInterval
+}
+
+func (clus *Cluster) updateMemberStatus(ctx context.Context) error {
 	clus.mmu.Lock()
 	defer clus.mmu.Unlock()
 
 	var wg sync.WaitGroup
-	wg.Add(clus.size)
-	for i := 0; i < clus.size; i++ {
-		go func(i int) {
-			defer func() {
-				if err := recover(); err != nil {
-					glog.Warning("recovered from panic", err)
-					select {
-					case <-clus.rootCtx.Done():
-						glog.Warning("rootCtx is done with", clus.rootCtx.Err())
-					default:
-					}
-				}
-				wg.Done()
-			}()
-			if err := clus.Members[i].FetchMemberStatus(); err != nil {
-				glog.Warning(err)
-			}
-		}(i)
+	for _, m := range clus.Members {
+		m := m
+		wg.Add(1)
+		if !clus.statusPool.submit(func() {
+			defer wg.Done()
+			m.fetchStatus()
+		}) {
+			return errors.New("cluster is shut down")
+		}
 	}
 
 	wf := func() <-chan struct{} {
-		wg.Wait()
 		ch := make(chan struct{})
-		close(ch)
+		go func() {
+			wg.Wait()
+			close(ch)
+		}()
 		return ch
 	}
 
 	select {
 	case <-clus.stopc:
+		return errors.New("cluster is shut down")
+	case <-ctx.Done():
+		return ctx.Err()
 	case <-wf():
+		clus.recordLeader()
+		clus.recordTolerance()
+		clus.recordConnectivity()
+		clus.notifyStatus()
+		return nil
 	}
-	return
 }
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -27,7 +28,7 @@ func (clus *Cluster) InitialCluster() string {
 func (clus *Cluster) allMemberIDs() map[uint64]bool {
 	ms := make(map[uint64]bool, len(clus.Members))
 	for _, m := range clus.Members {
-		ms[uint64(m.srv.Server.ID())] = true
+		ms[uint64(m.ID())] = true
 	}
 	return ms
 }
@@ -38,13 +39,21 @@ func (clus *Cluster) SetClientDialTimeout(d time.Duration) {
 }
 
 // StoppedStartedAt returns the node's last stop and (re)start action time.
-func (clus *Cluster) StoppedStartedAt(i int) time.Time {
-	return clus.Members[i].stoppedStartedAt
+func (clus *Cluster) StoppedStartedAt(i int) (time.Time, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return m.StoppedStartedAt(), nil
 }
 
 // Config returns the configuration of the server.
-func (clus *Cluster) Config(i int) embed.Config {
-	return *clus.Members[i].cfg
+func (clus *Cluster) Config(i int) (embed.Config, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return embed.Config{}, err
+	}
+	return *m.cfg, nil
 }
 
 // AllConfigs returns all configurations.
@@ -60,22 +69,24 @@ func (clus *Cluster) AllConfigs() []embed.Config {
 }
 
 // Endpoints returns the endpoints of the node.
-func (clus *Cluster) Endpoints(i int, scheme bool) []string {
-	clus.mmu.RLock()
-	defer clus.mmu.RUnlock()
-
+func (clus *Cluster) Endpoints(i int, scheme bool) ([]string, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return nil, err
+	}
 	var eps []string
-	for _, ep := range clus.Members[i].cfg.LCUrls {
+	for _, ep := range m.cfg.LCUrls {
 		if scheme {
 			eps = append(eps, ep.String())
 		} else {
 			eps = append(eps, ep.Host)
 		}
 	}
-	return eps
+	return eps, nil
 }
 
-// AllEndpoints returns all endpoints of clients.
+// AllEndpoints returns all endpoints of clients. The gRPC proxy,
+// if enabled, is at GRPCProxyEndpoint.
 func (clus *Cluster) AllEndpoints(scheme bool) []string {
 	clus.mmu.RLock()
 	defer clus.mmu.RUnlock()
@@ -85,21 +96,31 @@ func (clus *Cluster) AllEndpoints(scheme bool) []string {
 		if scheme {
 			eps[i] = clus.Members[i].cfg.LCUrls[0].String()
 		} else {
-			eps[i] = clus.Members[i].cfg.LCUrls[0].Host
+			eps[i] = clus.Members[i].clientEndpoint()
 		}
 	}
 	return eps
 }
 
 // IsStopped returns true if the node has stopped.
-func (clus *Cluster) IsStopped(i int) (stopped bool) {
+func (clus *Cluster) IsStopped(i int) (bool, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return false, err
+	}
+	return m.stopped(), nil
+}
+
+// startedNode returns the index of the first started node.
+func (clus *Cluster) startedNode() (int, error) {
 	clus.mmu.RLock()
 	defer clus.mmu.RUnlock()
-
-	clus.Members[i].statusLock.Lock()
-	stopped = clus.Members[i].status.State == clusterpb.StoppedMemberStatus
-	clus.Members[i].statusLock.Unlock()
-	return stopped
+	for i, m := range clus.Members {
+		if !m.stopped() {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no started node")
 }
 
 // Size returns the size of cluster.
@@ -133,9 +154,29 @@ func (clus *Cluster) ActiveNodeN() (cnt int) {
 	return
 }
 
+// HealthyNodeN returns the number of Members that served a linearizable
+// read at the last status update.
+func (clus *Cluster) HealthyNodeN() (cnt int) {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+
+	for i := range clus.Members {
+		clus.Members[i].statusLock.Lock()
+		if clus.Members[i].status.Healthy {
+			cnt++
+		}
+		clus.Members[i].statusLock.Unlock()
+	}
+	return
+}
+
 // MemberStatus returns the node status.
-func (clus *Cluster) MemberStatus(i int) clusterpb.MemberStatus {
-	return clus.Members[i].status
+func (clus *Cluster) MemberStatus(i int) (clusterpb.MemberStatus, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return clusterpb.MemberStatus{}, err
+	}
+	return m.statusCopy(), nil
 }
 
 // AllMemberStatus returns all node status.
@@ -143,9 +184,9 @@ func (clus *Cluster) AllMemberStatus() []clusterpb.MemberStatus {
 	clus.mmu.RLock()
 	defer clus.mmu.RUnlock()
 
-	st := make([]clusterpb.MemberStatus, clus.size)
+	st := make([]clusterpb.MemberStatus, len(clus.Members))
 	for i := range clus.Members {
-		st[i] = clus.Members[i].status
+		st[i] = clus.Members[i].statusCopy()
 	}
 	return st
 }
@@ -158,3 +199,16 @@ func (clus *Cluster) FindIndex(ep string) int {
 	}
 	return idx
 }
+
+// FindIndexByName returns the index of the node with the name (e.g. "node1"),
+// or -1 if not found.
+func (clus *Cluster) FindIndexByName(name string) int {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+	for i, m := range clus.Members {
+		if m.cfg.Name == name {
+			return i
+		}
+	}
+	return -1
+}
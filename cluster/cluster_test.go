@@ -142,7 +142,7 @@ func testCluster(t *testing.T, cfg Config, scheme, stopRecover bool) {
 		println()
 		println()
 		glog.Info("making write requests")
-		cli, _, err := c.Client(c.AllEndpoints(scheme)...)
+		cli, _, err := c.Client(context.Background(), c.AllEndpoints(scheme)...)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -214,7 +214,7 @@ func testCluster(t *testing.T, cfg Config, scheme, stopRecover bool) {
 		println()
 		println()
 		glog.Info("making read requests")
-		cli, _, err := c.Client(c.AllEndpoints(scheme)...)
+		cli, _, err := c.Client(context.Background(), c.AllEndpoints(scheme)...)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -256,7 +256,7 @@ func testCluster(t *testing.T, cfg Config, scheme, stopRecover bool) {
 		println()
 		println()
 		glog.Info("adding a new member")
-		if err := c.Add(); err != nil {
+		if err := c.Add(context.Background()); err != nil {
 			t.Fatal(err)
 		}
 		glog.Info("added a new member")
@@ -284,7 +284,7 @@ func testCluster(t *testing.T, cfg Config, scheme, stopRecover bool) {
 		println()
 		glog.Info("removing the member")
 		leadidx := c.LeadIdx
-		if err := c.Remove(leadidx); err != nil {
+		if err := c.Remove(context.Background(), leadidx); err != nil {
 			t.Fatal(err)
 		}
 		if err := c.WaitForLeader(); err != nil {
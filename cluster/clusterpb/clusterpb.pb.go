@@ -9,6 +9,11 @@
 
 	It has these top-level messages:
 		MemberStatus
+		NodeRequest
+		NodeResponse
+		StatusRequest
+		ClusterStatus
+		FaultRequest
 */
 package clusterpb
 
@@ -17,6 +22,13 @@ import fmt "fmt"
 import math "math"
 import _ "github.com/gogo/protobuf/gogoproto"
 
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+import encoding_binary "encoding/binary"
+
 import io "io"
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -33,15 +45,34 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 // MemberStatus defines node status information.
 // Keep the json tag to make it parsable by Typescript.
 type MemberStatus struct {
-	Name      string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
-	ID        string `protobuf:"bytes,2,opt,name=ID,proto3" json:"ID,omitempty"`
-	Endpoint  string `protobuf:"bytes,3,opt,name=Endpoint,proto3" json:"Endpoint,omitempty"`
-	IsLeader  bool   `protobuf:"varint,4,opt,name=IsLeader,proto3" json:"IsLeader,omitempty"`
-	State     string `protobuf:"bytes,5,opt,name=State,proto3" json:"State,omitempty"`
-	StateTxt  string `protobuf:"bytes,6,opt,name=StateTxt,proto3" json:"StateTxt,omitempty"`
-	DBSize    uint64 `protobuf:"varint,7,opt,name=DBSize,proto3" json:"DBSize,omitempty"`
-	DBSizeTxt string `protobuf:"bytes,8,opt,name=DBSizeTxt,proto3" json:"DBSizeTxt,omitempty"`
-	Hash      uint32 `protobuf:"varint,9,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	Name             string   `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	ID               string   `protobuf:"bytes,2,opt,name=ID,proto3" json:"ID,omitempty"`
+	Endpoint         string   `protobuf:"bytes,3,opt,name=Endpoint,proto3" json:"Endpoint,omitempty"`
+	IsLeader         bool     `protobuf:"varint,4,opt,name=IsLeader,proto3" json:"IsLeader,omitempty"`
+	State            string   `protobuf:"bytes,5,opt,name=State,proto3" json:"State,omitempty"`
+	StateTxt         string   `protobuf:"bytes,6,opt,name=StateTxt,proto3" json:"StateTxt,omitempty"`
+	DBSize           uint64   `protobuf:"varint,7,opt,name=DBSize,proto3" json:"DBSize,omitempty"`
+	DBSizeTxt        string   `protobuf:"bytes,8,opt,name=DBSizeTxt,proto3" json:"DBSizeTxt,omitempty"`
+	Hash             uint32   `protobuf:"varint,9,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	RaftTerm         uint64   `protobuf:"varint,10,opt,name=RaftTerm,proto3" json:"RaftTerm,omitempty"`
+	RaftIndex        uint64   `protobuf:"varint,11,opt,name=RaftIndex,proto3" json:"RaftIndex,omitempty"`
+	RaftAppliedIndex uint64   `protobuf:"varint,12,opt,name=RaftAppliedIndex,proto3" json:"RaftAppliedIndex,omitempty"`
+	Version          string   `protobuf:"bytes,13,opt,name=Version,proto3" json:"Version,omitempty"`
+	Alarms           []string `protobuf:"bytes,14,rep,name=Alarms" json:"Alarms,omitempty"`
+	// unix seconds when the generated certificates expire (0 if not generated)
+	ClientCertExpiry int64 `protobuf:"varint,15,opt,name=ClientCertExpiry,proto3" json:"ClientCertExpiry,omitempty"`
+	PeerCertExpiry   int64 `protobuf:"varint,16,opt,name=PeerCertExpiry,proto3" json:"PeerCertExpiry,omitempty"`
+	// effective raft timing of the member
+	HeartbeatIntervalMs uint64 `protobuf:"varint,17,opt,name=HeartbeatIntervalMs,proto3" json:"HeartbeatIntervalMs,omitempty"`
+	ElectionTimeoutMs   uint64 `protobuf:"varint,18,opt,name=ElectionTimeoutMs,proto3" json:"ElectionTimeoutMs,omitempty"`
+	// last compacted revision (1 if never compacted)
+	CompactRevision int64 `protobuf:"varint,19,opt,name=CompactRevision,proto3" json:"CompactRevision,omitempty"`
+	// log level of the member (e.g. "INFO")
+	LogLevel string `protobuf:"bytes,20,opt,name=LogLevel,proto3" json:"LogLevel,omitempty"`
+	// result of the linearizable read probe of a reachable member
+	Healthy         bool    `protobuf:"varint,21,opt,name=Healthy,proto3" json:"Healthy,omitempty"`
+	HealthLatencyMs float64 `protobuf:"fixed64,22,opt,name=HealthLatencyMs,proto3" json:"HealthLatencyMs,omitempty"`
+	HealthError     string  `protobuf:"bytes,23,opt,name=HealthError,proto3" json:"HealthError,omitempty"`
 }
 
 func (m *MemberStatus) Reset()                    { *m = MemberStatus{} }
@@ -49,9 +80,277 @@ func (m *MemberStatus) String() string            { return proto.CompactTextStri
 func (*MemberStatus) ProtoMessage()               {}
 func (*MemberStatus) Descriptor() ([]byte, []int) { return fileDescriptorClusterpb, []int{0} }
 
+type NodeRequest struct {
+	// node name (e.g. "node1")
+	Name string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+}
+
+func (m *NodeRequest) Reset()                    { *m = NodeRequest{} }
+func (m *NodeRequest) String() string            { return proto.CompactTextString(m) }
+func (*NodeRequest) ProtoMessage()               {}
+func (*NodeRequest) Descriptor() ([]byte, []int) { return fileDescriptorClusterpb, []int{1} }
+
+type NodeResponse struct {
+	Result string `protobuf:"bytes,1,opt,name=Result,proto3" json:"Result,omitempty"`
+}
+
+func (m *NodeResponse) Reset()                    { *m = NodeResponse{} }
+func (m *NodeResponse) String() string            { return proto.CompactTextString(m) }
+func (*NodeResponse) ProtoMessage()               {}
+func (*NodeResponse) Descriptor() ([]byte, []int) { return fileDescriptorClusterpb, []int{2} }
+
+type StatusRequest struct {
+	// interval between statuses (the cluster poll interval if zero, at least 100ms)
+	IntervalMs int64 `protobuf:"varint,1,opt,name=IntervalMs,proto3" json:"IntervalMs,omitempty"`
+}
+
+func (m *StatusRequest) Reset()                    { *m = StatusRequest{} }
+func (m *StatusRequest) String() string            { return proto.CompactTextString(m) }
+func (*StatusRequest) ProtoMessage()               {}
+func (*StatusRequest) Descriptor() ([]byte, []int) { return fileDescriptorClusterpb, []int{3} }
+
+type ClusterStatus struct {
+	ClusterSize int64          `protobuf:"varint,1,opt,name=ClusterSize,proto3" json:"ClusterSize,omitempty"`
+	Quorum      int64          `protobuf:"varint,2,opt,name=Quorum,proto3" json:"Quorum,omitempty"`
+	Active      int64          `protobuf:"varint,3,opt,name=Active,proto3" json:"Active,omitempty"`
+	Members     []MemberStatus `protobuf:"bytes,4,rep,name=Members" json:"Members"`
+}
+
+func (m *ClusterStatus) Reset()                    { *m = ClusterStatus{} }
+func (m *ClusterStatus) String() string            { return proto.CompactTextString(m) }
+func (*ClusterStatus) ProtoMessage()               {}
+func (*ClusterStatus) Descriptor() ([]byte, []int) { return fileDescriptorClusterpb, []int{4} }
+
+type FaultRequest struct {
+	// node name (e.g. "node1")
+	Node string `protobuf:"bytes,1,opt,name=Node,proto3" json:"Node,omitempty"`
+	// "stop" or "kill"
+	Type string `protobuf:"bytes,2,opt,name=Type,proto3" json:"Type,omitempty"`
+	// delay before the fault
+	AfterMs int64 `protobuf:"varint,3,opt,name=AfterMs,proto3" json:"AfterMs,omitempty"`
+}
+
+func (m *FaultRequest) Reset()                    { *m = FaultRequest{} }
+func (m *FaultRequest) String() string            { return proto.CompactTextString(m) }
+func (*FaultRequest) ProtoMessage()               {}
+func (*FaultRequest) Descriptor() ([]byte, []int) { return fileDescriptorClusterpb, []int{5} }
+
 func init() {
 	proto.RegisterType((*MemberStatus)(nil), "clusterpb.MemberStatus")
+	proto.RegisterType((*NodeRequest)(nil), "clusterpb.NodeRequest")
+	proto.RegisterType((*NodeResponse)(nil), "clusterpb.NodeResponse")
+	proto.RegisterType((*StatusRequest)(nil), "clusterpb.StatusRequest")
+	proto.RegisterType((*ClusterStatus)(nil), "clusterpb.ClusterStatus")
+	proto.RegisterType((*FaultRequest)(nil), "clusterpb.FaultRequest")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for ClusterControl service
+
+type ClusterControlClient interface {
+	// StartNode restarts a stopped node.
+	StartNode(ctx context.Context, in *NodeRequest, opts ...grpc.CallOption) (*NodeResponse, error)
+	// StopNode stops a node.
+	StopNode(ctx context.Context, in *NodeRequest, opts ...grpc.CallOption) (*NodeResponse, error)
+	// Status streams the cluster status at the interval.
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (ClusterControl_StatusClient, error)
+	// InjectFault schedules a failure of a node.
+	InjectFault(ctx context.Context, in *FaultRequest, opts ...grpc.CallOption) (*NodeResponse, error)
+}
+
+type clusterControlClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewClusterControlClient(cc *grpc.ClientConn) ClusterControlClient {
+	return &clusterControlClient{cc}
+}
+
+func (c *clusterControlClient) StartNode(ctx context.Context, in *NodeRequest, opts ...grpc.CallOption) (*NodeResponse, error) {
+	out := new(NodeResponse)
+	err := grpc.Invoke(ctx, "/clusterpb.ClusterControl/StartNode", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterControlClient) StopNode(ctx context.Context, in *NodeRequest, opts ...grpc.CallOption) (*NodeResponse, error) {
+	out := new(NodeResponse)
+	err := grpc.Invoke(ctx, "/clusterpb.ClusterControl/StopNode", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterControlClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (ClusterControl_StatusClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_ClusterControl_serviceDesc.Streams[0], c.cc, "/clusterpb.ClusterControl/Status", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &clusterControlStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ClusterControl_StatusClient interface {
+	Recv() (*ClusterStatus, error)
+	grpc.ClientStream
+}
+
+type clusterControlStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *clusterControlStatusClient) Recv() (*ClusterStatus, error) {
+	m := new(ClusterStatus)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *clusterControlClient) InjectFault(ctx context.Context, in *FaultRequest, opts ...grpc.CallOption) (*NodeResponse, error) {
+	out := new(NodeResponse)
+	err := grpc.Invoke(ctx, "/clusterpb.ClusterControl/InjectFault", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for ClusterControl service
+
+type ClusterControlServer interface {
+	// StartNode restarts a stopped node.
+	StartNode(context.Context, *NodeRequest) (*NodeResponse, error)
+	// StopNode stops a node.
+	StopNode(context.Context, *NodeRequest) (*NodeResponse, error)
+	// Status streams the cluster status at the interval.
+	Status(*StatusRequest, ClusterControl_StatusServer) error
+	// InjectFault schedules a failure of a node.
+	InjectFault(context.Context, *FaultRequest) (*NodeResponse, error)
 }
+
+func RegisterClusterControlServer(s *grpc.Server, srv ClusterControlServer) {
+	s.RegisterService(&_ClusterControl_serviceDesc, srv)
+}
+
+func _ClusterControl_StartNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterControlServer).StartNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterpb.ClusterControl/StartNode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterControlServer).StartNode(ctx, req.(*NodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterControl_StopNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterControlServer).StopNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterpb.ClusterControl/StopNode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterControlServer).StopNode(ctx, req.(*NodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterControl_Status_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClusterControlServer).Status(m, &clusterControlStatusServer{stream})
+}
+
+type ClusterControl_StatusServer interface {
+	Send(*ClusterStatus) error
+	grpc.ServerStream
+}
+
+type clusterControlStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *clusterControlStatusServer) Send(m *ClusterStatus) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ClusterControl_InjectFault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FaultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterControlServer).InjectFault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterpb.ClusterControl/InjectFault",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterControlServer).InjectFault(ctx, req.(*FaultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ClusterControl_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "clusterpb.ClusterControl",
+	HandlerType: (*ClusterControlServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartNode",
+			Handler:    _ClusterControl_StartNode_Handler,
+		},
+		{
+			MethodName: "StopNode",
+			Handler:    _ClusterControl_StopNode_Handler,
+		},
+		{
+			MethodName: "InjectFault",
+			Handler:    _ClusterControl_InjectFault_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Status",
+			Handler:       _ClusterControl_Status_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "cluster/clusterpb/clusterpb.proto",
+}
+
 func (m *MemberStatus) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -123,27 +422,265 @@ func (m *MemberStatus) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintClusterpb(dAtA, i, uint64(m.Hash))
 	}
+	if m.RaftTerm != 0 {
+		dAtA[i] = 0x50
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.RaftTerm))
+	}
+	if m.RaftIndex != 0 {
+		dAtA[i] = 0x58
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.RaftIndex))
+	}
+	if m.RaftAppliedIndex != 0 {
+		dAtA[i] = 0x60
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.RaftAppliedIndex))
+	}
+	if len(m.Version) > 0 {
+		dAtA[i] = 0x6a
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(len(m.Version)))
+		i += copy(dAtA[i:], m.Version)
+	}
+	if len(m.Alarms) > 0 {
+		for _, s := range m.Alarms {
+			dAtA[i] = 0x72
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if m.ClientCertExpiry != 0 {
+		dAtA[i] = 0x78
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.ClientCertExpiry))
+	}
+	if m.PeerCertExpiry != 0 {
+		dAtA[i] = 0x80
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.PeerCertExpiry))
+	}
+	if m.HeartbeatIntervalMs != 0 {
+		dAtA[i] = 0x88
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.HeartbeatIntervalMs))
+	}
+	if m.ElectionTimeoutMs != 0 {
+		dAtA[i] = 0x90
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.ElectionTimeoutMs))
+	}
+	if m.CompactRevision != 0 {
+		dAtA[i] = 0x98
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.CompactRevision))
+	}
+	if len(m.LogLevel) > 0 {
+		dAtA[i] = 0xa2
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(len(m.LogLevel)))
+		i += copy(dAtA[i:], m.LogLevel)
+	}
+	if m.Healthy {
+		dAtA[i] = 0xa8
+		i++
+		dAtA[i] = 0x1
+		i++
+		if m.Healthy {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.HealthLatencyMs != 0 {
+		dAtA[i] = 0xb1
+		i++
+		dAtA[i] = 0x1
+		i++
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.HealthLatencyMs))))
+		i += 8
+	}
+	if len(m.HealthError) > 0 {
+		dAtA[i] = 0xba
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(len(m.HealthError)))
+		i += copy(dAtA[i:], m.HealthError)
+	}
 	return i, nil
 }
 
-func encodeFixed64Clusterpb(dAtA []byte, offset int, v uint64) int {
-	dAtA[offset] = uint8(v)
-	dAtA[offset+1] = uint8(v >> 8)
-	dAtA[offset+2] = uint8(v >> 16)
-	dAtA[offset+3] = uint8(v >> 24)
-	dAtA[offset+4] = uint8(v >> 32)
-	dAtA[offset+5] = uint8(v >> 40)
-	dAtA[offset+6] = uint8(v >> 48)
-	dAtA[offset+7] = uint8(v >> 56)
-	return offset + 8
-}
-func encodeFixed32Clusterpb(dAtA []byte, offset int, v uint32) int {
-	dAtA[offset] = uint8(v)
-	dAtA[offset+1] = uint8(v >> 8)
-	dAtA[offset+2] = uint8(v >> 16)
-	dAtA[offset+3] = uint8(v >> 24)
-	return offset + 4
+func (m *NodeRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NodeRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Name) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
+	}
+	return i, nil
+}
+
+func (m *NodeResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NodeResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Result) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(len(m.Result)))
+		i += copy(dAtA[i:], m.Result)
+	}
+	return i, nil
+}
+
+func (m *StatusRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *StatusRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.IntervalMs != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.IntervalMs))
+	}
+	return i, nil
+}
+
+func (m *ClusterStatus) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ClusterStatus) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.ClusterSize != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.ClusterSize))
+	}
+	if m.Quorum != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.Quorum))
+	}
+	if m.Active != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.Active))
+	}
+	if len(m.Members) > 0 {
+		for _, msg := range m.Members {
+			dAtA[i] = 0x22
+			i++
+			i = encodeVarintClusterpb(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *FaultRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FaultRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Node) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(len(m.Node)))
+		i += copy(dAtA[i:], m.Node)
+	}
+	if len(m.Type) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(len(m.Type)))
+		i += copy(dAtA[i:], m.Type)
+	}
+	if m.AfterMs != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintClusterpb(dAtA, i, uint64(m.AfterMs))
+	}
+	return i, nil
 }
+
 func encodeVarintClusterpb(dAtA []byte, offset int, v uint64) int {
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
@@ -189,23 +726,798 @@ func (m *MemberStatus) Size() (n int) {
 	if m.Hash != 0 {
 		n += 1 + sovClusterpb(uint64(m.Hash))
 	}
-	return n
-}
+	if m.RaftTerm != 0 {
+		n += 1 + sovClusterpb(uint64(m.RaftTerm))
+	}
+	if m.RaftIndex != 0 {
+		n += 1 + sovClusterpb(uint64(m.RaftIndex))
+	}
+	if m.RaftAppliedIndex != 0 {
+		n += 1 + sovClusterpb(uint64(m.RaftAppliedIndex))
+	}
+	l = len(m.Version)
+	if l > 0 {
+		n += 1 + l + sovClusterpb(uint64(l))
+	}
+	if len(m.Alarms) > 0 {
+		for _, s := range m.Alarms {
+			l = len(s)
+			n += 1 + l + sovClusterpb(uint64(l))
+		}
+	}
+	if m.ClientCertExpiry != 0 {
+		n += 1 + sovClusterpb(uint64(m.ClientCertExpiry))
+	}
+	if m.PeerCertExpiry != 0 {
+		n += 2 + sovClusterpb(uint64(m.PeerCertExpiry))
+	}
+	if m.HeartbeatIntervalMs != 0 {
+		n += 2 + sovClusterpb(uint64(m.HeartbeatIntervalMs))
+	}
+	if m.ElectionTimeoutMs != 0 {
+		n += 2 + sovClusterpb(uint64(m.ElectionTimeoutMs))
+	}
+	if m.CompactRevision != 0 {
+		n += 2 + sovClusterpb(uint64(m.CompactRevision))
+	}
+	l = len(m.LogLevel)
+	if l > 0 {
+		n += 2 + l + sovClusterpb(uint64(l))
+	}
+	if m.Healthy {
+		n += 3
+	}
+	if m.HealthLatencyMs != 0 {
+		n += 10
+	}
+	l = len(m.HealthError)
+	if l > 0 {
+		n += 2 + l + sovClusterpb(uint64(l))
+	}
+	return n
+}
+
+func (m *NodeRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovClusterpb(uint64(l))
+	}
+	return n
+}
+
+func (m *NodeResponse) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Result)
+	if l > 0 {
+		n += 1 + l + sovClusterpb(uint64(l))
+	}
+	return n
+}
+
+func (m *StatusRequest) Size() (n int) {
+	var l int
+	_ = l
+	if m.IntervalMs != 0 {
+		n += 1 + sovClusterpb(uint64(m.IntervalMs))
+	}
+	return n
+}
+
+func (m *ClusterStatus) Size() (n int) {
+	var l int
+	_ = l
+	if m.ClusterSize != 0 {
+		n += 1 + sovClusterpb(uint64(m.ClusterSize))
+	}
+	if m.Quorum != 0 {
+		n += 1 + sovClusterpb(uint64(m.Quorum))
+	}
+	if m.Active != 0 {
+		n += 1 + sovClusterpb(uint64(m.Active))
+	}
+	if len(m.Members) > 0 {
+		for _, e := range m.Members {
+			l = e.Size()
+			n += 1 + l + sovClusterpb(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *FaultRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Node)
+	if l > 0 {
+		n += 1 + l + sovClusterpb(uint64(l))
+	}
+	l = len(m.Type)
+	if l > 0 {
+		n += 1 + l + sovClusterpb(uint64(l))
+	}
+	if m.AfterMs != 0 {
+		n += 1 + sovClusterpb(uint64(m.AfterMs))
+	}
+	return n
+}
+
+func sovClusterpb(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozClusterpb(x uint64) (n int) {
+	return sovClusterpb(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *MemberStatus) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowClusterpb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MemberStatus: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MemberStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Endpoint", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Endpoint = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsLeader", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsLeader = bool(v != 0)
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.State = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StateTxt", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.StateTxt = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DBSize", wireType)
+			}
+			m.DBSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DBSize |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DBSizeTxt", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DBSizeTxt = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			}
+			m.Hash = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Hash |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RaftTerm", wireType)
+			}
+			m.RaftTerm = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RaftTerm |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RaftIndex", wireType)
+			}
+			m.RaftIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RaftIndex |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RaftAppliedIndex", wireType)
+			}
+			m.RaftAppliedIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RaftAppliedIndex |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Version = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Alarms", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Alarms = append(m.Alarms, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClientCertExpiry", wireType)
+			}
+			m.ClientCertExpiry = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ClientCertExpiry |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 16:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PeerCertExpiry", wireType)
+			}
+			m.PeerCertExpiry = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PeerCertExpiry |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 17:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HeartbeatIntervalMs", wireType)
+			}
+			m.HeartbeatIntervalMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HeartbeatIntervalMs |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 18:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ElectionTimeoutMs", wireType)
+			}
+			m.ElectionTimeoutMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ElectionTimeoutMs |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 19:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CompactRevision", wireType)
+			}
+			m.CompactRevision = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CompactRevision |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 20:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogLevel", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LogLevel = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 21:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Healthy", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Healthy = bool(v != 0)
+		case 22:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HealthLatencyMs", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.HealthLatencyMs = float64(math.Float64frombits(v))
+		case 23:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HealthError", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.HealthError = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipClusterpb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *NodeRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowClusterpb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NodeRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NodeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowClusterpb
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipClusterpb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
 
-func sovClusterpb(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
-		}
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-func sozClusterpb(x uint64) (n int) {
-	return sovClusterpb(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *MemberStatus) Unmarshal(dAtA []byte) error {
+func (m *NodeResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -228,15 +1540,15 @@ func (m *MemberStatus) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MemberStatus: wiretype end group for non-group")
+			return fmt.Errorf("proto: NodeResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MemberStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: NodeResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Result", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -261,13 +1573,63 @@ func (m *MemberStatus) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Name = string(dAtA[iNdEx:postIndex])
+			m.Result = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipClusterpb(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			var stringLen uint64
+			if skippy < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *StatusRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowClusterpb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StatusRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StatusRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IntervalMs", wireType)
+			}
+			m.IntervalMs = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowClusterpb
@@ -277,26 +1639,66 @@ func (m *MemberStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				m.IntervalMs |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipClusterpb(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
 				return ErrInvalidLengthClusterpb
 			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ID = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Endpoint", wireType)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ClusterStatus) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowClusterpb
 			}
-			var stringLen uint64
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ClusterStatus: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ClusterStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClusterSize", wireType)
+			}
+			m.ClusterSize = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowClusterpb
@@ -306,26 +1708,16 @@ func (m *MemberStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				m.ClusterSize |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthClusterpb
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Endpoint = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 4:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IsLeader", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Quorum", wireType)
 			}
-			var v int
+			m.Quorum = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowClusterpb
@@ -335,17 +1727,16 @@ func (m *MemberStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.Quorum |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.IsLeader = bool(v != 0)
-		case 5:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Active", wireType)
 			}
-			var stringLen uint64
+			m.Active = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowClusterpb
@@ -355,26 +1746,16 @@ func (m *MemberStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				m.Active |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthClusterpb
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.State = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 6:
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StateTxt", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Members", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowClusterpb
@@ -384,26 +1765,78 @@ func (m *MemberStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthClusterpb
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.StateTxt = string(dAtA[iNdEx:postIndex])
+			m.Members = append(m.Members, MemberStatus{})
+			if err := m.Members[len(m.Members)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
-		case 7:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DBSize", wireType)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipClusterpb(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			m.DBSize = 0
+			if skippy < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *FaultRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowClusterpb
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: FaultRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: FaultRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Node", wireType)
+			}
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowClusterpb
@@ -413,14 +1846,24 @@ func (m *MemberStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.DBSize |= (uint64(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 8:
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthClusterpb
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Node = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DBSizeTxt", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -445,13 +1888,13 @@ func (m *MemberStatus) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.DBSizeTxt = string(dAtA[iNdEx:postIndex])
+			m.Type = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 9:
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field AfterMs", wireType)
 			}
-			m.Hash = 0
+			m.AfterMs = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowClusterpb
@@ -461,7 +1904,7 @@ func (m *MemberStatus) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Hash |= (uint32(b) & 0x7F) << shift
+				m.AfterMs |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -595,22 +2038,51 @@ var (
 func init() { proto.RegisterFile("cluster/clusterpb/clusterpb.proto", fileDescriptorClusterpb) }
 
 var fileDescriptorClusterpb = []byte{
-	// 263 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x44, 0x90, 0xc1, 0x4a, 0xc3, 0x30,
-	0x18, 0xc7, 0xf7, 0xd5, 0xae, 0xb6, 0x41, 0x3d, 0x84, 0x21, 0x61, 0x48, 0xa8, 0x9e, 0x7a, 0x71,
-	0x3d, 0xf8, 0x06, 0xa3, 0x82, 0x05, 0xf5, 0xd0, 0xf9, 0x02, 0xcd, 0x16, 0xbb, 0x82, 0x5d, 0x4a,
-	0x9b, 0x82, 0xf8, 0x24, 0x3e, 0xd2, 0x8e, 0x3e, 0x82, 0x56, 0xf0, 0x39, 0x24, 0x5f, 0xb6, 0xf6,
-	0x94, 0xdf, 0xef, 0x9f, 0xfc, 0x13, 0xf2, 0x91, 0xeb, 0xf5, 0x5b, 0xd7, 0x6a, 0xd9, 0xc4, 0x87,
-	0xb5, 0x16, 0x23, 0x2d, 0xea, 0x46, 0x69, 0x45, 0x83, 0x21, 0x98, 0xdf, 0x16, 0xa5, 0xde, 0x76,
-	0x62, 0xb1, 0x56, 0x55, 0x5c, 0xa8, 0x42, 0xc5, 0x78, 0x42, 0x74, 0xaf, 0x68, 0x28, 0x48, 0xb6,
-	0x79, 0xf3, 0x07, 0xe4, 0xec, 0x49, 0x56, 0x42, 0x36, 0x2b, 0x9d, 0xeb, 0xae, 0xa5, 0x94, 0xb8,
-	0xcf, 0x79, 0x25, 0x19, 0x84, 0x10, 0x05, 0x19, 0x32, 0xbd, 0x20, 0x4e, 0x9a, 0x30, 0x07, 0x13,
-	0x27, 0x4d, 0xe8, 0x9c, 0xf8, 0xf7, 0xbb, 0x4d, 0xad, 0xca, 0x9d, 0x66, 0x27, 0x98, 0x0e, 0x6e,
-	0xf6, 0xd2, 0xf6, 0x51, 0xe6, 0x1b, 0xd9, 0x30, 0x37, 0x84, 0xc8, 0xcf, 0x06, 0xa7, 0x33, 0x32,
-	0x35, 0xaf, 0x48, 0x36, 0xc5, 0x92, 0x15, 0xd3, 0x40, 0x78, 0x79, 0xd7, 0xcc, 0xb3, 0xb7, 0x1d,
-	0x9d, 0x5e, 0x12, 0x2f, 0x59, 0xae, 0xca, 0x0f, 0xc9, 0x4e, 0x43, 0x88, 0xdc, 0xec, 0x60, 0xf4,
-	0x8a, 0x04, 0x96, 0x4c, 0xc9, 0xc7, 0xd2, 0x18, 0x98, 0x3f, 0x3c, 0xe4, 0xed, 0x96, 0x05, 0x21,
-	0x44, 0xe7, 0x19, 0xf2, 0x72, 0xb6, 0xff, 0xe1, 0x93, 0x7d, 0xcf, 0xe1, 0xab, 0xe7, 0xf0, 0xdd,
-	0x73, 0xf8, 0xfc, 0xe5, 0x13, 0xe1, 0xe1, 0x14, 0xee, 0xfe, 0x03, 0x00, 0x00, 0xff, 0xff, 0xf7,
-	0x11, 0xba, 0xb0, 0x64, 0x01, 0x00, 0x00,
+	// 731 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0xcd, 0x4e, 0xdb, 0x4a,
+	0x14, 0x8e, 0x93, 0x10, 0xc8, 0xe4, 0x07, 0x18, 0xb8, 0x30, 0x8a, 0xae, 0x72, 0x8d, 0x17, 0xc8,
+	0xba, 0xba, 0x97, 0x20, 0xba, 0xe8, 0xaa, 0x55, 0x43, 0x48, 0x45, 0xa4, 0x04, 0x51, 0x13, 0x75,
+	0xef, 0x24, 0x87, 0xe0, 0xca, 0xf6, 0xb8, 0xe3, 0x71, 0x44, 0xfa, 0x1c, 0x5d, 0x54, 0x7d, 0x22,
+	0x96, 0x7d, 0x02, 0xd4, 0xd2, 0x17, 0xa9, 0xe6, 0xd8, 0x4e, 0x0c, 0x41, 0x5d, 0x74, 0xe5, 0xf3,
+	0x7d, 0xf3, 0x9d, 0x9f, 0x39, 0x67, 0x8e, 0xc9, 0xc1, 0xd8, 0x8d, 0x42, 0x09, 0xa2, 0x95, 0x7c,
+	0x83, 0xd1, 0xd2, 0x3a, 0x0a, 0x04, 0x97, 0x9c, 0x96, 0x17, 0x44, 0xe3, 0xff, 0xa9, 0x23, 0x6f,
+	0xa2, 0xd1, 0xd1, 0x98, 0x7b, 0xad, 0x29, 0x9f, 0xf2, 0x16, 0x2a, 0x46, 0xd1, 0x35, 0x22, 0x04,
+	0x68, 0xc5, 0x9e, 0xc6, 0xfd, 0x1a, 0xa9, 0x0e, 0xc0, 0x1b, 0x81, 0xb8, 0x92, 0xb6, 0x8c, 0x42,
+	0x4a, 0x49, 0xf1, 0xc2, 0xf6, 0x80, 0x69, 0xba, 0x66, 0x96, 0x2d, 0xb4, 0x69, 0x9d, 0xe4, 0x7b,
+	0x67, 0x2c, 0x8f, 0x4c, 0xbe, 0x77, 0x46, 0x1b, 0x64, 0xa3, 0xeb, 0x4f, 0x02, 0xee, 0xf8, 0x92,
+	0x15, 0x90, 0x5d, 0x60, 0x75, 0xd6, 0x0b, 0xfb, 0x60, 0x4f, 0x40, 0xb0, 0xa2, 0xae, 0x99, 0x1b,
+	0xd6, 0x02, 0xd3, 0x5d, 0xb2, 0xa6, 0xb2, 0x00, 0x5b, 0x43, 0xa7, 0x18, 0x28, 0x0f, 0x34, 0x86,
+	0xb7, 0x92, 0x95, 0xe2, 0x68, 0x29, 0xa6, 0x7b, 0xa4, 0x74, 0x76, 0x7a, 0xe5, 0x7c, 0x02, 0xb6,
+	0xae, 0x6b, 0x66, 0xd1, 0x4a, 0x10, 0xfd, 0x9b, 0x94, 0x63, 0x4b, 0x39, 0x6d, 0xa0, 0xd3, 0x92,
+	0x50, 0x77, 0x38, 0xb7, 0xc3, 0x1b, 0x56, 0xd6, 0x35, 0xb3, 0x66, 0xa1, 0xad, 0xb2, 0x58, 0xf6,
+	0xb5, 0x1c, 0x82, 0xf0, 0x18, 0xc1, 0x58, 0x0b, 0xac, 0xa2, 0x29, 0xbb, 0xe7, 0x4f, 0xe0, 0x96,
+	0x55, 0xf0, 0x70, 0x49, 0xd0, 0x7f, 0xc9, 0x96, 0x02, 0xed, 0x20, 0x70, 0x1d, 0x98, 0xc4, 0xa2,
+	0x2a, 0x8a, 0x56, 0x78, 0xca, 0xc8, 0xfa, 0x7b, 0x10, 0xa1, 0xc3, 0x7d, 0x56, 0xc3, 0xaa, 0x52,
+	0xa8, 0x6e, 0xd2, 0x76, 0x6d, 0xe1, 0x85, 0xac, 0xae, 0x17, 0xcc, 0xb2, 0x95, 0x20, 0x15, 0xbd,
+	0xe3, 0x3a, 0xe0, 0xcb, 0x0e, 0x08, 0xd9, 0xbd, 0x0d, 0x1c, 0x31, 0x67, 0x9b, 0xba, 0x66, 0x16,
+	0xac, 0x15, 0x9e, 0x1e, 0x92, 0xfa, 0x25, 0x80, 0xc8, 0x28, 0xb7, 0x50, 0xf9, 0x84, 0xa5, 0xc7,
+	0x64, 0xe7, 0x1c, 0x6c, 0x21, 0x47, 0x60, 0xcb, 0x9e, 0x2f, 0x41, 0xcc, 0x6c, 0x77, 0x10, 0xb2,
+	0x6d, 0x2c, 0xfa, 0xb9, 0x23, 0xfa, 0x1f, 0xd9, 0xee, 0xba, 0x30, 0x96, 0x0e, 0xf7, 0x87, 0x8e,
+	0x07, 0x3c, 0x92, 0x83, 0x90, 0x51, 0xd4, 0xaf, 0x1e, 0x50, 0x93, 0x6c, 0x76, 0xb8, 0x17, 0xd8,
+	0x63, 0x69, 0xc1, 0xcc, 0xc1, 0xdb, 0xee, 0x60, 0x21, 0x4f, 0x69, 0xd5, 0xf5, 0x3e, 0x9f, 0xf6,
+	0x61, 0x06, 0x2e, 0xdb, 0x8d, 0x67, 0x9b, 0x62, 0xd5, 0xab, 0x73, 0xb0, 0x5d, 0x79, 0x33, 0x67,
+	0x7f, 0xe1, 0x43, 0x49, 0xa1, 0x8a, 0x1f, 0x9b, 0x7d, 0x5b, 0x82, 0x3f, 0x9e, 0x0f, 0x42, 0xb6,
+	0xa7, 0x6b, 0xa6, 0x66, 0x3d, 0xa5, 0xa9, 0x4e, 0x2a, 0x31, 0xd5, 0x15, 0x82, 0x0b, 0xb6, 0x8f,
+	0x29, 0xb2, 0x94, 0x71, 0x40, 0x2a, 0x17, 0x7c, 0x02, 0x16, 0x7c, 0x8c, 0x20, 0x94, 0xcf, 0x3d,
+	0x6f, 0xe3, 0x90, 0x54, 0x63, 0x49, 0x18, 0x70, 0x3f, 0x04, 0x35, 0x2a, 0x0b, 0xc2, 0xc8, 0x95,
+	0x89, 0x2a, 0x41, 0x46, 0x8b, 0xd4, 0xe2, 0x25, 0x49, 0x83, 0x35, 0x09, 0xc9, 0xb4, 0x57, 0xc3,
+	0x16, 0x64, 0x18, 0xe3, 0xab, 0x46, 0x6a, 0x9d, 0x78, 0x33, 0x93, 0xed, 0xd2, 0x49, 0x25, 0x25,
+	0xd4, 0xa3, 0x8e, 0x5d, 0xb2, 0x94, 0x4a, 0xfe, 0x2e, 0xe2, 0x22, 0xf2, 0x70, 0xdf, 0x0a, 0x56,
+	0x82, 0xf0, 0xfd, 0x8c, 0xa5, 0x33, 0x03, 0xdc, 0xb8, 0x82, 0x95, 0x20, 0xfa, 0x92, 0xac, 0xc7,
+	0xfb, 0x1b, 0xb2, 0xa2, 0x5e, 0x30, 0x2b, 0x27, 0xfb, 0x47, 0xcb, 0xbf, 0x43, 0x76, 0xb3, 0x4f,
+	0x8b, 0x77, 0xf7, 0xff, 0xe4, 0xac, 0x54, 0x6d, 0x5c, 0x92, 0xea, 0x5b, 0x3b, 0x72, 0x65, 0xb6,
+	0x33, 0x7c, 0xb2, 0xec, 0x0c, 0x9f, 0x80, 0xe2, 0x86, 0xf3, 0x00, 0x92, 0xd5, 0x47, 0x5b, 0x8d,
+	0xad, 0x7d, 0x2d, 0x41, 0x0c, 0xc2, 0xa4, 0x92, 0x14, 0x9e, 0x7c, 0xce, 0x93, 0x7a, 0x72, 0x95,
+	0x0e, 0xf7, 0xa5, 0xe0, 0x2e, 0x7d, 0x4d, 0xca, 0x57, 0xd2, 0x16, 0x12, 0xa3, 0xed, 0x65, 0x2a,
+	0xcb, 0xcc, 0xa4, 0xb1, 0xbf, 0xc2, 0xc7, 0x83, 0x30, 0x72, 0xf4, 0x95, 0xfa, 0x37, 0xf0, 0xe0,
+	0x4f, 0xdd, 0xdf, 0x90, 0x52, 0xd2, 0x78, 0x96, 0x11, 0x3d, 0x1a, 0x62, 0x23, 0x7b, 0xf2, 0x68,
+	0x58, 0x46, 0xee, 0x58, 0xa3, 0x6d, 0x52, 0xe9, 0xf9, 0x1f, 0x60, 0x2c, 0xb1, 0x57, 0x34, 0x9b,
+	0x2b, 0xdb, 0xbd, 0xdf, 0x14, 0x71, 0xba, 0x7b, 0xf7, 0xa3, 0x99, 0xbb, 0x7b, 0x68, 0x6a, 0xdf,
+	0x1e, 0x9a, 0xda, 0xf7, 0x87, 0xa6, 0xf6, 0xe5, 0x67, 0x33, 0x37, 0x2a, 0xe1, 0xff, 0xf7, 0xc5,
+	0xaf, 0x01, 0x00, 0xa3, 0xae, 0xa4, 0x17, 0xde, 0x05, 0x00, 0x00,
 }
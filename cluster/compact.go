@@ -0,0 +1,121 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+)
+
+// CompactResponse is the result of a compaction.
+type CompactResponse struct {
+	Header ResponseHeader
+	// Revision is the compacted revision; reads below it fail.
+	Revision int64
+	Took     time.Duration
+}
+
+// Compact compacts the key-value history up to 'rev' through node 'i'
+// (the current revision if 'rev' is not positive). If 'physical' is true,
+// it waits until the compaction is applied to the backend.
+func (clus *Cluster) Compact(ctx context.Context, i int, rev int64, physical bool) (resp CompactResponse, err error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return resp, err
+	}
+
+	if rev <= 0 {
+		// only the header revision is used
+		gresp, err := cli.Get(ctx, "compact-revision", clientv3.WithCountOnly())
+		if err != nil {
+			return resp, err
+		}
+		rev = gresp.Header.Revision
+	}
+	var opts []clientv3.CompactOption
+	if physical {
+		opts = append(opts, clientv3.WithCompactPhysical())
+	}
+
+	now := time.Now()
+	cresp, err := cli.Compact(ctx, rev, opts...)
+	if err != nil {
+		return resp, err
+	}
+	resp.Took = time.Since(now)
+	resp.Header = toResponseHeader(cresp.Header)
+	resp.Revision = rev
+	return resp, nil
+}
+
+// TimeTravelResponse is the result of a read at a historical revision.
+type TimeTravelResponse struct {
+	Header ResponseHeader
+	// Revision is the requested revision.
+	Revision int64
+
+	// Compacted is true if the revision was removed by compaction.
+	Compacted bool
+	// CompactRevision is the oldest revision still readable,
+	// set when 'Compacted' is true.
+	CompactRevision int64
+
+	KeyValues []KeyValue
+	Took      time.Duration
+}
+
+// ReadAt reads a key (or all keys with the prefix) as of revision 'rev' through node 'i'.
+// A compacted revision is reported in the response rather than returned as an error.
+func (clus *Cluster) ReadAt(ctx context.Context, i int, key string, prefix bool, rev int64) (resp TimeTravelResponse, err error) {
+	if rev <= 0 {
+		return resp, fmt.Errorf("revision must be positive (got %d)", rev)
+	}
+
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return resp, err
+	}
+
+	opts := []clientv3.OpOption{clientv3.WithRev(rev)}
+	if prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+
+	resp.Revision = rev
+	now := time.Now()
+	gresp, err := cli.Get(ctx, key, opts...)
+	resp.Took = time.Since(now)
+	switch err {
+	case nil:
+		resp.Header = toResponseHeader(gresp.Header)
+		resp.KeyValues = toKeyValues(gresp.Kvs)
+		return resp, nil
+	case rpctypes.ErrCompacted:
+		resp.Compacted = true
+		resp.CompactRevision, err = compactRevision(ctx, cli, key)
+		return resp, err
+	default:
+		return resp, err
+	}
+}
+
+// compactRevision returns the current compaction revision, which
+// the server reports when watching from a compacted revision.
+func compactRevision(ctx context.Context, cli *clientv3.Client, key string) (int64, error) {
+	wctx, wcancel := context.WithTimeout(ctx, time.Second)
+	defer wcancel()
+
+	for wr := range cli.Watch(wctx, key, clientv3.WithRev(1), clientv3.WithCreatedNotify()) {
+		if wr.CompactRevision != 0 {
+			return wr.CompactRevision, nil
+		}
+		if err := wr.Err(); err != nil {
+			return 0, err
+		}
+		// revision 1 is not compacted
+		return 1, nil
+	}
+	return 0, fmt.Errorf("failed to get compact revision (%v)", wctx.Err())
+}
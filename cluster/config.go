@@ -0,0 +1,368 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/pkg/transport"
+	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
+)
+
+// Fault is a failure injected into a node after the cluster starts.
+type Fault struct {
+	// Node is the node name (e.g. "node1").
+	Node string
+	// Type is "stop", "kill" or "slow", which limits the peer traffic to
+	// the node (see Cluster.SlowPeer).
+	Type string
+	// After is the delay after the cluster starts.
+	After time.Duration
+}
+
+// duration is a time.Duration in Go syntax (e.g. "100ms") in config files.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"100ms\" (%v)", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+type tlsSpec struct {
+	CertFile       string `json:"cert-file"`
+	KeyFile        string `json:"key-file"`
+	TrustedCAFile  string `json:"trusted-ca-file"`
+	ClientCertAuth bool   `json:"client-cert-auth"`
+	CRLFile        string `json:"crl-file"`
+}
+
+func (s tlsSpec) tlsInfo() transport.TLSInfo {
+	return transport.TLSInfo{
+		CertFile:       s.CertFile,
+		KeyFile:        s.KeyFile,
+		TrustedCAFile:  s.TrustedCAFile,
+		ClientCertAuth: s.ClientCertAuth,
+		CRLFile:        s.CRLFile,
+	}
+}
+
+type nodeSpec struct {
+	Version string `json:"version"`
+
+	ClientInsecure bool    `json:"client-insecure"`
+	ClientTLS      tlsSpec `json:"client-tls"`
+	ClientAutoTLS  bool    `json:"client-auto-tls"`
+	PeerInsecure   bool    `json:"peer-insecure"`
+	PeerTLS        tlsSpec `json:"peer-tls"`
+	PeerAutoTLS    bool    `json:"peer-auto-tls"`
+}
+
+type faultSpec struct {
+	Node  string   `json:"node"`
+	Type  string   `json:"type"`
+	After duration `json:"after"`
+}
+
+// configSpec is the declarative cluster spec read by LoadConfig.
+type configSpec struct {
+	Size     int    `json:"size"`
+	RootDir  string `json:"root-dir"`
+	RootPort int    `json:"root-port"`
+
+	NodeNameTemplate string `json:"node-name-template"`
+
+	EmbeddedClient bool `json:"embedded-client"`
+
+	ClientHost string `json:"client-host"`
+	PeerHost   string `json:"peer-host"`
+
+	HeartbeatInterval duration `json:"heartbeat-interval"`
+	ElectionTimeout   duration `json:"election-timeout"`
+
+	PreVote                           bool `json:"pre-vote"`
+	DisableInitialElectionTickAdvance bool `json:"disable-initial-election-tick-advance"`
+
+	AutoCompactionMode      string `json:"auto-compaction-mode"`
+	AutoCompactionRetention int    `json:"auto-compaction-retention"`
+
+	MaxRequestBytes      uint   `json:"max-request-bytes"`
+	MaxConcurrentStreams uint32 `json:"max-concurrent-streams"`
+
+	UnsafeNoFsync bool `json:"unsafe-no-fsync"`
+
+	SnapshotCount uint64 `json:"snapshot-count"`
+	MaxSnapFiles  uint   `json:"max-snapshots"`
+	MaxWalFiles   uint   `json:"max-wals"`
+
+	CORS          []string `json:"cors"`
+	HostWhitelist []string `json:"host-whitelist"`
+
+	UnixSockets bool `json:"unix-sockets"`
+
+	ClientTLS     tlsSpec `json:"client-tls"`
+	ClientAutoTLS bool    `json:"client-auto-tls"`
+	PeerTLS       tlsSpec `json:"peer-tls"`
+	PeerAutoTLS   bool    `json:"peer-auto-tls"`
+
+	GenerateCerts  bool     `json:"generate-certs"`
+	ClientCertAuth bool     `json:"client-cert-auth"`
+	CertValidFor   duration `json:"cert-valid-for"`
+
+	MetricsRootPort   int     `json:"metrics-root-port"`
+	MetricsTLS        tlsSpec `json:"metrics-tls"`
+	PeerProxyRootPort int     `json:"peer-proxy-root-port"`
+	GatewayPort       int     `json:"gateway-port"`
+	GRPCProxyPort     int     `json:"grpc-proxy-port"`
+
+	Mode          string            `json:"mode"`
+	EtcdBinary    string            `json:"etcd-binary"`
+	EtcdBinaries  map[string]string `json:"etcd-binaries"`
+	EtcdctlBinary string            `json:"etcdctl-binary"`
+	DockerHost    string            `json:"docker-host"`
+	DockerImage   string            `json:"docker-image"`
+	KubeAPIServer string            `json:"kube-api-server"`
+	KubeNamespace string            `json:"kube-namespace"`
+	KubeNode      string            `json:"kube-node"`
+
+	SSHHosts        map[string]string `json:"ssh-hosts"`
+	SSHIdentityFile string            `json:"ssh-identity-file"`
+	SSHSudo         bool              `json:"ssh-sudo"`
+
+	DialTimeout       duration `json:"dial-timeout"`
+	LogBufferSize     int      `json:"log-buffer-size"`
+	EventLogSize      int      `json:"event-log-size"`
+	LeaderHistorySize int      `json:"leader-history-size"`
+	StatusInterval    duration `json:"status-interval"`
+	LifecycleInterval duration `json:"lifecycle-interval"`
+	StatusWorkers     int      `json:"status-workers"`
+	TimeScale         float64  `json:"time-scale"`
+
+	// Nodes overrides per node name (e.g. "node1").
+	Nodes map[string]nodeSpec `json:"nodes"`
+
+	BenchDir        string `json:"bench-dir"`
+	ShutdownArchive string `json:"shutdown-archive"`
+	Seed            int64  `json:"seed"`
+	Fixture         string `json:"fixture"`
+
+	Faults []faultSpec `json:"faults"`
+}
+
+// LoadConfig reads a cluster spec from a YAML or JSON file (JSON is
+// valid YAML). Keys are in etcd flag style (e.g. 'root-port',
+// 'heartbeat-interval: 50ms'), and per-node overrides are under 'nodes'
+// keyed by node name. The returned Config has a cancelable RootCtx.
+func LoadConfig(path string) (Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var spec configSpec
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(b, &spec)
+	default:
+		err = yaml.Unmarshal(b, &spec)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse %q (%v)", path, err)
+	}
+
+	ccfg := Config{
+		Size:     spec.Size,
+		RootDir:  spec.RootDir,
+		RootPort: spec.RootPort,
+
+		NodeNameTemplate: spec.NodeNameTemplate,
+
+		EmbeddedClient: spec.EmbeddedClient,
+
+		ClientHost: spec.ClientHost,
+		PeerHost:   spec.PeerHost,
+
+		HeartbeatInterval: time.Duration(spec.HeartbeatInterval),
+		ElectionTimeout:   time.Duration(spec.ElectionTimeout),
+
+		PreVote:                           spec.PreVote,
+		DisableInitialElectionTickAdvance: spec.DisableInitialElectionTickAdvance,
+
+		AutoCompactionMode:      spec.AutoCompactionMode,
+		AutoCompactionRetention: spec.AutoCompactionRetention,
+
+		MaxRequestBytes:      spec.MaxRequestBytes,
+		MaxConcurrentStreams: spec.MaxConcurrentStreams,
+
+		UnsafeNoFsync: spec.UnsafeNoFsync,
+
+		SnapshotCount: spec.SnapshotCount,
+		MaxSnapFiles:  spec.MaxSnapFiles,
+		MaxWalFiles:   spec.MaxWalFiles,
+
+		CORS:          spec.CORS,
+		HostWhitelist: spec.HostWhitelist,
+
+		UnixSockets: spec.UnixSockets,
+
+		ClientTLSInfo: spec.ClientTLS.tlsInfo(),
+		ClientAutoTLS: spec.ClientAutoTLS,
+		PeerTLSInfo:   spec.PeerTLS.tlsInfo(),
+		PeerAutoTLS:   spec.PeerAutoTLS,
+
+		GenerateCerts:  spec.GenerateCerts,
+		ClientCertAuth: spec.ClientCertAuth,
+		CertValidFor:   time.Duration(spec.CertValidFor),
+
+		MetricsRootPort:   spec.MetricsRootPort,
+		MetricsTLSInfo:    spec.MetricsTLS.tlsInfo(),
+		PeerProxyRootPort: spec.PeerProxyRootPort,
+		GatewayPort:       spec.GatewayPort,
+		GRPCProxyPort:     spec.GRPCProxyPort,
+
+		Mode:          spec.Mode,
+		EtcdBinary:    spec.EtcdBinary,
+		EtcdBinaries:  spec.EtcdBinaries,
+		EtcdctlBinary: spec.EtcdctlBinary,
+		DockerHost:    spec.DockerHost,
+		DockerImage:   spec.DockerImage,
+		KubeAPIServer: spec.KubeAPIServer,
+		KubeNamespace: spec.KubeNamespace,
+		KubeNode:      spec.KubeNode,
+
+		SSHHosts:        spec.SSHHosts,
+		SSHIdentityFile: spec.SSHIdentityFile,
+		SSHSudo:         spec.SSHSudo,
+
+		DialTimeout:       time.Duration(spec.DialTimeout),
+		LogBufferSize:     spec.LogBufferSize,
+		EventLogSize:      spec.EventLogSize,
+		LeaderHistorySize: spec.LeaderHistorySize,
+		StatusInterval:    time.Duration(spec.StatusInterval),
+		LifecycleInterval: time.Duration(spec.LifecycleInterval),
+		StatusWorkers:     spec.StatusWorkers,
+		TimeScale:         spec.TimeScale,
+
+		BenchDir:        spec.BenchDir,
+		ShutdownArchive: spec.ShutdownArchive,
+		Seed:            spec.Seed,
+		Fixture:         spec.Fixture,
+	}
+
+	for name, ns := range spec.Nodes {
+		if ns.Version != "" {
+			if ccfg.NodeVersions == nil {
+				ccfg.NodeVersions = make(map[string]string)
+			}
+			ccfg.NodeVersions[name] = ns.Version
+		}
+		nt := NodeTLS{
+			ClientInsecure: ns.ClientInsecure,
+			ClientTLSInfo:  ns.ClientTLS.tlsInfo(),
+			ClientAutoTLS:  ns.ClientAutoTLS,
+			PeerInsecure:   ns.PeerInsecure,
+			PeerTLSInfo:    ns.PeerTLS.tlsInfo(),
+			PeerAutoTLS:    ns.PeerAutoTLS,
+		}
+		if ns != (nodeSpec{Version: ns.Version}) {
+			if ccfg.NodeTLS == nil {
+				ccfg.NodeTLS = make(map[string]NodeTLS)
+			}
+			ccfg.NodeTLS[name] = nt
+		}
+	}
+
+	for _, fs := range spec.Faults {
+		ccfg.Faults = append(ccfg.Faults, Fault{Node: fs.Node, Type: fs.Type, After: time.Duration(fs.After)})
+	}
+	if err = ccfg.validateFaults(); err != nil {
+		return Config{}, err
+	}
+
+	ccfg.RootCtx, ccfg.RootCancel = context.WithCancel(context.Background())
+	return ccfg, nil
+}
+
+func (c Config) validateFaults() error {
+	for _, f := range c.Faults {
+		switch f.Type {
+		case "stop", "kill", "slow":
+		default:
+			return fmt.Errorf("unknown fault type %q on %q", f.Type, f.Node)
+		}
+		if f.After < 0 {
+			return fmt.Errorf("fault %q on %q has negative delay", f.Type, f.Node)
+		}
+	}
+	return nil
+}
+
+// armFaults schedules the configured faults.
+func (clus *Cluster) armFaults() error {
+	if err := clus.ccfg.validateFaults(); err != nil {
+		return err
+	}
+	for _, f := range clus.ccfg.Faults {
+		if err := clus.armFault(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InjectFault schedules the fault on the running cluster,
+// with its delay counted from now.
+func (clus *Cluster) InjectFault(f Fault) error {
+	if err := (Config{Faults: []Fault{f}}).validateFaults(); err != nil {
+		return err
+	}
+	return clus.armFault(f)
+}
+
+func (clus *Cluster) armFault(f Fault) error {
+	if clus.FindIndexByName(f.Node) == -1 {
+		return fmt.Errorf("fault %q targets unknown node %q", f.Type, f.Node)
+	}
+
+	clus.recordEvent("fault-armed", f.Node, "%q on %q armed in %v", f.Type, f.Node, f.After)
+	go func() {
+		select {
+		case <-time.After(f.After):
+		case <-clus.rootCtx.Done():
+			return
+		}
+		// the node may have moved by Add/Remove
+		idx := clus.FindIndexByName(f.Node)
+		if idx == -1 {
+			glog.Warningf("fault %q targets removed node %q", f.Type, f.Node)
+			return
+		}
+		switch f.Type {
+		case "stop":
+			if err := clus.Stop(idx); err != nil {
+				glog.Warningf("fault %q on %q failed (%v)", f.Type, f.Node, err)
+			}
+		case "kill":
+			if err := clus.Kill(idx); err != nil {
+				glog.Warningf("fault %q on %q failed (%v)", f.Type, f.Node, err)
+			}
+		case "slow":
+			if err := clus.SlowPeer(idx, slowPeerRate); err != nil {
+				glog.Warningf("fault %q on %q failed (%v)", f.Type, f.Node, err)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,78 @@
+package cluster
+
+import (
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+	"github.com/coreos/etcdlabs/pkg/peertrace"
+)
+
+// connectivityWindow is how recent the traffic of a link must be for the
+// link to be up. rafthttp streams carry a heartbeat every few seconds even
+// between followers, so an idle link is down.
+var connectivityWindow = 5 * time.Second
+
+// Connectivity is the peer-to-peer reachability of the members,
+// as of the last status update.
+type Connectivity struct {
+	// Members are the member names, in the order of the matrix.
+	Members []string
+	// Reachable[i][j] is true if member i can send to member j.
+	Reachable [][]bool
+
+	// Observed is true if the matrix comes from the traffic seen by the
+	// peer proxies (see Config.PeerProxyRootPort). Otherwise links are
+	// up between running members.
+	Observed bool
+
+	Updated time.Time
+}
+
+// recordConnectivity updates the connectivity matrix from member statuses
+// and, if the peer proxies are enabled, the traffic of each link.
+// Must be called with 'mmu' held.
+func (clus *Cluster) recordConnectivity() {
+	now := time.Now()
+	cn := Connectivity{
+		Members:   make([]string, len(clus.Members)),
+		Reachable: make([][]bool, len(clus.Members)),
+		Observed:  clus.peerTracer != nil,
+		Updated:   now,
+	}
+	var active map[peertrace.Link]time.Time
+	if cn.Observed {
+		active = clus.peerTracer.Active()
+	}
+
+	running := make([]bool, len(clus.Members))
+	for i, m := range clus.Members {
+		cn.Members[i] = m.cfg.Name
+		m.statusLock.RLock()
+		running[i] = m.status.State != clusterpb.StoppedMemberStatus
+		m.statusLock.RUnlock()
+	}
+	for i, from := range clus.Members {
+		cn.Reachable[i] = make([]bool, len(clus.Members))
+		for j, to := range clus.Members {
+			switch {
+			case !running[i] || !running[j]:
+			case i == j || !cn.Observed:
+				cn.Reachable[i][j] = true
+			default:
+				last := active[peertrace.Link{From: from.ID(), To: to.ID()}]
+				cn.Reachable[i][j] = now.Sub(last) < connectivityWindow
+			}
+		}
+	}
+
+	clus.connectivityMu.Lock()
+	clus.connectivity = cn
+	clus.connectivityMu.Unlock()
+}
+
+// Connectivity returns the N×N peer reachability matrix of the members.
+func (clus *Cluster) Connectivity() Connectivity {
+	clus.connectivityMu.RLock()
+	defer clus.connectivityMu.RUnlock()
+	return clus.connectivity
+}
@@ -0,0 +1,218 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+var (
+	defaultDockerHost  = "unix:///var/run/docker.sock"
+	defaultDockerImage = "quay.io/coreos/etcd:v3.2.0"
+
+	// dockerAPIVersion is the oldest Engine API version with the endpoints in use.
+	dockerAPIVersion = "v1.24"
+)
+
+// dockerClient talks to the Docker Engine API.
+type dockerClient struct {
+	restClient
+}
+
+func newDockerClient(host string) (*dockerClient, error) {
+	if host == "" {
+		host = defaultDockerHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+
+	dc := &dockerClient{restClient{cli: &http.Client{}, api: "docker"}}
+	switch u.Scheme {
+	case "unix":
+		sock := u.Path
+		dc.cli.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", sock)
+			},
+		}
+		dc.base = "http://docker/" + dockerAPIVersion
+	case "tcp", "http":
+		dc.base = "http://" + u.Host + "/" + dockerAPIVersion
+	default:
+		return nil, fmt.Errorf("unsupported docker host %q", host)
+	}
+	return dc, nil
+}
+
+// container runs an etcd server in a Docker container on the host network,
+// with the root directory bind-mounted so that data and certificates
+// live at the same paths as in the other modes.
+type container struct {
+	dc      *dockerClient
+	name    string
+	rootDir string
+	logf    func(line string)
+
+	mu    sync.Mutex
+	image string
+}
+
+func newContainer(dc *dockerClient, name, image, rootDir string, logf func(string)) *container {
+	if image == "" {
+		image = defaultDockerImage
+	}
+	return &container{dc: dc, name: "etcdlabs-" + name, image: image, rootDir: rootDir, logf: logf}
+}
+
+// Image returns the image of the container.
+func (c *container) Image() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.image
+}
+
+// SetImage sets the image used on next start.
+func (c *container) SetImage(image string) {
+	c.mu.Lock()
+	c.image = image
+	c.mu.Unlock()
+}
+
+// Start implements externalNode. It recreates the container,
+// pulling the image if it is not present.
+func (c *container) Start(flags []string) error {
+	c.remove()
+
+	image := c.Image()
+	body := map[string]interface{}{
+		"Image": image,
+		"Cmd":   append([]string{"etcd"}, flags...),
+		"HostConfig": map[string]interface{}{
+			"NetworkMode": "host",
+			"Binds":       []string{c.rootDir + ":" + c.rootDir},
+		},
+	}
+	err := c.dc.call(http.MethodPost, "/containers/create?name="+url.QueryEscape(c.name), body)
+	if isAPINotFound(err) {
+		if err = c.pull(image); err != nil {
+			return err
+		}
+		err = c.dc.call(http.MethodPost, "/containers/create?name="+url.QueryEscape(c.name), body)
+	}
+	if err != nil {
+		return err
+	}
+	if err = c.dc.call(http.MethodPost, "/containers/"+c.name+"/start", nil); err != nil {
+		return err
+	}
+	glog.Infof("started container %q (image %q): %v", c.name, image, flags)
+
+	go c.streamLogs()
+	return nil
+}
+
+func (c *container) pull(image string) error {
+	glog.Infof("pulling image %q", image)
+	name, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+	return c.dc.call(http.MethodPost, "/images/create?fromImage="+url.QueryEscape(name)+"&tag="+url.QueryEscape(tag), nil)
+}
+
+// streamLogs forwards the container output until it stops.
+// Output of containers without TTY is multiplexed in frames
+// with an 8-byte header holding the frame size.
+func (c *container) streamLogs() {
+	resp, err := c.dc.do(http.MethodGet, "/containers/"+c.name+"/logs?follow=1&stdout=1&stderr=1", nil)
+	if err != nil {
+		glog.Warningf("failed to stream logs of %q (%v)", c.name, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		sc := bufio.NewScanner(pr)
+		for sc.Scan() {
+			c.logf(sc.Text())
+		}
+	}()
+	defer pw.Close()
+
+	hdr := make([]byte, 8)
+	for {
+		if _, err = io.ReadFull(resp.Body, hdr); err != nil {
+			return
+		}
+		if _, err = io.CopyN(pw, resp.Body, int64(binary.BigEndian.Uint32(hdr[4:]))); err != nil {
+			return
+		}
+	}
+}
+
+// Stop implements externalNode. The stopped container is removed;
+// its data stays in the bind-mounted root directory.
+func (c *container) Stop() error {
+	err := c.dc.call(http.MethodPost, fmt.Sprintf("/containers/%s/stop?t=%d", c.name, int(processStopTimeout/time.Second)), nil)
+	if isAPINotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.remove()
+	return nil
+}
+
+// Kill implements externalNode.
+func (c *container) Kill() error {
+	err := c.dc.call(http.MethodPost, "/containers/"+c.name+"/kill?signal=SIGKILL", nil)
+	if isAPINotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.remove()
+	return nil
+}
+
+// Running implements externalNode.
+func (c *container) Running() bool {
+	resp, err := c.dc.do(http.MethodGet, "/containers/"+c.name+"/json", nil)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	var st struct {
+		State struct{ Running bool }
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return false
+	}
+	return st.State.Running
+}
+
+// remove force-removes the container, if any.
+func (c *container) remove() {
+	err := c.dc.call(http.MethodDelete, "/containers/"+c.name+"?force=1", nil)
+	if err != nil && !isAPINotFound(err) {
+		glog.Warningf("failed to remove container %q (%v)", c.name, err)
+	}
+}
@@ -0,0 +1,225 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/pkg/transport"
+	"github.com/coreos/etcd/version"
+)
+
+const defaultEtcdctlBinary = "etcdctl"
+
+var downgradeClusterVersionTimeout = time.Minute
+
+// majorMinor returns the "major.minor" prefix of the version (e.g. "3.4" of "v3.4.2").
+func majorMinor(v string) string {
+	ss := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(ss) < 2 {
+		return strings.Join(ss, ".")
+	}
+	return ss[0] + "." + ss[1]
+}
+
+// Versions returns the server and cluster versions the node reports.
+func (clus *Cluster) Versions(i int) (version.Versions, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return version.Versions{}, err
+	}
+	return m.versions()
+}
+
+// ClusterVersion returns the cluster version the node reports.
+func (clus *Cluster) ClusterVersion(i int) (string, error) {
+	vs, err := clus.Versions(i)
+	return vs.Cluster, err
+}
+
+func (m *Member) versions() (vs version.Versions, err error) {
+	err = m.getJSON("/version", &vs)
+	return vs, err
+}
+
+// getJSON decodes the response of GET on the path of the client URL.
+func (m *Member) getJSON(path string, v interface{}) error {
+	var tlsInfo transport.TLSInfo
+	if isTLSScheme(m.cfg.LCUrls[0].Scheme) {
+		tlsInfo = m.cfg.ClientTLSInfo
+		if !m.clus.rootClientTLSInfo.Empty() {
+			tlsInfo = m.clus.rootClientTLSInfo
+		}
+	}
+	// transport dials unix sockets for 'unix://' URLs
+	tr, err := transport.NewTransport(tlsInfo, time.Second)
+	if err != nil {
+		return err
+	}
+	hc := &http.Client{Transport: tr, Timeout: 3 * time.Second}
+	resp, err := hc.Get(m.cfg.LCUrls[0].String() + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s on %q returned %s", path, m.cfg.Name, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// etcdctl runs etcdctl v3 against the member, as root once auth is enabled.
+func (clus *Cluster) etcdctl(ctx context.Context, m *Member, args ...string) (string, error) {
+	bin := clus.ccfg.EtcdctlBinary
+	if bin == "" {
+		bin = defaultEtcdctlBinary
+	}
+
+	flags := []string{"--endpoints=" + m.cfg.LCUrls[0].String()}
+	tlsInfo := m.cfg.ClientTLSInfo
+	if !clus.rootClientTLSInfo.Empty() {
+		tlsInfo = clus.rootClientTLSInfo
+	}
+	if isTLSScheme(m.cfg.LCUrls[0].Scheme) {
+		if tlsInfo.TrustedCAFile != "" {
+			flags = append(flags, "--cacert="+tlsInfo.TrustedCAFile)
+		} else {
+			flags = append(flags, "--insecure-skip-tls-verify")
+		}
+		if tlsInfo.CertFile != "" {
+			flags = append(flags, "--cert="+tlsInfo.CertFile, "--key="+tlsInfo.KeyFile)
+		}
+	}
+	if user, pw := clus.rootCredentials(); user != "" {
+		flags = append(flags, "--user="+user+":"+pw)
+	}
+
+	cmd := exec.CommandContext(ctx, bin, append(flags, args...)...)
+	cmd.Env = append(os.Environ(), "ETCDCTL_API=3")
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		return out.String(), fmt.Errorf("etcdctl %s failed (%v, %q)", strings.Join(args, " "), err, strings.TrimSpace(out.String()))
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+func (clus *Cluster) downgrade(ctx context.Context, i int, action, targetVersion string) error {
+	if clus.ccfg.Mode != ModeSubprocess {
+		return fmt.Errorf("downgrade is only supported in %s mode", ModeSubprocess)
+	}
+	m, err := clus.member(i)
+	if err != nil {
+		return err
+	}
+	_, err = clus.etcdctl(ctx, m, "downgrade", action, majorMinor(targetVersion))
+	return err
+}
+
+// DowngradeValidate checks if the cluster can downgrade to the version.
+func (clus *Cluster) DowngradeValidate(ctx context.Context, i int, targetVersion string) error {
+	return clus.downgrade(ctx, i, "validate", targetVersion)
+}
+
+// DowngradeEnable enables downgrading the cluster to the version.
+func (clus *Cluster) DowngradeEnable(ctx context.Context, i int, targetVersion string) error {
+	return clus.downgrade(ctx, i, "enable", targetVersion)
+}
+
+// DowngradeCancel cancels the ongoing downgrade.
+func (clus *Cluster) DowngradeCancel(ctx context.Context, i int) error {
+	if clus.ccfg.Mode != ModeSubprocess {
+		return fmt.Errorf("downgrade is only supported in %s mode", ModeSubprocess)
+	}
+	m, err := clus.member(i)
+	if err != nil {
+		return err
+	}
+	_, err = clus.etcdctl(ctx, m, "downgrade", "cancel")
+	return err
+}
+
+// recordClusterVersion records the cluster version if it changed from 'prev',
+// and returns the current one.
+func (clus *Cluster) recordClusterVersion(prev string) string {
+	for _, m := range clus.upgradeOrder() {
+		vs, err := m.versions()
+		if err != nil {
+			continue
+		}
+		if vs.Cluster != prev {
+			clus.recordEvent("cluster-version", "", "cluster version changed from %q to %q", prev, vs.Cluster)
+		}
+		return vs.Cluster
+	}
+	return prev
+}
+
+// Downgrade rehearses the etcd downgrade workflow in ModeSubprocess:
+// it validates and enables the downgrade, then switches members to the
+// binary of the target version one at a time, followers first. The
+// cluster version transitions are recorded in the event log.
+func (clus *Cluster) Downgrade(targetVersion string) (err error) {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+
+	clus.recordEvent("downgrade-start", "", "downgrade to version %q started", targetVersion)
+	defer func() {
+		if err != nil {
+			clus.recordEvent("downgrade-failed", "", "downgrade to version %q failed (%v)", targetVersion, err)
+		} else {
+			clus.recordEvent("downgrade-complete", "", "downgrade to version %q completed", targetVersion)
+		}
+	}()
+
+	if clus.ccfg.Mode != ModeSubprocess {
+		return fmt.Errorf("downgrade is only supported in %s mode", ModeSubprocess)
+	}
+	if _, ok := clus.ccfg.EtcdBinaries[targetVersion]; !ok {
+		return fmt.Errorf("no etcd binary is configured for version %q", targetVersion)
+	}
+	if err = clus.waitHealthy(upgradeHealthTimeout); err != nil {
+		return fmt.Errorf("cluster is not healthy before downgrade (%v)", err)
+	}
+	cv := clus.recordClusterVersion("")
+
+	ctx, cancel := context.WithTimeout(clus.rootCtx, 10*time.Second)
+	defer cancel()
+	if err = clus.DowngradeValidate(ctx, 0, targetVersion); err != nil {
+		return err
+	}
+	if err = clus.DowngradeEnable(ctx, 0, targetVersion); err != nil {
+		return err
+	}
+	clus.recordEvent("downgrade-enabled", "", "downgrade to version %q enabled", majorMinor(targetVersion))
+
+	// the cluster version drops to the target once the downgrade is enabled
+	deadline := time.Now().Add(downgradeClusterVersionTimeout)
+	for majorMinor(cv) != majorMinor(targetVersion) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("cluster version is %q, expected %q", cv, majorMinor(targetVersion))
+		}
+		time.Sleep(500 * time.Millisecond)
+		cv = clus.recordClusterVersion(cv)
+	}
+
+	for _, m := range clus.upgradeOrder() {
+		from := m.version
+		clus.recordEvent("member-downgrade-start", m.cfg.Name, "downgrading %q from version %q to %q", m.cfg.Name, from, targetVersion)
+		if err = clus.setNodeVersion(m, targetVersion); err != nil {
+			return err
+		}
+		if err = clus.waitHealthy(upgradeHealthTimeout); err != nil {
+			return err
+		}
+		clus.recordEvent("member-downgraded", m.cfg.Name, "downgraded %q from version %q to %q", m.cfg.Name, from, targetVersion)
+		cv = clus.recordClusterVersion(cv)
+	}
+	return nil
+}
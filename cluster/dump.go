@@ -0,0 +1,173 @@
+package cluster
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// Keyspace dump formats.
+const (
+	// DumpJSON is JSON lines of FixtureEntry, as fixture files.
+	DumpJSON = "json"
+	// DumpCSV has a "key,value,lease" header, and a row per key.
+	DumpCSV = "csv"
+)
+
+// dumpPageSize is the number of keys read per range request.
+var dumpPageSize int64 = 1000
+
+// DumpKeyspace writes the keys under the prefix (all keys if empty),
+// in the format, as of the revision of the first read. The lease of a
+// key is its remaining TTL, so an import attaches a new lease with the
+// same TTL. It returns the number of written keys.
+func (clus *Cluster) DumpKeyspace(ctx context.Context, w io.Writer, prefix, format string) (int, error) {
+	var write func(FixtureEntry) error
+	var flush func() error
+	switch format {
+	case DumpJSON:
+		enc := json.NewEncoder(w)
+		write = func(e FixtureEntry) error { return enc.Encode(e) }
+		flush = func() error { return nil }
+	case DumpCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"key", "value", "lease"}); err != nil {
+			return 0, err
+		}
+		write = func(e FixtureEntry) error {
+			return cw.Write([]string{e.Key, e.Value, strconv.FormatInt(e.Lease, 10)})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		return 0, fmt.Errorf("unknown keyspace format %q (expected %q or %q)", format, DumpJSON, DumpCSV)
+	}
+
+	idx, err := clus.startedNode()
+	if err != nil {
+		return 0, err
+	}
+	cli, err := clus.SharedClient(idx)
+	if err != nil {
+		return 0, err
+	}
+
+	ttls := make(map[int64]int64)
+	n := 0
+	_, err = rangePages(ctx, cli, prefix, func(kvs []*mvccpb.KeyValue) error {
+		for _, kv := range kvs {
+			e := FixtureEntry{Key: string(kv.Key), Value: string(kv.Value)}
+			if kv.Lease != 0 {
+				ttl, ok := ttls[kv.Lease]
+				if !ok {
+					lresp, err := cli.TimeToLive(ctx, clientv3.LeaseID(kv.Lease))
+					if err != nil {
+						return err
+					}
+					ttl, ttls[kv.Lease] = lresp.TTL, lresp.TTL
+				}
+				// expired leases (TTL -1) are dumped without a lease
+				if ttl > 0 {
+					e.Lease = ttl
+				}
+			}
+			if err := write(e); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, flush()
+}
+
+// rangePages reads the keys under the prefix (all keys if empty) in pages
+// of dumpPageSize keys, sorted by key, as of the revision of the first
+// page, which it returns.
+func rangePages(ctx context.Context, cli *clientv3.Client, prefix string, page func([]*mvccpb.KeyValue) error) (int64, error) {
+	return rangePagesAt(ctx, cli, prefix, 0, page)
+}
+
+// rangePagesAt is rangePages as of the revision, if positive.
+func rangePagesAt(ctx context.Context, cli *clientv3.Client, prefix string, rev int64, page func([]*mvccpb.KeyValue) error) (int64, error) {
+	key, end := prefix, clientv3.GetPrefixRangeEnd(prefix)
+	if prefix == "" {
+		key, end = "\x00", "\x00"
+	}
+	for {
+		opts := []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithLimit(dumpPageSize), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend)}
+		if rev > 0 {
+			opts = append(opts, clientv3.WithRev(rev))
+		}
+		resp, err := cli.Get(ctx, key, opts...)
+		if err != nil {
+			return rev, err
+		}
+		if rev == 0 {
+			rev = resp.Header.Revision
+		}
+		if err = page(resp.Kvs); err != nil {
+			return rev, err
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			return rev, nil
+		}
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
+
+// ImportKeyspace writes the keys of a dump in the format (see
+// DumpKeyspace), as LoadFixture does. The source names the dump in
+// errors and events.
+func (clus *Cluster) ImportKeyspace(ctx context.Context, r io.Reader, format, source string) (FixtureResult, error) {
+	switch format {
+	case DumpJSON:
+		return clus.LoadFixtureFrom(ctx, r, source)
+	case DumpCSV:
+	default:
+		return FixtureResult{}, fmt.Errorf("unknown keyspace format %q (expected %q or %q)", format, DumpJSON, DumpCSV)
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	row := 0
+	res, err := clus.putEntries(ctx, func() (FixtureEntry, error) {
+		var e FixtureEntry
+		for {
+			rec, err := cr.Read()
+			if err != nil {
+				return e, err
+			}
+			row++
+			if row == 1 && rec[0] == "key" && rec[1] == "value" && rec[2] == "lease" {
+				continue
+			}
+			e.Key, e.Value = rec[0], rec[1]
+			if rec[2] != "" {
+				if e.Lease, err = strconv.ParseInt(rec[2], 10, 64); err != nil || e.Lease < 0 {
+					return e, fmt.Errorf("row %d: invalid lease TTL %q", row, rec[2])
+				}
+			}
+			if e.Key == "" {
+				return e, fmt.Errorf("row %d: empty key", row)
+			}
+			return e, nil
+		}
+	})
+	if err != nil {
+		return res, fmt.Errorf("failed to import %q (%v)", source, err)
+	}
+	clus.recordEvent("keyspace-import", "", "imported %d keys with %d leases from %q", res.Keys, res.Leases, source)
+	return res, nil
+}
@@ -0,0 +1,190 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/golang/glog"
+)
+
+// electionPrefix is the key prefix of demo elections.
+const electionPrefix = "/etcdlabs/election"
+
+// ElectionCandidate is a session campaigning in an election.
+type ElectionCandidate struct {
+	Session        string
+	Key            string
+	Value          string
+	CreateRevision int64
+}
+
+// ElectionState describes the leader and the candidates of an election.
+type ElectionState struct {
+	Name     string
+	Revision int64
+
+	// Leader is the elected candidate, nil if none.
+	Leader *ElectionCandidate
+	// Candidates are all campaigning sessions in campaign order,
+	// including the leader.
+	Candidates []ElectionCandidate
+}
+
+type electionHandle struct {
+	session string
+	e       *concurrency.Election
+	cancel  context.CancelFunc
+	elected chan struct{}
+}
+
+type elections struct {
+	mu      sync.Mutex
+	handles map[string]*electionHandle // keyed by session/election
+}
+
+// Campaign puts the named session (created on node 'i' if needed) up for
+// election with the proposed 'value'. It returns immediately; the session
+// becomes leader once all earlier candidates resign or expire.
+func (clus *Cluster) Campaign(i int, session, name, value string) error {
+	if name == "" {
+		return fmt.Errorf("election name is empty")
+	}
+	s, err := clus.session(i, session)
+	if err != nil {
+		return err
+	}
+
+	hk := handleKey(session, name)
+	clus.elections.mu.Lock()
+	defer clus.elections.mu.Unlock()
+	if _, ok := clus.elections.handles[hk]; ok {
+		return fmt.Errorf("session %q is already campaigning in election %q", session, name)
+	}
+
+	ctx, cancel := context.WithCancel(clus.rootCtx)
+	h := &electionHandle{
+		session: session,
+		e:       concurrency.NewElection(s.sess, path.Join(electionPrefix, name)),
+		cancel:  cancel,
+		elected: make(chan struct{}),
+	}
+	clus.elections.handles[hk] = h
+
+	go func() {
+		if err := h.e.Campaign(ctx, value); err != nil {
+			glog.Warningf("session %q failed to campaign in election %q (%v)", session, name, err)
+			clus.elections.mu.Lock()
+			if clus.elections.handles[hk] == h {
+				delete(clus.elections.handles, hk)
+			}
+			clus.elections.mu.Unlock()
+			return
+		}
+		close(h.elected)
+		glog.Infof("session %q is elected leader of election %q", session, name)
+	}()
+	return nil
+}
+
+// Resign gives up leadership of the election, or
+// withdraws the candidacy if the session is not yet elected.
+func (clus *Cluster) Resign(ctx context.Context, session, name string) error {
+	hk := handleKey(session, name)
+	clus.elections.mu.Lock()
+	h, ok := clus.elections.handles[hk]
+	delete(clus.elections.handles, hk)
+	clus.elections.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("session %q is not campaigning in election %q", session, name)
+	}
+
+	select {
+	case <-h.elected:
+		err := h.e.Resign(ctx)
+		h.cancel()
+		return err
+	default:
+		// canceling Campaign deletes the candidate key
+		h.cancel()
+		return nil
+	}
+}
+
+func (clus *Cluster) electionState(ctx context.Context, cli *clientv3.Client, name string) (ElectionState, error) {
+	pfx := path.Join(electionPrefix, name) + "/"
+	resp, err := cli.Get(ctx, pfx, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByCreateRevision, clientv3.SortAscend))
+	if err != nil {
+		return ElectionState{}, err
+	}
+
+	st := ElectionState{Name: name, Revision: resp.Header.Revision}
+	for _, kv := range resp.Kvs {
+		st.Candidates = append(st.Candidates, ElectionCandidate{
+			Session:        clus.sessionName(clientv3.LeaseID(kv.Lease)),
+			Key:            string(kv.Key),
+			Value:          string(kv.Value),
+			CreateRevision: kv.CreateRevision,
+		})
+	}
+	if len(st.Candidates) > 0 {
+		st.Leader = &st.Candidates[0]
+	}
+	return st, nil
+}
+
+// ElectionLeader returns the leader and candidates of the election, read through node 'i'.
+func (clus *Cluster) ElectionLeader(ctx context.Context, i int, name string) (ElectionState, error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return ElectionState{}, err
+	}
+
+	return clus.electionState(ctx, cli, name)
+}
+
+// ElectionObserve streams the election state through node 'i', starting with
+// the current state and followed by every change of leader or candidates.
+// The returned channel is closed when 'ctx' is canceled or the watch fails.
+func (clus *Cluster) ElectionObserve(ctx context.Context, i int, name string) (<-chan ElectionState, error) {
+	cli, _, err := clus.Members[i].Client(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	st, err := clus.electionState(ctx, cli, name)
+	if err != nil {
+		cli.Close()
+		return nil, err
+	}
+
+	wctx, wcancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	wch := cli.Watch(wctx, path.Join(electionPrefix, name)+"/", clientv3.WithPrefix(), clientv3.WithRev(st.Revision+1))
+
+	rch := make(chan ElectionState)
+	go func() {
+		defer func() {
+			wcancel()
+			cli.Close()
+			close(rch)
+		}()
+		for {
+			select {
+			case rch <- st:
+			case <-ctx.Done():
+				return
+			}
+
+			wr, ok := <-wch
+			if !ok || wr.Err() != nil {
+				return
+			}
+			if st, err = clus.electionState(ctx, cli, name); err != nil {
+				return
+			}
+		}
+	}()
+	return rch, nil
+}
@@ -0,0 +1,76 @@
+package cluster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Event is a cluster operation or state change, recorded in the event log.
+type Event struct {
+	Time time.Time
+	// Type identifies the event (e.g. "member-stop", "upgrade-start").
+	Type string
+	// Node is the name of the node the event is about, if any.
+	Node    string
+	Message string
+}
+
+var defaultEventLogSize = 512
+
+// eventLog is a fixed-size ring buffer of events.
+type eventLog struct {
+	mu     sync.RWMutex
+	events []Event
+	next   int
+	full   bool
+}
+
+func newEventLog(size int) *eventLog {
+	if size <= 0 {
+		size = defaultEventLogSize
+	}
+	return &eventLog{events: make([]Event, size)}
+}
+
+func (el *eventLog) add(ev Event) {
+	el.mu.Lock()
+	el.events[el.next] = ev
+	el.next = (el.next + 1) % len(el.events)
+	if el.next == 0 {
+		el.full = true
+	}
+	el.mu.Unlock()
+}
+
+// last returns up to 'n' most recent events, oldest first.
+// It returns all events if 'n' is not positive.
+func (el *eventLog) last(n int) []Event {
+	el.mu.RLock()
+	defer el.mu.RUnlock()
+
+	var evs []Event
+	if el.full {
+		evs = append(evs, el.events[el.next:]...)
+	}
+	evs = append(evs, el.events[:el.next]...)
+	if n > 0 && len(evs) > n {
+		evs = evs[len(evs)-n:]
+	}
+	return evs
+}
+
+// recordEvent adds an event to the event log.
+func (clus *Cluster) recordEvent(typ, node, format string, args ...interface{}) {
+	ev := Event{Time: time.Now(), Type: typ, Node: node, Message: fmt.Sprintf(format, args...)}
+	glog.Infof("event %q: %s", typ, ev.Message)
+	clus.events.add(ev)
+}
+
+// Events returns up to 'lastN' most recent events, oldest first.
+// It returns all recorded events if 'lastN' is not positive.
+func (clus *Cluster) Events(lastN int) []Event {
+	return clus.events.last(lastN)
+}
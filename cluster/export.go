@@ -0,0 +1,141 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/etcd/embed"
+)
+
+// Export formats.
+const (
+	ExportFlags         = "flags"
+	ExportSystemd       = "systemd"
+	ExportDockerCompose = "docker-compose"
+	ExportManifest      = "manifest"
+)
+
+// Export returns deployment artifacts equivalent to the running cluster,
+// keyed by file name:
+//
+//	ExportFlags:         etcd command-line flags per member ('node1.flags')
+//	ExportSystemd:       a systemd unit per member ('etcd-node1.service')
+//	ExportDockerCompose: a single 'docker-compose.yml' with a service per member
+//	ExportManifest:      a Kubernetes static pod manifest per member ('etcd-node1.yaml')
+//
+// The artifacts bootstrap a new cluster with the current membership,
+// so every member uses the initial cluster state "new".
+func (clus *Cluster) Export(format string) (map[string]string, error) {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+
+	ms := clus.exportMembers("")
+	files := make(map[string]string)
+	switch format {
+	case ExportFlags:
+		for _, m := range ms {
+			files[m.name+".flags"] = strings.Join(m.flags, " \\\n  ") + "\n"
+		}
+
+	case ExportSystemd:
+		for _, m := range ms {
+			files["etcd-"+m.name+".service"] = m.systemdUnit()
+		}
+
+	case ExportDockerCompose:
+		image := clus.ccfg.DockerImage
+		if image == "" {
+			image = defaultDockerImage
+		}
+		var buf bytes.Buffer
+		buf.WriteString("version: '2'\nservices:\n")
+		for _, m := range ms {
+			fmt.Fprintf(&buf, "  %s:\n", m.name)
+			fmt.Fprintf(&buf, "    image: %s\n", image)
+			buf.WriteString("    network_mode: host\n")
+			buf.WriteString("    command:\n      - etcd\n")
+			for _, f := range m.flags {
+				fmt.Fprintf(&buf, "      - %q\n", f)
+			}
+		}
+		files["docker-compose.yml"] = buf.String()
+
+	case ExportManifest:
+		image := clus.ccfg.DockerImage
+		if image == "" {
+			image = defaultDockerImage
+		}
+		for _, m := range ms {
+			var buf bytes.Buffer
+			buf.WriteString("apiVersion: v1\nkind: Pod\nmetadata:\n")
+			fmt.Fprintf(&buf, "  name: etcd-%s\n  namespace: kube-system\n", m.name)
+			buf.WriteString("spec:\n  hostNetwork: true\n  containers:\n")
+			fmt.Fprintf(&buf, "  - name: etcd\n    image: %s\n    command:\n    - etcd\n", image)
+			for _, f := range m.flags {
+				fmt.Fprintf(&buf, "    - %q\n", f)
+			}
+			files["etcd-"+m.name+".yaml"] = buf.String()
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	return files, nil
+}
+
+// exportMember is a member as deployed by the exported artifacts.
+type exportMember struct {
+	name  string
+	flags []string
+	// version is the etcd version of the member, if known.
+	version string
+}
+
+// exportMembers returns the members with the flags that bootstrap a new
+// cluster of the current membership. If 'dataDir' is not empty, every
+// member stores its data there instead of under the root directory.
+// Must be called with 'mmu' held.
+func (clus *Cluster) exportMembers(dataDir string) []exportMember {
+	ms := make([]exportMember, 0, len(clus.Members))
+	for _, m := range clus.Members {
+		cfg := *m.cfg
+		cfg.ClusterState = embed.ClusterStateFlagNew
+		cfg.InitialCluster = clus.initialCluster()
+		if dataDir != "" {
+			cfg.Dir, cfg.WalDir = dataDir, ""
+		}
+		version := m.version
+		if version == "" {
+			version = m.statusCopy().Version
+		}
+		ms = append(ms, exportMember{
+			name:    m.cfg.Name,
+			flags:   append(etcdFlags(&cfg, m.metricsURL), clus.ccfg.externalFlags()...),
+			version: strings.TrimPrefix(version, "v"),
+		})
+	}
+	return ms
+}
+
+// systemdUnit returns the systemd unit of the member,
+// running '/usr/local/bin/etcd'.
+func (m exportMember) systemdUnit() string {
+	return fmt.Sprintf(`[Unit]
+Description=etcd member %s
+Documentation=https://github.com/coreos/etcd
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=notify
+ExecStart=/usr/local/bin/etcd \
+  %s
+Restart=on-failure
+RestartSec=5
+LimitNOFILE=65536
+
+[Install]
+WantedBy=multi-user.target
+`, m.name, strings.Join(m.flags, " \\\n  "))
+}
@@ -0,0 +1,83 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+
+	"github.com/golang/glog"
+)
+
+// ForcedElection is a raft leader election forced by ForceElection.
+type ForcedElection struct {
+	From string
+	To   string
+
+	Started time.Time
+	// Duration is from the leadership transfer request until
+	// the new leader is recorded in the event log.
+	Duration time.Duration
+}
+
+// ForceElection transfers the leadership to a random running follower,
+// and waits until the new leader appears in the event log, to measure
+// failover latency repeatably. Followers are picked with the cluster seed.
+func (clus *Cluster) ForceElection(ctx context.Context) (ForcedElection, error) {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+
+	if err := clus.RefreshStatus(ctx); err != nil {
+		return ForcedElection{}, err
+	}
+	lead, followers := -1, []int{}
+	for i, st := range clus.AllMemberStatus() {
+		switch st.State {
+		case clusterpb.LeaderMemberStatus:
+			lead = i
+		case clusterpb.FollowerMemberStatus:
+			followers = append(followers, i)
+		}
+	}
+	if lead == -1 {
+		return ForcedElection{}, fmt.Errorf("no leader to move")
+	}
+	if len(followers) == 0 {
+		return ForcedElection{}, fmt.Errorf("no running follower to elect")
+	}
+	to := followers[clus.NewRand().Intn(len(followers))]
+
+	clus.mmu.RLock()
+	from, target := clus.Members[lead], clus.Members[to]
+	clus.mmu.RUnlock()
+
+	el := ForcedElection{From: from.cfg.Name, To: target.cfg.Name, Started: time.Now()}
+	glog.Infof("moving leader from %q to %q", el.From, el.To)
+
+	cli, err := from.sharedClient()
+	if err != nil {
+		return ForcedElection{}, err
+	}
+	if _, err = cli.MoveLeader(ctx, uint64(target.ID())); err != nil {
+		return ForcedElection{}, err
+	}
+
+	for {
+		if err = clus.RefreshStatus(ctx); err != nil {
+			return ForcedElection{}, err
+		}
+		for _, ev := range clus.events.last(0) {
+			if ev.Type == "leader-change" && ev.Node == el.To && !ev.Time.Before(el.Started) {
+				el.Duration = ev.Time.Sub(el.Started)
+				clus.recordEvent("force-election", el.To, "moved leader from %q to %q in %v", el.From, el.To, el.Duration)
+				return el, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ForcedElection{}, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
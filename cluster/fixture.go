@@ -0,0 +1,189 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/coreos/etcdlabs/pkg/workload"
+
+	"github.com/coreos/etcd/clientv3"
+)
+
+// FixtureEntry is a line of a fixture file, in JSON.
+type FixtureEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Lease is the TTL in seconds of a lease attached to the key.
+	// Keys with the same TTL share a lease. No lease is attached if zero.
+	Lease int64 `json:"lease,omitempty"`
+}
+
+// FixtureResult is the result of LoadFixture.
+type FixtureResult struct {
+	Keys   int
+	Leases int
+	Took   time.Duration
+}
+
+// fixtureTimeout bounds the load of Config.Fixture at startup.
+var fixtureTimeout = 5 * time.Minute
+
+// fixtureBatch is the number of keys written per transaction,
+// within the default limit of etcd (--max-txn-ops).
+var fixtureBatch = 128
+
+// LoadFixture writes the keys of the fixture file (JSON lines of
+// FixtureEntry) through any started node, so that demos and benchmarks
+// start from a known keyspace. Keys are written in transactions of up to
+// 128 keys, so a failure leaves the keys of the earlier batches written.
+func (clus *Cluster) LoadFixture(ctx context.Context, path string) (FixtureResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FixtureResult{}, err
+	}
+	defer f.Close()
+	return clus.LoadFixtureFrom(ctx, f, path)
+}
+
+// LoadFixtureFrom loads the fixture from the reader (see LoadFixture).
+// The source names the fixture in errors and events.
+func (clus *Cluster) LoadFixtureFrom(ctx context.Context, rd io.Reader, source string) (FixtureResult, error) {
+	r, err := clus.loadFixture(ctx, rd)
+	if err != nil {
+		return r, fmt.Errorf("failed to load fixture %q (%v)", source, err)
+	}
+	clus.recordEvent("fixture-load", "", "loaded %d keys with %d leases from %q", r.Keys, r.Leases, source)
+	return r, nil
+}
+
+func (clus *Cluster) loadFixture(ctx context.Context, rd io.Reader) (FixtureResult, error) {
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 64*1024), 2*1024*1024)
+	line := 0
+	return clus.putEntries(ctx, func() (FixtureEntry, error) {
+		var e FixtureEntry
+		for {
+			if !sc.Scan() {
+				if err := sc.Err(); err != nil {
+					return e, err
+				}
+				return e, io.EOF
+			}
+			line++
+			if len(sc.Bytes()) > 0 {
+				break
+			}
+		}
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return e, fmt.Errorf("line %d: %v", line, err)
+		}
+		if e.Key == "" {
+			return e, fmt.Errorf("line %d: empty key", line)
+		}
+		if e.Lease < 0 {
+			return e, fmt.Errorf("line %d: negative lease TTL %d", line, e.Lease)
+		}
+		return e, nil
+	})
+}
+
+// Fill writes 'n' keys generated from the workload spec, with a random
+// generator from NewRand, so that the keyspace can be reproduced from the
+// cluster seed. Keys are written as by LoadFixture.
+func (clus *Cluster) Fill(ctx context.Context, spec workload.Spec, n int) (FixtureResult, error) {
+	gen, err := workload.New(spec, clus.NewRand())
+	if err != nil {
+		return FixtureResult{}, err
+	}
+	if n <= 0 {
+		return FixtureResult{}, fmt.Errorf("number of keys must be positive, got %d", n)
+	}
+	written := 0
+	r, err := clus.putEntries(ctx, func() (FixtureEntry, error) {
+		if written == n {
+			return FixtureEntry{}, io.EOF
+		}
+		written++
+		k, v := gen.Next()
+		return FixtureEntry{Key: k, Value: v}, nil
+	})
+	if err != nil {
+		return r, fmt.Errorf("failed to fill (%v)", err)
+	}
+	dist := spec.Distribution
+	if dist == "" {
+		dist = workload.Uniform
+	}
+	clus.recordEvent("fill", "", "wrote %d %s keys under %q", r.Keys, dist, spec.Prefix)
+	return r, nil
+}
+
+// putEntries writes the entries returned by 'next', until it returns
+// io.EOF, in transactions through any started node.
+func (clus *Cluster) putEntries(ctx context.Context, next func() (FixtureEntry, error)) (FixtureResult, error) {
+	start := time.Now()
+	var r FixtureResult
+
+	idx, err := clus.startedNode()
+	if err != nil {
+		return r, err
+	}
+	cli, err := clus.SharedClient(idx)
+	if err != nil {
+		return r, err
+	}
+
+	leases := make(map[int64]clientv3.LeaseID)
+	var ops []clientv3.Op
+	flush := func() error {
+		if len(ops) == 0 {
+			return nil
+		}
+		if _, err := cli.Txn(ctx).Then(ops...).Commit(); err != nil {
+			return err
+		}
+		r.Keys += len(ops)
+		ops = ops[:0]
+		return nil
+	}
+
+	for {
+		e, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return r, err
+		}
+
+		var opts []clientv3.OpOption
+		if e.Lease > 0 {
+			id, ok := leases[e.Lease]
+			if !ok {
+				resp, err := cli.Grant(ctx, e.Lease)
+				if err != nil {
+					return r, err
+				}
+				id, leases[e.Lease] = resp.ID, resp.ID
+				r.Leases++
+			}
+			opts = append(opts, clientv3.WithLease(id))
+		}
+		ops = append(ops, clientv3.OpPut(e.Key, e.Value, opts...))
+		if len(ops) == fixtureBatch {
+			if err = flush(); err != nil {
+				return r, err
+			}
+		}
+	}
+	if err = flush(); err != nil {
+		return r, err
+	}
+	r.Took = time.Since(start)
+	return r, nil
+}
@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/coreos/etcd/compactor"
+	"github.com/coreos/etcd/embed"
+	"github.com/coreos/etcd/pkg/transport"
+)
+
+// etcdFlags translates the member configuration into etcd command-line flags.
+// Only options that differ from etcd defaults are set, so that the flags
+// work with as many etcd versions as possible.
+func etcdFlags(cfg *embed.Config, metricsURL url.URL) []string {
+	def := embed.NewConfig()
+
+	fs := []string{
+		"--name=" + cfg.Name,
+		"--data-dir=" + cfg.Dir,
+		"--listen-client-urls=" + joinURLs(cfg.LCUrls),
+		"--advertise-client-urls=" + joinURLs(cfg.ACUrls),
+		"--listen-peer-urls=" + joinURLs(cfg.LPUrls),
+		"--initial-advertise-peer-urls=" + joinURLs(cfg.APUrls),
+		"--initial-cluster=" + cfg.InitialCluster,
+		"--initial-cluster-state=" + cfg.ClusterState,
+	}
+	if cfg.WalDir != "" {
+		fs = append(fs, "--wal-dir="+cfg.WalDir)
+	}
+	if cfg.InitialClusterToken != def.InitialClusterToken {
+		fs = append(fs, "--initial-cluster-token="+cfg.InitialClusterToken)
+	}
+	if cfg.TickMs != def.TickMs {
+		fs = append(fs, fmt.Sprintf("--heartbeat-interval=%d", cfg.TickMs))
+	}
+	if cfg.ElectionMs != def.ElectionMs {
+		fs = append(fs, fmt.Sprintf("--election-timeout=%d", cfg.ElectionMs))
+	}
+	if cfg.SnapCount != def.SnapCount {
+		fs = append(fs, fmt.Sprintf("--snapshot-count=%d", cfg.SnapCount))
+	}
+	if cfg.MaxSnapFiles != def.MaxSnapFiles {
+		fs = append(fs, fmt.Sprintf("--max-snapshots=%d", cfg.MaxSnapFiles))
+	}
+	if cfg.MaxWalFiles != def.MaxWalFiles {
+		fs = append(fs, fmt.Sprintf("--max-wals=%d", cfg.MaxWalFiles))
+	}
+	if cfg.QuotaBackendBytes != def.QuotaBackendBytes {
+		fs = append(fs, fmt.Sprintf("--quota-backend-bytes=%d", cfg.QuotaBackendBytes))
+	}
+	if cfg.MaxRequestBytes != def.MaxRequestBytes {
+		fs = append(fs, fmt.Sprintf("--max-request-bytes=%d", cfg.MaxRequestBytes))
+	}
+	if cfg.AutoCompactionMode != "" && cfg.AutoCompactionMode != compactor.ModePeriodic {
+		fs = append(fs, "--auto-compaction-mode="+cfg.AutoCompactionMode)
+	}
+	if cfg.AutoCompactionRetention != 0 {
+		fs = append(fs, fmt.Sprintf("--auto-compaction-retention=%d", cfg.AutoCompactionRetention))
+	}
+	if cfg.CorsInfo != nil && len(*cfg.CorsInfo) > 0 {
+		fs = append(fs, "--cors="+cfg.CorsInfo.String())
+	}
+	if metricsURL.Host != "" {
+		fs = append(fs, "--listen-metrics-urls="+metricsURL.String())
+	}
+
+	fs = append(fs, tlsFlags("", cfg.ClientTLSInfo, cfg.ClientAutoTLS)...)
+	fs = append(fs, tlsFlags("peer-", cfg.PeerTLSInfo, cfg.PeerAutoTLS)...)
+	return fs
+}
+
+// externalFlags returns the flags of options that the vendored embedded
+// server does not support, so they only apply to external nodes.
+func (c Config) externalFlags() []string {
+	var fs []string
+	if c.PreVote {
+		fs = append(fs, "--pre-vote")
+	}
+	if c.DisableInitialElectionTickAdvance {
+		fs = append(fs, "--initial-election-tick-advance=false")
+	}
+	if len(c.HostWhitelist) > 0 {
+		fs = append(fs, "--host-whitelist="+strings.Join(c.HostWhitelist, ","))
+	}
+	if c.MaxConcurrentStreams > 0 {
+		fs = append(fs, fmt.Sprintf("--max-concurrent-streams=%d", c.MaxConcurrentStreams))
+	}
+	if c.UnsafeNoFsync {
+		fs = append(fs, "--unsafe-no-fsync")
+	}
+	return fs
+}
+
+// flags returns the command-line flags of the external node.
+func (m *Member) flags() []string {
+	return append(etcdFlags(m.cfg, m.metricsURL), m.clus.ccfg.externalFlags()...)
+}
+
+func tlsFlags(pfx string, info transport.TLSInfo, auto bool) []string {
+	var fs []string
+	if auto {
+		fs = append(fs, "--"+pfx+"auto-tls")
+	}
+	if info.CertFile != "" {
+		fs = append(fs, "--"+pfx+"cert-file="+info.CertFile)
+	}
+	if info.KeyFile != "" {
+		fs = append(fs, "--"+pfx+"key-file="+info.KeyFile)
+	}
+	if info.TrustedCAFile != "" {
+		fs = append(fs, "--"+pfx+"trusted-ca-file="+info.TrustedCAFile)
+	}
+	if info.ClientCertAuth {
+		fs = append(fs, "--"+pfx+"client-cert-auth")
+	}
+	if info.CRLFile != "" {
+		fs = append(fs, "--"+pfx+"crl-file="+info.CRLFile)
+	}
+	return fs
+}
+
+func joinURLs(us []url.URL) string {
+	ss := make([]string, len(us))
+	for i := range us {
+		ss[i] = us[i].String()
+	}
+	return strings.Join(ss, ",")
+}
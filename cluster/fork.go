@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// forkTimeout bounds the keyspace dump, the leader election of the copy
+// and the import in Fork.
+var forkTimeout = time.Minute
+
+// Fork starts an independent copy of the cluster with the same
+// configuration and number of nodes, under 'newRootDir' with ports from
+// 'newPortBase', and loads the current keyspace into it. The metrics, peer
+// proxy, gateway and gRPC proxy ports are shifted by the same offset.
+//
+// The keys and their leases are copied as DumpKeyspace does, so revisions
+// start over, and users, roles and scheduled faults are not copied. The
+// copy is not stopped with the cluster; the caller shuts it down.
+func (clus *Cluster) Fork(newRootDir string, newPortBase int) (*Cluster, error) {
+	switch clus.ccfg.Mode {
+	case ModeDocker, ModeKubernetes, ModeSSH:
+		return nil, fmt.Errorf("cannot fork a cluster in %s mode (node names would conflict)", clus.ccfg.Mode)
+	}
+	if newRootDir == "" || newRootDir == clus.rootDir {
+		return nil, fmt.Errorf("fork needs a root directory other than %q", clus.rootDir)
+	}
+
+	f, err := ioutil.TempFile("", "etcdlabs-fork")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(clus.rootCtx, forkTimeout)
+	defer cancel()
+
+	n, err := clus.DumpKeyspace(ctx, f, "", DumpJSON)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	fork, err := Start(clus.forkConfig(newRootDir, newPortBase))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = fork.WaitLeader(ctx); err == nil {
+		_, err = fork.ImportKeyspace(ctx, f, DumpJSON, fmt.Sprintf("fork of %q", clus.rootDir))
+	}
+	if err != nil {
+		fork.Shutdown()
+		return nil, err
+	}
+
+	clus.recordEvent("fork", "", "forked %d keys into %q (root port :%d)", n, newRootDir, newPortBase)
+	fork.recordEvent("fork", "", "forked %d keys from %q", n, clus.rootDir)
+	return fork, nil
+}
+
+// forkConfig returns the configuration of a copy of the cluster.
+func (clus *Cluster) forkConfig(rootDir string, portBase int) Config {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+
+	ccfg := clus.ccfg
+	ccfg.Size = len(clus.Members)
+	ccfg.RootDir = rootDir
+	ccfg.RootPort = portBase
+	ccfg.PortAllocator = nil
+
+	shift := func(port int) int {
+		if port == 0 {
+			return 0
+		}
+		return port + portBase - clus.ccfg.RootPort
+	}
+	ccfg.MetricsRootPort = shift(ccfg.MetricsRootPort)
+	ccfg.PeerProxyRootPort = shift(ccfg.PeerProxyRootPort)
+	ccfg.GatewayPort = shift(ccfg.GatewayPort)
+	ccfg.GRPCProxyPort = shift(ccfg.GRPCProxyPort)
+
+	// the nodes keep their current versions under the names of the copy
+	ccfg.NodeVersions = nil
+	for i, m := range clus.Members {
+		if m.version == "" {
+			continue
+		}
+		if ccfg.NodeVersions == nil {
+			ccfg.NodeVersions = make(map[string]string)
+		}
+		ccfg.NodeVersions[ccfg.nodeName(i+1)] = m.version
+	}
+
+	// the keyspace is imported instead
+	ccfg.Faults, ccfg.Fixture = nil, ""
+	// does not overwrite the archive of the cluster
+	ccfg.ShutdownArchive = ""
+	ccfg.RootCtx, ccfg.RootCancel = context.WithCancel(context.Background())
+	return ccfg
+}
@@ -0,0 +1,178 @@
+package cluster
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/golang/glog"
+)
+
+// gatewayRetryDelay is how long the gateway skips an endpoint
+// after failing to connect to it.
+var gatewayRetryDelay = 3 * time.Second
+
+// gateway is a TCP proxy in front of the cluster client endpoints,
+// like 'etcd gateway'. It proxies each connection to the next healthy
+// member in round-robin, and routes around members it cannot reach.
+type gateway struct {
+	clus *Cluster
+	ln   net.Listener
+
+	mu        sync.Mutex
+	next      int
+	downUntil map[string]time.Time
+	conns     map[net.Conn]struct{}
+	// closed is true once the gateway stops, so that no connection
+	// is proxied afterwards.
+	closed bool
+	donec  chan struct{}
+}
+
+func (clus *Cluster) startGateway() error {
+	if clus.ccfg.GatewayPort <= 0 {
+		return nil
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(clus.ccfg.clientHost(), fmt.Sprint(clus.ccfg.GatewayPort)))
+	if err != nil {
+		return err
+	}
+	gw := &gateway{
+		clus:      clus,
+		ln:        ln,
+		downUntil: make(map[string]time.Time),
+		conns:     make(map[net.Conn]struct{}),
+		donec:     make(chan struct{}),
+	}
+	go gw.serve()
+	clus.gateway = gw
+	glog.Infof("gateway is serving on %q", ln.Addr().String())
+	return nil
+}
+
+// GatewayEndpoint returns the gateway endpoint, or an empty string
+// if the gateway is disabled.
+func (clus *Cluster) GatewayEndpoint() string {
+	if clus.gateway == nil {
+		return ""
+	}
+	return clus.gateway.ln.Addr().String()
+}
+
+// GatewayClient returns a client connected through the gateway.
+func (clus *Cluster) GatewayClient() (*clientv3.Client, *tls.Config, error) {
+	ep := clus.GatewayEndpoint()
+	if ep == "" {
+		return nil, nil, errors.New("gateway is disabled")
+	}
+	if clus.embeddedClient {
+		return nil, nil, errors.New("embedded clients cannot connect through the gateway")
+	}
+	clus.mmu.RLock()
+	m := clus.Members[0]
+	clus.mmu.RUnlock()
+	return m.Client(clus.rootCtx, false, ep)
+}
+
+func (gw *gateway) serve() {
+	defer close(gw.donec)
+	for {
+		in, err := gw.ln.Accept()
+		if err != nil {
+			glog.Infof("gateway stopped serving (%v)", err)
+			return
+		}
+		go gw.proxy(in)
+	}
+}
+
+// endpoints returns the client endpoints in round-robin order,
+// skipping the ones that recently failed.
+func (gw *gateway) endpoints() []string {
+	eps := gw.clus.AllEndpoints(false)
+
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+
+	now := time.Now()
+	var healthy []string
+	for i := range eps {
+		ep := eps[(gw.next+i)%len(eps)]
+		if now.Before(gw.downUntil[ep]) {
+			continue
+		}
+		healthy = append(healthy, ep)
+	}
+	gw.next++
+	return healthy
+}
+
+func (gw *gateway) markDown(ep string) {
+	gw.mu.Lock()
+	gw.downUntil[ep] = time.Now().Add(gatewayRetryDelay)
+	gw.mu.Unlock()
+}
+
+func (gw *gateway) proxy(in net.Conn) {
+	var out net.Conn
+	for _, ep := range gw.endpoints() {
+		var err error
+		out, err = dialEndpoint(ep, time.Second)
+		if err == nil {
+			break
+		}
+		glog.Warningf("gateway failed to connect to %q (%v)", ep, err)
+		gw.markDown(ep)
+	}
+	if out == nil {
+		glog.Warning("gateway found no reachable endpoint")
+		in.Close()
+		return
+	}
+
+	gw.mu.Lock()
+	if gw.closed {
+		gw.mu.Unlock()
+		in.Close()
+		out.Close()
+		return
+	}
+	gw.conns[in], gw.conns[out] = struct{}{}, struct{}{}
+	gw.mu.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		io.Copy(in, out)
+		in.Close()
+		wg.Done()
+	}()
+	go func() {
+		io.Copy(out, in)
+		out.Close()
+		wg.Done()
+	}()
+	wg.Wait()
+
+	gw.mu.Lock()
+	delete(gw.conns, in)
+	delete(gw.conns, out)
+	gw.mu.Unlock()
+}
+
+func (gw *gateway) stop() {
+	gw.ln.Close()
+	<-gw.donec
+
+	gw.mu.Lock()
+	gw.closed = true
+	for c := range gw.conns {
+		c.Close()
+	}
+	gw.mu.Unlock()
+}
@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/golang/glog"
+)
+
+// grpcProxy runs 'etcd grpc-proxy' in front of the cluster. The proxy
+// caches serializable ranges and coalesces watches on the same key
+// range into a single watch against the cluster.
+type grpcProxy struct {
+	addr string
+	proc *process
+}
+
+func (clus *Cluster) startGRPCProxy() error {
+	if clus.ccfg.GRPCProxyPort <= 0 {
+		return nil
+	}
+	bin := clus.ccfg.EtcdBinary
+	if bin == "" {
+		bin = defaultEtcdBinary
+	}
+
+	addr := net.JoinHostPort(clus.ccfg.clientHost(), fmt.Sprint(clus.ccfg.GRPCProxyPort))
+	flags := []string{
+		"grpc-proxy", "start",
+		"--endpoints=" + strings.Join(clus.AllEndpoints(false), ","),
+		"--listen-addr=" + addr,
+	}
+	tlsInfo := clus.ccfg.ClientTLSInfo
+	if !clus.rootClientTLSInfo.Empty() {
+		tlsInfo = clus.rootClientTLSInfo
+	}
+	if isTLSScheme(clus.Members[0].cfg.LCUrls[0].Scheme) {
+		if tlsInfo.TrustedCAFile != "" {
+			flags = append(flags, "--cacert="+tlsInfo.TrustedCAFile)
+		} else {
+			flags = append(flags, "--insecure-skip-tls-verify")
+		}
+		if tlsInfo.CertFile != "" {
+			flags = append(flags, "--cert="+tlsInfo.CertFile, "--key="+tlsInfo.KeyFile)
+		}
+	}
+
+	p := newProcess("grpc-proxy", bin, func(line string) { glog.Info("grpc-proxy: ", line) })
+	if err := p.Start(flags); err != nil {
+		return err
+	}
+
+	// wait for the proxy to listen
+	deadline := time.Now().Add(nodeReadyTimeout)
+	for {
+		conn, err := dialEndpoint(addr, time.Second)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			p.Kill()
+			return fmt.Errorf("grpc-proxy is not serving on %q (%v)", addr, err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	clus.grpcProxy = &grpcProxy{addr: addr, proc: p}
+	glog.Infof("grpc-proxy is serving on %q", addr)
+	return nil
+}
+
+// GRPCProxyEndpoint returns the gRPC proxy endpoint, or an empty string
+// if the proxy is disabled.
+func (clus *Cluster) GRPCProxyEndpoint() string {
+	if clus.grpcProxy == nil {
+		return ""
+	}
+	return clus.grpcProxy.addr
+}
+
+// GRPCProxyClient returns a client connected through the gRPC proxy,
+// to compare its latency with direct access.
+func (clus *Cluster) GRPCProxyClient() (*clientv3.Client, *tls.Config, error) {
+	ep := clus.GRPCProxyEndpoint()
+	if ep == "" {
+		return nil, nil, errors.New("grpc-proxy is disabled")
+	}
+	if clus.embeddedClient {
+		return nil, nil, errors.New("embedded clients cannot connect through the grpc-proxy")
+	}
+	ccfg := clientv3.Config{
+		Endpoints:   []string{ep},
+		DialTimeout: clus.clientDialTimeout,
+	}
+	// the proxy serves plaintext, and forwards auth tokens to the cluster
+	ccfg.Username, ccfg.Password = clus.rootCredentials()
+	cli, err := clientv3.New(ccfg)
+	return cli, nil, err
+}
+
+func (gp *grpcProxy) stop() {
+	if err := gp.proc.Stop(); err != nil {
+		glog.Warningf("failed to stop grpc-proxy (%v)", err)
+	}
+}
@@ -0,0 +1,58 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+)
+
+var healthProbeTimeout = time.Second
+
+// HealthResponse is the result of a health probe.
+type HealthResponse struct {
+	Healthy bool
+	Took    time.Duration
+	Error   string
+}
+
+// probeHealth reads a key linearizably, as 'etcdctl endpoint health' does.
+// Permission errors are healthy, since the request reached consensus.
+func probeHealth(ctx context.Context, cli *clientv3.Client) (resp HealthResponse) {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	now := time.Now()
+	_, err := cli.Get(ctx, "health")
+	resp.Took = time.Since(now)
+	if err != nil && err != rpctypes.ErrPermissionDenied {
+		resp.Error = err.Error()
+		return resp
+	}
+	resp.Healthy = true
+	return resp
+}
+
+// Health probes node 'i' with a linearizable read, which fails when the
+// node is reachable but cannot reach the leader or the leader lost quorum.
+func (clus *Cluster) Health(ctx context.Context, i int) (HealthResponse, error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return HealthResponse{}, err
+	}
+	return probeHealth(ctx, cli), nil
+}
+
+// HealthByName is Health of the node with the name.
+func (clus *Cluster) HealthByName(ctx context.Context, name string) (HealthResponse, error) {
+	m, err := clus.memberByName(name)
+	if err != nil {
+		return HealthResponse{}, err
+	}
+	cli, err := m.sharedClient()
+	if err != nil {
+		return HealthResponse{}, err
+	}
+	return probeHealth(ctx, cli), nil
+}
@@ -0,0 +1,74 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
+)
+
+var defaultKeyHistoryLimit = 100
+
+// KeyHistory is the revision history of a key.
+type KeyHistory struct {
+	Key      string
+	Revision int64
+
+	// KeyValues are the versions of the key, newest first.
+	// Each entry is the value as of its ModRevision.
+	KeyValues []KeyValue
+
+	// Compacted is true if older versions were removed by compaction.
+	Compacted bool
+	// Truncated is true if the history exceeds the limit.
+	Truncated bool
+}
+
+// KeyHistory walks back the revisions of a key through node 'i', reading the
+// key at the revision before each modification until it reaches the creation
+// of the key, a compacted revision, or 'limit' versions (0 for the default).
+// Versions of the key before its last deletion are not included.
+func (clus *Cluster) KeyHistory(ctx context.Context, i int, key string, limit int) (h KeyHistory, err error) {
+	if key == "" {
+		return h, fmt.Errorf("key is empty")
+	}
+	if limit <= 0 {
+		limit = defaultKeyHistoryLimit
+	}
+
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return h, err
+	}
+
+	h.Key = key
+	var opts []clientv3.OpOption
+	for {
+		resp, err := cli.Get(ctx, key, opts...)
+		if err == rpctypes.ErrCompacted {
+			h.Compacted = true
+			return h, nil
+		}
+		if err != nil {
+			return h, err
+		}
+		if h.Revision == 0 {
+			h.Revision = resp.Header.Revision
+		}
+		if len(resp.Kvs) == 0 {
+			return h, nil
+		}
+		if len(h.KeyValues) == limit {
+			h.Truncated = true
+			return h, nil
+		}
+
+		kv := toKeyValues(resp.Kvs)[0]
+		h.KeyValues = append(h.KeyValues, kv)
+		if kv.Version == 1 {
+			return h, nil
+		}
+		opts = []clientv3.OpOption{clientv3.WithRev(kv.ModRevision - 1)}
+	}
+}
@@ -0,0 +1,150 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Infrastructure export formats.
+const (
+	ExportCloudInit = "cloud-init"
+	ExportTerraform = "terraform"
+)
+
+// infraDataDir is the data directory of exported members on real machines.
+var infraDataDir = "/var/lib/etcd"
+
+// ExportInfra returns provisioning templates of the current topology,
+// keyed by file name:
+//
+//	ExportCloudInit: cloud-init user-data per member ('etcd-node1.cloud-init.yaml')
+//	                 that installs the etcd release of the member and runs it
+//	                 as a systemd unit
+//	ExportTerraform: the user-data, and a 'main.tf' that renders it per member
+//	                 with the cloudinit provider, to pass to the instances
+//
+// Members keep their flags and URLs, with data in "/var/lib/etcd", so hosts
+// of a cluster on localhost must be replaced with the machine addresses,
+// and certificate files copied to the same paths.
+func (clus *Cluster) ExportInfra(format string) (map[string]string, error) {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+
+	ms := clus.exportMembers(infraDataDir)
+	files := make(map[string]string)
+	switch format {
+	case ExportCloudInit, ExportTerraform:
+		for _, m := range ms {
+			files[m.cloudInitFile()] = clus.cloudInit(m)
+		}
+		if format == ExportTerraform {
+			files["main.tf"] = clus.terraform(ms)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown infrastructure export format %q", format)
+	}
+	return files, nil
+}
+
+// imageVersion returns the etcd version of the image tag (e.g. "3.2.0"
+// for "quay.io/coreos/etcd:v3.2.0"), or an empty string if it has no tag.
+func imageVersion(image string) string {
+	i := strings.LastIndex(image, ":")
+	if i <= strings.LastIndex(image, "/") {
+		return ""
+	}
+	return strings.TrimPrefix(image[i+1:], "v")
+}
+
+func (m exportMember) cloudInitFile() string {
+	return "etcd-" + m.name + ".cloud-init.yaml"
+}
+
+// cloudInit returns the cloud-config that installs and starts the member.
+// Must be called with 'mmu' held.
+func (clus *Cluster) cloudInit(m exportMember) string {
+	version := m.version
+	if version == "" {
+		version = imageVersion(clus.ccfg.DockerImage)
+	}
+	if version == "" {
+		version = imageVersion(defaultDockerImage)
+	}
+	release := fmt.Sprintf("etcd-v%s-linux-amd64", version)
+
+	var buf bytes.Buffer
+	buf.WriteString("#cloud-config\n")
+	buf.WriteString("write_files:\n")
+	buf.WriteString("  - path: /etc/systemd/system/etcd.service\n")
+	buf.WriteString("    permissions: '0644'\n")
+	buf.WriteString("    content: |\n")
+	for _, l := range strings.SplitAfter(m.systemdUnit(), "\n") {
+		if l != "" && l != "\n" {
+			buf.WriteString("      ")
+		}
+		buf.WriteString(l)
+	}
+	buf.WriteString("runcmd:\n")
+	fmt.Fprintf(&buf, "  - [sh, -c, \"curl -fsSL https://github.com/coreos/etcd/releases/download/v%s/%s.tar.gz | tar -xz -C /usr/local/bin --strip-components=1 %s/etcd %s/etcdctl\"]\n", version, release, release, release)
+	fmt.Fprintf(&buf, "  - [mkdir, -p, %s]\n", infraDataDir)
+	buf.WriteString("  - [systemctl, daemon-reload]\n")
+	buf.WriteString("  - [systemctl, enable, --now, etcd.service]\n")
+	return buf.String()
+}
+
+// terraform returns a Terraform configuration that renders the user-data
+// of each member, and outputs the client endpoints.
+// Must be called with 'mmu' held.
+func (clus *Cluster) terraform(ms []exportMember) string {
+	var buf bytes.Buffer
+	buf.WriteString(`terraform {
+  required_providers {
+    cloudinit = {
+      source = "hashicorp/cloudinit"
+    }
+  }
+}
+
+`)
+	buf.WriteString("locals {\n")
+	fmt.Fprintf(&buf, "  etcd_initial_cluster  = %q\n", clus.initialCluster())
+	buf.WriteString("  etcd_client_endpoints = [\n")
+	for _, m := range clus.Members {
+		for _, u := range m.cfg.ACUrls {
+			fmt.Fprintf(&buf, "    %q,\n", u.String())
+		}
+	}
+	buf.WriteString("  ]\n}\n\n")
+	buf.WriteString("output \"etcd_client_endpoints\" {\n  value = local.etcd_client_endpoints\n}\n")
+
+	for _, m := range ms {
+		id := "etcd_" + strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+				return r
+			}
+			return '_'
+		}, m.name)
+		fmt.Fprintf(&buf, `
+# user-data of %s, to pass to its instance (e.g. 'user_data' of aws_instance,
+# 'custom_data' of azurerm_linux_virtual_machine, or 'user-data' in the
+# metadata of google_compute_instance)
+data "cloudinit_config" %q {
+  gzip          = false
+  base64_encode = false
+
+  part {
+    content_type = "text/cloud-config"
+    content      = file("${path.module}/%s")
+  }
+}
+
+output "%s_user_data" {
+  value = data.cloudinit_config.%s.rendered
+}
+`, m.name, id, m.cloudInitFile(), id, id)
+	}
+	return buf.String()
+}
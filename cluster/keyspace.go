@@ -0,0 +1,138 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// keyspaceTopPrefixes is the default number of prefixes in KeyspaceStats.
+var keyspaceTopPrefixes = 10
+
+// KeyspaceStats describes the keys in the store, as of Revision.
+type KeyspaceStats struct {
+	Revision   int64
+	Keys       int64
+	ValueBytes int64
+	// MinModRevision and MaxModRevision are the revisions of the oldest
+	// and the latest modified keys.
+	MinModRevision int64
+	MaxModRevision int64
+	// TopByCount and TopBySize are the prefixes with the most keys
+	// and the most value bytes.
+	TopByCount []PrefixStats
+	TopBySize  []PrefixStats
+}
+
+// PrefixStats describes the keys under a prefix.
+type PrefixStats struct {
+	Prefix     string
+	Keys       int64
+	ValueBytes int64
+}
+
+// keyPrefix returns the first segment of the key, up to and including
+// the first '/' after its first byte, so "/registry/pods/a" is under
+// "/registry/" and "foo/bar" under "foo/". Keys without one are under
+// the empty prefix.
+func keyPrefix(key string) string {
+	if len(key) < 2 {
+		return ""
+	}
+	i := strings.IndexByte(key[1:], '/')
+	if i < 0 {
+		return ""
+	}
+	return key[:i+2]
+}
+
+// KeyspaceStats returns the statistics of the keyspace, with up to 'top'
+// prefixes by count and size (keyspaceTopPrefixes if not positive). The
+// count and the revision span are read with count-only and single-key
+// ranges, and the value bytes, which no range returns, are summed from
+// pages of keys at the same revision.
+func (clus *Cluster) KeyspaceStats(ctx context.Context, top int) (KeyspaceStats, error) {
+	if top <= 0 {
+		top = keyspaceTopPrefixes
+	}
+	idx, err := clus.startedNode()
+	if err != nil {
+		return KeyspaceStats{}, err
+	}
+	cli, err := clus.SharedClient(idx)
+	if err != nil {
+		return KeyspaceStats{}, err
+	}
+
+	resp, err := cli.Get(ctx, "\x00", clientv3.WithFromKey(), clientv3.WithCountOnly())
+	if err != nil {
+		return KeyspaceStats{}, err
+	}
+	st := KeyspaceStats{Revision: resp.Header.Revision, Keys: resp.Count}
+	if st.Keys == 0 {
+		return st, nil
+	}
+
+	for _, order := range []clientv3.SortOrder{clientv3.SortAscend, clientv3.SortDescend} {
+		resp, err = cli.Get(ctx, "\x00", clientv3.WithFromKey(), clientv3.WithRev(st.Revision), clientv3.WithKeysOnly(), clientv3.WithLimit(1), clientv3.WithSort(clientv3.SortByModRevision, order))
+		if err != nil {
+			return st, err
+		}
+		if len(resp.Kvs) == 0 {
+			continue
+		}
+		if order == clientv3.SortAscend {
+			st.MinModRevision = resp.Kvs[0].ModRevision
+		} else {
+			st.MaxModRevision = resp.Kvs[0].ModRevision
+		}
+	}
+
+	prefixes := make(map[string]*PrefixStats)
+	_, err = rangePagesAt(ctx, cli, "", st.Revision, func(kvs []*mvccpb.KeyValue) error {
+		for _, kv := range kvs {
+			p := keyPrefix(string(kv.Key))
+			ps, ok := prefixes[p]
+			if !ok {
+				ps = &PrefixStats{Prefix: p}
+				prefixes[p] = ps
+			}
+			ps.Keys++
+			ps.ValueBytes += int64(len(kv.Value))
+			st.ValueBytes += int64(len(kv.Value))
+		}
+		return nil
+	})
+	if err != nil {
+		return st, err
+	}
+
+	all := make([]PrefixStats, 0, len(prefixes))
+	for _, ps := range prefixes {
+		all = append(all, *ps)
+	}
+	st.TopByCount = topPrefixes(all, top, func(a, b PrefixStats) bool { return a.Keys > b.Keys })
+	st.TopBySize = topPrefixes(all, top, func(a, b PrefixStats) bool { return a.ValueBytes > b.ValueBytes })
+	return st, nil
+}
+
+// topPrefixes returns the first 'n' prefixes in the order, ties sorted by prefix.
+func topPrefixes(ps []PrefixStats, n int, less func(a, b PrefixStats) bool) []PrefixStats {
+	s := append([]PrefixStats(nil), ps...)
+	sort.Slice(s, func(i, j int) bool {
+		if less(s[i], s[j]) {
+			return true
+		}
+		if less(s[j], s[i]) {
+			return false
+		}
+		return s[i].Prefix < s[j].Prefix
+	})
+	if len(s) > n {
+		s = s[:n]
+	}
+	return s
+}
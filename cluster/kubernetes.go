@@ -0,0 +1,313 @@
+package cluster
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+var (
+	// defaultKubeAPIServer is the address of 'kubectl proxy',
+	// used outside of a Kubernetes pod.
+	defaultKubeAPIServer = "http://127.0.0.1:8001"
+	defaultKubeNamespace = "default"
+
+	kubeServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// podStartTimeout is how long a pod may take to be scheduled,
+	// pull its image and run.
+	podStartTimeout = 2 * time.Minute
+	podPollInterval = 500 * time.Millisecond
+)
+
+// kubeClient talks to the Kubernetes API server.
+type kubeClient struct {
+	restClient
+	namespace string
+}
+
+// newKubeClient returns a client of the API server at 'server'. If empty,
+// the client uses the service account inside a pod, and 'kubectl proxy'
+// otherwise. The namespace defaults to the one of the service account,
+// or "default".
+func newKubeClient(server, namespace string) (*kubeClient, error) {
+	kc := &kubeClient{
+		restClient: restClient{cli: &http.Client{}, base: strings.TrimSuffix(server, "/"), api: "kubernetes"},
+		namespace:  namespace,
+	}
+
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if kc.base == "" && host != "" && port != "" {
+		token, err := ioutil.ReadFile(filepath.Join(kubeServiceAccountDir, "token"))
+		if err != nil {
+			return nil, err
+		}
+		ca, err := ioutil.ReadFile(filepath.Join(kubeServiceAccountDir, "ca.crt"))
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate in %q", filepath.Join(kubeServiceAccountDir, "ca.crt"))
+		}
+		kc.cli.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+		kc.base = "https://" + net.JoinHostPort(host, port)
+		kc.token = strings.TrimSpace(string(token))
+		if kc.namespace == "" {
+			if ns, err := ioutil.ReadFile(filepath.Join(kubeServiceAccountDir, "namespace")); err == nil {
+				kc.namespace = strings.TrimSpace(string(ns))
+			}
+		}
+	}
+	if kc.base == "" {
+		kc.base = defaultKubeAPIServer
+	}
+	if kc.namespace == "" {
+		kc.namespace = defaultKubeNamespace
+	}
+	return kc, nil
+}
+
+// podPath returns the API path of the pod in the namespace of the client.
+func (kc *kubeClient) podPath(name string) string {
+	return "/api/v1/namespaces/" + kc.namespace + "/pods/" + name
+}
+
+// pod runs an etcd server in a Kubernetes pod on the host network, with
+// the root directory mounted from the host, as containers in ModeDocker.
+// The pod is pinned to a node if one is configured.
+type pod struct {
+	kc       *kubeClient
+	name     string
+	member   string
+	kubeNode string
+	rootDir  string
+	logf     func(line string)
+
+	mu    sync.Mutex
+	image string
+}
+
+func newPod(kc *kubeClient, member, image, kubeNode, rootDir string, logf func(string)) *pod {
+	if image == "" {
+		image = defaultDockerImage
+	}
+	// pod names are DNS labels
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, member)
+	return &pod{
+		kc:       kc,
+		name:     "etcdlabs-" + strings.Trim(name, "-"),
+		member:   member,
+		kubeNode: kubeNode,
+		rootDir:  rootDir,
+		logf:     logf,
+		image:    image,
+	}
+}
+
+// Image returns the image of the pod.
+func (p *pod) Image() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.image
+}
+
+// SetImage sets the image used on next start.
+func (p *pod) SetImage(image string) {
+	p.mu.Lock()
+	p.image = image
+	p.mu.Unlock()
+}
+
+// Start implements externalNode. It recreates the pod, and waits
+// until it is scheduled and its container runs.
+func (p *pod) Start(flags []string) error {
+	if err := p.delete(0); err != nil {
+		return err
+	}
+
+	image := p.Image()
+	spec := map[string]interface{}{
+		"hostNetwork":                   true,
+		"restartPolicy":                 "Never",
+		"terminationGracePeriodSeconds": int(processStopTimeout / time.Second),
+		"containers": []interface{}{map[string]interface{}{
+			"name":         "etcd",
+			"image":        image,
+			"command":      append([]string{"etcd"}, flags...),
+			"volumeMounts": []interface{}{map[string]interface{}{"name": "root", "mountPath": p.rootDir}},
+		}},
+		"volumes": []interface{}{map[string]interface{}{
+			"name":     "root",
+			"hostPath": map[string]interface{}{"path": p.rootDir, "type": "DirectoryOrCreate"},
+		}},
+	}
+	if p.kubeNode != "" {
+		spec["nodeName"] = p.kubeNode
+	}
+	body := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":   p.name,
+			"labels": map[string]string{"app": "etcdlabs", "etcdlabs-node": p.member},
+		},
+		"spec": spec,
+	}
+	if err := p.kc.call(http.MethodPost, "/api/v1/namespaces/"+p.kc.namespace+"/pods", body); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(podStartTimeout)
+	for {
+		st, err := p.status()
+		if err != nil {
+			return err
+		}
+		if st.running() {
+			break
+		}
+		if st.Status.Phase == "Failed" || st.Status.Phase == "Succeeded" || time.Now().After(deadline) {
+			return fmt.Errorf("pod %q did not start (%s)", p.name, st.reason())
+		}
+		time.Sleep(podPollInterval)
+	}
+	glog.Infof("started pod %q (image %q): %v", p.name, image, flags)
+
+	go p.streamLogs()
+	return nil
+}
+
+// streamLogs forwards the container output until it stops.
+func (p *pod) streamLogs() {
+	resp, err := p.kc.do(http.MethodGet, p.kc.podPath(p.name)+"/log?container=etcd&follow=true", nil)
+	if err != nil {
+		glog.Warningf("failed to stream logs of %q (%v)", p.name, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		p.logf(sc.Text())
+	}
+}
+
+// Stop implements externalNode. The pod is deleted with a grace period;
+// its data stays in the root directory on the host.
+func (p *pod) Stop() error {
+	return p.delete(int(processStopTimeout / time.Second))
+}
+
+// Kill implements externalNode. The pod is deleted without a grace period,
+// so its container is killed.
+func (p *pod) Kill() error {
+	return p.delete(0)
+}
+
+// Running implements externalNode.
+func (p *pod) Running() bool {
+	st, err := p.status()
+	return err == nil && st.running()
+}
+
+// delete deletes the pod, if any, and waits until it is gone.
+func (p *pod) delete(graceSeconds int) error {
+	err := p.kc.call(http.MethodDelete, fmt.Sprintf("%s?gracePeriodSeconds=%d", p.kc.podPath(p.name), graceSeconds), nil)
+	if isAPINotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(time.Duration(graceSeconds)*time.Second + podStartTimeout)
+	for {
+		_, err = p.status()
+		if isAPINotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("pod %q was not deleted", p.name)
+		}
+		time.Sleep(podPollInterval)
+	}
+}
+
+// podStatus is the part of the pod object that is in use.
+type podStatus struct {
+	Metadata struct {
+		DeletionTimestamp *time.Time `json:"deletionTimestamp"`
+	} `json:"metadata"`
+	Status struct {
+		Phase             string `json:"phase"`
+		Message           string `json:"message"`
+		ContainerStatuses []struct {
+			State struct {
+				Running *struct{} `json:"running"`
+				Waiting *struct {
+					Reason string `json:"reason"`
+				} `json:"waiting"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+func (st podStatus) running() bool {
+	if st.Metadata.DeletionTimestamp != nil || st.Status.Phase != "Running" {
+		return false
+	}
+	for _, cs := range st.Status.ContainerStatuses {
+		if cs.State.Running == nil {
+			return false
+		}
+	}
+	return len(st.Status.ContainerStatuses) > 0
+}
+
+// reason describes why the pod is not running.
+func (st podStatus) reason() string {
+	for _, cs := range st.Status.ContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			return cs.State.Waiting.Reason
+		}
+	}
+	if st.Status.Message != "" {
+		return st.Status.Message
+	}
+	return "phase " + st.Status.Phase
+}
+
+func (p *pod) status() (podStatus, error) {
+	var st podStatus
+	resp, err := p.kc.do(http.MethodGet, p.kc.podPath(p.name), nil)
+	if err != nil {
+		return st, err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&st)
+	return st, err
+}
@@ -0,0 +1,286 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcdlabs/pkg/linearizability"
+
+	"github.com/coreos/etcd/clientv3"
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/coreos/etcd/pkg/types"
+)
+
+// ResponseHeader is the header of an etcd response.
+type ResponseHeader struct {
+	ClusterID string
+	MemberID  string
+	Revision  int64
+	RaftTerm  uint64
+}
+
+// KeyValue is a key-value pair with its revision metadata.
+type KeyValue struct {
+	Key   string
+	Value string
+
+	CreateRevision int64
+	ModRevision    int64
+	Version        int64
+	Lease          int64
+}
+
+// KVResponse is the result of a key-value operation on a node.
+type KVResponse struct {
+	Header ResponseHeader
+
+	KeyValues     []KeyValue
+	PrevKeyValues []KeyValue
+
+	// Deleted is the number of keys deleted by Delete.
+	Deleted int64
+
+	Took time.Duration
+}
+
+func toResponseHeader(h *pb.ResponseHeader) ResponseHeader {
+	if h == nil {
+		return ResponseHeader{}
+	}
+	return ResponseHeader{
+		ClusterID: types.ID(h.ClusterId).String(),
+		MemberID:  types.ID(h.MemberId).String(),
+		Revision:  h.Revision,
+		RaftTerm:  h.RaftTerm,
+	}
+}
+
+func toKeyValues(kvs []*mvccpb.KeyValue) []KeyValue {
+	rs := make([]KeyValue, len(kvs))
+	for i, kv := range kvs {
+		rs[i] = KeyValue{
+			Key:            string(kv.Key),
+			Value:          string(kv.Value),
+			CreateRevision: kv.CreateRevision,
+			ModRevision:    kv.ModRevision,
+			Version:        kv.Version,
+			Lease:          kv.Lease,
+		}
+	}
+	return rs
+}
+
+// Put writes a key-value pair through the node.
+func (clus *Cluster) Put(ctx context.Context, i int, key, val string) (resp KVResponse, err error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return resp, err
+	}
+
+	now := time.Now()
+	presp, err := cli.Put(ctx, key, val, clientv3.WithPrevKV())
+	if clus.recording() {
+		clus.recordOps(i, now, err, linearizability.Operation{Kind: linearizability.Put, Key: key, Value: val})
+	}
+	if err != nil {
+		return resp, err
+	}
+	resp.Took = time.Since(now)
+	resp.Header = toResponseHeader(presp.Header)
+	if presp.PrevKv != nil {
+		resp.PrevKeyValues = toKeyValues([]*mvccpb.KeyValue{presp.PrevKv})
+	}
+	return resp, nil
+}
+
+// Get reads a key (or all keys with the prefix) through the node.
+func (clus *Cluster) Get(ctx context.Context, i int, key string, prefix bool) (resp KVResponse, err error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return resp, err
+	}
+
+	var opts []clientv3.OpOption
+	if prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+
+	now := time.Now()
+	gresp, err := cli.Get(ctx, key, opts...)
+	if clus.recording() && err == nil {
+		var ops []linearizability.Operation
+		if !prefix && len(gresp.Kvs) == 0 {
+			ops = append(ops, linearizability.Operation{Kind: linearizability.Get, Key: key})
+		}
+		for _, kv := range gresp.Kvs {
+			ops = append(ops, linearizability.Operation{Kind: linearizability.Get, Key: string(kv.Key), Value: string(kv.Value), Found: true})
+		}
+		clus.recordOps(i, now, nil, ops...)
+	}
+	if err != nil {
+		return resp, err
+	}
+	resp.Took = time.Since(now)
+	resp.Header = toResponseHeader(gresp.Header)
+	resp.KeyValues = toKeyValues(gresp.Kvs)
+	return resp, nil
+}
+
+// Delete deletes a key (or all keys with the prefix) through the node.
+func (clus *Cluster) Delete(ctx context.Context, i int, key string, prefix bool) (resp KVResponse, err error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return resp, err
+	}
+
+	opts := []clientv3.OpOption{clientv3.WithPrevKV()}
+	if prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+
+	now := time.Now()
+	dresp, err := cli.Delete(ctx, key, opts...)
+	if clus.recording() {
+		var ops []linearizability.Operation
+		switch {
+		case !prefix:
+			ops = append(ops, linearizability.Operation{Kind: linearizability.Delete, Key: key})
+		case err == nil:
+			for _, kv := range dresp.PrevKvs {
+				ops = append(ops, linearizability.Operation{Kind: linearizability.Delete, Key: string(kv.Key)})
+			}
+		}
+		clus.recordOps(i, now, err, ops...)
+	}
+	if err != nil {
+		return resp, err
+	}
+	resp.Took = time.Since(now)
+	resp.Header = toResponseHeader(dresp.Header)
+	resp.PrevKeyValues = toKeyValues(dresp.PrevKvs)
+	resp.Deleted = dresp.Deleted
+	return resp, nil
+}
+
+// RangeRequest defines a range query.
+type RangeRequest struct {
+	Key string
+	// RangeEnd is the exclusive end of the range.
+	// If empty, only 'Key' is read unless 'Prefix' is set.
+	RangeEnd string
+	Prefix   bool
+
+	// Limit is the maximum number of keys returned (0 for no limit).
+	Limit int64
+	// Revision is the revision to read at (0 for the latest).
+	Revision int64
+
+	// SortOrder is one of "NONE", "ASCEND", "DESCEND".
+	SortOrder string
+	// SortTarget is one of "KEY", "VERSION", "CREATE", "MOD", "VALUE".
+	SortTarget string
+
+	CountOnly    bool
+	KeysOnly     bool
+	Serializable bool
+}
+
+// RangeResponse is the result of a range query.
+type RangeResponse struct {
+	Header    ResponseHeader
+	KeyValues []KeyValue
+
+	// More is true if there are more keys to return in the range.
+	More bool
+	// Count is the number of keys within the range.
+	Count int64
+
+	Took time.Duration
+}
+
+var (
+	sortOrders = map[string]clientv3.SortOrder{
+		"":        clientv3.SortNone,
+		"NONE":    clientv3.SortNone,
+		"ASCEND":  clientv3.SortAscend,
+		"DESCEND": clientv3.SortDescend,
+	}
+	sortTargets = map[string]clientv3.SortTarget{
+		"":        clientv3.SortByKey,
+		"KEY":     clientv3.SortByKey,
+		"VERSION": clientv3.SortByVersion,
+		"CREATE":  clientv3.SortByCreateRevision,
+		"MOD":     clientv3.SortByModRevision,
+		"VALUE":   clientv3.SortByValue,
+	}
+)
+
+// opOptions translates the request into clientv3 options.
+func (rr RangeRequest) opOptions() ([]clientv3.OpOption, error) {
+	order, ok := sortOrders[strings.ToUpper(rr.SortOrder)]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort order %q", rr.SortOrder)
+	}
+	target, ok := sortTargets[strings.ToUpper(rr.SortTarget)]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort target %q", rr.SortTarget)
+	}
+	if rr.Prefix && rr.RangeEnd != "" {
+		return nil, fmt.Errorf("prefix and range end cannot be set together")
+	}
+
+	var opts []clientv3.OpOption
+	switch {
+	case rr.Prefix:
+		opts = append(opts, clientv3.WithPrefix())
+	case rr.RangeEnd != "":
+		opts = append(opts, clientv3.WithRange(rr.RangeEnd))
+	}
+	if rr.Limit > 0 {
+		opts = append(opts, clientv3.WithLimit(rr.Limit))
+	}
+	if rr.Revision > 0 {
+		opts = append(opts, clientv3.WithRev(rr.Revision))
+	}
+	if order != clientv3.SortNone {
+		opts = append(opts, clientv3.WithSort(target, order))
+	}
+	if rr.CountOnly {
+		opts = append(opts, clientv3.WithCountOnly())
+	}
+	if rr.KeysOnly {
+		opts = append(opts, clientv3.WithKeysOnly())
+	}
+	if rr.Serializable {
+		opts = append(opts, clientv3.WithSerializable())
+	}
+	return opts, nil
+}
+
+// Range runs a range query through the node.
+func (clus *Cluster) Range(ctx context.Context, i int, rr RangeRequest) (resp RangeResponse, err error) {
+	opts, err := rr.opOptions()
+	if err != nil {
+		return resp, err
+	}
+
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return resp, err
+	}
+
+	now := time.Now()
+	gresp, err := cli.Get(ctx, rr.Key, opts...)
+	if err != nil {
+		return resp, err
+	}
+	resp.Took = time.Since(now)
+	resp.Header = toResponseHeader(gresp.Header)
+	resp.KeyValues = toKeyValues(gresp.Kvs)
+	resp.More = gresp.More
+	resp.Count = gresp.Count
+	return resp, nil
+}
@@ -0,0 +1,120 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+)
+
+// LeaderChange is a leadership transition observed by the status loop.
+// Empty 'To' means the cluster lost its leader.
+type LeaderChange struct {
+	Time time.Time
+
+	From   string
+	FromID string
+	To     string
+	ToID   string
+	Term   uint64
+
+	// Duration is how long the previous leader (or leaderless period) lasted.
+	Duration time.Duration
+}
+
+var defaultLeaderHistorySize = 128
+
+// leaderHistory is a fixed-size ring buffer of leader changes.
+type leaderHistory struct {
+	mu      sync.RWMutex
+	changes []LeaderChange
+	next    int
+	full    bool
+
+	lastName string
+	lastID   string
+	lastTime time.Time
+}
+
+func newLeaderHistory(size int) *leaderHistory {
+	if size <= 0 {
+		size = defaultLeaderHistorySize
+	}
+	return &leaderHistory{changes: make([]LeaderChange, size)}
+}
+
+// observe records a transition if the leader differs from the last observed one.
+// It returns the change and true if one was recorded.
+func (lh *leaderHistory) observe(name, id string, term uint64, now time.Time) (LeaderChange, bool) {
+	lh.mu.Lock()
+	defer lh.mu.Unlock()
+
+	if name == lh.lastName && id == lh.lastID && !lh.lastTime.IsZero() {
+		return LeaderChange{}, false
+	}
+
+	ch := LeaderChange{
+		Time:   now,
+		From:   lh.lastName,
+		FromID: lh.lastID,
+		To:     name,
+		ToID:   id,
+		Term:   term,
+	}
+	if !lh.lastTime.IsZero() {
+		ch.Duration = now.Sub(lh.lastTime)
+	}
+	lh.changes[lh.next] = ch
+	lh.next = (lh.next + 1) % len(lh.changes)
+	if lh.next == 0 {
+		lh.full = true
+	}
+
+	lh.lastName, lh.lastID, lh.lastTime = name, id, now
+	return ch, true
+}
+
+// list returns the recorded changes, oldest first.
+func (lh *leaderHistory) list() []LeaderChange {
+	lh.mu.RLock()
+	defer lh.mu.RUnlock()
+
+	if !lh.full {
+		return append([]LeaderChange(nil), lh.changes[:lh.next]...)
+	}
+	cs := make([]LeaderChange, 0, len(lh.changes))
+	cs = append(cs, lh.changes[lh.next:]...)
+	cs = append(cs, lh.changes[:lh.next]...)
+	return cs
+}
+
+// recordLeader records the current leader from member statuses.
+// Must be called with 'mmu' held.
+func (clus *Cluster) recordLeader() {
+	var (
+		name, id string
+		term     uint64
+	)
+	for _, m := range clus.Members {
+		st := m.statusCopy()
+		if st.State == clusterpb.LeaderMemberStatus && st.RaftTerm >= term {
+			name, id, term = st.Name, st.ID, st.RaftTerm
+		}
+	}
+	ch, ok := clus.leaderHistory.observe(name, id, term, time.Now())
+	if !ok {
+		return
+	}
+	clus.notify(func(o Observer) { o.LeaderChanged(ch) })
+	if name == "" {
+		clus.recordEvent("leader-change", "", "lost the leader")
+	} else {
+		clus.recordEvent("leader-change", name, "%q(%s) became the leader at term %d", name, id, term)
+	}
+}
+
+// LeaderHistory returns the leadership transitions, oldest first.
+// The last element is the current leader.
+func (clus *Cluster) LeaderHistory() []LeaderChange {
+	return clus.leaderHistory.list()
+}
@@ -0,0 +1,208 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/golang/glog"
+)
+
+// LeaseInfo describes a lease.
+type LeaseInfo struct {
+	ID string
+	// TTL is the remaining TTL in seconds.
+	TTL int64
+	// GrantedTTL is the TTL in seconds at grant or last renewal.
+	GrantedTTL int64
+	// Keys are the keys attached to the lease.
+	Keys []string
+	// KeepAlive is true if the lease is kept alive by the cluster.
+	KeepAlive bool
+}
+
+func leaseIDString(id clientv3.LeaseID) string {
+	return fmt.Sprintf("%016x", int64(id))
+}
+
+// ParseLeaseID parses a lease ID in hexadecimal.
+func ParseLeaseID(s string) (int64, error) {
+	var id int64
+	if _, err := fmt.Sscanf(s, "%x", &id); err != nil {
+		return 0, fmt.Errorf("invalid lease ID %q (%v)", s, err)
+	}
+	return id, nil
+}
+
+// LeaseGrant grants a lease with the TTL in seconds through the node.
+func (clus *Cluster) LeaseGrant(ctx context.Context, i int, ttl int64) (LeaseInfo, error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return LeaseInfo{}, err
+	}
+
+	resp, err := cli.Grant(ctx, ttl)
+	if err != nil {
+		return LeaseInfo{}, err
+	}
+	return LeaseInfo{ID: leaseIDString(resp.ID), TTL: resp.TTL, GrantedTTL: resp.TTL}, nil
+}
+
+// LeaseKeepAlive keeps the lease alive in the background through the node
+// until LeaseKeepAliveStop is called, the lease is revoked, or the cluster shuts down.
+func (clus *Cluster) LeaseKeepAlive(i int, id int64) error {
+	clus.leaseMu.Lock()
+	defer clus.leaseMu.Unlock()
+
+	if _, ok := clus.leaseKeepAlives[id]; ok {
+		return fmt.Errorf("lease %016x is already kept alive", id)
+	}
+
+	cli, _, err := clus.Members[i].Client(clus.rootCtx, false)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(clus.rootCtx)
+	kch, err := cli.KeepAlive(ctx, clientv3.LeaseID(id))
+	if err != nil {
+		cancel()
+		cli.Close()
+		return err
+	}
+	clus.leaseKeepAlives[id] = cancel
+
+	go func() {
+		defer func() {
+			cancel()
+			cli.Close()
+			clus.leaseMu.Lock()
+			delete(clus.leaseKeepAlives, id)
+			clus.leaseMu.Unlock()
+		}()
+		for range kch {
+		}
+		glog.Infof("stopped keep-alive on lease %016x", id)
+	}()
+	return nil
+}
+
+// LeaseKeepAliveStop stops keeping the lease alive.
+// It returns false if the lease was not kept alive.
+func (clus *Cluster) LeaseKeepAliveStop(id int64) bool {
+	clus.leaseMu.Lock()
+	cancel, ok := clus.leaseKeepAlives[id]
+	clus.leaseMu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+func (clus *Cluster) isKeptAlive(id int64) bool {
+	clus.leaseMu.Lock()
+	_, ok := clus.leaseKeepAlives[id]
+	clus.leaseMu.Unlock()
+	return ok
+}
+
+// LeaseTimeToLive returns the remaining TTL of the lease, and its attached keys if 'keys' is true.
+func (clus *Cluster) LeaseTimeToLive(ctx context.Context, i int, id int64, keys bool) (LeaseInfo, error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return LeaseInfo{}, err
+	}
+
+	var opts []clientv3.LeaseOption
+	if keys {
+		opts = append(opts, clientv3.WithAttachedKeys())
+	}
+	resp, err := cli.TimeToLive(ctx, clientv3.LeaseID(id), opts...)
+	if err != nil {
+		return LeaseInfo{}, err
+	}
+	if resp.TTL == -1 {
+		return LeaseInfo{}, fmt.Errorf("lease %016x not found or already expired", id)
+	}
+	li := LeaseInfo{
+		ID:         leaseIDString(resp.ID),
+		TTL:        resp.TTL,
+		GrantedTTL: resp.GrantedTTL,
+		KeepAlive:  clus.isKeptAlive(id),
+	}
+	for _, k := range resp.Keys {
+		li.Keys = append(li.Keys, string(k))
+	}
+	return li, nil
+}
+
+// Leases returns the active leases, sorted by ID, with their remaining
+// TTL and attached keys. Leases expiring while listed are left out.
+func (clus *Cluster) Leases(ctx context.Context, i int) ([]LeaseInfo, error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cli.Leases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(resp.Leases))
+	for _, l := range resp.Leases {
+		ids = append(ids, int64(l.ID))
+	}
+	sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+
+	lis := make([]LeaseInfo, 0, len(ids))
+	for _, id := range ids {
+		tresp, err := cli.TimeToLive(ctx, clientv3.LeaseID(id), clientv3.WithAttachedKeys())
+		if err != nil {
+			return nil, err
+		}
+		if tresp.TTL == -1 {
+			continue
+		}
+		li := LeaseInfo{
+			ID:         leaseIDString(tresp.ID),
+			TTL:        tresp.TTL,
+			GrantedTTL: tresp.GrantedTTL,
+			KeepAlive:  clus.isKeptAlive(id),
+		}
+		for _, k := range tresp.Keys {
+			li.Keys = append(li.Keys, string(k))
+		}
+		lis = append(lis, li)
+	}
+	return lis, nil
+}
+
+// LeaseRevoke revokes the lease, deleting all attached keys.
+func (clus *Cluster) LeaseRevoke(ctx context.Context, i int, id int64) error {
+	clus.LeaseKeepAliveStop(id)
+
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.Revoke(ctx, clientv3.LeaseID(id))
+	return err
+}
+
+// PutWithLease writes a key-value pair attached to the lease.
+func (clus *Cluster) PutWithLease(ctx context.Context, i int, key, val string, id int64) (resp KVResponse, err error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return resp, err
+	}
+
+	now := time.Now()
+	presp, err := cli.Put(ctx, key, val, clientv3.WithLease(clientv3.LeaseID(id)))
+	if err != nil {
+		return resp, err
+	}
+	resp.Took = time.Since(now)
+	resp.Header = toResponseHeader(presp.Header)
+	return resp, nil
+}
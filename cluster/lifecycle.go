@@ -0,0 +1,139 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+)
+
+var (
+	// ErrAlreadyStopped is returned when stopping a stopped node.
+	ErrAlreadyStopped = errors.New("node is already stopped")
+	// ErrAlreadyStarted is returned when restarting a running node.
+	ErrAlreadyStarted = errors.New("node is already started")
+)
+
+// ErrRateLimited is returned when a node is stopped or restarted
+// sooner than Config.LifecycleInterval after the last one.
+type ErrRateLimited struct {
+	// Wait is how long to wait before trying again.
+	Wait time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("rate limit exceeded (try again after %v)", e.Wait)
+}
+
+// lockOp acquires 'opLock', unless 'ctx' is done first.
+func (clus *Cluster) lockOp(ctx context.Context) error {
+	locked := make(chan struct{})
+	go func() {
+		clus.opLock.Lock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		return nil
+	case <-ctx.Done():
+		go func() {
+			<-locked
+			clus.opLock.Unlock()
+		}()
+		return ctx.Err()
+	}
+}
+
+// allowLifecycle returns an error if a stop or restart is rate limited.
+// It does not wait for the limiter.
+func (clus *Cluster) allowLifecycle() error {
+	if clus.lifecycleLimiter == nil {
+		return nil
+	}
+	now := time.Now()
+	r := clus.lifecycleLimiter.ReserveN(now, 1)
+	if d := r.DelayFrom(now); d > 0 {
+		r.CancelAt(now)
+		return &ErrRateLimited{Wait: d}
+	}
+	return nil
+}
+
+// stopped returns true if the member is stopped.
+func (m *Member) stopped() bool {
+	m.statusLock.RLock()
+	defer m.statusLock.RUnlock()
+	return m.status.State == clusterpb.StoppedMemberStatus
+}
+
+// StopCtx stops a node, unless 'ctx' is done before the
+// operation starts. Unlike Stop, it returns an error if the
+// node is already stopped or rate limited.
+func (clus *Cluster) StopCtx(ctx context.Context, i int) error {
+	return clus.stopCtx(ctx, func() (*Member, error) { return clus.member(i) })
+}
+
+// StopByNameCtx is StopCtx of the node with the name.
+func (clus *Cluster) StopByNameCtx(ctx context.Context, name string) error {
+	return clus.stopCtx(ctx, func() (*Member, error) { return clus.memberByName(name) })
+}
+
+// stopCtx stops the member that 'lookup' returns with 'opLock' held.
+func (clus *Cluster) stopCtx(ctx context.Context, lookup func() (*Member, error)) error {
+	if err := clus.lockOp(ctx); err != nil {
+		return err
+	}
+	defer clus.opLock.Unlock()
+
+	m, err := lookup()
+	if err != nil {
+		return err
+	}
+	if m.stopped() {
+		return ErrAlreadyStopped
+	}
+	if err := clus.allowLifecycle(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return clus.stop(m)
+}
+
+// RestartCtx restarts a stopped node, unless 'ctx' is done before
+// the operation starts. Unlike Restart, it returns an error if the
+// node is already started or rate limited.
+func (clus *Cluster) RestartCtx(ctx context.Context, i int) error {
+	return clus.restartCtx(ctx, func() (*Member, error) { return clus.member(i) })
+}
+
+// RestartByNameCtx is RestartCtx of the node with the name.
+func (clus *Cluster) RestartByNameCtx(ctx context.Context, name string) error {
+	return clus.restartCtx(ctx, func() (*Member, error) { return clus.memberByName(name) })
+}
+
+// restartCtx restarts the member that 'lookup' returns with 'opLock' held.
+func (clus *Cluster) restartCtx(ctx context.Context, lookup func() (*Member, error)) error {
+	if err := clus.lockOp(ctx); err != nil {
+		return err
+	}
+	defer clus.opLock.Unlock()
+
+	m, err := lookup()
+	if err != nil {
+		return err
+	}
+	if !m.stopped() {
+		return ErrAlreadyStarted
+	}
+	if err := clus.allowLifecycle(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return clus.restart(m)
+}
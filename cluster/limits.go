@@ -0,0 +1,56 @@
+package cluster
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd/embed"
+)
+
+// RequestLimitResponse is the result of a request over the size limit.
+type RequestLimitResponse struct {
+	// RequestBytes is the size of the value sent.
+	RequestBytes int
+	// MaxRequestBytes is the server limit.
+	MaxRequestBytes int
+	// Rejected is true if the server rejected the request.
+	Rejected bool
+	// Error is the error the client got.
+	Error string
+	Took  time.Duration
+}
+
+// maxRequestBytes returns the request size limit of the member.
+func (m *Member) maxRequestBytes() int {
+	if m.cfg.MaxRequestBytes == 0 {
+		return int(embed.DefaultMaxRequestBytes)
+	}
+	return int(m.cfg.MaxRequestBytes)
+}
+
+// RequestLimit puts a value one byte over the request size limit of the
+// node and reports the error, to show how etcd rejects large requests.
+func (clus *Cluster) RequestLimit(ctx context.Context, i int, key string) (resp RequestLimitResponse, err error) {
+	clus.mmu.RLock()
+	m := clus.Members[i]
+	clus.mmu.RUnlock()
+
+	cli, err := m.sharedClient()
+	if err != nil {
+		return resp, err
+	}
+
+	resp.MaxRequestBytes = m.maxRequestBytes()
+	resp.RequestBytes = resp.MaxRequestBytes + 1
+	val := strings.Repeat("x", resp.RequestBytes)
+
+	now := time.Now()
+	_, perr := cli.Put(ctx, key, val)
+	resp.Took = time.Since(now)
+	if perr != nil {
+		resp.Rejected = true
+		resp.Error = perr.Error()
+	}
+	return resp, nil
+}
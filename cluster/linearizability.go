@@ -0,0 +1,98 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coreos/etcdlabs/pkg/linearizability"
+)
+
+// History captures the key-value operations through Put, Get and Delete
+// (used by the backend key-value proxy) while it records, to check that
+// they are linearizable, e.g. while faults are injected.
+//
+// Writes that fail have an unknown outcome. Ranges are recorded as reads
+// of the returned keys, and deletes of a prefix as deletes of the deleted
+// keys; deletes of a prefix that fail are not recorded.
+type History struct {
+	clus *Cluster
+
+	mu  sync.Mutex
+	ops []linearizability.Operation
+}
+
+type histories struct {
+	mu sync.RWMutex
+	hs map[*History]struct{}
+}
+
+// RecordHistory starts recording the operations, until History.Stop.
+func (clus *Cluster) RecordHistory() *History {
+	h := &History{clus: clus}
+	clus.histories.mu.Lock()
+	if clus.histories.hs == nil {
+		clus.histories.hs = make(map[*History]struct{})
+	}
+	clus.histories.hs[h] = struct{}{}
+	clus.histories.mu.Unlock()
+	return h
+}
+
+// Stop stops recording.
+func (h *History) Stop() {
+	h.clus.histories.mu.Lock()
+	delete(h.clus.histories.hs, h)
+	h.clus.histories.mu.Unlock()
+}
+
+// Operations returns the recorded operations.
+func (h *History) Operations() []linearizability.Operation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]linearizability.Operation(nil), h.ops...)
+}
+
+// Check checks that the recorded operations are linearizable.
+func (h *History) Check() linearizability.Result {
+	return linearizability.Check(h.Operations())
+}
+
+// recording returns true if any history is recording.
+func (clus *Cluster) recording() bool {
+	clus.histories.mu.RLock()
+	defer clus.histories.mu.RUnlock()
+	return len(clus.histories.hs) > 0
+}
+
+// recordOps records the operations of node 'i' that returned with the error,
+// called at the time. Failed writes have an unknown outcome, and failed
+// reads are not recorded.
+func (clus *Cluster) recordOps(i int, call time.Time, err error, ops ...linearizability.Operation) {
+	ret := time.Now().UnixNano()
+	for j := range ops {
+		ops[j].ClientID, ops[j].Call, ops[j].Return = i, call.UnixNano(), ret
+		if err != nil {
+			ops[j].Return = linearizability.Unknown
+		}
+	}
+	if err != nil {
+		ws := ops[:0]
+		for _, op := range ops {
+			if op.Kind != linearizability.Get {
+				ws = append(ws, op)
+			}
+		}
+		ops = ws
+	}
+	if len(ops) == 0 {
+		return
+	}
+
+	clus.histories.mu.RLock()
+	defer clus.histories.mu.RUnlock()
+	for h := range clus.histories.hs {
+		h.mu.Lock()
+		h.ops = append(h.ops, ops...)
+		h.mu.Unlock()
+	}
+}
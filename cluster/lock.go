@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/golang/glog"
+)
+
+// lockPrefix is the key prefix of demo locks.
+const lockPrefix = "/etcdlabs/lock"
+
+// LockState describes the holder and the queue of a lock.
+type LockState struct {
+	Name string
+
+	// Holder is the session holding the lock, empty if none.
+	Holder    string
+	HolderKey string
+
+	// Waiters are the sessions waiting for the lock, in acquisition order.
+	Waiters []string
+}
+
+type lockHandle struct {
+	session  string
+	mu       *concurrency.Mutex
+	cancel   context.CancelFunc
+	acquired chan struct{}
+}
+
+type locks struct {
+	mu      sync.Mutex
+	handles map[string]*lockHandle // keyed by session/lock
+}
+
+// LockAcquire requests the lock for the named session (created on node 'i' if needed).
+// It returns immediately; if the lock is held by another session,
+// the session waits in queue until the holder releases it.
+func (clus *Cluster) LockAcquire(i int, session, name string) error {
+	if name == "" {
+		return fmt.Errorf("lock name is empty")
+	}
+	s, err := clus.session(i, session)
+	if err != nil {
+		return err
+	}
+
+	hk := handleKey(session, name)
+	clus.locks.mu.Lock()
+	defer clus.locks.mu.Unlock()
+	if _, ok := clus.locks.handles[hk]; ok {
+		return fmt.Errorf("session %q already acquired or is waiting for lock %q", session, name)
+	}
+
+	ctx, cancel := context.WithCancel(clus.rootCtx)
+	h := &lockHandle{
+		session:  session,
+		mu:       concurrency.NewMutex(s.sess, path.Join(lockPrefix, name)),
+		cancel:   cancel,
+		acquired: make(chan struct{}),
+	}
+	clus.locks.handles[hk] = h
+
+	go func() {
+		if err := h.mu.Lock(ctx); err != nil {
+			glog.Warningf("session %q failed to acquire lock %q (%v)", session, name, err)
+			clus.locks.mu.Lock()
+			if clus.locks.handles[hk] == h {
+				delete(clus.locks.handles, hk)
+			}
+			clus.locks.mu.Unlock()
+			return
+		}
+		close(h.acquired)
+		glog.Infof("session %q acquired lock %q", session, name)
+	}()
+	return nil
+}
+
+// LockRelease releases the lock held by the session, or
+// stops waiting if the session is still in queue.
+func (clus *Cluster) LockRelease(ctx context.Context, session, name string) error {
+	hk := handleKey(session, name)
+	clus.locks.mu.Lock()
+	h, ok := clus.locks.handles[hk]
+	delete(clus.locks.handles, hk)
+	clus.locks.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("session %q does not hold lock %q", session, name)
+	}
+
+	select {
+	case <-h.acquired:
+		err := h.mu.Unlock(ctx)
+		h.cancel()
+		return err
+	default:
+		// still waiting; canceling Lock removes the queued key
+		h.cancel()
+		return nil
+	}
+}
+
+// LockState returns the holder and waiters of the lock, read through node 'i'.
+func (clus *Cluster) LockState(ctx context.Context, i int, name string) (LockState, error) {
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return LockState{}, err
+	}
+
+	pfx := path.Join(lockPrefix, name) + "/"
+	resp, err := cli.Get(ctx, pfx, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByCreateRevision, clientv3.SortAscend))
+	if err != nil {
+		return LockState{}, err
+	}
+
+	st := LockState{Name: name}
+	for j, kv := range resp.Kvs {
+		owner := clus.sessionName(clientv3.LeaseID(kv.Lease))
+		if j == 0 {
+			st.Holder, st.HolderKey = owner, string(kv.Key)
+			continue
+		}
+		st.Waiters = append(st.Waiters, owner)
+	}
+	return st, nil
+}
@@ -0,0 +1,242 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+)
+
+// LogLine is a single log line from an embedded etcd server.
+type LogLine struct {
+	Time    time.Time
+	Package string
+	Level   string
+	Text    string
+}
+
+var defaultLogBufferSize = 1000
+
+// logBuffer is a fixed-size ring buffer of log lines.
+type logBuffer struct {
+	mu    sync.RWMutex
+	lines []LogLine
+	next  int
+	full  bool
+}
+
+func newLogBuffer(size int) *logBuffer {
+	if size <= 0 {
+		size = defaultLogBufferSize
+	}
+	return &logBuffer{lines: make([]LogLine, size)}
+}
+
+func (lb *logBuffer) add(l LogLine) {
+	lb.mu.Lock()
+	lb.lines[lb.next] = l
+	lb.next = (lb.next + 1) % len(lb.lines)
+	if lb.next == 0 {
+		lb.full = true
+	}
+	lb.mu.Unlock()
+}
+
+// last returns up to 'n' most recent lines, oldest first.
+// It returns all lines if 'n' is not positive.
+func (lb *logBuffer) last(n int) []LogLine {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	var ls []LogLine
+	if lb.full {
+		ls = append(ls, lb.lines[lb.next:]...)
+	}
+	ls = append(ls, lb.lines[:lb.next]...)
+	if n > 0 && len(ls) > n {
+		ls = ls[len(ls)-n:]
+	}
+	return ls
+}
+
+// logRouter is installed as the process-wide capnslog formatter.
+// All embedded servers share one logger, so each line is attributed
+// to the member whose name or ID appears first in the line.
+type logRouter struct {
+	mu      sync.RWMutex
+	members map[*Member]struct{}
+	next    capnslog.Formatter
+}
+
+var (
+	globalLogRouterOnce sync.Once
+	globalLogRouter     = &logRouter{
+		members: make(map[*Member]struct{}),
+		next:    capnslog.NewDefaultFormatter(os.Stderr),
+	}
+)
+
+func registerMemberLogs(m *Member) {
+	globalLogRouterOnce.Do(func() { capnslog.SetFormatter(globalLogRouter) })
+	globalLogRouter.mu.Lock()
+	globalLogRouter.members[m] = struct{}{}
+	globalLogRouter.mu.Unlock()
+}
+
+func unregisterMemberLogs(m *Member) {
+	globalLogRouter.mu.Lock()
+	delete(globalLogRouter.members, m)
+	globalLogRouter.mu.Unlock()
+}
+
+// Format implements capnslog.Formatter.
+func (lr *logRouter) Format(pkg string, level capnslog.LogLevel, depth int, entries ...interface{}) {
+	text := strings.TrimSuffix(fmt.Sprint(entries...), "\n")
+	line := LogLine{Time: time.Now(), Package: pkg, Level: level.String(), Text: text}
+
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	var (
+		owner *Member
+		pos   = len(text)
+	)
+	for m := range lr.members {
+		for _, tok := range m.logTokens() {
+			if i := strings.Index(text, tok); i >= 0 && i < pos {
+				owner, pos = m, i
+			}
+		}
+	}
+
+	// the global level is the most verbose member level,
+	// so filter lines by the level of the owner
+	max := capnslog.INFO
+	if owner != nil {
+		max = owner.LogLevel()
+	}
+	if level > max {
+		return
+	}
+	lr.next.Format(pkg, level, depth+1, entries...)
+	if owner != nil {
+		owner.logs.add(line)
+	}
+}
+
+// Flush implements capnslog.Formatter.
+func (lr *logRouter) Flush() {
+	lr.next.Flush()
+}
+
+// parseLogLine parses a line written by an etcd process, such as
+// "2017-06-20 12:00:00.000000 I | etcdserver: published ...".
+// Lines in other formats are kept as they are.
+func parseLogLine(line string) LogLine {
+	l := LogLine{Time: time.Now(), Text: line}
+
+	i := strings.Index(line, " | ")
+	if i < 0 {
+		return l
+	}
+	head, rest := strings.Fields(line[:i]), line[i+3:]
+	if len(head) == 0 {
+		return l
+	}
+	lvl, err := capnslog.ParseLevel(head[len(head)-1])
+	if err != nil {
+		return l
+	}
+	l.Level = lvl.String()
+	if j := strings.Index(rest, ": "); j > 0 && !strings.Contains(rest[:j], " ") {
+		l.Package, rest = rest[:j], rest[j+2:]
+	}
+	l.Text = rest
+	return l
+}
+
+// logTokens returns the strings that identify the member in log lines.
+func (m *Member) logTokens() []string {
+	m.logMu.RLock()
+	defer m.logMu.RUnlock()
+	return m.logIDs
+}
+
+// setLogTokens updates the member identifiers for log attribution.
+func (m *Member) setLogTokens() {
+	toks := []string{m.cfg.Name + " ", m.cfg.Name + ".", "Name:" + m.cfg.Name}
+	if id := m.ID(); id != 0 {
+		toks = append(toks, id.String())
+	}
+	m.logMu.Lock()
+	m.logIDs = toks
+	m.logMu.Unlock()
+}
+
+// LogLevel returns the log level of the member.
+func (m *Member) LogLevel() capnslog.LogLevel {
+	m.logMu.RLock()
+	defer m.logMu.RUnlock()
+	return m.logLevel
+}
+
+// addLog captures the line of an external node, if its level is enabled.
+func (m *Member) addLog(l LogLine) {
+	if l.Level != "" {
+		if lvl, err := capnslog.ParseLevel(l.Level); err == nil && lvl > m.LogLevel() {
+			return
+		}
+	}
+	m.logs.add(l)
+}
+
+// SetLogLevel sets the log level of the node at runtime (e.g. "DEBUG").
+// Embedded servers share the process-wide logger, whose level is set to
+// the most verbose node level, and lines are filtered per node. External
+// nodes keep the verbosity they started with, so only captured lines are
+// filtered.
+func (clus *Cluster) SetLogLevel(i int, level string) error {
+	lvl, err := capnslog.ParseLevel(strings.ToUpper(level))
+	if err != nil {
+		return err
+	}
+
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+
+	m := clus.Members[i]
+	m.logMu.Lock()
+	m.logLevel = lvl
+	m.logMu.Unlock()
+
+	m.statusLock.Lock()
+	m.status.LogLevel = lvl.String()
+	m.statusLock.Unlock()
+
+	if clus.ccfg.Mode == ModeEmbedded {
+		max := capnslog.INFO
+		for _, m := range clus.Members {
+			if l := m.LogLevel(); l > max {
+				max = l
+			}
+		}
+		capnslog.SetGlobalLogLevel(max)
+	}
+	clus.recordEvent("log-level", m.cfg.Name, "set log level of %q to %s", m.cfg.Name, lvl)
+	return nil
+}
+
+// Logs returns up to 'lastN' most recent log lines of the node, oldest first.
+// It returns all captured lines if 'lastN' is not positive.
+func (clus *Cluster) Logs(i, lastN int) ([]LogLine, error) {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+
+	if i < 0 || i >= len(clus.Members) {
+		return nil, &UnknownNodeError{Node: fmt.Sprint(i)}
+	}
+	return clus.Members[i].logs.last(lastN), nil
+}
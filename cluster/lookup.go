@@ -0,0 +1,161 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+
+	"github.com/coreos/etcd/pkg/types"
+)
+
+// UnknownNodeError is returned when no node has the name, member ID or index.
+type UnknownNodeError struct {
+	// Node is the name, member ID or index that was looked up.
+	Node string
+}
+
+func (e *UnknownNodeError) Error() string {
+	return fmt.Sprintf("unknown node %q", e.Node)
+}
+
+// IndexByName returns the index of the node with the name (e.g. "node2").
+func (clus *Cluster) IndexByName(name string) (int, error) {
+	if i := clus.FindIndexByName(name); i != -1 {
+		return i, nil
+	}
+	return -1, &UnknownNodeError{Node: name}
+}
+
+// IndexByID returns the index of the node with the member ID.
+// Nodes that never started have no ID.
+func (clus *Cluster) IndexByID(id types.ID) (int, error) {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+	for i, m := range clus.Members {
+		if id != 0 && m.ID() == id {
+			return i, nil
+		}
+	}
+	return -1, &UnknownNodeError{Node: id.String()}
+}
+
+// member returns the node with the index. Call it with 'opLock' held
+// to act on the node, so that Remove cannot shift the nodes meanwhile.
+func (clus *Cluster) member(i int) (*Member, error) {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+	if i < 0 || i >= len(clus.Members) {
+		return nil, &UnknownNodeError{Node: fmt.Sprint(i)}
+	}
+	return clus.Members[i], nil
+}
+
+// memberByName returns the node with the name.
+// Call it with 'opLock' held to act on the node.
+func (clus *Cluster) memberByName(name string) (*Member, error) {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+	for _, m := range clus.Members {
+		if m.cfg.Name == name {
+			return m, nil
+		}
+	}
+	return nil, &UnknownNodeError{Node: name}
+}
+
+// memberByID returns the node with the member ID.
+// Call it with 'opLock' held to act on the node.
+func (clus *Cluster) memberByID(id types.ID) (*Member, error) {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+	for _, m := range clus.Members {
+		if id != 0 && m.ID() == id {
+			return m, nil
+		}
+	}
+	return nil, &UnknownNodeError{Node: id.String()}
+}
+
+// StopByName stops the node with the name.
+func (clus *Cluster) StopByName(name string) error {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+	m, err := clus.memberByName(name)
+	if err != nil {
+		return err
+	}
+	return clus.stop(m)
+}
+
+// StopByID stops the node with the member ID.
+func (clus *Cluster) StopByID(id types.ID) error {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+	m, err := clus.memberByID(id)
+	if err != nil {
+		return err
+	}
+	return clus.stop(m)
+}
+
+// RestartByName restarts the node with the name.
+func (clus *Cluster) RestartByName(name string) error {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+	m, err := clus.memberByName(name)
+	if err != nil {
+		return err
+	}
+	return clus.restart(m)
+}
+
+// RestartByID restarts the node with the member ID.
+func (clus *Cluster) RestartByID(id types.ID) error {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+	m, err := clus.memberByID(id)
+	if err != nil {
+		return err
+	}
+	return clus.restart(m)
+}
+
+// KillByName kills the node with the name (see Member.Kill).
+func (clus *Cluster) KillByName(name string) error {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+	m, err := clus.memberByName(name)
+	if err != nil {
+		return err
+	}
+	return clus.kill(m)
+}
+
+// KillByID kills the node with the member ID (see Member.Kill).
+func (clus *Cluster) KillByID(id types.ID) error {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+	m, err := clus.memberByID(id)
+	if err != nil {
+		return err
+	}
+	return clus.kill(m)
+}
+
+// MemberStatusByName returns the status of the node with the name.
+func (clus *Cluster) MemberStatusByName(name string) (clusterpb.MemberStatus, error) {
+	m, err := clus.memberByName(name)
+	if err != nil {
+		return clusterpb.MemberStatus{}, err
+	}
+	return m.statusCopy(), nil
+}
+
+// MemberStatusByID returns the status of the node with the member ID.
+func (clus *Cluster) MemberStatusByID(id types.ID) (clusterpb.MemberStatus, error) {
+	m, err := clus.memberByID(id)
+	if err != nil {
+		return clusterpb.MemberStatus{}, err
+	}
+	return m.statusCopy(), nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
+	"net/url"
 	"sync"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/coreos/etcd/etcdserver/api/v3client"
 	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
 	"github.com/coreos/etcd/pkg/types"
+	"github.com/coreos/pkg/capnslog"
 	humanize "github.com/dustin/go-humanize"
 	"github.com/golang/glog"
 	"google.golang.org/grpc"
@@ -26,39 +29,76 @@ type Member struct {
 	cfg  *embed.Config
 	srv  *embed.Etcd
 
+	// statusLock protects 'status' and 'stoppedStartedAt', which are
+	// written by lifecycle operations and the status updates while
+	// being read concurrently. Readers take copies (see statusCopy).
+	statusLock       sync.RWMutex
+	status           clusterpb.MemberStatus
 	stoppedStartedAt time.Time
 
-	statusLock sync.RWMutex
-	status     clusterpb.MemberStatus
+	statusErrMu sync.Mutex
+	statusErrs  StatusErrors
+
+	logs     *logBuffer
+	logMu    sync.RWMutex
+	logIDs   []string
+	logLevel capnslog.LogLevel
+
+	sharedMu sync.Mutex
+	shared   *clientv3.Client
+
+	metricsURL url.URL
+	metricsLn  net.Listener
+
+	peerProxy *peerProxy // set if the peer proxy is enabled
+
+	// ext is set if the server runs outside of this process.
+	ext   externalNode
+	extID types.ID
+
+	// version is the configured etcd version, empty for the default.
+	version string
 }
 
 // Start starts the member.
 func (m *Member) Start() error {
-	srv, err := embed.StartEtcd(m.cfg)
-	if err != nil {
-		return err
-	}
-	m.srv = srv
-
-	// copy and overwrite with internal configuration
-	// in case it was configured with auto TLS
-	nc := m.srv.Config()
-	m.cfg = &nc
-
-	var rerr error
-	select {
-	case <-m.srv.Server.ReadyNotify():
-	case rerr = <-m.srv.Err():
-	case <-m.srv.Server.StopNotify():
-		rerr = fmt.Errorf("received from etcdserver.Server.StopNotify")
-	}
-	if rerr != nil {
-		return rerr
+	if m.ext != nil {
+		if err := m.ext.Start(m.flags()); err != nil {
+			return err
+		}
+		if err := m.waitReady(nodeReadyTimeout); err != nil {
+			return err
+		}
+	} else {
+		srv, err := embed.StartEtcd(m.cfg)
+		if err != nil {
+			return err
+		}
+		m.srv = srv
+
+		// copy and overwrite with internal configuration
+		// in case it was configured with auto TLS
+		nc := m.srv.Config()
+		m.cfg = &nc
+		m.setLogTokens()
+
+		var rerr error
+		select {
+		case <-m.srv.Server.ReadyNotify():
+		case rerr = <-m.srv.Err():
+		case <-m.srv.Server.StopNotify():
+			rerr = fmt.Errorf("received from etcdserver.Server.StopNotify")
+		}
+		if rerr != nil {
+			return rerr
+		}
+		if err = m.startMetrics(); err != nil {
+			return err
+		}
 	}
 
-	m.stoppedStartedAt = time.Now()
-
 	m.statusLock.Lock()
+	m.stoppedStartedAt = time.Now()
 	m.status.State = clusterpb.FollowerMemberStatus
 	m.status.StateTxt = fmt.Sprintf("%s just started (%s)", m.status.Name, humanize.Time(m.stoppedStartedAt))
 	m.status.IsLeader = false
@@ -70,7 +110,7 @@ func (m *Member) Start() error {
 
 // Restart restarts the member.
 func (m *Member) Restart() error {
-	glog.Infof("restarting %q(%s)", m.cfg.Name, m.srv.Server.ID().String())
+	glog.Infof("restarting %q(%s)", m.cfg.Name, m.ID())
 
 	m.statusLock.RLock()
 	if m.status.State != clusterpb.StoppedMemberStatus {
@@ -83,56 +123,79 @@ func (m *Member) Restart() error {
 	m.cfg.ClusterState = embed.ClusterStateFlagExisting
 
 	// start server
-	srv, err := embed.StartEtcd(m.cfg)
-	if err != nil {
-		return err
-	}
-	m.srv = srv
+	if m.ext != nil {
+		// readiness is not awaited, since it blocks when quorum is lost
+		if err := m.ext.Start(m.flags()); err != nil {
+			return err
+		}
+	} else {
+		srv, err := embed.StartEtcd(m.cfg)
+		if err != nil {
+			return err
+		}
+		m.srv = srv
 
-	nc := m.srv.Config()
-	m.cfg = &nc
+		nc := m.srv.Config()
+		m.cfg = &nc
+		m.setLogTokens()
 
-	// this blocks when quorum is lost
-	// <-m.srv.Server.ReadyNotify()
+		// this blocks when quorum is lost
+		// <-m.srv.Server.ReadyNotify()
 
-	m.stoppedStartedAt = time.Now()
+		if err = m.startMetrics(); err != nil {
+			return err
+		}
+	}
 
 	m.statusLock.Lock()
+	m.stoppedStartedAt = time.Now()
 	m.status.IsLeader = false
 	m.status.State = clusterpb.FollowerMemberStatus
 	m.status.StateTxt = fmt.Sprintf("%s just restarted (%s)", m.status.Name, humanize.Time(m.stoppedStartedAt))
 	m.statusLock.Unlock()
 
-	glog.Infof("restarted %q(%s)", m.cfg.Name, m.srv.Server.ID().String())
+	glog.Infof("restarted %q(%s)", m.cfg.Name, m.ID())
 	return nil
 }
 
-// Stop stops the member.
-func (m *Member) Stop() {
-	glog.Infof("stopping %q(%s)", m.cfg.Name, m.srv.Server.ID().String())
+// Stop stops the member. It returns the error of stopping an external
+// node (a process, container or pod); shutdown errors of an embedded
+// server are logged.
+func (m *Member) Stop() error {
+	glog.Infof("stopping %q(%s)", m.cfg.Name, m.ID())
 
 	m.statusLock.RLock()
 	if m.status.State == clusterpb.StoppedMemberStatus {
 		glog.Warningf("%s is already stopped", m.cfg.Name)
 		m.statusLock.RUnlock()
-		return
+		return nil
 	}
 	m.statusLock.RUnlock()
 
-	m.stoppedStartedAt = time.Now()
-
 	m.statusLock.Lock()
+	m.stoppedStartedAt = time.Now()
 	m.status.IsLeader = false
 	m.status.State = clusterpb.StoppedMemberStatus
 	m.status.StateTxt = fmt.Sprintf("%s just stopped (%s)", m.status.Name, humanize.Time(m.stoppedStartedAt))
-	m.status.DBSize = 0
-	m.status.DBSizeTxt = ""
-	m.status.Hash = 0
+	m.clearStatus()
 	m.statusLock.Unlock()
 
+	m.closeSharedClient()
+
 	// TODO: stop with/without leadership transfer?
 	// m.srv.Server.HardStop()
 
+	if m.ext != nil {
+		if err := m.ext.Stop(); err != nil {
+			glog.Warningf("shutdown with %q", err.Error())
+			return err
+		}
+		glog.Infof("stopped %q(%s)", m.cfg.Name, m.ID())
+		return nil
+	}
+
+	m.stopMetrics()
+
 	// stops embedded server to trigger
 	// gRPC server graceful shutdown
 	m.srv.Close()
@@ -148,11 +211,44 @@ func (m *Member) Stop() {
 	} else {
 		glog.Infof("shutdown with no error")
 	}
-	glog.Infof("stopped %q(%s)", m.cfg.Name, m.srv.Server.ID().String())
+	glog.Infof("stopped %q(%s)", m.cfg.Name, m.ID())
+	return nil
+}
+
+// statusCopy returns a copy of the member status. Slices in the status
+// are replaced on update and never modified in place, so the copy does
+// not share mutable state with the member.
+func (m *Member) statusCopy() clusterpb.MemberStatus {
+	m.statusLock.RLock()
+	defer m.statusLock.RUnlock()
+	return m.status
+}
+
+// StoppedStartedAt returns the time of the last stop or (re)start.
+func (m *Member) StoppedStartedAt() time.Time {
+	m.statusLock.RLock()
+	defer m.statusLock.RUnlock()
+	return m.stoppedStartedAt
+}
+
+// clearStatus resets the fields fetched from the server.
+// Must be called with statusLock held.
+func (m *Member) clearStatus() {
+	m.status.DBSize = 0
+	m.status.DBSizeTxt = ""
+	m.status.Hash = 0
+	m.status.RaftTerm = 0
+	m.status.RaftIndex = 0
+	m.status.RaftAppliedIndex = 0
+	m.status.Version = ""
+	m.status.Alarms = nil
+	m.status.Healthy = false
+	m.status.HealthLatencyMs = 0
+	m.status.HealthError = ""
 }
 
 // WaitForLeader waits for the member to find a leader.
-func (m *Member) WaitForLeader() error {
+func (m *Member) WaitForLeader() (err error) {
 	m.statusLock.Lock()
 	stopped := m.status.State == clusterpb.StoppedMemberStatus
 	m.statusLock.Unlock()
@@ -162,7 +258,11 @@ func (m *Member) WaitForLeader() error {
 
 	possibleLead := m.clus.allMemberIDs()
 
-	cli, _, err := m.Client(false)
+	tctx, sp := m.clus.startSpan(m.clus.rootCtx, "cluster.Member.WaitForLeader")
+	sp.setAttribute("member", m.cfg.Name)
+	defer func() { sp.end(err) }()
+
+	cli, _, err := m.Client(tctx, false)
 	if err != nil {
 		return err
 	}
@@ -170,54 +270,52 @@ func (m *Member) WaitForLeader() error {
 
 	for {
 		// ensure leader is up via linearizable get
-		ctx, cancel := context.WithTimeout(m.clus.rootCtx, 3*time.Second)
+		ctx, cancel := context.WithTimeout(tctx, 3*time.Second)
 		_, err = cli.Get(ctx, "0")
 		cancel()
 		if err == nil {
 			break
 		}
+		if tctx.Err() != nil {
+			return tctx.Err()
+		}
 		glog.Warning(err)
 	}
 
 	for {
 		var lead uint64
 		for lead == 0 || !possibleLead[lead] {
-			lead = 0
-			select {
-			case <-m.srv.Server.StopNotify():
-				continue
-			default:
-			}
-			lead = m.srv.Server.Lead()
+			lead = m.lead()
 			time.Sleep(time.Second)
 		}
 
-		ctx, cancel := context.WithTimeout(m.clus.rootCtx, 3*time.Second)
-		resp, err := cli.Status(ctx, m.cfg.LCUrls[0].Host)
+		sctx, ssp := m.clus.startSpan(tctx, "maintenance.Status")
+		ctx, cancel := context.WithTimeout(sctx, 3*time.Second)
+		resp, err := cli.Status(ctx, m.clientEndpoint())
 		cancel()
+		ssp.end(err)
 		if err != nil {
 			glog.Warning(err)
 			time.Sleep(time.Second)
 			continue
 		}
 
+		isLeader, state := resp.Leader == resp.Header.MemberId, clusterpb.FollowerMemberStatus
+		if isLeader {
+			state = clusterpb.LeaderMemberStatus
+		}
+		m.statusLock.Lock()
 		m.status.ID = types.ID(resp.Header.MemberId).String()
+		m.status.IsLeader, m.status.State = isLeader, state
+		m.statusLock.Unlock()
 
 		if resp.Leader == uint64(0) {
 			glog.Infof("%s %s has no leader yet", m.cfg.Name, types.ID(resp.Header.MemberId))
-			m.status.IsLeader = false
-			m.status.State = clusterpb.FollowerMemberStatus
 			time.Sleep(time.Second)
 			continue
 		}
 
 		glog.Infof("%s %s has leader %s", m.cfg.Name, types.ID(resp.Header.MemberId), types.ID(resp.Leader))
-		m.status.IsLeader = resp.Leader == resp.Header.MemberId
-		if m.status.IsLeader {
-			m.status.State = clusterpb.LeaderMemberStatus
-		} else {
-			m.status.State = clusterpb.FollowerMemberStatus
-		}
 
 		if lead == resp.Leader {
 			break
@@ -230,7 +328,13 @@ func (m *Member) WaitForLeader() error {
 // If 'eps' is not empty, it overwrites clientv3.Config.Endpoints.
 // If 'embedded' is true, it ignores 'scheme' and 'eps' arguments,
 // since it directly connects to a single embedded server.
-func (m *Member) Client(scheme bool, eps ...string) (cli *clientv3.Client, tlsCfg *tls.Config, err error) {
+// The span of the client creation is a child of the span in 'ctx', if any.
+func (m *Member) Client(ctx context.Context, scheme bool, eps ...string) (cli *clientv3.Client, tlsCfg *tls.Config, err error) {
+	_, sp := m.clus.startSpan(ctx, "cluster.Member.Client")
+	sp.setAttribute("member", m.cfg.Name)
+	sp.setAttribute("embedded", fmt.Sprint(m.clus.embeddedClient))
+	defer func() { sp.end(err) }()
+
 	if m.clus.embeddedClient {
 		cli = v3client.New(m.srv.Server)
 		if !m.clus.ccfg.ClientTLSInfo.Empty() || m.clus.ccfg.ClientAutoTLS {
@@ -243,7 +347,7 @@ func (m *Member) Client(scheme bool, eps ...string) (cli *clientv3.Client, tlsCf
 
 	ep := m.cfg.LCUrls[0].String()
 	if !scheme {
-		ep = m.cfg.LCUrls[0].Host
+		ep = m.clientEndpoint()
 	}
 	ccfg := clientv3.Config{
 		Endpoints:   []string{ep},
@@ -252,20 +356,101 @@ func (m *Member) Client(scheme bool, eps ...string) (cli *clientv3.Client, tlsCf
 	if len(eps) != 0 {
 		ccfg.Endpoints = eps
 	}
-	if !m.cfg.ClientTLSInfo.Empty() {
-		tlsCfg, err = m.cfg.ClientTLSInfo.ClientConfig()
+	tlsInfo := m.cfg.ClientTLSInfo
+	if !m.clus.rootClientTLSInfo.Empty() {
+		// server certificate CN is not a user in client certificate auth mode
+		tlsInfo = m.clus.rootClientTLSInfo
+	}
+	if !tlsInfo.Empty() && isTLSScheme(m.cfg.LCUrls[0].Scheme) {
+		tlsCfg, err = tlsInfo.ClientConfig()
 		if err != nil {
 			return cli, tlsCfg, err
 		}
 		ccfg.TLS = tlsCfg
 	}
+	// internal clients act as root once auth is enabled
+	ccfg.Username, ccfg.Password = m.clus.rootCredentials()
 	cli, err = clientv3.New(ccfg)
 	return cli, tlsCfg, err
 }
 
+// UserClient returns a client authenticated as the user.
+// It fails if the credentials are rejected. In client certificate
+// auth mode, the password is checked first, and the client then
+// presents a certificate whose CN is the user name. The root user
+// is refused, since its credentials belong to internal clients.
+func (m *Member) UserClient(user, password string) (*clientv3.Client, error) {
+	if m.clus.embeddedClient {
+		return nil, ErrAuthEmbeddedClient
+	}
+	if user == rootUser {
+		return nil, fmt.Errorf("user %q is reserved for internal clients", rootUser)
+	}
+	ccfg := clientv3.Config{
+		Endpoints:   []string{m.clientEndpoint()},
+		DialTimeout: m.clus.clientDialTimeout,
+		Username:    user,
+		Password:    password,
+	}
+	tlsInfo := m.cfg.ClientTLSInfo
+	if m.clus.ccfg.ClientCertAuth {
+		if err := m.checkPassword(user, password); err != nil {
+			return nil, err
+		}
+		var err error
+		if tlsInfo, err = m.clus.clientCert(user); err != nil {
+			return nil, err
+		}
+		ccfg.Username, ccfg.Password = "", ""
+	}
+	if !tlsInfo.Empty() && isTLSScheme(m.cfg.LCUrls[0].Scheme) {
+		tlsCfg, err := tlsInfo.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		ccfg.TLS = tlsCfg
+	}
+	return clientv3.New(ccfg)
+}
+
+// checkPassword authenticates the user with the password in client
+// certificate auth mode, before a certificate is issued for the user.
+// The connection presents the root certificate, which the token of
+// the user overrides. Any password passes while auth is disabled.
+func (m *Member) checkPassword(user, password string) error {
+	if password == "" {
+		return fmt.Errorf("password of %q is empty", user)
+	}
+	ccfg := clientv3.Config{
+		Endpoints:   []string{m.clientEndpoint()},
+		DialTimeout: m.clus.clientDialTimeout,
+		Username:    user,
+		Password:    password,
+	}
+	if !m.clus.rootClientTLSInfo.Empty() && isTLSScheme(m.cfg.LCUrls[0].Scheme) {
+		tlsCfg, err := m.clus.rootClientTLSInfo.ClientConfig()
+		if err != nil {
+			return err
+		}
+		ccfg.TLS = tlsCfg
+	}
+	cli, err := clientv3.New(ccfg)
+	if err != nil {
+		return err
+	}
+	return cli.Close()
+}
+
 // FetchMemberStatus fetches member status (make sure to close the client outside of this function).
-func (m *Member) FetchMemberStatus() error {
-	cli, tlsCfg, err := m.Client(false)
+func (m *Member) FetchMemberStatus() (err error) {
+	tctx, sp := m.clus.startSpan(m.clus.rootCtx, "cluster.Member.FetchMemberStatus")
+	sp.setAttribute("member", m.cfg.Name)
+	defer func() { sp.end(err) }()
+
+	// expired certificates make the member unreachable, so read them first
+	m.updateCertExpiry()
+
+	cli, tlsCfg, err := m.Client(tctx, false)
 	if err != nil {
 		return err
 	}
@@ -273,17 +458,17 @@ func (m *Member) FetchMemberStatus() error {
 
 	now := time.Now()
 
-	ctx, cancel := context.WithTimeout(m.clus.rootCtx, time.Second)
+	sctx, ssp := m.clus.startSpan(tctx, "maintenance.Status")
+	ctx, cancel := context.WithTimeout(sctx, time.Second)
 	resp, err := cli.Status(ctx, m.cfg.LCUrls[0].String())
 	cancel()
+	ssp.end(err)
 	if err != nil {
 		m.statusLock.Lock()
 		m.status.State = clusterpb.StoppedMemberStatus
 		m.status.StateTxt = fmt.Sprintf("%s is not reachable (%s - %v)", m.status.Name, humanize.Time(now), err)
 		m.status.IsLeader = false
-		m.status.DBSize = 0
-		m.status.DBSizeTxt = ""
-		m.status.Hash = 0
+		m.clearStatus()
 		m.statusLock.Unlock()
 		return err
 	}
@@ -298,9 +483,53 @@ func (m *Member) FetchMemberStatus() error {
 		Endpoint:  m.cfg.LCUrls[0].String(),
 		IsLeader:  isLeader,
 		State:     state,
-		StateTxt:  fmt.Sprintf("%s has been healthy (since %s)", m.status.Name, humanize.Time(m.stoppedStartedAt)),
+		StateTxt:  fmt.Sprintf("%s has been healthy (since %s)", m.cfg.Name, humanize.Time(m.StoppedStartedAt())),
 		DBSize:    uint64(resp.DbSize),
 		DBSizeTxt: humanize.Bytes(uint64(resp.DbSize)),
+
+		RaftTerm:         resp.RaftTerm,
+		RaftIndex:        resp.RaftIndex,
+		RaftAppliedIndex: m.appliedIndex(resp.RaftIndex),
+		Version:          resp.Version,
+
+		HeartbeatIntervalMs: uint64(m.cfg.TickMs),
+		ElectionTimeoutMs:   uint64(m.cfg.ElectionMs),
+		LogLevel:            m.LogLevel().String(),
+	}
+
+	cctx, csp := m.clus.startSpan(tctx, "watch.CompactRevision")
+	status.CompactRevision, err = compactRevision(cctx, cli, "compact-revision")
+	csp.end(err)
+	if err != nil {
+		// compact revision is informational; do not mark the member unreachable
+		glog.Warningf("failed to get compact revision on %q (%v)", m.cfg.Name, err)
+	}
+
+	hpctx, hpsp := m.clus.startSpan(tctx, "kv.HealthProbe")
+	health := probeHealth(hpctx, cli)
+	hpsp.setAttribute("healthy", fmt.Sprint(health.Healthy))
+	hpsp.end(nil)
+	status.Healthy = health.Healthy
+	status.HealthLatencyMs = float64(health.Took) / float64(time.Millisecond)
+	status.HealthError = health.Error
+	if !health.Healthy {
+		status.StateTxt = fmt.Sprintf("%s is reachable but unhealthy (%s)", m.cfg.Name, health.Error)
+	}
+
+	actx, asp := m.clus.startSpan(tctx, "maintenance.AlarmList")
+	ctx, cancel = context.WithTimeout(actx, time.Second)
+	aresp, err := cli.AlarmList(ctx)
+	cancel()
+	asp.end(err)
+	if err != nil {
+		// alarms are informational; do not mark the member unreachable
+		glog.Warningf("failed to list alarms on %q (%v)", m.cfg.Name, err)
+	} else {
+		for _, a := range aresp.Alarms {
+			if a.MemberID == resp.Header.MemberId {
+				status.Alarms = append(status.Alarms, a.Alarm.String())
+			}
+		}
 	}
 
 	now = time.Now()
@@ -310,15 +539,18 @@ func (m *Member) FetchMemberStatus() error {
 	} else {
 		dopts = append(dopts, grpc.WithInsecure())
 	}
+	if isUnixScheme(m.cfg.LCUrls[0].Scheme) {
+		dopts = append(dopts, grpc.WithDialer(func(addr string, t time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, t)
+		}))
+	}
 	conn, err := grpc.Dial(m.cfg.LCUrls[0].Host, dopts...)
 	if err != nil {
 		m.statusLock.Lock()
 		m.status.State = clusterpb.StoppedMemberStatus
 		m.status.StateTxt = fmt.Sprintf("%s is not reachable (%s - %v)", m.status.Name, humanize.Time(now), err)
 		m.status.IsLeader = false
-		m.status.DBSize = 0
-		m.status.DBSizeTxt = ""
-		m.status.Hash = 0
+		m.clearStatus()
 		m.statusLock.Unlock()
 		return err
 	}
@@ -327,24 +559,26 @@ func (m *Member) FetchMemberStatus() error {
 	now = time.Now()
 	mc := pb.NewMaintenanceClient(conn)
 
-	ctx, cancel = context.WithTimeout(m.clus.rootCtx, time.Second)
+	hctx, hsp := m.clus.startSpan(tctx, "maintenance.Hash")
+	ctx, cancel = context.WithTimeout(hctx, time.Second)
 	var hresp *pb.HashResponse
 	hresp, err = mc.Hash(ctx, &pb.HashRequest{}, grpc.FailFast(false))
 	cancel()
+	hsp.end(err)
 	if err != nil {
 		m.statusLock.Lock()
 		m.status.State = clusterpb.StoppedMemberStatus
 		m.status.StateTxt = fmt.Sprintf("%s was not reachable while getting hash (%s - %v)", m.status.Name, humanize.Time(now), err)
 		m.status.IsLeader = false
-		m.status.DBSize = 0
-		m.status.DBSizeTxt = ""
-		m.status.Hash = 0
+		m.clearStatus()
 		m.statusLock.Unlock()
 		return err
 	}
 	status.Hash = hresp.Hash
 
 	m.statusLock.Lock()
+	// certificate expiry is read outside of the status request
+	status.ClientCertExpiry, status.PeerCertExpiry = m.status.ClientCertExpiry, m.status.PeerCertExpiry
 	m.status = status
 	m.statusLock.Unlock()
 	return nil
@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/coreos/etcd/etcdserver/api/etcdhttp"
+	"github.com/coreos/etcd/pkg/transport"
+	"github.com/golang/glog"
+)
+
+// metricsScheme returns the scheme of the metrics endpoints.
+func (c Config) metricsScheme() string {
+	if c.MetricsTLSInfo.Empty() {
+		return "http"
+	}
+	return "https"
+}
+
+// validateMetricsPorts checks that the metrics ports of the initial nodes,
+// 'MetricsRootPort' to 'MetricsRootPort+Size-1', do not overlap the client
+// and peer ports of the default allocator ('RootPort' to 'RootPort+2*Size-1')
+// or the peer proxy ports. Custom PortAllocators are not checked.
+func (c Config) validateMetricsPorts() error {
+	if c.MetricsRootPort <= 0 {
+		return nil
+	}
+	overlaps := func(min1, n1, min2, n2 int) bool {
+		return min1 < min2+n2 && min2 < min1+n1
+	}
+	if c.PortAllocator == nil && overlaps(c.MetricsRootPort, c.Size, c.RootPort, 2*c.Size) {
+		return fmt.Errorf("metrics ports [%d, %d] overlap client and peer ports [%d, %d]",
+			c.MetricsRootPort, c.MetricsRootPort+c.Size-1, c.RootPort, c.RootPort+2*c.Size-1)
+	}
+	if c.PeerProxyRootPort > 0 && overlaps(c.MetricsRootPort, c.Size, c.PeerProxyRootPort, c.Size) {
+		return fmt.Errorf("metrics ports [%d, %d] overlap peer proxy ports [%d, %d]",
+			c.MetricsRootPort, c.MetricsRootPort+c.Size-1, c.PeerProxyRootPort, c.PeerProxyRootPort+c.Size-1)
+	}
+	return nil
+}
+
+// nextMetricsURL allocates the metrics URL of a new member,
+// or returns an empty URL if metrics endpoints are disabled.
+func (clus *Cluster) nextMetricsURL() url.URL {
+	if clus.ccfg.MetricsRootPort <= 0 {
+		return url.URL{}
+	}
+	u := url.URL{Scheme: clus.ccfg.metricsScheme(), Host: net.JoinHostPort(clus.ccfg.clientHost(), fmt.Sprint(clus.metricsPort))}
+	clus.metricsPort++
+	return u
+}
+
+// startMetrics serves '/metrics' and '/health' of the member on its
+// own listener, with the metrics TLS configuration independent of
+// the client TLS.
+func (m *Member) startMetrics() error {
+	if m.metricsURL.Host == "" {
+		return nil
+	}
+
+	var tlsInfo *transport.TLSInfo
+	if !m.clus.ccfg.MetricsTLSInfo.Empty() {
+		tlsInfo = &m.clus.ccfg.MetricsTLSInfo
+	}
+	ln, err := transport.NewListener(m.metricsURL.Host, m.metricsURL.Scheme, tlsInfo)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	etcdhttp.HandleMetricsHealth(mux, m.srv.Server)
+	m.metricsLn = ln
+	go func(ln net.Listener) {
+		if err := http.Serve(ln, mux); err != nil {
+			glog.Infof("%q stopped serving metrics (%v)", m.cfg.Name, err)
+		}
+	}(ln)
+
+	glog.Infof("%q is serving metrics on %q", m.cfg.Name, m.metricsURL.String())
+	return nil
+}
+
+// stopMetrics closes the metrics listener of the member.
+func (m *Member) stopMetrics() {
+	if m.metricsLn != nil {
+		m.metricsLn.Close()
+		m.metricsLn = nil
+	}
+}
+
+// MetricsEndpoints returns the metrics URL of each node,
+// or nil if metrics endpoints are disabled.
+func (clus *Cluster) MetricsEndpoints() []string {
+	if clus.ccfg.MetricsRootPort <= 0 {
+		return nil
+	}
+
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+
+	eps := make([]string, len(clus.Members))
+	for i, m := range clus.Members {
+		eps[i] = m.metricsURL.String() + "/metrics"
+	}
+	return eps
+}
@@ -0,0 +1,226 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/golang/glog"
+)
+
+// MirrorStatus is the replication state of a Mirror.
+type MirrorStatus struct {
+	Source     string
+	Prefix     string
+	DestPrefix string
+
+	// Synced is the number of keys copied by the initial sync,
+	// and Puts and Deletes the changes replicated since then.
+	Synced  int
+	Puts    int
+	Deletes int
+
+	// Revision is the last replicated revision of the source cluster,
+	// and SourceRevision its current revision.
+	Revision       int64
+	SourceRevision int64
+	// PendingKeys is the number of keys under the prefix changed after
+	// Revision, which are yet to be replicated.
+	PendingKeys int64
+	// LastReplicated is the time of the last replicated change.
+	LastReplicated time.Time
+
+	// Error is the error that stopped the mirror, if any.
+	Error string
+}
+
+// Mirror replicates the keys under a prefix of a source cluster to a
+// destination cluster, with an initial copy and then a watch, as
+// 'etcdctl make-mirror'. Leases are not replicated.
+type Mirror struct {
+	src            *Cluster
+	srcCli, dstCli *clientv3.Client
+
+	cancel   func()
+	donec    chan struct{}
+	stopOnce sync.Once
+
+	mu     sync.Mutex
+	status MirrorStatus
+}
+
+// StartMirror starts replicating the keys under the prefix of the cluster
+// to the destination cluster, under the destination prefix (the same
+// prefix if empty). Stop the mirror with Mirror.Stop.
+func (clus *Cluster) StartMirror(dst *Cluster, prefix, destPrefix string) (*Mirror, error) {
+	if destPrefix == "" {
+		destPrefix = prefix
+	}
+	srcCli, _, err := clus.Client(clus.rootCtx, clus.AllEndpoints(false)...)
+	if err != nil {
+		return nil, err
+	}
+	dstCli, _, err := dst.Client(dst.rootCtx, dst.AllEndpoints(false)...)
+	if err != nil {
+		srcCli.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(clus.rootCtx)
+	mr := &Mirror{
+		src:    clus,
+		srcCli: srcCli,
+		dstCli: dstCli,
+		cancel: cancel,
+		donec:  make(chan struct{}),
+		status: MirrorStatus{Source: clus.InitialCluster(), Prefix: prefix, DestPrefix: destPrefix},
+	}
+	go mr.run(ctx)
+	clus.recordEvent("mirror-start", "", "mirroring %q to %q", prefix, destPrefix)
+	return mr, nil
+}
+
+// Stop stops replicating, and closes the clients.
+func (mr *Mirror) Stop() {
+	mr.stopOnce.Do(func() {
+		mr.cancel()
+		<-mr.donec
+		mr.srcCli.Close()
+		mr.dstCli.Close()
+		mr.src.recordEvent("mirror-stop", "", "stopped mirroring %q", mr.status.Prefix)
+	})
+}
+
+// Done returns a channel closed when the mirror stops, after Stop or
+// a replication error (see MirrorStatus.Error).
+func (mr *Mirror) Done() <-chan struct{} {
+	return mr.donec
+}
+
+// Status returns the replication state, with the current revision and
+// the pending keys of the source cluster.
+func (mr *Mirror) Status(ctx context.Context) (MirrorStatus, error) {
+	mr.mu.Lock()
+	st := mr.status
+	mr.mu.Unlock()
+
+	opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithCountOnly()}
+	if st.Revision > 0 {
+		opts = append(opts, clientv3.WithMinModRev(st.Revision+1))
+	}
+	resp, err := mr.srcCli.Get(ctx, st.Prefix, opts...)
+	if err != nil {
+		return st, err
+	}
+	st.SourceRevision, st.PendingKeys = resp.Header.Revision, resp.Count
+	return st, nil
+}
+
+func (mr *Mirror) run(ctx context.Context) {
+	defer close(mr.donec)
+	err := mr.replicate(ctx)
+	if err != nil && ctx.Err() == nil {
+		glog.Warningf("mirror of %q failed (%v)", mr.status.Prefix, err)
+		mr.mu.Lock()
+		mr.status.Error = err.Error()
+		mr.mu.Unlock()
+	}
+}
+
+// replicate copies the keys, and then applies the changes until 'ctx' is done.
+func (mr *Mirror) replicate(ctx context.Context) error {
+	prefix, destPrefix := mr.status.Prefix, mr.status.DestPrefix
+	destKey := func(k []byte) string { return destPrefix + strings.TrimPrefix(string(k), prefix) }
+
+	rev, err := rangePages(ctx, mr.srcCli, prefix, func(kvs []*mvccpb.KeyValue) error {
+		ops := make([]clientv3.Op, 0, len(kvs))
+		for _, kv := range kvs {
+			ops = append(ops, clientv3.OpPut(destKey(kv.Key), string(kv.Value)))
+		}
+		if err := mr.apply(ctx, ops); err != nil {
+			return err
+		}
+		mr.mu.Lock()
+		mr.status.Synced += len(ops)
+		mr.mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	mr.mu.Lock()
+	mr.status.Revision = rev
+	mr.mu.Unlock()
+
+	wch := mr.srcCli.Watch(clientv3.WithRequireLeader(ctx), prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1), clientv3.WithProgressNotify())
+	for wresp := range wch {
+		if err := wresp.Err(); err != nil {
+			return err
+		}
+		// a transaction cannot change a key twice,
+		// so only the last change of each key is applied
+		var (
+			ops           []clientv3.Op
+			opIdx         = make(map[string]int)
+			puts, deletes int
+			lastRev       = wresp.Header.Revision
+		)
+		for _, ev := range wresp.Events {
+			k := destKey(ev.Kv.Key)
+			var op clientv3.Op
+			switch ev.Type {
+			case mvccpb.PUT:
+				op = clientv3.OpPut(k, string(ev.Kv.Value))
+				puts++
+			case mvccpb.DELETE:
+				op = clientv3.OpDelete(k)
+				deletes++
+			}
+			if i, ok := opIdx[k]; ok {
+				ops[i] = op
+			} else {
+				opIdx[k] = len(ops)
+				ops = append(ops, op)
+			}
+			lastRev = ev.Kv.ModRevision
+		}
+		if err := mr.apply(ctx, ops); err != nil {
+			return err
+		}
+
+		mr.mu.Lock()
+		mr.status.Puts += puts
+		mr.status.Deletes += deletes
+		if lastRev > mr.status.Revision {
+			mr.status.Revision = lastRev
+		}
+		if len(ops) > 0 {
+			mr.status.LastReplicated = time.Now()
+		}
+		mr.mu.Unlock()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("watch of %q closed", prefix)
+}
+
+// apply writes the operations to the destination, in transactions
+// of up to fixtureBatch operations.
+func (mr *Mirror) apply(ctx context.Context, ops []clientv3.Op) error {
+	for len(ops) > 0 {
+		n := len(ops)
+		if n > fixtureBatch {
+			n = fixtureBatch
+		}
+		if _, err := mr.dstCli.Txn(ctx).Then(ops[:n]...).Commit(); err != nil {
+			return err
+		}
+		ops = ops[n:]
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+
+	"github.com/coreos/etcd/pkg/types"
+	humanize "github.com/dustin/go-humanize"
+	"github.com/golang/glog"
+)
+
+const (
+	// ModeEmbedded runs each node as an embedded etcd server in this process.
+	ModeEmbedded = "embedded"
+	// ModeSubprocess runs each node as an etcd binary in a child process.
+	ModeSubprocess = "subprocess"
+	// ModeDocker runs each node in a Docker container.
+	ModeDocker = "docker"
+	// ModeKubernetes runs each node in a Kubernetes pod.
+	ModeKubernetes = "kubernetes"
+	// ModeSSH runs each node as a systemd unit on a remote host.
+	ModeSSH = "ssh"
+)
+
+var (
+	defaultEtcdBinary = "etcd"
+	nodeReadyTimeout  = 10 * time.Second
+)
+
+// ID returns the member ID, or zero if the member has never started.
+func (m *Member) ID() types.ID {
+	if m.ext != nil {
+		return m.extID
+	}
+	if m.srv == nil {
+		return 0
+	}
+	return m.srv.Server.ID()
+}
+
+// lead returns the leader ID known to the member, or zero if none.
+func (m *Member) lead() uint64 {
+	if m.ext == nil {
+		select {
+		case <-m.srv.Server.StopNotify():
+			return 0
+		default:
+		}
+		return m.srv.Server.Lead()
+	}
+
+	if !m.ext.Running() {
+		return 0
+	}
+	cli, err := m.sharedClient()
+	if err != nil {
+		return 0
+	}
+
+	ctx, cancel := context.WithTimeout(m.clus.rootCtx, time.Second)
+	resp, err := cli.Status(ctx, m.clientEndpoint())
+	cancel()
+	if err != nil {
+		return 0
+	}
+	return resp.Leader
+}
+
+// appliedIndex returns the applied index of the member. Nodes outside
+// of this process do not report it, so the raft index is returned.
+func (m *Member) appliedIndex(raftIndex uint64) uint64 {
+	if m.ext != nil {
+		return raftIndex
+	}
+	return m.srv.Server.KV().ConsistentIndex()
+}
+
+// initNode sets up the node backend of the member.
+func (clus *Cluster) initNode(m *Member) {
+	logf := func(line string) { m.addLog(parseLogLine(line)) }
+	switch clus.ccfg.Mode {
+	case ModeSubprocess:
+		bin := clus.ccfg.EtcdBinary
+		if bin == "" {
+			bin = defaultEtcdBinary
+		}
+		m.ext = newProcess(m.cfg.Name, bin, logf)
+	case ModeDocker:
+		m.ext = newContainer(clus.docker, m.cfg.Name, clus.ccfg.DockerImage, clus.rootDir, logf)
+	case ModeKubernetes:
+		m.ext = newPod(clus.kube, m.cfg.Name, clus.ccfg.DockerImage, clus.ccfg.KubeNode, clus.rootDir, logf)
+	case ModeSSH:
+		bin := clus.ccfg.EtcdBinary
+		if bin == "" {
+			bin = defaultEtcdBinary
+		}
+		m.ext = newRemoteNode(m.cfg.Name, clus.ccfg.SSHHosts[m.cfg.Name], bin, clus.rootDir, m.cfg.Dir, clus.ccfg, logf)
+	}
+}
+
+// initNodeVersion applies the configured etcd version of the member, if any.
+func (clus *Cluster) initNodeVersion(m *Member) error {
+	v, ok := clus.ccfg.NodeVersions[m.cfg.Name]
+	if !ok {
+		return nil
+	}
+	return clus.applyVersion(m, v)
+}
+
+// imageNode is a node that runs an image (a container or a pod).
+type imageNode interface {
+	Image() string
+	SetImage(image string)
+}
+
+// NodeImage returns the image of the node.
+func (clus *Cluster) NodeImage(i int) (string, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return "", err
+	}
+	c, ok := m.ext.(imageNode)
+	if !ok {
+		return "", fmt.Errorf("%q does not run in %s or %s mode", m.cfg.Name, ModeDocker, ModeKubernetes)
+	}
+	return c.Image(), nil
+}
+
+// UpgradeNode restarts the node with another image (e.g. a newer etcd
+// release), keeping its data. Upgrade nodes one at a time to keep quorum.
+// Like StopCtx, it returns *ErrRateLimited within Config.LifecycleInterval
+// of the last stop or restart.
+func (clus *Cluster) UpgradeNode(i int, image string) error {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+
+	m, err := clus.member(i)
+	if err != nil {
+		return err
+	}
+	c, ok := m.ext.(imageNode)
+	if !ok {
+		return fmt.Errorf("%q does not run in %s or %s mode", m.cfg.Name, ModeDocker, ModeKubernetes)
+	}
+	if err = clus.allowLifecycle(); err != nil {
+		return err
+	}
+	glog.Infof("upgrading %q from %q to %q", m.cfg.Name, c.Image(), image)
+
+	if err = clus.stop(m); err != nil {
+		return err
+	}
+	c.SetImage(image)
+	if err = clus.restart(m); err != nil {
+		return err
+	}
+	return m.waitReady(nodeReadyTimeout)
+}
+
+// waitReady waits until the member serves client requests.
+func (m *Member) waitReady(timeout time.Duration) error {
+	if m.ext == nil {
+		select {
+		case <-m.srv.Server.ReadyNotify():
+			return nil
+		case <-time.After(timeout):
+			return fmt.Errorf("%q did not become ready in %v", m.cfg.Name, timeout)
+		}
+	}
+
+	cli, _, err := m.Client(m.clus.rootCtx, false)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if !m.ext.Running() {
+			return fmt.Errorf("%q exited before becoming ready", m.cfg.Name)
+		}
+		ctx, cancel := context.WithTimeout(m.clus.rootCtx, time.Second)
+		resp, err := cli.Status(ctx, m.clientEndpoint())
+		cancel()
+		if err == nil {
+			m.extID = types.ID(resp.Header.MemberId)
+			m.setLogTokens()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%q did not become ready in %v (%v)", m.cfg.Name, timeout, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Kill stops the member without a graceful shutdown, as if the process crashed.
+// Only nodes running outside of this process can be killed.
+func (m *Member) Kill() error {
+	if m.ext == nil {
+		return fmt.Errorf("%q runs in %s mode and cannot be killed", m.cfg.Name, ModeEmbedded)
+	}
+	glog.Infof("killing %q(%s)", m.cfg.Name, m.ID())
+
+	m.statusLock.Lock()
+	m.stoppedStartedAt = time.Now()
+	m.status.IsLeader = false
+	m.status.State = clusterpb.StoppedMemberStatus
+	m.status.StateTxt = fmt.Sprintf("%s just killed (%s)", m.status.Name, humanize.Time(m.stoppedStartedAt))
+	m.clearStatus()
+	m.statusLock.Unlock()
+
+	m.closeSharedClient()
+	return m.ext.Kill()
+}
+
+// Kill kills a node (see Member.Kill).
+func (clus *Cluster) Kill(i int) error {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+	m, err := clus.member(i)
+	if err != nil {
+		return err
+	}
+	return clus.kill(m)
+}
+
+// kill kills the member. Must be called with 'opLock' held.
+func (clus *Cluster) kill(m *Member) error {
+	if err := m.Kill(); err != nil {
+		return err
+	}
+	clus.recordEvent("member-kill", m.cfg.Name, "killed %q", m.cfg.Name)
+	clus.notifyStopped(m.cfg.Name)
+	return nil
+}
@@ -0,0 +1,120 @@
+package cluster
+
+import (
+	"sync"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+
+	"github.com/golang/glog"
+)
+
+// Observer is notified of cluster lifecycle changes (see Cluster.Subscribe).
+// Notifications are delivered in order from a goroutine per observer, so
+// observers may call back into the cluster.
+type Observer interface {
+	// NodeStarted is called when a node is added or restarted.
+	NodeStarted(name string)
+	// NodeStopped is called when a node is stopped or killed.
+	NodeStopped(name string)
+	// LeaderChanged is called when the status loop sees a new leader,
+	// or sees that the cluster has lost its leader.
+	LeaderChanged(LeaderChange)
+	// StatusUpdated is called after each status update.
+	StatusUpdated([]clusterpb.MemberStatus)
+}
+
+// BaseObserver ignores all notifications. Embed it in observers that
+// only handle some of them.
+type BaseObserver struct{}
+
+// NodeStarted implements Observer.
+func (BaseObserver) NodeStarted(string) {}
+
+// NodeStopped implements Observer.
+func (BaseObserver) NodeStopped(string) {}
+
+// LeaderChanged implements Observer.
+func (BaseObserver) LeaderChanged(LeaderChange) {}
+
+// StatusUpdated implements Observer.
+func (BaseObserver) StatusUpdated([]clusterpb.MemberStatus) {}
+
+// observerQueueSize is the number of notifications queued per observer.
+// Notifications are dropped for observers that fall further behind.
+var observerQueueSize = 64
+
+type subscriber struct {
+	o     Observer
+	queue chan func(Observer)
+	donec chan struct{}
+}
+
+// observers are the subscribed observers of a cluster.
+type observers struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+// Subscribe registers the observer, and returns a function that
+// unsubscribes it.
+func (clus *Cluster) Subscribe(o Observer) (unsubscribe func()) {
+	s := &subscriber{o: o, queue: make(chan func(Observer), observerQueueSize), donec: make(chan struct{})}
+	go func() {
+		for {
+			select {
+			case f := <-s.queue:
+				f(s.o)
+			case <-s.donec:
+				return
+			}
+		}
+	}()
+
+	clus.observers.mu.Lock()
+	if clus.observers.subs == nil {
+		clus.observers.subs = make(map[*subscriber]struct{})
+	}
+	clus.observers.subs[s] = struct{}{}
+	clus.observers.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			clus.observers.mu.Lock()
+			delete(clus.observers.subs, s)
+			clus.observers.mu.Unlock()
+			close(s.donec)
+		})
+	}
+}
+
+// notify queues the notification for each observer.
+func (clus *Cluster) notify(f func(Observer)) {
+	clus.observers.mu.Lock()
+	defer clus.observers.mu.Unlock()
+	for s := range clus.observers.subs {
+		select {
+		case s.queue <- f:
+		default:
+			glog.Warningf("dropped a notification of a slow observer %T", s.o)
+		}
+	}
+}
+
+func (clus *Cluster) notifyStarted(name string) {
+	clus.notify(func(o Observer) { o.NodeStarted(name) })
+}
+
+func (clus *Cluster) notifyStopped(name string) {
+	clus.notify(func(o Observer) { o.NodeStopped(name) })
+}
+
+// notifyStatus notifies the observers of the member statuses.
+// Must be called with 'mmu' held.
+func (clus *Cluster) notifyStatus() {
+	sts := make([]clusterpb.MemberStatus, len(clus.Members))
+	for i, m := range clus.Members {
+		sts[i] = m.statusCopy()
+	}
+	clus.notify(func(o Observer) { o.StatusUpdated(sts) })
+}
@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/coreos/etcd/pkg/transport"
+)
+
+// Option configures a cluster created by New.
+type Option func(*Config)
+
+// defaultRootPort is the first client port of clusters created by New,
+// so that the first node listens on the etcd default ports.
+const defaultRootPort = 2379
+
+// WithRootDir stores the data of the nodes under 'dir'.
+// New uses a temporary directory by default.
+func WithRootDir(dir string) Option {
+	return func(c *Config) { c.RootDir = dir }
+}
+
+// WithPortRange assigns the client and peer ports of the nodes
+// between 'min' and 'max' (see NewRangePortAllocator).
+func WithPortRange(min, max int) Option {
+	return func(c *Config) { c.PortAllocator = NewRangePortAllocator(min, max) }
+}
+
+// WithTLS serves clients and peers over TLS with the certificates.
+// Empty TLS info leaves the scheme as it is.
+func WithTLS(client, peer transport.TLSInfo) Option {
+	return func(c *Config) {
+		c.ClientTLSInfo, c.PeerTLSInfo = client, peer
+	}
+}
+
+// WithAutoTLS serves clients and peers over TLS with self-signed certificates.
+func WithAutoTLS() Option {
+	return func(c *Config) {
+		c.ClientAutoTLS, c.PeerAutoTLS = true, true
+	}
+}
+
+// WithMode runs the nodes in the mode (e.g. ModeSubprocess).
+func WithMode(mode string) Option {
+	return func(c *Config) { c.Mode = mode }
+}
+
+// WithShutdownArchive archives the data, logs and events of the cluster
+// to 'path' on Shutdown (see Config.ShutdownArchive).
+func WithShutdownArchive(path string) Option {
+	return func(c *Config) { c.ShutdownArchive = path }
+}
+
+// WithContext derives the cluster context from 'ctx'.
+func WithContext(ctx context.Context) Option {
+	return func(c *Config) {
+		c.RootCtx, c.RootCancel = context.WithCancel(ctx)
+	}
+}
+
+// WithOverrides applies arbitrary changes to the Config,
+// for the settings that have no option.
+func WithOverrides(f func(*Config)) Option {
+	return Option(f)
+}
+
+// New starts a cluster of 'size' nodes configured by the options.
+// It is equivalent to Start with the resulting Config.
+func New(size int, opts ...Option) (*Cluster, error) {
+	ccfg := Config{Size: size, RootPort: defaultRootPort}
+	for _, opt := range opts {
+		opt(&ccfg)
+	}
+	if ccfg.RootDir == "" {
+		dir, err := ioutil.TempDir("", "etcdlabs")
+		if err != nil {
+			return nil, err
+		}
+		ccfg.RootDir = dir
+	}
+	if ccfg.RootCtx == nil {
+		ccfg.RootCtx, ccfg.RootCancel = context.WithCancel(context.Background())
+	}
+	return Start(ccfg)
+}
@@ -0,0 +1,264 @@
+package cluster
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/coreos/etcdlabs/pkg/peertrace"
+	"github.com/coreos/etcdlabs/pkg/ratelimit"
+
+	"github.com/coreos/etcd/pkg/types"
+	"github.com/golang/glog"
+	"golang.org/x/time/rate"
+)
+
+var (
+	// peerTraceSamples is the number of recent raft messages kept by the tracer.
+	peerTraceSamples = 256
+	// slowPeerRate is the peer bandwidth of a member slowed by a "slow" fault,
+	// in bytes per second.
+	slowPeerRate = 16 * 1024
+)
+
+// rafthttp paths
+const (
+	raftPath          = "/raft"
+	raftSnapshotPath  = "/raft/snapshot"
+	raftStreamMessage = "/raft/stream/message/"
+	raftStreamMsgApp  = "/raft/stream/msgapp/"
+)
+
+// peerProxy forwards the peer traffic of a member from its advertised
+// peer URL to its listener, and traces the raft messages.
+type peerProxy struct {
+	ln  net.Listener
+	srv *http.Server
+}
+
+func (c Config) validatePeerProxy() error {
+	if c.PeerProxyRootPort <= 0 {
+		return nil
+	}
+	if c.Mode == ModeDocker || c.Mode == ModeKubernetes || c.Mode == ModeSSH || c.UnixSockets {
+		return fmt.Errorf("peer proxy requires local TCP peers, not in %s mode", c.Mode)
+	}
+	if !c.PeerTLSInfo.Empty() || c.PeerAutoTLS || c.GenerateCerts {
+		return fmt.Errorf("peer proxy cannot decode TLS peer traffic")
+	}
+	for name, o := range c.NodeTLS {
+		if o.peerScheme("http") != "http" {
+			return fmt.Errorf("peer proxy cannot decode TLS peer traffic of %q", name)
+		}
+	}
+	return nil
+}
+
+// startPeerProxy starts the peer proxy of the new member, if enabled,
+// and advertises it as the member peer URL.
+func (clus *Cluster) startPeerProxy(m *Member) error {
+	if clus.peerTracer == nil {
+		return nil
+	}
+	u := url.URL{Scheme: "http", Host: net.JoinHostPort(clus.ccfg.peerHost(), fmt.Sprint(clus.peerProxyPort))}
+	clus.peerProxyPort++
+	ln, err := net.Listen("tcp", u.Host)
+	if err != nil {
+		return err
+	}
+
+	target := m.cfg.LPUrls[0]
+	rp := httputil.NewSingleHostReverseProxy(&target)
+	// streams must reach the member as they are written
+	rp.FlushInterval = -1
+	director := rp.Director
+	rp.Director = func(req *http.Request) {
+		director(req)
+		clus.traceRequest(req)
+	}
+	rp.ModifyResponse = func(resp *http.Response) error {
+		clus.traceResponse(resp)
+		return nil
+	}
+	rp.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		// the member is stopped, as if its peer port were closed
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	pp := &peerProxy{ln: ln, srv: &http.Server{Handler: rp}}
+	go func() {
+		if err := pp.srv.Serve(ln); err != http.ErrServerClosed {
+			glog.Infof("peer proxy of %q stopped serving (%v)", m.cfg.Name, err)
+		}
+	}()
+	m.peerProxy = pp
+	m.cfg.APUrls = []url.URL{u}
+	glog.Infof("%q advertises peer proxy %q", m.cfg.Name, u.String())
+	return nil
+}
+
+func (pp *peerProxy) stop() {
+	pp.srv.Close()
+}
+
+// peerLink returns the sender and the receiver of a peer request.
+func peerLink(h http.Header) peertrace.Link {
+	from, _ := types.IDFromString(h.Get("X-Server-From"))
+	to, _ := types.IDFromString(h.Get("X-Raft-To"))
+	return peertrace.Link{From: from, To: to}
+}
+
+// traceRequest traces the messages posted by the pipeline.
+func (clus *Cluster) traceRequest(req *http.Request) {
+	if req.Method != http.MethodPost || req.Body == nil {
+		return
+	}
+	l := peerLink(req.Header)
+	req.Body = clus.throttlePeer(req.Body, l.To)
+	var decode func(peertrace.Link, io.Reader) error
+	switch req.URL.Path {
+	case raftPath:
+		decode = clus.peerTracer.DecodeMessage
+	case raftSnapshotPath:
+		decode = clus.peerTracer.DecodeSnapshot
+	default:
+		return
+	}
+	req.Body = peertrace.Tap(req.Body, func(r io.Reader) error { return decode(l, r) })
+}
+
+// traceResponse traces the messages of streams, which the receiver
+// opens to the sender, so they flow back in the response.
+func (clus *Cluster) traceResponse(resp *http.Response) {
+	req := resp.Request
+	if req == nil || req.Method != http.MethodGet || resp.StatusCode != http.StatusOK {
+		return
+	}
+	l := peerLink(req.Header)
+	l.From, l.To = l.To, l.From
+	resp.Body = clus.throttlePeer(resp.Body, l.To)
+	var decode func(peertrace.Link, io.Reader) error
+	switch {
+	case strings.HasPrefix(req.URL.Path, raftStreamMessage):
+		decode = clus.peerTracer.DecodeStream
+	case strings.HasPrefix(req.URL.Path, raftStreamMsgApp):
+		decode = clus.peerTracer.DecodeMsgAppV2
+	default:
+		return
+	}
+	resp.Body = peertrace.Tap(resp.Body, func(r io.Reader) error { return decode(l, r) })
+}
+
+// throttlePeer limits the reads of the peer traffic to the member
+// by its bandwidth, if it is slowed (see SlowPeer).
+func (clus *Cluster) throttlePeer(rc io.ReadCloser, to types.ID) io.ReadCloser {
+	r := ratelimit.NewReader(rc, func() *rate.Limiter {
+		clus.peerLimitMu.Lock()
+		defer clus.peerLimitMu.Unlock()
+		return clus.peerLimits[to]
+	})
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, rc}
+}
+
+// SlowPeer limits the peer traffic to the node to 'bytesPerSec', or lifts
+// the limit if it is not positive, as a slow network to a follower would.
+// The leader backlog grows, and a follower falling too far behind catches
+// up from a snapshot. It requires the peer proxy (see PeerProxyRootPort).
+func (clus *Cluster) SlowPeer(i, bytesPerSec int) error {
+	if clus.peerTracer == nil {
+		return fmt.Errorf("slowing peers requires the peer proxy")
+	}
+	clus.mmu.RLock()
+	m := clus.Members[i]
+	clus.mmu.RUnlock()
+	id := m.ID()
+	if id == 0 {
+		return fmt.Errorf("%q has never started", m.cfg.Name)
+	}
+
+	clus.peerLimitMu.Lock()
+	if bytesPerSec > 0 {
+		// bursts of a tenth of a second, so messages are not held for long
+		burst := bytesPerSec / 10
+		if burst < 1 {
+			burst = 1
+		}
+		clus.peerLimits[id] = rate.NewLimiter(rate.Limit(bytesPerSec), burst)
+	} else {
+		delete(clus.peerLimits, id)
+	}
+	clus.peerLimitMu.Unlock()
+
+	if bytesPerSec > 0 {
+		clus.recordEvent("peer-slow", m.cfg.Name, "limited peer traffic to %q to %d bytes/s", m.cfg.Name, bytesPerSec)
+	} else {
+		clus.recordEvent("peer-slow", m.cfg.Name, "lifted peer traffic limit of %q", m.cfg.Name)
+	}
+	return nil
+}
+
+// PeerTraffic is the flow of raft messages between the members.
+type PeerTraffic struct {
+	// Matrix is the number of messages from a member to another, by name.
+	Matrix map[string]map[string]int64
+	// Flows are the messages and bytes per link and message type,
+	// and Samples the most recent messages.
+	Flows   []peertrace.Flow
+	Samples []peertrace.Sample
+}
+
+// PeerTraffic returns the raft messages traced by the peer proxies
+// (see Config.PeerProxyRootPort), with member names for IDs.
+func (clus *Cluster) PeerTraffic() (PeerTraffic, error) {
+	if clus.peerTracer == nil {
+		return PeerTraffic{}, fmt.Errorf("peer proxy is disabled")
+	}
+	names := make(map[string]string)
+	clus.mmu.RLock()
+	for _, m := range clus.Members {
+		if id := m.ID(); id != 0 {
+			names[id.String()] = m.cfg.Name
+		}
+	}
+	clus.mmu.RUnlock()
+	name := func(id string) string {
+		if n, ok := names[id]; ok {
+			return n
+		}
+		return id
+	}
+
+	pt := PeerTraffic{
+		Matrix:  make(map[string]map[string]int64),
+		Flows:   clus.peerTracer.Flows(),
+		Samples: clus.peerTracer.Samples(),
+	}
+	for i := range pt.Flows {
+		f := &pt.Flows[i]
+		f.From, f.To = name(f.From), name(f.To)
+		if pt.Matrix[f.From] == nil {
+			pt.Matrix[f.From] = make(map[string]int64)
+		}
+		pt.Matrix[f.From][f.To] += f.Messages
+	}
+	for i := range pt.Samples {
+		pt.Samples[i].From, pt.Samples[i].To = name(pt.Samples[i].From), name(pt.Samples[i].To)
+	}
+	return pt, nil
+}
+
+// ResetPeerTraffic discards the traced raft messages.
+func (clus *Cluster) ResetPeerTraffic() error {
+	if clus.peerTracer == nil {
+		return fmt.Errorf("peer proxy is disabled")
+	}
+	clus.peerTracer.Reset()
+	return nil
+}
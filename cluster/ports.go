@@ -0,0 +1,97 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// PortAllocator assigns client and peer ports to nodes, so embedders
+// can plug in their own port reservation.
+type PortAllocator interface {
+	// Allocate returns the client and peer ports of the named node.
+	Allocate(name string) (clientPort, peerPort int, err error)
+	// Release returns the ports of the removed node.
+	Release(name string)
+}
+
+// sequentialPorts allocates consecutive port pairs from a root port,
+// the client port followed by the peer port. Released ports are not reused.
+type sequentialPorts struct {
+	mu   sync.Mutex
+	next int
+}
+
+// NewSequentialPortAllocator returns the default PortAllocator, which
+// assigns 'rootPort' and 'rootPort+1' to the first node, and following
+// pairs to the next nodes.
+func NewSequentialPortAllocator(rootPort int) PortAllocator {
+	return &sequentialPorts{next: rootPort}
+}
+
+func (sp *sequentialPorts) Allocate(name string) (int, int, error) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	c, p := sp.next, sp.next+1
+	sp.next += 2
+	return c, p, nil
+}
+
+func (sp *sequentialPorts) Release(name string) {}
+
+// rangePorts allocates consecutive port pairs within a range,
+// reusing the pairs of removed nodes.
+type rangePorts struct {
+	mu       sync.Mutex
+	min, max int
+	used     map[int]string // by client port
+}
+
+// NewRangePortAllocator returns a PortAllocator that assigns port pairs
+// between 'min' and 'max' (inclusive), failing once the range is used up.
+func NewRangePortAllocator(min, max int) PortAllocator {
+	return &rangePorts{min: min, max: max, used: make(map[int]string)}
+}
+
+func (rp *rangePorts) Allocate(name string) (int, int, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for c := rp.min; c+1 <= rp.max; c += 2 {
+		if _, ok := rp.used[c]; !ok {
+			rp.used[c] = name
+			return c, c + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("no free ports in [%d, %d] for %q", rp.min, rp.max, name)
+}
+
+func (rp *rangePorts) Release(name string) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for c, n := range rp.used {
+		if n == name {
+			delete(rp.used, c)
+		}
+	}
+}
+
+const defaultNodeNameTemplate = "node%d"
+
+// nodeName returns the name of the n-th node (starting from 1).
+func (c Config) nodeName(n int) string {
+	tmpl := c.NodeNameTemplate
+	if tmpl == "" {
+		tmpl = defaultNodeNameTemplate
+	}
+	return fmt.Sprintf(tmpl, n)
+}
+
+func (c Config) validateNodeNameTemplate() error {
+	if c.NodeNameTemplate == "" {
+		return nil
+	}
+	if strings.Count(c.NodeNameTemplate, "%d") != 1 || strings.Count(c.NodeNameTemplate, "%") != 1 {
+		return fmt.Errorf("node name template %q must have exactly one '%%d'", c.NodeNameTemplate)
+	}
+	return nil
+}
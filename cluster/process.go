@@ -0,0 +1,140 @@
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// externalNode is an etcd server running outside of this process.
+type externalNode interface {
+	// Start starts the server with the command-line flags.
+	Start(flags []string) error
+	// Stop gracefully stops the server.
+	Stop() error
+	// Kill stops the server without a graceful shutdown.
+	Kill() error
+	// Running returns true if the server is running.
+	Running() bool
+}
+
+var processStopTimeout = 10 * time.Second
+
+// process runs an etcd binary as a child process.
+type process struct {
+	name   string
+	binary string
+	logf   func(line string)
+
+	mu    sync.Mutex
+	cmd   *exec.Cmd
+	donec chan struct{}
+}
+
+func newProcess(name, binary string, logf func(string)) *process {
+	return &process{name: name, binary: binary, logf: logf}
+}
+
+// SetBinary sets the etcd binary used on next start.
+func (p *process) SetBinary(binary string) {
+	p.mu.Lock()
+	p.binary = binary
+	p.mu.Unlock()
+}
+
+// Start implements externalNode.
+func (p *process) Start(flags []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.donec != nil {
+		select {
+		case <-p.donec:
+		default:
+			return fmt.Errorf("%q is already running", p.name)
+		}
+	}
+
+	cmd := exec.Command(p.binary, flags...)
+	pr, pw := io.Pipe()
+	cmd.Stdout, cmd.Stderr = pw, pw
+	if err := cmd.Start(); err != nil {
+		pw.Close()
+		return err
+	}
+	glog.Infof("started %q (pid %d): %s %v", p.name, cmd.Process.Pid, p.binary, flags)
+
+	donec := make(chan struct{})
+	go func() {
+		sc := bufio.NewScanner(pr)
+		for sc.Scan() {
+			p.logf(sc.Text())
+		}
+	}()
+	go func() {
+		err := cmd.Wait()
+		pw.Close()
+		glog.Infof("%q (pid %d) exited (%v)", p.name, cmd.Process.Pid, err)
+		close(donec)
+	}()
+
+	p.cmd, p.donec = cmd, donec
+	return nil
+}
+
+// Stop implements externalNode. It sends SIGTERM, and
+// SIGKILL if the process does not exit in time.
+func (p *process) Stop() error {
+	return p.signal(syscall.SIGTERM, processStopTimeout)
+}
+
+// Kill implements externalNode. It sends SIGKILL.
+func (p *process) Kill() error {
+	return p.signal(syscall.SIGKILL, processStopTimeout)
+}
+
+func (p *process) signal(sig syscall.Signal, timeout time.Duration) error {
+	p.mu.Lock()
+	cmd, donec := p.cmd, p.donec
+	p.mu.Unlock()
+	if cmd == nil || !p.Running() {
+		return nil
+	}
+
+	if err := cmd.Process.Signal(sig); err != nil {
+		return err
+	}
+	select {
+	case <-donec:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	glog.Warningf("%q did not exit in %v after %v; killing", p.name, timeout, sig)
+	if err := cmd.Process.Kill(); err != nil {
+		return err
+	}
+	<-donec
+	return nil
+}
+
+// Running implements externalNode.
+func (p *process) Running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.donec == nil {
+		return false
+	}
+	select {
+	case <-p.donec:
+		return false
+	default:
+		return true
+	}
+}
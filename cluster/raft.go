@@ -0,0 +1,104 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RaftProgress is the replication progress of the members,
+// as tracked by the leader.
+type RaftProgress struct {
+	Leader string
+	Term   uint64
+	Commit uint64
+	// Approximate is true if the leader progress is not available, and
+	// Match is the raft index each member reports. Embedded nodes share
+	// the process-wide raft status, which only one of them publishes.
+	Approximate bool
+	Members     []MemberProgress
+}
+
+// MemberProgress is the replication progress of a member.
+type MemberProgress struct {
+	Name string
+	ID   string
+	// Match is the highest index known to be replicated on the member,
+	// and Next the index of the next entry to send to it.
+	Match uint64
+	Next  uint64
+	// State is "probe", "replicate" or "snapshot" (empty if approximate).
+	State string
+	// Lag is the number of entries the member is behind the leader.
+	Lag uint64
+}
+
+// raftStatus is the 'raft.status' of '/debug/vars'.
+type raftStatus struct {
+	ID       string `json:"id"`
+	Term     uint64 `json:"term"`
+	Commit   uint64 `json:"commit"`
+	Lead     string `json:"lead"`
+	Progress map[string]struct {
+		Match uint64 `json:"match"`
+		Next  uint64 `json:"next"`
+		State string `json:"state"`
+	} `json:"progress"`
+}
+
+// RaftProgress returns the replication progress of each member, as the
+// leader tracks it, to see which follower is lagging.
+func (clus *Cluster) RaftProgress() (RaftProgress, error) {
+	sts := clus.AllMemberStatus()
+	clus.mmu.RLock()
+	ms := append([]*Member(nil), clus.Members...)
+	clus.mmu.RUnlock()
+
+	li := -1
+	for i := range sts {
+		if sts[i].IsLeader {
+			li = i
+			break
+		}
+	}
+	if li == -1 {
+		return RaftProgress{}, fmt.Errorf("no leader")
+	}
+	leader := ms[li]
+
+	var vars struct {
+		Raft raftStatus `json:"raft.status"`
+	}
+	if err := leader.getJSON("/debug/vars", &vars); err != nil {
+		return RaftProgress{}, err
+	}
+	st := vars.Raft
+	rp := RaftProgress{Leader: leader.cfg.Name, Term: st.Term, Commit: st.Commit}
+
+	if st.ID != leader.ID().String() || len(st.Progress) == 0 {
+		// published by another embedded node, or the leader changed
+		rp.Approximate = true
+		rp.Term, rp.Commit = sts[li].RaftTerm, sts[li].RaftIndex
+		for i, m := range ms {
+			mp := MemberProgress{Name: m.cfg.Name, ID: sts[i].ID, Match: sts[i].RaftIndex, Next: sts[i].RaftIndex + 1}
+			if rp.Commit > mp.Match {
+				mp.Lag = rp.Commit - mp.Match
+			}
+			rp.Members = append(rp.Members, mp)
+		}
+		return rp, nil
+	}
+
+	last := st.Progress[st.ID].Match
+	for _, m := range ms {
+		mp := MemberProgress{Name: m.cfg.Name, ID: m.ID().String()}
+		if p, ok := st.Progress[mp.ID]; ok {
+			mp.Match, mp.Next = p.Match, p.Next
+			mp.State = strings.ToLower(strings.TrimPrefix(p.State, "ProgressState"))
+		}
+		if last > mp.Match {
+			mp.Lag = last - mp.Match
+		}
+		rp.Members = append(rp.Members, mp)
+	}
+	return rp, nil
+}
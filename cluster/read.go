@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+)
+
+// Read consistency levels.
+const (
+	// ReadLinearizable reads go through raft consensus,
+	// so they see every write committed before them.
+	ReadLinearizable = "linearizable"
+	// ReadSerializable reads are served from the local store of the node,
+	// so they are faster but may be stale on a lagging or isolated member.
+	ReadSerializable = "serializable"
+)
+
+// ReadResponse is the result of a read with a consistency level.
+type ReadResponse struct {
+	KVResponse
+
+	Consistency string
+
+	// LatestRevision is the cluster revision read linearizably through
+	// any member right after the read (zero if none is reachable), and
+	// RevisionLag is how many revisions the read is behind it.
+	LatestRevision int64
+	RevisionLag    int64
+}
+
+// Read reads a key (or all keys with the prefix) through node 'i' with
+// the consistency level, and reports how stale the result is.
+func (clus *Cluster) Read(ctx context.Context, i int, key string, prefix bool, consistency string) (resp ReadResponse, err error) {
+	var opts []clientv3.OpOption
+	switch consistency {
+	case ReadLinearizable:
+	case ReadSerializable:
+		opts = append(opts, clientv3.WithSerializable())
+	default:
+		return resp, fmt.Errorf("unknown consistency %q", consistency)
+	}
+	if prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	resp.Consistency = consistency
+
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return resp, err
+	}
+
+	now := time.Now()
+	gresp, err := cli.Get(ctx, key, opts...)
+	if err != nil {
+		return resp, err
+	}
+	resp.Took = time.Since(now)
+	resp.Header = toResponseHeader(gresp.Header)
+	resp.KeyValues = toKeyValues(gresp.Kvs)
+
+	resp.LatestRevision = clus.latestRevision(ctx, i)
+	if resp.LatestRevision > resp.Header.Revision {
+		resp.RevisionLag = resp.LatestRevision - resp.Header.Revision
+	}
+	return resp, nil
+}
+
+// latestRevision reads the cluster revision linearizably, through node 'i'
+// first and then through the others, so an isolated node is skipped.
+func (clus *Cluster) latestRevision(ctx context.Context, i int) int64 {
+	clus.mmu.RLock()
+	n := len(clus.Members)
+	clus.mmu.RUnlock()
+
+	for j := 0; j < n; j++ {
+		cli, err := clus.SharedClient((i + j) % n)
+		if err != nil {
+			continue
+		}
+		tctx, cancel := context.WithTimeout(ctx, time.Second)
+		gresp, err := cli.Get(tctx, "latest-revision", clientv3.WithCountOnly())
+		cancel()
+		if err == nil {
+			return gresp.Header.Revision
+		}
+	}
+	return 0
+}
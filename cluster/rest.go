@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// restClient sends JSON requests to an HTTP API, such as the Docker
+// Engine API and the Kubernetes API server.
+type restClient struct {
+	cli  *http.Client
+	base string
+	// api names the API in errors (e.g. "docker").
+	api string
+	// token is sent as a bearer token, if not empty.
+	token string
+}
+
+// do sends a request and returns the response if its status is 2xx.
+func (rc *restClient) do(method, path string, body interface{}) (*http.Response, error) {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, rc.base+path, rd)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if rc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+rc.token)
+	}
+	resp, err := rc.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return resp, &apiError{api: rc.api, code: resp.StatusCode, msg: strings.TrimSpace(string(msg))}
+	}
+	return resp, nil
+}
+
+// call sends a request and discards the response body.
+func (rc *restClient) call(method, path string, body interface{}) error {
+	resp, err := rc.do(method, path, body)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// apiError is a non-2xx response of the API.
+type apiError struct {
+	api  string
+	code int
+	msg  string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s API error %d (%s)", e.api, e.code, e.msg)
+}
+
+func isAPINotFound(err error) bool {
+	ae, ok := err.(*apiError)
+	return ok && ae.code == http.StatusNotFound
+}
@@ -0,0 +1,49 @@
+package cluster
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// seeder derives the seeds of random generators from the cluster seed,
+// so a sequence of randomized runs is reproducible from one seed.
+type seeder struct {
+	seed int64
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newSeeder(seed int64) *seeder {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	glog.Infof("random seed %d", seed)
+	return &seeder{seed: seed, rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (s *seeder) next() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rnd.Int63()
+}
+
+// Seed returns the seed of the cluster (see Config.Seed).
+func (clus *Cluster) Seed() int64 {
+	return clus.seeder.seed
+}
+
+// NextSeed returns the next seed derived from the cluster seed.
+func (clus *Cluster) NextSeed() int64 {
+	return clus.seeder.next()
+}
+
+// NewRand returns a random generator seeded with the next seed derived
+// from the cluster seed (e.g. to generate keys). It is not safe for
+// concurrent use.
+func (clus *Cluster) NewRand() *rand.Rand {
+	return rand.New(rand.NewSource(clus.NextSeed()))
+}
@@ -0,0 +1,133 @@
+package cluster
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
+)
+
+// defaultSessionTTL is the lease TTL of named sessions in seconds.
+var defaultSessionTTL = 10
+
+// namedSession is a concurrency session owned by a simulated client.
+type namedSession struct {
+	name string
+	idx  int
+	cli  *clientv3.Client
+	sess *concurrency.Session
+}
+
+// sessions holds named concurrency sessions shared by lock and election demos.
+type sessions struct {
+	mu     sync.Mutex
+	byName map[string]*namedSession
+	byID   map[clientv3.LeaseID]string
+}
+
+// handleKey identifies a lock or election handle of a session.
+func handleKey(session, name string) string {
+	return session + "/" + name
+}
+
+// session returns the named session, creating one on node 'i' if none exists.
+func (clus *Cluster) session(i int, name string) (*namedSession, error) {
+	if name == "" {
+		return nil, fmt.Errorf("session name is empty")
+	}
+
+	clus.sessions.mu.Lock()
+	defer clus.sessions.mu.Unlock()
+
+	if s, ok := clus.sessions.byName[name]; ok {
+		select {
+		case <-s.sess.Done():
+			// lease expired (e.g. node stopped); create a new one
+			delete(clus.sessions.byID, s.sess.Lease())
+			clus.dropHandles(name)
+			s.cli.Close()
+		default:
+			return s, nil
+		}
+	}
+
+	cli, _, err := clus.Members[i].Client(clus.rootCtx, false)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := concurrency.NewSession(cli, concurrency.WithTTL(defaultSessionTTL), concurrency.WithContext(clus.rootCtx))
+	if err != nil {
+		cli.Close()
+		return nil, err
+	}
+	s := &namedSession{name: name, idx: i, cli: cli, sess: sess}
+	clus.sessions.byName[name] = s
+	clus.sessions.byID[sess.Lease()] = name
+	return s, nil
+}
+
+// sessionName returns the session name that owns the lease.
+func (clus *Cluster) sessionName(id clientv3.LeaseID) string {
+	clus.sessions.mu.Lock()
+	defer clus.sessions.mu.Unlock()
+	if name, ok := clus.sessions.byID[id]; ok {
+		return name
+	}
+	return leaseIDString(id)
+}
+
+// CloseSession closes the named session, releasing all locks and
+// resigning all elections held by it.
+func (clus *Cluster) CloseSession(name string) error {
+	clus.sessions.mu.Lock()
+	s, ok := clus.sessions.byName[name]
+	delete(clus.sessions.byName, name)
+	if ok {
+		delete(clus.sessions.byID, s.sess.Lease())
+	}
+	clus.sessions.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("session %q not found", name)
+	}
+
+	err := s.sess.Close()
+	clus.dropHandles(name)
+	s.cli.Close()
+	return err
+}
+
+// dropHandles cancels and forgets the lock and election handles of the
+// session, whose keys are deleted with its lease, so that the session
+// name can acquire the same locks and campaign again.
+func (clus *Cluster) dropHandles(session string) {
+	clus.locks.mu.Lock()
+	for hk, h := range clus.locks.handles {
+		if h.session == session {
+			h.cancel()
+			delete(clus.locks.handles, hk)
+		}
+	}
+	clus.locks.mu.Unlock()
+
+	clus.elections.mu.Lock()
+	for hk, h := range clus.elections.handles {
+		if h.session == session {
+			h.cancel()
+			delete(clus.elections.handles, hk)
+		}
+	}
+	clus.elections.mu.Unlock()
+}
+
+// SessionNames returns the names of open sessions.
+func (clus *Cluster) SessionNames() []string {
+	clus.sessions.mu.Lock()
+	defer clus.sessions.mu.Unlock()
+
+	ns := make([]string, 0, len(clus.sessions.byName))
+	for name := range clus.sessions.byName {
+		ns = append(ns, name)
+	}
+	return ns
+}
@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCluster_CloseSession_reacquire(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "cluster-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCtx, rootCancel := context.WithCancel(context.Background())
+	defer rootCancel()
+	cfg := Config{
+		Size:       1,
+		RootDir:    dir,
+		RootPort:   int(atomic.AddUint32(&basePort, 10)),
+		RootCtx:    rootCtx,
+		RootCancel: rootCancel,
+	}
+	c, err := Start(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err = c.WaitLeader(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	waitHolder := func(holder string) {
+		for {
+			st, err := c.LockState(ctx, 0, "l")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if st.Holder == holder {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				t.Fatalf("expected holder %q, got %+v", holder, st)
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+	}
+
+	if err = c.LockAcquire(0, "s1", "l"); err != nil {
+		t.Fatal(err)
+	}
+	waitHolder("s1")
+
+	if err = c.CloseSession("s1"); err != nil {
+		t.Fatal(err)
+	}
+	waitHolder("")
+
+	if err = c.LockAcquire(0, "s1", "l"); err != nil {
+		t.Fatalf("expected lock acquired again after closing the session, got %v", err)
+	}
+	waitHolder("s1")
+}
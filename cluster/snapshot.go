@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/coreos/etcdlabs/pkg/snapshot"
+
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// SaveSnapshot saves a snapshot of the backend of the node to the file,
+// as 'etcdctl snapshot save', and returns its size in bytes.
+func (clus *Cluster) SaveSnapshot(ctx context.Context, i int, path string) (int64, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return 0, err
+	}
+	n, err := saveSnapshot(ctx, m, path)
+	if err != nil {
+		return 0, err
+	}
+	clus.recordEvent("snapshot-save", m.cfg.Name, "saved a snapshot of %q to %q (%d bytes)", m.cfg.Name, path, n)
+	return n, nil
+}
+
+func saveSnapshot(ctx context.Context, m *Member, path string) (int64, error) {
+	cli, err := m.sharedClient()
+	if err != nil {
+		return 0, err
+	}
+	rc, err := cli.Snapshot(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	// write and rename, so the file is always a complete snapshot
+	tmp := path + ".part"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, rc)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return n, nil
+}
+
+// BackendStats returns the page statistics of the backend database of the
+// node. The database of a started node is read from a snapshot, and the one
+// of a stopped node from a copy of its file, since bolt locks the file.
+func (clus *Cluster) BackendStats(ctx context.Context, i int) (snapshot.BackendStats, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return snapshot.BackendStats{}, err
+	}
+	return backendStats(ctx, m)
+}
+
+// BackendStatsByName is BackendStats of the node with the name.
+func (clus *Cluster) BackendStatsByName(ctx context.Context, name string) (snapshot.BackendStats, error) {
+	m, err := clus.memberByName(name)
+	if err != nil {
+		return snapshot.BackendStats{}, err
+	}
+	return backendStats(ctx, m)
+}
+
+func backendStats(ctx context.Context, m *Member) (snapshot.BackendStats, error) {
+	f, err := ioutil.TempFile("", "etcdlabs-backend")
+	if err != nil {
+		return snapshot.BackendStats{}, err
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	if m.stopped() {
+		err = copyFile(filepath.Join(m.cfg.Dir, "member", "snap", "db"), path)
+	} else {
+		_, err = saveSnapshot(ctx, m, path)
+	}
+	if err != nil {
+		return snapshot.BackendStats{}, err
+	}
+	return snapshot.ReadBackendStats(path)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// CompareSnapshot compares the keyspace of the snapshot file with the
+// live keyspace, read from any started node: keys added since the
+// snapshot are in Added, and keys missing from the cluster in Removed.
+func (clus *Cluster) CompareSnapshot(ctx context.Context, path string) (snapshot.Diff, error) {
+	ks, err := snapshot.ReadKeyspace(path)
+	if err != nil {
+		return snapshot.Diff{}, err
+	}
+	idx, err := clus.startedNode()
+	if err != nil {
+		return snapshot.Diff{}, err
+	}
+	cli, err := clus.SharedClient(idx)
+	if err != nil {
+		return snapshot.Diff{}, err
+	}
+	live := make(map[string]string)
+	_, err = rangePages(ctx, cli, "", func(kvs []*mvccpb.KeyValue) error {
+		for _, kv := range kvs {
+			live[string(kv.Key)] = string(kv.Value)
+		}
+		return nil
+	})
+	if err != nil {
+		return snapshot.Diff{}, err
+	}
+	return snapshot.Compare(ks.KVs, live), nil
+}
@@ -0,0 +1,281 @@
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// sshHost returns the host of the SSH destination of the node in ModeSSH.
+func (c Config) sshHost(name string) (string, bool) {
+	if c.Mode != ModeSSH {
+		return "", false
+	}
+	dest, ok := c.SSHHosts[name]
+	if !ok {
+		return "", false
+	}
+	return dest[strings.LastIndex(dest, "@")+1:], true
+}
+
+// nodeClientHost returns the client host of the node,
+// which is its SSH host in ModeSSH.
+func (c Config) nodeClientHost(name string) string {
+	if h, ok := c.sshHost(name); ok {
+		return h
+	}
+	return c.clientHost()
+}
+
+// nodePeerHost returns the peer host of the node,
+// which is its SSH host in ModeSSH.
+func (c Config) nodePeerHost(name string) string {
+	if h, ok := c.sshHost(name); ok {
+		return h
+	}
+	return c.peerHost()
+}
+
+// validateSSH checks that every node has a host in ModeSSH, and that no
+// option needs local files or ports on the hosts.
+func (c Config) validateSSH() error {
+	if c.Mode != ModeSSH {
+		return nil
+	}
+	for i := 1; i <= c.Size; i++ {
+		if _, ok := c.SSHHosts[c.nodeName(i)]; !ok {
+			return fmt.Errorf("no SSH host for %q", c.nodeName(i))
+		}
+	}
+	if c.GenerateCerts || !c.ClientTLSInfo.Empty() || !c.PeerTLSInfo.Empty() || len(c.NodeTLS) > 0 {
+		return fmt.Errorf("certificate files are not copied to SSH hosts; use auto TLS in %s mode", ModeSSH)
+	}
+	if c.MetricsRootPort > 0 {
+		return fmt.Errorf("metrics ports cannot be used in %s mode", ModeSSH)
+	}
+	return nil
+}
+
+// remoteNode runs an etcd binary on a remote host as a systemd unit,
+// managed over SSH with the system 'ssh' client. The binary is uploaded
+// next to the data directory, which has the same path as it would have
+// locally.
+type remoteNode struct {
+	name    string
+	dest    string
+	dataDir string
+	binDir  string
+	unit    string
+	sshArgs []string
+	sudo    bool
+	logf    func(line string)
+
+	mu       sync.Mutex
+	binary   string // local binary
+	uploaded string // local binary on the host, if any
+	fresh    bool   // data not yet removed from the host
+	journal  *exec.Cmd
+}
+
+func newRemoteNode(name, dest, binary, rootDir, dataDir string, c Config, logf func(string)) *remoteNode {
+	args := []string{"-o", "BatchMode=yes"}
+	if c.SSHIdentityFile != "" {
+		args = append(args, "-i", c.SSHIdentityFile)
+	}
+	return &remoteNode{
+		name:    name,
+		dest:    dest,
+		dataDir: dataDir,
+		binDir:  path.Join(rootDir, "bin"),
+		unit:    "etcdlabs-" + name + ".service",
+		sshArgs: args,
+		sudo:    c.SSHSudo,
+		logf:    logf,
+		binary:  binary,
+		fresh:   true,
+	}
+}
+
+// SetBinary sets the local etcd binary uploaded and run on next start.
+func (r *remoteNode) SetBinary(binary string) {
+	r.mu.Lock()
+	r.binary = binary
+	r.mu.Unlock()
+}
+
+// Start implements externalNode. It uploads the binary if it changed,
+// removes the data of a previous cluster on first start, and (re)writes
+// and starts the systemd unit.
+func (r *remoteNode) Start(flags []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remoteBin := path.Join(r.binDir, r.name+"-etcd")
+	if r.uploaded != r.binary {
+		if err := r.upload(r.binary, remoteBin); err != nil {
+			return err
+		}
+		r.uploaded = r.binary
+	}
+	if r.fresh {
+		if err := r.run(nil, r.sudoCmd("rm -rf "+shellQuote(r.dataDir))); err != nil {
+			return err
+		}
+		r.fresh = false
+	}
+
+	unit := fmt.Sprintf(`[Unit]
+Description=etcdlabs member %s
+
+[Service]
+Type=notify
+ExecStart=%s
+Restart=no
+TimeoutStopSec=%d
+LimitNOFILE=65536
+`, r.name, systemdCommand(append([]string{remoteBin}, flags...)), int(processStopTimeout/time.Second))
+	script := r.sudoCmd("tee "+shellQuote("/etc/systemd/system/"+r.unit)) + " >/dev/null && " + r.sudoCmd("systemctl daemon-reload")
+	if err := r.run(strings.NewReader(unit), script); err != nil {
+		return err
+	}
+
+	// follows the journal before starting, so no line is missed
+	r.stopJournal()
+	journal := r.command(r.sudoCmd("journalctl -f -n 0 -o cat -u " + shellQuote(r.unit)))
+	out, err := journal.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = journal.Start(); err != nil {
+		return err
+	}
+	go func() {
+		sc := bufio.NewScanner(out)
+		for sc.Scan() {
+			r.logf(sc.Text())
+		}
+		journal.Wait()
+	}()
+	r.journal = journal
+
+	// 'systemctl start' of a notify unit waits until etcd is ready,
+	// which blocks when quorum is lost
+	if err = r.run(nil, r.sudoCmd("systemctl start --no-block "+shellQuote(r.unit))); err != nil {
+		r.stopJournal()
+		return err
+	}
+	glog.Infof("started %q on %q: %s %v", r.unit, r.dest, remoteBin, flags)
+	return nil
+}
+
+// Stop implements externalNode. systemd sends SIGTERM,
+// and SIGKILL if etcd does not exit in time.
+func (r *remoteNode) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer r.stopJournal()
+	return r.run(nil, r.sudoCmd("systemctl stop "+shellQuote(r.unit)))
+}
+
+// Kill implements externalNode. It sends SIGKILL, and waits until
+// the unit is inactive.
+func (r *remoteNode) Kill() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer r.stopJournal()
+	if err := r.run(nil, r.sudoCmd("systemctl kill --signal=SIGKILL "+shellQuote(r.unit))); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(processStopTimeout)
+	for r.active() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%q on %q did not exit after SIGKILL", r.unit, r.dest)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil
+}
+
+// Running implements externalNode.
+func (r *remoteNode) Running() bool {
+	return r.active()
+}
+
+func (r *remoteNode) active() bool {
+	return r.run(nil, r.sudoCmd("systemctl is-active --quiet "+shellQuote(r.unit))) == nil
+}
+
+func (r *remoteNode) stopJournal() {
+	if r.journal != nil && r.journal.Process != nil {
+		r.journal.Process.Kill()
+	}
+	r.journal = nil
+}
+
+// upload copies the local binary to the host, replacing the remote file
+// only once it is complete.
+func (r *remoteNode) upload(local, remote string) error {
+	if lp, err := exec.LookPath(local); err == nil {
+		local = lp
+	}
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	glog.Infof("uploading %q to %s:%s", local, r.dest, remote)
+	part := shellQuote(remote + ".part")
+	script := fmt.Sprintf("mkdir -p %s && cat > %s && chmod +x %s && mv %s %s", shellQuote(r.binDir), part, part, part, shellQuote(remote))
+	return r.run(f, script)
+}
+
+// run runs the shell script on the host.
+func (r *remoteNode) run(stdin io.Reader, script string) error {
+	cmd := r.command(script)
+	cmd.Stdin = stdin
+	var buf bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &buf, &buf
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ssh %s %q failed (%v: %s)", r.dest, script, err, strings.TrimSpace(buf.String()))
+	}
+	return nil
+}
+
+// command returns the command that runs the shell script on the host.
+func (r *remoteNode) command(script string) *exec.Cmd {
+	args := append(append([]string{}, r.sshArgs...), r.dest, script)
+	return exec.Command("ssh", args...)
+}
+
+func (r *remoteNode) sudoCmd(cmd string) string {
+	if r.sudo {
+		return "sudo -n " + cmd
+	}
+	return cmd
+}
+
+// shellQuote quotes the string for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// systemdCommand returns the command line of ExecStart, quoting the
+// arguments and escaping '%' specifiers.
+func systemdCommand(args []string) string {
+	qs := make([]string, len(args))
+	for i, a := range args {
+		qs[i] = strings.Replace(strconv.Quote(a), "%", "%%", -1)
+	}
+	return strings.Join(qs, " ")
+}
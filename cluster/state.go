@@ -0,0 +1,145 @@
+package cluster
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+)
+
+// NodeState is the configuration and status of a node (see State).
+type NodeState struct {
+	Name    string
+	DataDir string
+	// Version is the etcd version of the node, empty in embedded mode.
+	Version string
+
+	ClientURLs          []string
+	AdvertiseClientURLs []string
+	PeerURLs            []string
+	AdvertisePeerURLs   []string
+	// MetricsURL is empty if the node serves metrics on its client URLs.
+	MetricsURL string
+
+	HeartbeatIntervalMs     uint
+	ElectionTimeoutMs       uint
+	SnapshotCount           uint64
+	QuotaBackendBytes       int64
+	AutoCompactionMode      string
+	AutoCompactionRetention int
+
+	// StoppedStartedAt is the time of the last stop or (re)start.
+	StoppedStartedAt time.Time
+	Status           clusterpb.MemberStatus
+}
+
+// State is the whole state of the cluster in one document. Nodes are
+// in index order, and leader changes and events oldest first, so the
+// document only changes when the cluster does.
+type State struct {
+	Started time.Time
+	Mode    string
+	Seed    int64
+
+	Size   int
+	Quorum int
+	Active int
+	// Leader is the name of the leader node, empty if there is none.
+	Leader string
+	// Endpoints are the client endpoints of the nodes.
+	Endpoints []string
+	// GRPCProxyEndpoint is the plaintext endpoint of the gRPC proxy,
+	// empty if it is disabled.
+	GRPCProxyEndpoint string
+
+	Nodes         []NodeState
+	Tolerance     FailureTolerance
+	Connectivity  Connectivity
+	LeaderHistory []LeaderChange
+	Events        []Event
+}
+
+// State returns the configuration and status of the cluster and its nodes,
+// as of the last status update, with all recorded events.
+func (clus *Cluster) State() State {
+	st := State{
+		Started:           clus.Started,
+		Mode:              clus.ccfg.Mode,
+		Seed:              clus.Seed(),
+		Endpoints:         clus.AllEndpoints(true),
+		GRPCProxyEndpoint: clus.GRPCProxyEndpoint(),
+		Tolerance:         clus.FailureTolerance(),
+		Connectivity:      clus.Connectivity(),
+		LeaderHistory:     clus.LeaderHistory(),
+		Events:            clus.Events(0),
+	}
+
+	clus.mmu.RLock()
+	st.Size = len(clus.Members)
+	st.Quorum = len(clus.Members)/2 + 1
+	st.Nodes = make([]NodeState, 0, len(clus.Members))
+	for _, m := range clus.Members {
+		ns := m.state()
+		if ns.Status.State != clusterpb.StoppedMemberStatus {
+			st.Active++
+		}
+		if ns.Status.IsLeader {
+			st.Leader = ns.Name
+		}
+		st.Nodes = append(st.Nodes, ns)
+	}
+	clus.mmu.RUnlock()
+
+	// encode empty lists as '[]', not 'null'
+	if st.Endpoints == nil {
+		st.Endpoints = []string{}
+	}
+	if st.LeaderHistory == nil {
+		st.LeaderHistory = []LeaderChange{}
+	}
+	if st.Events == nil {
+		st.Events = []Event{}
+	}
+	return st
+}
+
+// MarshalJSON implements json.Marshaler, encoding the cluster State.
+func (clus *Cluster) MarshalJSON() ([]byte, error) {
+	return json.Marshal(clus.State())
+}
+
+func (m *Member) state() NodeState {
+	ns := NodeState{
+		Name:                    m.cfg.Name,
+		DataDir:                 m.cfg.Dir,
+		Version:                 m.version,
+		ClientURLs:              urlStrings(m.cfg.LCUrls),
+		AdvertiseClientURLs:     urlStrings(m.cfg.ACUrls),
+		PeerURLs:                urlStrings(m.cfg.LPUrls),
+		AdvertisePeerURLs:       urlStrings(m.cfg.APUrls),
+		HeartbeatIntervalMs:     m.cfg.TickMs,
+		ElectionTimeoutMs:       m.cfg.ElectionMs,
+		SnapshotCount:           m.cfg.SnapCount,
+		QuotaBackendBytes:       m.cfg.QuotaBackendBytes,
+		AutoCompactionMode:      m.cfg.AutoCompactionMode,
+		AutoCompactionRetention: m.cfg.AutoCompactionRetention,
+	}
+	if m.metricsURL.Host != "" {
+		ns.MetricsURL = m.metricsURL.String()
+	}
+
+	m.statusLock.RLock()
+	ns.StoppedStartedAt = m.stoppedStartedAt
+	ns.Status = m.status
+	m.statusLock.RUnlock()
+	return ns
+}
+
+func urlStrings(us []url.URL) []string {
+	ss := make([]string, len(us))
+	for i := range us {
+		ss[i] = us[i].String()
+	}
+	return ss
+}
@@ -0,0 +1,119 @@
+package cluster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+var defaultStatusWorkers = 8
+
+// workerPool runs jobs on a fixed set of goroutines,
+// instead of starting goroutines for every status update.
+type workerPool struct {
+	jobs  chan func()
+	stopc chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newWorkerPool(n int) *workerPool {
+	if n <= 0 {
+		n = defaultStatusWorkers
+	}
+	p := &workerPool{jobs: make(chan func()), stopc: make(chan struct{})}
+	p.wg.Add(n)
+	for i := 0; i < n; i++ {
+		go p.run()
+	}
+	return p
+}
+
+func (p *workerPool) run() {
+	defer p.wg.Done()
+	for {
+		select {
+		case f := <-p.jobs:
+			f()
+		case <-p.stopc:
+			return
+		}
+	}
+}
+
+// submit runs 'f' on a worker, blocking until one is free.
+// It returns false if the pool is stopped.
+func (p *workerPool) submit(f func()) bool {
+	select {
+	case p.jobs <- f:
+		return true
+	case <-p.stopc:
+		return false
+	}
+}
+
+// stop stops the workers and waits for running jobs.
+func (p *workerPool) stop() {
+	close(p.stopc)
+	p.wg.Wait()
+}
+
+// StatusErrors counts the failed status updates of a node.
+type StatusErrors struct {
+	// Consecutive is the number of failures since the last success.
+	Consecutive int
+	Total       int
+	LastError   string
+	LastTime    time.Time
+}
+
+// recordStatusErr accounts the result of a status update,
+// logging only when the node starts or stops failing.
+func (m *Member) recordStatusErr(err error) {
+	m.statusErrMu.Lock()
+	defer m.statusErrMu.Unlock()
+
+	if err == nil {
+		if m.statusErrs.Consecutive > 0 {
+			glog.Infof("status of %q recovered after %d failures", m.cfg.Name, m.statusErrs.Consecutive)
+		}
+		m.statusErrs.Consecutive = 0
+		return
+	}
+	if m.statusErrs.Consecutive == 0 {
+		glog.Warningf("failed to fetch status of %q (%v)", m.cfg.Name, err)
+	}
+	m.statusErrs.Consecutive++
+	m.statusErrs.Total++
+	m.statusErrs.LastError = err.Error()
+	m.statusErrs.LastTime = time.Now()
+}
+
+// StatusErrors returns the status update failures of node 'i'.
+func (clus *Cluster) StatusErrors(i int) StatusErrors {
+	clus.mmu.RLock()
+	m := clus.Members[i]
+	clus.mmu.RUnlock()
+
+	m.statusErrMu.Lock()
+	defer m.statusErrMu.Unlock()
+	return m.statusErrs
+}
+
+// fetchStatus updates the node status, recovering from panics.
+func (m *Member) fetchStatus() {
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered from panic (%v)", r)
+			select {
+			case <-m.clus.rootCtx.Done():
+				glog.Warning("rootCtx is done with", m.clus.rootCtx.Err())
+			default:
+			}
+		}
+		m.recordStatusErr(err)
+	}()
+	err = m.FetchMemberStatus()
+}
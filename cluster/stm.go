@@ -0,0 +1,139 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/clientv3/concurrency"
+)
+
+// STMRequest configures a read-modify-write contention run.
+type STMRequest struct {
+	// Key is the counter key incremented by every writer.
+	Key string
+	// Writers is the number of concurrent writers.
+	Writers int
+	// Iterations is the number of increments per writer.
+	Iterations int
+	// Isolation is one of "SERIALIZABLE_SNAPSHOT" (default),
+	// "SERIALIZABLE", "REPEATABLE_READS" and "READ_COMMITTED".
+	Isolation string
+}
+
+// STMResponse is the result of a contention run.
+type STMResponse struct {
+	// InitialValue and FinalValue are the counter values before and after the run.
+	InitialValue int
+	FinalValue   int
+	// Expected is the final value if no update was lost.
+	Expected int
+
+	// Attempts is the total number of transaction attempts.
+	Attempts int
+	// Retries is the number of attempts that conflicted and were retried.
+	Retries int
+	// WriterRetries is the number of retries per writer.
+	WriterRetries []int
+
+	Took time.Duration
+}
+
+var stmIsolations = map[string]concurrency.Isolation{
+	"":                      concurrency.SerializableSnapshot,
+	"SERIALIZABLE_SNAPSHOT": concurrency.SerializableSnapshot,
+	"SERIALIZABLE":          concurrency.Serializable,
+	"REPEATABLE_READS":      concurrency.RepeatableReads,
+	"READ_COMMITTED":        concurrency.ReadCommitted,
+}
+
+// RunSTM increments the counter key with 'Writers' concurrent clients on node 'i',
+// each running 'Iterations' software transactions, and reports the conflicts.
+func (clus *Cluster) RunSTM(ctx context.Context, i int, sr STMRequest) (resp STMResponse, err error) {
+	iso, ok := stmIsolations[strings.ToUpper(sr.Isolation)]
+	if !ok {
+		return resp, fmt.Errorf("unknown isolation level %q", sr.Isolation)
+	}
+	if sr.Key == "" {
+		return resp, fmt.Errorf("STM key is empty")
+	}
+	if sr.Writers < 1 || sr.Iterations < 1 {
+		return resp, fmt.Errorf("writers and iterations must be positive (got %d, %d)", sr.Writers, sr.Iterations)
+	}
+
+	cli, err := clus.SharedClient(i)
+	if err != nil {
+		return resp, err
+	}
+
+	readCounter := func() (int, error) {
+		gresp, err := cli.Get(ctx, sr.Key)
+		if err != nil {
+			return 0, err
+		}
+		if len(gresp.Kvs) == 0 {
+			return 0, nil
+		}
+		return strconv.Atoi(string(gresp.Kvs[0].Value))
+	}
+	if resp.InitialValue, err = readCounter(); err != nil {
+		return resp, fmt.Errorf("key %q does not hold a counter (%v)", sr.Key, err)
+	}
+	resp.Expected = resp.InitialValue + sr.Writers*sr.Iterations
+	resp.WriterRetries = make([]int, sr.Writers)
+
+	now := time.Now()
+	var (
+		wg    sync.WaitGroup
+		errMu sync.Mutex
+	)
+	wg.Add(sr.Writers)
+	for w := 0; w < sr.Writers; w++ {
+		go func(w int) {
+			defer wg.Done()
+
+			wcli, _, werr := clus.Members[i].Client(ctx, false)
+			if werr == nil {
+				defer wcli.Close()
+				for n := 0; n < sr.Iterations && werr == nil; n++ {
+					attempts := 0
+					_, werr = concurrency.NewSTM(wcli, func(stm concurrency.STM) error {
+						attempts++
+						v := 0
+						if s := stm.Get(sr.Key); s != "" {
+							var aerr error
+							if v, aerr = strconv.Atoi(s); aerr != nil {
+								return aerr
+							}
+						}
+						stm.Put(sr.Key, strconv.Itoa(v+1))
+						return nil
+					}, concurrency.WithIsolation(iso), concurrency.WithAbortContext(ctx))
+					resp.WriterRetries[w] += attempts - 1
+				}
+			}
+			if werr != nil {
+				errMu.Lock()
+				err = werr
+				errMu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+	resp.Took = time.Since(now)
+	if err != nil {
+		return resp, err
+	}
+
+	for _, r := range resp.WriterRetries {
+		resp.Retries += r
+	}
+	resp.Attempts = sr.Writers*sr.Iterations + resp.Retries
+	if resp.FinalValue, err = readCounter(); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
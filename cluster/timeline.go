@@ -0,0 +1,210 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+	"github.com/coreos/etcdlabs/pkg/peertrace"
+
+	"github.com/coreos/etcd/clientv3"
+)
+
+// Stages of a write in a WriteTimeline.
+const (
+	// StageProposed is when the client sent the write to the node.
+	StageProposed = "proposed"
+	// StageForwarded is when a follower forwarded the proposal to the leader.
+	StageForwarded = "forwarded"
+	// StageAppended is when the leader sent the entry to a follower.
+	StageAppended = "appended"
+	// StageAcked is when a follower acknowledged the entry to the leader.
+	StageAcked = "acked"
+	// StageCommitted is when a quorum of the members had the entry.
+	StageCommitted = "committed"
+	// StageApplied is when the node applied the entry, as seen by a watch on it.
+	StageApplied = "applied"
+	// StageResponded is when the client got the response.
+	StageResponded = "responded"
+)
+
+// TimelineStage is a step of a write on a node.
+type TimelineStage struct {
+	Stage string
+	Node  string
+	// Offset is the time since the write was sent.
+	Offset time.Duration
+}
+
+// WriteTimeline shows where the time goes in a consensus write.
+// The forwarded, appended, acked and committed stages come from
+// the peer proxies, so they are only set if they are enabled
+// (see Config.PeerProxyRootPort).
+type WriteTimeline struct {
+	Key      string
+	Revision int64
+	// Node is the node the write was issued through.
+	Node   string
+	Leader string
+
+	Started time.Time
+	Took    time.Duration
+
+	// Stages are ordered by offset.
+	Stages []TimelineStage
+}
+
+var timelineApplyTimeout = 5 * time.Second
+
+// TracePut writes a key-value pair through the node, and returns the
+// timeline of the write from the proposal to the apply on each member.
+func (clus *Cluster) TracePut(ctx context.Context, i int, key, val string) (WriteTimeline, error) {
+	tctx, sp := clus.startSpan(ctx, "cluster.TracePut")
+
+	clus.mmu.RLock()
+	ms := append([]*Member(nil), clus.Members...)
+	clus.mmu.RUnlock()
+
+	tl := WriteTimeline{Key: key, Node: ms[i].cfg.Name}
+
+	// watch the key on each running member, so that the
+	// events tell when each member applied the write
+	wctx, wcancel := context.WithCancel(tctx)
+	defer wcancel()
+	type applied struct {
+		node string
+		rev  int64
+		at   time.Time
+	}
+	appliedc := make(chan applied, len(ms)*4)
+	watched := 0
+	for _, m := range ms {
+		st := m.statusCopy()
+		if st.State == clusterpb.StoppedMemberStatus {
+			continue
+		}
+		if st.IsLeader {
+			tl.Leader = m.cfg.Name
+		}
+		cli, err := m.sharedClient()
+		if err != nil {
+			sp.end(err)
+			return tl, err
+		}
+		wch := cli.Watch(wctx, key, clientv3.WithCreatedNotify())
+		if wresp, ok := <-wch; !ok || !wresp.Created {
+			err = fmt.Errorf("failed to watch %q on %q", key, m.cfg.Name)
+			sp.end(err)
+			return tl, err
+		}
+		watched++
+		go func(name string, wch clientv3.WatchChan) {
+			for wresp := range wch {
+				for _, ev := range wresp.Events {
+					select {
+					case appliedc <- applied{node: name, rev: ev.Kv.ModRevision, at: time.Now()}:
+					case <-wctx.Done():
+						return
+					}
+				}
+			}
+		}(m.cfg.Name, wch)
+	}
+
+	tl.Started = time.Now()
+	resp, err := clus.Put(tctx, i, key, val)
+	if err != nil {
+		sp.end(err)
+		return tl, err
+	}
+	tl.Took, tl.Revision = resp.Took, resp.Header.Revision
+	tl.Stages = append(tl.Stages,
+		TimelineStage{Stage: StageProposed, Node: tl.Node},
+		TimelineStage{Stage: StageResponded, Node: tl.Node, Offset: tl.Took},
+	)
+
+	timeout := time.After(timelineApplyTimeout)
+	for seen := 0; seen < watched; {
+		select {
+		case a := <-appliedc:
+			if a.rev != tl.Revision {
+				continue
+			}
+			seen++
+			tl.Stages = append(tl.Stages, TimelineStage{Stage: StageApplied, Node: a.node, Offset: a.at.Sub(tl.Started)})
+		case <-timeout:
+			seen = watched
+		case <-tctx.Done():
+			sp.end(tctx.Err())
+			return tl, tctx.Err()
+		}
+	}
+
+	if clus.peerTracer != nil {
+		pt, err := clus.PeerTraffic()
+		if err == nil {
+			tl.Stages = append(tl.Stages, raftStages(pt.Samples, tl, len(ms)/2+1)...)
+		}
+	}
+	sort.SliceStable(tl.Stages, func(a, b int) bool { return tl.Stages[a].Offset < tl.Stages[b].Offset })
+
+	sp.setAttribute("key", key)
+	sp.setAttribute("node", tl.Node)
+	sp.setAttribute("leader", tl.Leader)
+	for _, st := range tl.Stages {
+		sp.setAttribute(st.Stage+"."+st.Node, st.Offset.String())
+	}
+	sp.end(nil)
+	return tl, nil
+}
+
+// raftStages returns the stages of the write seen in the raft messages
+// traced during the write. The entry is the first one the leader sent
+// after the write started.
+func raftStages(samples []peertrace.Sample, tl WriteTimeline, quorum int) (sts []TimelineStage) {
+	end := tl.Started.Add(tl.Took)
+	var (
+		index uint64
+		acks  []time.Duration
+		acked = make(map[string]bool)
+		sent  = make(map[string]bool)
+	)
+	for _, s := range samples {
+		if s.Time.Before(tl.Started) || s.Time.After(end) {
+			continue
+		}
+		off := s.Time.Sub(tl.Started)
+		switch s.Type {
+		case "MsgProp":
+			if s.From == tl.Node && s.To == tl.Leader && index == 0 {
+				sts = append(sts, TimelineStage{Stage: StageForwarded, Node: s.From, Offset: off})
+			}
+		case "MsgApp":
+			if s.From != tl.Leader || s.Entries == 0 {
+				continue
+			}
+			if index == 0 {
+				index = s.Index + uint64(s.Entries)
+			}
+			if s.Index < index && index <= s.Index+uint64(s.Entries) && !sent[s.To] {
+				sent[s.To] = true
+				sts = append(sts, TimelineStage{Stage: StageAppended, Node: s.To, Offset: off})
+			}
+		case "MsgAppResp":
+			if index == 0 || s.To != tl.Leader || s.Index < index || acked[s.From] {
+				continue
+			}
+			acked[s.From] = true
+			acks = append(acks, off)
+			sts = append(sts, TimelineStage{Stage: StageAcked, Node: s.From, Offset: off})
+		}
+	}
+	// the leader has the entry when it sends it,
+	// so a quorum needs 'quorum-1' acks
+	if quorum-1 <= len(acks) && quorum > 1 {
+		sts = append(sts, TimelineStage{Stage: StageCommitted, Node: tl.Leader, Offset: acks[quorum-2]})
+	}
+	return sts
+}
@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coreos/etcd/embed"
+	"github.com/golang/glog"
+)
+
+// minScaledStatusInterval bounds the status polling interval of
+// accelerated clusters, since each poll queries every member.
+var minScaledStatusInterval = 10 * time.Millisecond
+
+// scaleDuration scales the duration, to no less than 'min'.
+func scaleDuration(d time.Duration, scale float64, min time.Duration) time.Duration {
+	d = time.Duration(float64(d) * scale)
+	if d < min {
+		d = min
+	}
+	return d
+}
+
+// applyTimeScale scales the raft timing, the snapshot count, the status
+// polling interval and the fault delays by Config.TimeScale, starting from
+// etcd defaults if they are not set.
+func (c *Config) applyTimeScale() error {
+	if c.TimeScale < 0 {
+		return fmt.Errorf("time scale cannot be negative, got %v", c.TimeScale)
+	}
+	if c.TimeScale == 0 || c.TimeScale == 1 || c.timeScaled {
+		return nil
+	}
+	s := c.TimeScale
+	glog.Infof("scaling cluster timing by %v", s)
+
+	def := embed.NewConfig()
+	hb, et := c.HeartbeatInterval, c.ElectionTimeout
+	if hb == 0 {
+		hb = time.Duration(def.TickMs) * time.Millisecond
+	}
+	if et == 0 {
+		et = time.Duration(def.ElectionMs) * time.Millisecond
+	}
+	c.HeartbeatInterval = scaleDuration(hb, s, time.Millisecond)
+	// keeps the election timeout valid when the heartbeat is at its minimum
+	c.ElectionTimeout = scaleDuration(et, s, 5*c.HeartbeatInterval)
+
+	snap := c.SnapshotCount
+	if snap == 0 {
+		snap = def.SnapCount
+	}
+	if c.SnapshotCount = uint64(float64(snap) * s); c.SnapshotCount == 0 {
+		c.SnapshotCount = 1
+	}
+
+	si := c.StatusInterval
+	if si == 0 {
+		si = defaultStatusInterval
+	}
+	c.StatusInterval = scaleDuration(si, s, minScaledStatusInterval)
+
+	fs := make([]Fault, len(c.Faults))
+	for i, f := range c.Faults {
+		f.After = scaleDuration(f.After, s, 0)
+		fs[i] = f
+	}
+	c.Faults = fs
+	c.timeScaled = true
+	return nil
+}
+
+// TimeScale returns the factor that scales the cluster timing
+// (see Config.TimeScale), 1 if it is not scaled.
+func (clus *Cluster) TimeScale() float64 {
+	if clus.ccfg.TimeScale == 0 {
+		return 1
+	}
+	return clus.ccfg.TimeScale
+}
@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"github.com/coreos/etcd/embed"
+	"github.com/coreos/etcd/pkg/transport"
+)
+
+// NodeTLS overrides the cluster-wide TLS settings of a single node,
+// to reproduce misconfigured deployments (e.g. one node serving
+// clients over HTTPS while the others serve plain HTTP).
+type NodeTLS struct {
+	// ClientInsecure serves clients over plain HTTP.
+	ClientInsecure bool
+	ClientTLSInfo  transport.TLSInfo
+	ClientAutoTLS  bool
+
+	// PeerInsecure talks to peers over plain HTTP.
+	PeerInsecure bool
+	PeerTLSInfo  transport.TLSInfo
+	PeerAutoTLS  bool
+}
+
+func (o NodeTLS) clientScheme(def string) string {
+	switch {
+	case o.ClientInsecure:
+		return "http"
+	case !o.ClientTLSInfo.Empty() || o.ClientAutoTLS:
+		return "https"
+	}
+	return def
+}
+
+func (o NodeTLS) peerScheme(def string) string {
+	switch {
+	case o.PeerInsecure:
+		return "http"
+	case !o.PeerTLSInfo.Empty() || o.PeerAutoTLS:
+		return "https"
+	}
+	return def
+}
+
+// nodeSchemes returns the client and peer schemes of the node.
+func (clus *Cluster) nodeSchemes(name string) (client, peer string) {
+	o := clus.ccfg.NodeTLS[name]
+	client, peer = o.clientScheme(clus.ccfg.ClientScheme()), o.peerScheme(clus.ccfg.PeerScheme())
+	if clus.ccfg.UnixSockets {
+		client, peer = unixScheme(client), unixScheme(peer)
+	}
+	return client, peer
+}
+
+// applyNodeTLS overwrites the TLS configuration of the node with its overrides, if any.
+func (clus *Cluster) applyNodeTLS(cfg *embed.Config) {
+	o, ok := clus.ccfg.NodeTLS[cfg.Name]
+	if !ok {
+		return
+	}
+
+	switch {
+	case o.ClientInsecure:
+		cfg.ClientTLSInfo, cfg.ClientAutoTLS = transport.TLSInfo{}, false
+	case !o.ClientTLSInfo.Empty():
+		cfg.ClientTLSInfo, cfg.ClientAutoTLS = o.ClientTLSInfo, false
+	case o.ClientAutoTLS:
+		cfg.ClientTLSInfo, cfg.ClientAutoTLS = transport.TLSInfo{}, true
+	}
+
+	switch {
+	case o.PeerInsecure:
+		cfg.PeerTLSInfo, cfg.PeerAutoTLS = transport.TLSInfo{}, false
+	case !o.PeerTLSInfo.Empty():
+		cfg.PeerTLSInfo, cfg.PeerAutoTLS = o.PeerTLSInfo, false
+	case o.PeerAutoTLS:
+		cfg.PeerTLSInfo, cfg.PeerAutoTLS = transport.TLSInfo{}, true
+	}
+}
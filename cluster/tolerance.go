@@ -0,0 +1,47 @@
+package cluster
+
+import "time"
+
+// FailureTolerance summarizes how many more node failures the cluster
+// can survive, as of the last status update.
+type FailureTolerance struct {
+	Members int
+	Healthy int
+	Quorum  int
+
+	// Tolerable is the number of healthy members that can still fail
+	// without losing quorum. It is negative when quorum is already lost.
+	Tolerable int
+
+	Updated time.Time
+}
+
+// recordTolerance updates the failure tolerance from member statuses.
+// Must be called with 'mmu' held.
+func (clus *Cluster) recordTolerance() {
+	ft := FailureTolerance{
+		Members: len(clus.Members),
+		Quorum:  len(clus.Members)/2 + 1,
+		Updated: time.Now(),
+	}
+	for _, m := range clus.Members {
+		m.statusLock.RLock()
+		if m.status.Healthy {
+			ft.Healthy++
+		}
+		m.statusLock.RUnlock()
+	}
+	ft.Tolerable = ft.Healthy - ft.Quorum
+
+	clus.toleranceMu.Lock()
+	clus.tolerance = ft
+	clus.toleranceMu.Unlock()
+}
+
+// FailureTolerance returns the healthy member count, the quorum size and
+// how many more failures can be tolerated, as of the last status update.
+func (clus *Cluster) FailureTolerance() FailureTolerance {
+	clus.toleranceMu.RLock()
+	defer clus.toleranceMu.RUnlock()
+	return clus.tolerance
+}
@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// SpanData is a finished span handed to the SpanExporter.
+// Field names follow the OpenTelemetry span model, so that
+// an exporter can translate it into OTLP without loss.
+type SpanData struct {
+	Name     string
+	TraceID  string
+	SpanID   string
+	ParentID string
+
+	Start time.Time
+	End   time.Time
+
+	Attributes map[string]string
+	Err        error
+}
+
+// Duration returns the span latency.
+func (sd SpanData) Duration() time.Duration {
+	return sd.End.Sub(sd.Start)
+}
+
+// SpanExporter exports finished spans (e.g. to an OpenTelemetry collector).
+type SpanExporter interface {
+	ExportSpan(SpanData)
+}
+
+// SpanExporterFunc adapts a function to SpanExporter.
+type SpanExporterFunc func(SpanData)
+
+// ExportSpan calls f(sd).
+func (f SpanExporterFunc) ExportSpan(sd SpanData) { f(sd) }
+
+// LogExporter exports spans to glog.
+var LogExporter = SpanExporterFunc(func(sd SpanData) {
+	if sd.Err != nil {
+		glog.Infof("span %q (trace %s, span %s, parent %s) took %v with error %v %v", sd.Name, sd.TraceID, sd.SpanID, sd.ParentID, sd.Duration(), sd.Err, sd.Attributes)
+		return
+	}
+	glog.Infof("span %q (trace %s, span %s, parent %s) took %v %v", sd.Name, sd.TraceID, sd.SpanID, sd.ParentID, sd.Duration(), sd.Attributes)
+})
+
+// span is an in-flight traced operation.
+// nil span is valid and does nothing.
+type span struct {
+	exp  SpanExporter
+	data SpanData
+}
+
+type spanKey struct{}
+
+// startSpan starts a span as a child of the span in 'ctx', if any.
+// It returns nil span when tracing is disabled.
+func (clus *Cluster) startSpan(ctx context.Context, name string) (context.Context, *span) {
+	if clus.ccfg.TraceExporter == nil {
+		return ctx, nil
+	}
+	sp := &span{
+		exp: clus.ccfg.TraceExporter,
+		data: SpanData{
+			Name:       name,
+			SpanID:     randomID(8),
+			Start:      time.Now(),
+			Attributes: make(map[string]string),
+		},
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*span); ok && parent != nil {
+		sp.data.TraceID = parent.data.TraceID
+		sp.data.ParentID = parent.data.SpanID
+	} else {
+		sp.data.TraceID = randomID(16)
+	}
+	return context.WithValue(ctx, spanKey{}, sp), sp
+}
+
+func (sp *span) setAttribute(k, v string) {
+	if sp == nil {
+		return
+	}
+	sp.data.Attributes[k] = v
+}
+
+// end finishes the span and exports it.
+func (sp *span) end(err error) {
+	if sp == nil {
+		return
+	}
+	sp.data.End = time.Now()
+	sp.data.Err = err
+	sp.exp.ExportSpan(sp.data)
+}
+
+// randomID returns a random hex ID of 'n' bytes. If the random source
+// fails, it falls back to the current time so that IDs stay non-zero.
+func randomID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		glog.Warningf("failed to generate random span ID (%v)", err)
+		binary.BigEndian.PutUint64(b[len(b)-8:], uint64(time.Now().UnixNano()))
+	}
+	return hex.EncodeToString(b)
+}
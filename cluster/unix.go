@@ -0,0 +1,71 @@
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// isTLSScheme returns true if the URL scheme serves TLS.
+func isTLSScheme(scheme string) bool {
+	return scheme == "https" || scheme == "unixs"
+}
+
+// isUnixScheme returns true if the URL scheme is a unix domain socket.
+func isUnixScheme(scheme string) bool {
+	return scheme == "unix" || scheme == "unixs"
+}
+
+// unixScheme returns the unix domain socket scheme of the HTTP scheme.
+func unixScheme(scheme string) string {
+	if scheme == "https" {
+		return "unixs"
+	}
+	return "unix"
+}
+
+// listenURL returns the URL of the port, or the unix domain socket
+// named after the port if unix sockets are enabled.
+//
+// etcd dials peer sockets by URL host, so the socket path is the
+// host in 'name:port' form, relative to the working directory.
+// The name is derived from the root directory, to avoid conflicts
+// with other clusters started from the same directory.
+func (clus *Cluster) listenURL(scheme, host string, port int) url.URL {
+	if isUnixScheme(scheme) {
+		return url.URL{Scheme: scheme, Host: fmt.Sprintf("%s:%d", clus.unixSocketName(), port)}
+	}
+	return url.URL{Scheme: scheme, Host: net.JoinHostPort(host, fmt.Sprint(port))}
+}
+
+func (clus *Cluster) unixSocketName() string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '-'
+	}, filepath.Base(clus.rootDir))
+	return "etcdlabs." + strings.Trim(name, "-.")
+}
+
+// clientEndpoint returns the client endpoint of the member.
+// TCP endpoints are host:port, and unix sockets keep the
+// scheme for clients to pick the network.
+func (m *Member) clientEndpoint() string {
+	if isUnixScheme(m.cfg.LCUrls[0].Scheme) {
+		return m.cfg.LCUrls[0].String()
+	}
+	return m.cfg.LCUrls[0].Host
+}
+
+// dialEndpoint connects to the endpoint returned by clientEndpoint.
+func dialEndpoint(ep string, timeout time.Duration) (net.Conn, error) {
+	if u, err := url.Parse(ep); err == nil && isUnixScheme(u.Scheme) {
+		return net.DialTimeout("unix", u.Host+u.Path, timeout)
+	}
+	return net.DialTimeout("tcp", ep, timeout)
+}
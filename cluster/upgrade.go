@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+)
+
+var upgradeHealthTimeout = 30 * time.Second
+
+// upgradeOrder returns the members with the leader last,
+// so that leadership moves at most once.
+func (clus *Cluster) upgradeOrder() []*Member {
+	clus.mmu.RLock()
+	defer clus.mmu.RUnlock()
+
+	var ms []*Member
+	var lead *Member
+	for _, m := range clus.Members {
+		m.statusLock.RLock()
+		isLeader := m.status.State == clusterpb.LeaderMemberStatus
+		m.statusLock.RUnlock()
+		if isLeader && lead == nil {
+			lead = m
+			continue
+		}
+		ms = append(ms, m)
+	}
+	if lead != nil {
+		ms = append(ms, lead)
+	}
+	return ms
+}
+
+// waitHealthy waits until every started member serves linearizable reads.
+func (clus *Cluster) waitHealthy(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(clus.rootCtx, timeout)
+	defer cancel()
+	return clus.WaitHealthy(ctx, false)
+}
+
+// RollingUpgrade switches members to the etcd version one at a time,
+// followers first and the leader last, waiting for the cluster to be
+// healthy between steps. Each step is recorded in the event log.
+func (clus *Cluster) RollingUpgrade(targetVersion string) (err error) {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+
+	clus.recordEvent("upgrade-start", "", "rolling upgrade to version %q started", targetVersion)
+	defer func() {
+		if err != nil {
+			clus.recordEvent("upgrade-failed", "", "rolling upgrade to version %q failed (%v)", targetVersion, err)
+		} else {
+			clus.recordEvent("upgrade-complete", "", "rolling upgrade to version %q completed", targetVersion)
+		}
+	}()
+
+	if err = clus.waitHealthy(upgradeHealthTimeout); err != nil {
+		return fmt.Errorf("cluster is not healthy before upgrade (%v)", err)
+	}
+
+	for _, m := range clus.upgradeOrder() {
+		if m.version == targetVersion {
+			clus.recordEvent("member-upgrade-skip", m.cfg.Name, "%q already runs version %q", m.cfg.Name, targetVersion)
+			continue
+		}
+		from := m.version
+		clus.recordEvent("member-upgrade-start", m.cfg.Name, "upgrading %q from version %q to %q", m.cfg.Name, from, targetVersion)
+		if err = clus.setNodeVersion(m, targetVersion); err != nil {
+			return err
+		}
+		if err = clus.waitHealthy(upgradeHealthTimeout); err != nil {
+			return err
+		}
+		clus.recordEvent("member-upgraded", m.cfg.Name, "upgraded %q from version %q to %q", m.cfg.Name, from, targetVersion)
+	}
+	return nil
+}
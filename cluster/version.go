@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// binaryNode is a node that runs a local etcd binary
+// (a child process or a remote node).
+type binaryNode interface {
+	SetBinary(binary string)
+}
+
+// dockerImageVersion returns the image of the etcd version,
+// in the repository of the configured image.
+func (clus *Cluster) dockerImageVersion(version string) string {
+	repo := clus.ccfg.DockerImage
+	if repo == "" {
+		repo = defaultDockerImage
+	}
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	return repo + ":v" + strings.TrimPrefix(version, "v")
+}
+
+// applyVersion points the node backend of the member at the etcd version.
+// An empty version selects the default binary or image.
+func (clus *Cluster) applyVersion(m *Member, version string) error {
+	switch n := m.ext.(type) {
+	case binaryNode:
+		bin := clus.ccfg.EtcdBinary
+		if version != "" {
+			var ok bool
+			if bin, ok = clus.ccfg.EtcdBinaries[version]; !ok {
+				return fmt.Errorf("no etcd binary is configured for version %q", version)
+			}
+		}
+		if bin == "" {
+			bin = defaultEtcdBinary
+		}
+		n.SetBinary(bin)
+	case imageNode:
+		image := clus.ccfg.DockerImage
+		if version != "" {
+			image = clus.dockerImageVersion(version)
+		}
+		n.SetImage(image)
+	default:
+		if version != "" {
+			return fmt.Errorf("%q runs in %s mode and cannot pin etcd version", m.cfg.Name, ModeEmbedded)
+		}
+	}
+	m.version = version
+	return nil
+}
+
+// NodeVersion returns the etcd version the node is configured to run,
+// or an empty string for the default binary or image. The version the
+// node reports is in its member status.
+func (clus *Cluster) NodeVersion(i int) (string, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return "", err
+	}
+	return m.version, nil
+}
+
+// SetNodeVersion restarts the node with another etcd version, keeping its data.
+// Like StopCtx, it returns *ErrRateLimited within Config.LifecycleInterval
+// of the last stop or restart.
+func (clus *Cluster) SetNodeVersion(i int, version string) error {
+	clus.opLock.Lock()
+	defer clus.opLock.Unlock()
+	m, err := clus.member(i)
+	if err != nil {
+		return err
+	}
+	if err = clus.allowLifecycle(); err != nil {
+		return err
+	}
+	return clus.setNodeVersion(m, version)
+}
+
+// setNodeVersion must be called with 'opLock' held.
+func (clus *Cluster) setNodeVersion(m *Member, version string) error {
+	glog.Infof("switching %q from version %q to %q", m.cfg.Name, m.version, version)
+
+	if err := clus.stop(m); err != nil {
+		return err
+	}
+	if err := clus.applyVersion(m, version); err != nil {
+		return err
+	}
+	if err := clus.restart(m); err != nil {
+		return err
+	}
+	return m.waitReady(nodeReadyTimeout)
+}
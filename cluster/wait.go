@@ -0,0 +1,110 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// waitPollInterval is how often WaitLeader and WaitHealthy refresh
+// the member statuses.
+var waitPollInterval = 200 * time.Millisecond
+
+// WaitLeader waits until exactly one running member is the leader,
+// and returns its index.
+func (clus *Cluster) WaitLeader(ctx context.Context) (int, error) {
+	for {
+		if err := clus.RefreshStatus(ctx); err != nil {
+			return -1, err
+		}
+		if idx := clus.findLeader(); idx != -1 {
+			return idx, nil
+		}
+		select {
+		case <-ctx.Done():
+			return -1, fmt.Errorf("no leader elected (%v)", ctx.Err())
+		case <-time.After(waitPollInterval):
+		}
+	}
+}
+
+// findLeader updates LeadIdx, and returns it if exactly
+// one member is the leader, or -1 otherwise.
+func (clus *Cluster) findLeader() int {
+	clus.mmu.Lock()
+	defer clus.mmu.Unlock()
+
+	lead := -1
+	for i, m := range clus.Members {
+		m.statusLock.RLock()
+		isLeader := m.status.IsLeader
+		m.statusLock.RUnlock()
+		if !isLeader {
+			continue
+		}
+		if lead != -1 {
+			return -1
+		}
+		lead = i
+	}
+	if lead != -1 && clus.LeadIdx != lead {
+		clus.LeadIdx = lead
+		glog.Infof("%q(%s) is the leader", clus.Members[lead].cfg.Name, clus.Members[lead].ID())
+	}
+	return lead
+}
+
+// WaitHealthy waits until every member running when it is called serves
+// linearizable reads or, if 'quorumOnly' is true, until a quorum of the
+// members does.
+func (clus *Cluster) WaitHealthy(ctx context.Context, quorumOnly bool) error {
+	clus.mmu.RLock()
+	members := append([]*Member{}, clus.Members...)
+	clus.mmu.RUnlock()
+
+	quorum := len(members)/2 + 1
+	var running []*Member
+	for _, m := range members {
+		if !m.stopped() {
+			running = append(running, m)
+		}
+	}
+
+	for {
+		if err := clus.RefreshStatus(ctx); err != nil {
+			return err
+		}
+		healthy, unhealthy := 0, []string{}
+		for _, m := range members {
+			m.statusLock.RLock()
+			ok := m.status.Healthy
+			m.statusLock.RUnlock()
+			if ok {
+				healthy++
+			}
+		}
+		for _, m := range running {
+			m.statusLock.RLock()
+			ok := m.status.Healthy
+			m.statusLock.RUnlock()
+			if !ok {
+				unhealthy = append(unhealthy, m.cfg.Name)
+			}
+		}
+		if (quorumOnly && healthy >= quorum) || (!quorumOnly && len(unhealthy) == 0) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if quorumOnly {
+				return fmt.Errorf("%d of %d members are healthy, quorum is %d (%v)", healthy, len(members), quorum, ctx.Err())
+			}
+			return fmt.Errorf("%s not healthy (%v)", strings.Join(unhealthy, ", "), ctx.Err())
+		case <-time.After(waitPollInterval):
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/coreos/etcdlabs/pkg/wal"
+)
+
+// ReadWAL decodes the write-ahead log of the node. The node must be stopped,
+// so that the log is not being written.
+func (clus *Cluster) ReadWAL(i int) (wal.Log, error) {
+	m, err := clus.member(i)
+	if err != nil {
+		return wal.Log{}, err
+	}
+	return readWAL(m)
+}
+
+// ReadWALByName is ReadWAL of the node with the name.
+func (clus *Cluster) ReadWALByName(name string) (wal.Log, error) {
+	m, err := clus.memberByName(name)
+	if err != nil {
+		return wal.Log{}, err
+	}
+	return readWAL(m)
+}
+
+func readWAL(m *Member) (wal.Log, error) {
+	if !m.stopped() {
+		return wal.Log{}, fmt.Errorf("%q must be stopped to read its WAL", m.cfg.Name)
+	}
+	return wal.Read(m.cfg.WalDir)
+}
@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// WatchEvent is a single key change.
+type WatchEvent struct {
+	// Type is either "PUT" or "DELETE".
+	Type         string
+	KeyValue     KeyValue
+	PrevKeyValue *KeyValue
+}
+
+// WatchResponse is a batch of watch events.
+type WatchResponse struct {
+	Header ResponseHeader
+	Events []WatchEvent
+
+	// CompactRevision is set when the watch revision was compacted.
+	CompactRevision int64
+	Canceled        bool
+	Err             string
+}
+
+func toWatchResponse(wr clientv3.WatchResponse) WatchResponse {
+	resp := WatchResponse{
+		Header:          toResponseHeader(&wr.Header),
+		Events:          make([]WatchEvent, len(wr.Events)),
+		CompactRevision: wr.CompactRevision,
+		Canceled:        wr.Canceled,
+	}
+	if err := wr.Err(); err != nil {
+		resp.Err = err.Error()
+	}
+	for i, ev := range wr.Events {
+		resp.Events[i] = WatchEvent{
+			Type:     ev.Type.String(),
+			KeyValue: toKeyValues([]*mvccpb.KeyValue{ev.Kv})[0],
+		}
+		if ev.PrevKv != nil {
+			prev := toKeyValues([]*mvccpb.KeyValue{ev.PrevKv})[0]
+			resp.Events[i].PrevKeyValue = &prev
+		}
+	}
+	return resp
+}
+
+// Watch watches a key (or all keys with the prefix) through the node,
+// starting at 'rev' (0 for the current revision). The returned channel
+// is closed when 'ctx' is canceled or the watch fails.
+func (clus *Cluster) Watch(ctx context.Context, i int, key string, prefix bool, rev int64) (<-chan WatchResponse, error) {
+	cli, _, err := clus.Members[i].Client(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []clientv3.OpOption{clientv3.WithPrevKV()}
+	if prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	if rev > 0 {
+		opts = append(opts, clientv3.WithRev(rev))
+	}
+
+	wctx, wcancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	wch := cli.Watch(wctx, key, opts...)
+
+	rch := make(chan WatchResponse)
+	go func() {
+		defer func() {
+			wcancel()
+			cli.Close()
+			close(rch)
+		}()
+		for wr := range wch {
+			select {
+			case rch <- toWatchResponse(wr):
+			case <-ctx.Done():
+				return
+			}
+			if wr.Canceled {
+				return
+			}
+		}
+	}()
+	return rch, nil
+}
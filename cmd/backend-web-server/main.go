@@ -19,6 +19,8 @@ import (
 	"flag"
 	"os"
 	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/coreos/etcdlabs/backend/web"
 
@@ -28,12 +30,20 @@ import (
 var (
 	webPort         int
 	recordTesterEps string
+	trustedProxies  string
 )
 
 func main() {
 	flag.IntVar(&webPort, "web-port", 2200, "Specify the web port for backend.")
+	flag.StringVar(&trustedProxies, "trusted-proxies", "", "Comma-separated IPs or CIDRs of the reverse proxies in front of backend.")
 	flag.Parse()
 
+	if trustedProxies != "" {
+		if err := web.SetTrustedProxies(strings.Split(trustedProxies, ",")); err != nil {
+			glog.Fatal(err)
+		}
+	}
+
 	glog.Info("starting web server")
 	srv, err := web.StartServer(webPort)
 	if err != nil {
@@ -43,7 +53,7 @@ func main() {
 	defer srv.Stop()
 
 	sc := make(chan os.Signal, 10)
-	signal.Notify(sc, os.Interrupt, os.Kill)
+	signal.Notify(sc, os.Interrupt, syscall.SIGTERM)
 	select {
 	case s := <-sc:
 		glog.Infof("shutting down server with signal %q", s.String())
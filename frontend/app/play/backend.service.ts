@@ -28,6 +28,23 @@ export class MemberStatus {
   DBSizeTxt: string;
   Hash: number;
 
+  RaftTerm?: number;
+  RaftIndex?: number;
+  RaftAppliedIndex?: number;
+  Version?: string;
+  Alarms?: string[];
+
+  ClientCertExpiry?: number;
+  PeerCertExpiry?: number;
+
+  HeartbeatIntervalMs?: number;
+  ElectionTimeoutMs?: number;
+  CompactRevision?: number;
+  LogLevel?: string;
+  Healthy?: boolean;
+  HealthLatencyMs?: number;
+  HealthError?: string;
+
   constructor(
     name: string,
     id: string,
@@ -0,0 +1,155 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nemesis defines failure modes that chaos calendars and scenarios
+// inject into cluster nodes, and a registry of them, so that new failure
+// modes (e.g. throttling the CPU of a node with cgroups) can be added
+// without changing those packages:
+//
+//	func init() { nemesis.Register(cpuThrottle{}) }
+//
+// The "stop", "kill" and "slow" nemeses are registered by default.
+package nemesis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/coreos/etcdlabs/cluster"
+)
+
+// Nemesis is a failure mode of a node.
+type Nemesis interface {
+	// Name is the name of the failure mode (e.g. "kill").
+	Name() string
+	// Inject injects the failure into the node 'i' of the cluster.
+	Inject(ctx context.Context, clus *cluster.Cluster, i int) error
+	// Recover undoes the failure, if the node still has it.
+	Recover(ctx context.Context, clus *cluster.Cluster, i int) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Nemesis)
+)
+
+// Register makes the nemesis available by its name.
+// It panics if the name is empty or already registered.
+func Register(n Nemesis) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	name := n.Name()
+	if name == "" {
+		panic("nemesis: Register with an empty name")
+	}
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("nemesis: Register called twice for %q", name))
+	}
+	registry[name] = n
+}
+
+// Get returns the registered nemesis.
+func Get(name string) (Nemesis, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	n, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown nemesis %q (registered %v)", name, names())
+	}
+	return n, nil
+}
+
+// Names returns the names of the registered nemeses, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return names()
+}
+
+func names() []string {
+	ns := make([]string, 0, len(registry))
+	for name := range registry {
+		ns = append(ns, name)
+	}
+	sort.Strings(ns)
+	return ns
+}
+
+func init() {
+	Register(Stop)
+	Register(Kill)
+	Register(Slow)
+}
+
+// Stop stops the node gracefully, and recovers by restarting it.
+var Stop Nemesis = stop{}
+
+// Kill kills the node as if the process crashed (see cluster.Member.Kill),
+// and recovers by restarting it.
+var Kill Nemesis = kill{}
+
+// Slow limits the peer traffic to the node, so it falls behind the
+// leader (see cluster.Cluster.SlowPeer), and recovers by lifting the limit.
+var Slow Nemesis = slow{}
+
+type stop struct{}
+
+func (stop) Name() string { return "stop" }
+
+func (stop) Inject(ctx context.Context, clus *cluster.Cluster, i int) error {
+	clus.Stop(i)
+	return nil
+}
+
+func (stop) Recover(ctx context.Context, clus *cluster.Cluster, i int) error {
+	return restart(clus, i)
+}
+
+type kill struct{}
+
+func (kill) Name() string { return "kill" }
+
+func (kill) Inject(ctx context.Context, clus *cluster.Cluster, i int) error {
+	return clus.Kill(i)
+}
+
+func (kill) Recover(ctx context.Context, clus *cluster.Cluster, i int) error {
+	return restart(clus, i)
+}
+
+// slowRate is the peer bandwidth of a node slowed by Slow, in bytes per second.
+var slowRate = 16 * 1024
+
+type slow struct{}
+
+func (slow) Name() string { return "slow" }
+
+func (slow) Inject(ctx context.Context, clus *cluster.Cluster, i int) error {
+	return clus.SlowPeer(i, slowRate)
+}
+
+func (slow) Recover(ctx context.Context, clus *cluster.Cluster, i int) error {
+	return clus.SlowPeer(i, 0)
+}
+
+// restart restarts the node, if it is stopped.
+func restart(clus *cluster.Cluster, i int) error {
+	stopped, err := clus.IsStopped(i)
+	if err != nil || !stopped {
+		return err
+	}
+	return clus.Restart(i)
+}
@@ -0,0 +1,188 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records the requests that change a cluster (who, what,
+// when and the result) to an append-only log, and queries it.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is an audited request.
+type Entry struct {
+	Time time.Time
+	// Who identifies the caller (e.g. the bearer token),
+	// and Remote is its address.
+	Who    string
+	Remote string
+	// Protocol is "http" or "grpc".
+	Protocol string
+	// Method is the HTTP method or the gRPC method, Target is the
+	// request path, and Request is the request body or gRPC request.
+	Method  string
+	Target  string
+	Request string
+
+	Success bool
+	// Status is the HTTP status (e.g. "409 Conflict") or the gRPC code.
+	Status string
+	Error  string
+	Took   time.Duration
+}
+
+// Query selects entries. Zero fields match all entries.
+type Query struct {
+	// Who matches entries whose caller contains it, and Target
+	// the ones whose target or request contains it (e.g. "node3").
+	Who    string
+	Target string
+	Since  time.Time
+	Until  time.Time
+	// Failed selects only failed requests.
+	Failed bool
+	// Last returns only the 'Last' most recent matching entries.
+	Last int
+}
+
+func (q Query) match(e Entry) bool {
+	switch {
+	case q.Who != "" && !strings.Contains(e.Who, q.Who):
+		return false
+	case q.Target != "" && !strings.Contains(e.Target, q.Target) && !strings.Contains(e.Request, q.Target):
+		return false
+	case !q.Since.IsZero() && e.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && e.Time.After(q.Until):
+		return false
+	case q.Failed && e.Success:
+		return false
+	}
+	return true
+}
+
+// DefaultMemoryEntries is the number of entries kept by a log without a file.
+var DefaultMemoryEntries = 10000
+
+// maxLineSize bounds the size of an entry read from the file.
+const maxLineSize = 1 << 20
+
+// Log is an append-only audit log, safe for concurrent use.
+// Entries are written as JSON lines to its file, which keeps the entries
+// of previous runs, or kept in memory (up to DefaultMemoryEntries).
+type Log struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	entries []Entry
+}
+
+// Open opens the log that appends to the file, creating it if it
+// does not exist. If 'path' is empty, entries are kept in memory.
+func Open(path string) (*Log, error) {
+	l := &Log{path: path}
+	if path == "" {
+		return l, nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	l.f = f
+	return l, nil
+}
+
+// Append records the entry.
+func (l *Log) Append(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		if l.path != "" {
+			return fmt.Errorf("audit log %q is closed", l.path)
+		}
+		l.entries = append(l.entries, e)
+		if n := len(l.entries) - DefaultMemoryEntries; n > 0 {
+			l.entries = append(l.entries[:0], l.entries[n:]...)
+		}
+		return nil
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = l.f.Write(append(b, '\n'))
+	return err
+}
+
+// Query returns the matching entries, oldest first.
+func (l *Log) Query(q Query) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var es []Entry
+	if l.path == "" {
+		for _, e := range l.entries {
+			if q.match(e) {
+				es = append(es, e)
+			}
+		}
+	} else {
+		f, err := os.Open(l.path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 4096), maxLineSize)
+		for sc.Scan() {
+			var e Entry
+			if err = json.Unmarshal(sc.Bytes(), &e); err != nil {
+				return nil, fmt.Errorf("invalid audit entry %q (%v)", sc.Text(), err)
+			}
+			if q.match(e) {
+				es = append(es, e)
+			}
+		}
+		if err = sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if q.Last > 0 && len(es) > q.Last {
+		es = es[len(es)-q.Last:]
+	}
+	return es, nil
+}
+
+// Close closes the file of the log.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
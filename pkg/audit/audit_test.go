@@ -0,0 +1,91 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	for _, p := range []string{"", path} {
+		l, err := Open(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now()
+		es := []Entry{
+			{Time: now.Add(-time.Hour), Who: "token:alice", Method: "POST", Target: "/v1/members/node3/stop", Success: true, Status: "200 OK"},
+			{Time: now, Who: "token:bob", Method: "POST", Target: "/v1/members/node3/restart", Status: "409 Conflict", Error: `"node3" is already started`},
+			{Time: now, Who: "token:alice", Protocol: "grpc", Method: "/clusterpb.ClusterControl/StopNode", Target: "/clusterpb.ClusterControl/StopNode", Request: `Name:"node3"`, Success: true, Status: "OK"},
+		}
+		for _, e := range es {
+			if err = l.Append(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tests := []struct {
+			q    Query
+			want int
+		}{
+			{Query{}, 3},
+			{Query{Who: "alice"}, 2},
+			{Query{Target: "node3"}, 3},
+			{Query{Target: "restart"}, 1},
+			{Query{Since: now.Add(-time.Minute)}, 2},
+			{Query{Failed: true}, 1},
+			{Query{Who: "alice", Last: 1}, 1},
+		}
+		for i, tt := range tests {
+			got, err := l.Query(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Fatalf("%q #%d: expected %d entries, got %d (%+v)", p, i, tt.want, len(got), got)
+			}
+		}
+		if got, _ := l.Query(Query{Who: "alice", Last: 1}); got[0].Protocol != "grpc" {
+			t.Fatalf("%q: expected the most recent entry, got %+v", p, got[0])
+		}
+		if err = l.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// entries of previous runs are kept
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err = l.Append(Entry{Time: time.Now(), Who: "token:carol"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := l.Query(Query{}); len(got) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(got))
+	}
+}
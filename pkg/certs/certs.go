@@ -0,0 +1,242 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/pkg/transport"
+)
+
+// DefaultValidFor is the default lifetime of generated certificates.
+var DefaultValidFor = 365 * 24 * time.Hour
+
+// CA is a certificate authority that signs server, peer and client certificates.
+type CA struct {
+	// CertFile is the PEM-encoded CA certificate, to be trusted by clients and servers.
+	CertFile string
+	// KeyFile is the PEM-encoded CA private key.
+	KeyFile string
+	// CRLFile is the PEM-encoded certificate revocation list.
+	CRLFile string
+
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+
+	mu      sync.Mutex
+	revoked []pkix.RevokedCertificate
+}
+
+// NewCA generates a self-signed CA and writes 'ca.pem' and 'ca-key.pem' into 'dir'.
+func NewCA(dir string, validFor time.Duration) (*CA, error) {
+	if validFor <= 0 {
+		validFor = DefaultValidFor
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "etcdlabs CA", Organization: []string{"etcdlabs"}},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(validFor),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	ca := &CA{
+		CertFile: filepath.Join(dir, "ca.pem"),
+		KeyFile:  filepath.Join(dir, "ca-key.pem"),
+		CRLFile:  filepath.Join(dir, "crl.pem"),
+		cert:     cert,
+		key:      key,
+	}
+	if err = writeFiles(dir, ca.CertFile, der, ca.KeyFile, key); err != nil {
+		return nil, err
+	}
+	// empty revocation list, so it can be configured from the start
+	if err = ca.writeCRL(); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// Revoke adds the PEM-encoded certificate to the revocation list and rewrites CRLFile.
+func (ca *CA) Revoke(certFile string) error {
+	cert, err := readCert(certFile)
+	if err != nil {
+		return err
+	}
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	for _, e := range ca.revoked {
+		if e.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return nil
+		}
+	}
+	ca.revoked = append(ca.revoked, pkix.RevokedCertificate{SerialNumber: cert.SerialNumber, RevocationTime: time.Now()})
+	return ca.writeCRL()
+}
+
+// writeCRL must be called with 'mu' held, or before the CA is shared.
+func (ca *CA) writeCRL() error {
+	der, err := ca.cert.CreateCRL(rand.Reader, ca.key, ca.revoked, time.Now().Add(-time.Minute), ca.cert.NotAfter)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ca.CRLFile, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0644)
+}
+
+// Request defines a certificate to issue.
+type Request struct {
+	// CommonName is the subject CN; etcd maps it to the user name
+	// when client certificate authentication is enabled.
+	CommonName string
+	// Hosts are the DNS names and IP addresses the certificate is valid for.
+	Hosts []string
+	// ValidFor is the lifetime of the certificate (DefaultValidFor if zero).
+	ValidFor time.Duration
+
+	// Server and Client set the extended key usages.
+	// Peer certificates need both.
+	Server bool
+	Client bool
+}
+
+// Issue signs a certificate and writes '<name>.pem' and '<name>-key.pem' into 'dir'.
+// The returned TLSInfo trusts the CA.
+func (ca *CA) Issue(dir, name string, r Request) (transport.TLSInfo, error) {
+	validFor := r.ValidFor
+	if validFor <= 0 {
+		validFor = DefaultValidFor
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return transport.TLSInfo{}, err
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return transport.TLSInfo{}, err
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: r.CommonName, Organization: []string{"etcdlabs"}},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(validFor),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+	}
+	if r.Server {
+		tmpl.ExtKeyUsage = append(tmpl.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+	}
+	if r.Client {
+		tmpl.ExtKeyUsage = append(tmpl.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+	}
+	for _, h := range r.Hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	if tmpl.NotAfter.After(ca.cert.NotAfter) {
+		tmpl.NotAfter = ca.cert.NotAfter
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return transport.TLSInfo{}, err
+	}
+
+	info := transport.TLSInfo{
+		CertFile:      filepath.Join(dir, name+".pem"),
+		KeyFile:       filepath.Join(dir, name+"-key.pem"),
+		TrustedCAFile: ca.CertFile,
+	}
+	if err = writeFiles(dir, info.CertFile, der, info.KeyFile, key); err != nil {
+		return transport.TLSInfo{}, err
+	}
+	return info, nil
+}
+
+// Expiry returns the expiration time of the PEM-encoded certificate.
+func Expiry(certFile string) (time.Time, error) {
+	cert, err := readCert(certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+func readCert(certFile string) (*x509.Certificate, error) {
+	b, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	blk, _ := pem.Decode(b)
+	if blk == nil || blk.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no certificate found in %q", certFile)
+	}
+	return x509.ParseCertificate(blk.Bytes)
+}
+
+func newSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func writeFiles(dir, certFile string, der []byte, keyFile string, key *ecdsa.PrivateKey) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	kb, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kb}), 0600)
+}
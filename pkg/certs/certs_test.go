@@ -0,0 +1,142 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestIssue(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "certs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, err := NewCA(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srvInfo, err := ca.Issue(dir, "server", Request{CommonName: "server", Hosts: []string{"localhost", "127.0.0.1"}, Server: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srvInfo.ClientCertAuth = true
+	cliInfo, err := ca.Issue(dir, "client", Request{CommonName: "client", Client: true, ValidFor: 2 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// client certificate must not outlive its requested lifetime
+	exp, err := Expiry(cliInfo.CertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(exp); d > 2*time.Minute || d < time.Minute {
+		t.Fatalf("expected expiry in 2 minutes, got %v", d)
+	}
+
+	srvCfg, err := srvInfo.ServerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", srvCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	donec := make(chan error)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			donec <- err
+			return
+		}
+		defer conn.Close()
+		tc := conn.(*tls.Conn)
+		if err = tc.Handshake(); err != nil {
+			donec <- err
+			return
+		}
+		if cn := tc.ConnectionState().PeerCertificates[0].Subject.CommonName; cn != "client" {
+			t.Errorf("expected client CN 'client', got %q", cn)
+		}
+		donec <- nil
+	}()
+
+	cliCfg, err := cliInfo.ClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := tls.Dial("tcp", ln.Addr().String(), cliCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err = <-donec; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "certs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, err := NewCA(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := ca.Issue(dir, "client", Request{CommonName: "client", Client: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ca.Revoke(info.CertFile); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := readCert(info.CertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(ca.CRLFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blk, _ := pem.Decode(b)
+	if blk == nil {
+		t.Fatalf("no CRL found in %q", ca.CRLFile)
+	}
+	crl, err := x509.ParseCRL(blk.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ca.cert.CheckCRLSignature(crl); err != nil {
+		t.Fatal(err)
+	}
+	revoked := crl.TBSCertList.RevokedCertificates
+	if len(revoked) != 1 || revoked[0].SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		t.Fatalf("expected serial %v revoked, got %+v", cert.SerialNumber, revoked)
+	}
+}
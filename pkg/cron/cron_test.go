@@ -0,0 +1,73 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2017, time.June, 20, 12, 3, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2017, time.June, 20, 12, 4, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2017, time.June, 20, 12, 5, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2017, time.June, 20, 13, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2017, time.June, 21, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2017, time.June, 25, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 0", time.Date(2017, time.June, 25, 0, 0, 0, 0, time.UTC)},
+		{"10-20/5 12 * * *", time.Date(2017, time.June, 20, 12, 10, 0, 0, time.UTC)},
+		{"1,2 3 * 1 *", time.Date(2018, time.January, 1, 3, 1, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+		{"@hourly", time.Date(2017, time.June, 20, 13, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2017, time.June, 20, 12, 5, 0, 0, time.UTC)},
+	}
+	for i, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("#%d: %q: unexpected error %v", i, tt.expr, err)
+		}
+		if next := s.Next(from); !next.Equal(tt.next) {
+			t.Errorf("#%d: %q: next expected %v, got %v", i, tt.expr, tt.next, next)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for i, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 1ms",
+		"@every soon",
+		"@sometimes",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("#%d: %q: expected error", i, expr)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// subBucketBits is the number of linear sub-buckets per power of two,
+// as log2. 6 bits bound the relative error to 1/64 (~1.6%).
+const subBucketBits = 6
+
+const (
+	subBucketCount = 1 << subBucketBits
+	// values are in microseconds, up to 2^40 (~12 days)
+	maxExponent = 40
+	bucketCount = (maxExponent - subBucketBits + 2) * subBucketCount
+)
+
+// Histogram records durations in log-linear buckets with bounded relative
+// error, like HdrHistogram, using constant memory. It is safe for
+// concurrent use.
+type Histogram struct {
+	mu     sync.Mutex
+	counts []uint64
+	total  uint64
+	min    time.Duration
+	max    time.Duration
+	sum    time.Duration
+}
+
+// New returns a new Histogram.
+func New() *Histogram {
+	return &Histogram{counts: make([]uint64, bucketCount)}
+}
+
+// bucketIndex returns the bucket of the value in microseconds.
+// Values below 'subBucketCount' have their own buckets; larger
+// values share a bucket with the values of the same top
+// 'subBucketBits+1' bits.
+func bucketIndex(v uint64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1 // v in [2^exp, 2^(exp+1))
+	if exp > maxExponent {
+		exp, v = maxExponent, 1<<(maxExponent+1)-1
+	}
+	shift := uint(exp - subBucketBits)
+	sub := int(v>>shift) - subBucketCount // in [0, subBucketCount)
+	return (exp-subBucketBits+1)*subBucketCount + sub
+}
+
+// bucketValue returns the highest value of the bucket in microseconds.
+func bucketValue(idx int) uint64 {
+	if idx < subBucketCount {
+		return uint64(idx)
+	}
+	exp := idx/subBucketCount + subBucketBits - 1
+	sub := uint64(idx%subBucketCount + subBucketCount)
+	shift := uint(exp - subBucketBits)
+	return (sub+1)<<shift - 1
+}
+
+// Record records a duration. Negative durations are recorded as zero.
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	idx := bucketIndex(uint64(d / time.Microsecond))
+
+	h.mu.Lock()
+	h.counts[idx]++
+	if h.total == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.total++
+	h.sum += d
+	h.mu.Unlock()
+}
+
+// Count returns the number of recorded durations.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.total
+}
+
+// Quantile returns the duration at the quantile (e.g. 0.99),
+// or zero if nothing was recorded.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.quantile(q)
+}
+
+func (h *Histogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.min
+	}
+	if q >= 1 {
+		return h.max
+	}
+	rank := uint64(q*float64(h.total) + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			d := time.Duration(bucketValue(i)) * time.Microsecond
+			// bucket bounds are coarser than the observed extremes
+			if d > h.max {
+				d = h.max
+			}
+			if d < h.min {
+				d = h.min
+			}
+			return d
+		}
+	}
+	return h.max
+}
+
+// Summary is a snapshot of a Histogram.
+type Summary struct {
+	Count uint64
+	Min   time.Duration
+	Avg   time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// Summary returns the current percentiles.
+func (h *Histogram) Summary() Summary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := Summary{Count: h.total, Min: h.min, Max: h.max}
+	if h.total > 0 {
+		s.Avg = h.sum / time.Duration(h.total)
+	}
+	s.P50, s.P95, s.P99 = h.quantile(0.50), h.quantile(0.95), h.quantile(0.99)
+	return s
+}
+
+// Reset clears all recorded durations.
+func (h *Histogram) Reset() {
+	h.mu.Lock()
+	for i := range h.counts {
+		h.counts[i] = 0
+	}
+	h.total, h.min, h.max, h.sum = 0, 0, 0, 0
+	h.mu.Unlock()
+}
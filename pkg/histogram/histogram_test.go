@@ -0,0 +1,75 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketIndex(t *testing.T) {
+	for _, v := range []uint64{0, 1, 63, 64, 65, 127, 128, 1000, 123456, 1 << 30} {
+		idx := bucketIndex(v)
+		hi := bucketValue(idx)
+		if hi < v {
+			t.Fatalf("value %d: bucket %d upper bound %d is lower than value", v, idx, hi)
+		}
+		// relative error is bounded by the sub-bucket resolution
+		if float64(hi-v) > float64(v)/subBucketCount+1 {
+			t.Fatalf("value %d: bucket %d upper bound %d is too coarse", v, idx, hi)
+		}
+		if idx >= bucketCount {
+			t.Fatalf("value %d: bucket %d out of range", v, idx)
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	h := New()
+	if d := h.Quantile(0.5); d != 0 {
+		t.Fatalf("expected 0 on empty histogram, got %v", d)
+	}
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	if h.Count() != 1000 {
+		t.Fatalf("expected 1000, got %d", h.Count())
+	}
+	tests := []struct {
+		q   float64
+		exp time.Duration
+	}{
+		{0.5, 500 * time.Millisecond},
+		{0.95, 950 * time.Millisecond},
+		{0.99, 990 * time.Millisecond},
+		{1, 1000 * time.Millisecond},
+	}
+	for i, tt := range tests {
+		d := h.Quantile(tt.q)
+		if d < tt.exp || float64(d-tt.exp) > float64(tt.exp)/subBucketCount {
+			t.Errorf("#%d: quantile %v expected about %v, got %v", i, tt.q, tt.exp, d)
+		}
+	}
+
+	s := h.Summary()
+	if s.Min != time.Millisecond || s.Max != time.Second || s.Avg != 500500*time.Microsecond {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+
+	h.Reset()
+	if h.Count() != 0 || h.Summary() != (Summary{}) {
+		t.Fatalf("expected empty histogram after reset, got %+v", h.Summary())
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package linearizability checks whether a history of key-value operations
+// is linearizable, with the algorithm of Wing and Gong and the memoization
+// of Lowe, as in the porcupine checker. Keys are independent registers,
+// so the history is checked per key.
+package linearizability
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Operation kinds.
+const (
+	Put    = "put"
+	Get    = "get"
+	Delete = "delete"
+)
+
+// Unknown is the return time of an operation whose outcome is unknown
+// (e.g. a write that timed out), which may take effect at any time after
+// its call.
+const Unknown = math.MaxInt64
+
+// Operation is a completed operation on a key.
+type Operation struct {
+	ClientID int
+	Kind     string
+	Key      string
+	// Value is the written value of Put, and the read value of Get.
+	Value string
+	// Found is true if Get read the key.
+	Found bool
+	// Call and Return are the times (e.g. in nanoseconds) the operation
+	// was invoked and returned. Return is Unknown if the outcome is unknown.
+	Call   int64
+	Return int64
+}
+
+func (op Operation) String() string {
+	ret := fmt.Sprint(op.Return)
+	if op.Return == Unknown {
+		ret = "?"
+	}
+	switch {
+	case op.Kind == Put:
+		return fmt.Sprintf("client %d: put(%q, %q) [%d, %s]", op.ClientID, op.Key, op.Value, op.Call, ret)
+	case op.Kind == Get && !op.Found:
+		return fmt.Sprintf("client %d: get(%q) -> not found [%d, %s]", op.ClientID, op.Key, op.Call, ret)
+	case op.Kind == Get:
+		return fmt.Sprintf("client %d: get(%q) -> %q [%d, %s]", op.ClientID, op.Key, op.Value, op.Call, ret)
+	}
+	return fmt.Sprintf("client %d: %s(%q) [%d, %s]", op.ClientID, op.Kind, op.Key, op.Call, ret)
+}
+
+// Violation is a key whose history is not linearizable.
+type Violation struct {
+	Key string
+	// Operations are the operations on the key, by call time.
+	Operations []Operation
+}
+
+// Result is the result of a check.
+type Result struct {
+	Linearizable bool
+	// Operations is the number of checked operations.
+	Operations int
+	Keys       int
+	Violations []Violation
+}
+
+func (r Result) String() string {
+	if r.Linearizable {
+		return fmt.Sprintf("%d operations on %d keys are linearizable", r.Operations, r.Keys)
+	}
+	keys := make([]string, len(r.Violations))
+	for i, v := range r.Violations {
+		keys[i] = fmt.Sprintf("%q", v.Key)
+	}
+	return fmt.Sprintf("%d operations on %d keys are not linearizable (keys %s)", r.Operations, r.Keys, strings.Join(keys, ", "))
+}
+
+// Check checks the history.
+func Check(ops []Operation) Result {
+	byKey := make(map[string][]Operation)
+	for _, op := range ops {
+		byKey[op.Key] = append(byKey[op.Key], op)
+	}
+	keys := make([]string, 0, len(byKey))
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	r := Result{Linearizable: true, Operations: len(ops), Keys: len(keys)}
+	for _, k := range keys {
+		if !checkKey(byKey[k]) {
+			kops := byKey[k]
+			sort.SliceStable(kops, func(i, j int) bool { return kops[i].Call < kops[j].Call })
+			r.Linearizable = false
+			r.Violations = append(r.Violations, Violation{Key: k, Operations: kops})
+		}
+	}
+	return r
+}
+
+// state is the state of a key.
+type state struct {
+	exists bool
+	value  string
+}
+
+// step applies the operation to the state. It returns false if the
+// operation cannot take effect in the state (e.g. a stale read).
+func step(s state, op Operation) (bool, state) {
+	switch op.Kind {
+	case Put:
+		return true, state{exists: true, value: op.Value}
+	case Delete:
+		return true, state{}
+	case Get:
+		return op.Found == s.exists && (!op.Found || op.Value == s.value), s
+	}
+	return false, s
+}
+
+// entry is a call or return event in the history, in a doubly linked list.
+type entry struct {
+	call  bool
+	id    int
+	op    Operation
+	time  int64
+	match *entry // the return of a call
+	prev  *entry
+	next  *entry
+}
+
+// makeEntries returns the head of the list of events sorted by time,
+// with calls before returns at the same time. The head is a sentinel.
+func makeEntries(ops []Operation) *entry {
+	es := make([]*entry, 0, 2*len(ops))
+	for i, op := range ops {
+		ret := &entry{id: i, time: op.Return}
+		es = append(es, &entry{call: true, id: i, op: op, time: op.Call, match: ret}, ret)
+	}
+	sort.SliceStable(es, func(i, j int) bool {
+		if es[i].time != es[j].time {
+			return es[i].time < es[j].time
+		}
+		return es[i].call && !es[j].call
+	})
+	head := &entry{}
+	prev := head
+	for _, e := range es {
+		prev.next, e.prev = e, prev
+		prev = e
+	}
+	return head
+}
+
+// lift removes the call and its return from the list.
+func lift(e *entry) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	m := e.match
+	m.prev.next = m.next
+	if m.next != nil {
+		m.next.prev = m.prev
+	}
+}
+
+// unlift restores the call and its return removed by lift.
+func unlift(e *entry) {
+	m := e.match
+	m.prev.next = m
+	if m.next != nil {
+		m.next.prev = m
+	}
+	e.prev.next = e
+	e.next.prev = e
+}
+
+type bitset []uint64
+
+func (b bitset) set(i int)   { b[i/64] |= 1 << uint(i%64) }
+func (b bitset) clear(i int) { b[i/64] &^= 1 << uint(i%64) }
+
+func (b bitset) key(s state) string {
+	var buf bytes.Buffer
+	for _, w := range b {
+		fmt.Fprintf(&buf, "%x.", w)
+	}
+	fmt.Fprintf(&buf, "%v.%s", s.exists, s.value)
+	return buf.String()
+}
+
+// checkKey searches for a linearization of the operations on a key,
+// backtracking when a return is reached before its call is linearized.
+// Configurations (linearized operations and state) already explored
+// are skipped.
+func checkKey(ops []Operation) bool {
+	head := makeEntries(ops)
+	linearized := make(bitset, (len(ops)+63)/64)
+	seen := make(map[string]struct{})
+
+	type frame struct {
+		e *entry
+		s state
+	}
+	var (
+		stack []frame
+		s     state
+		e     = head.next
+	)
+	for head.next != nil {
+		if e.call {
+			ok, next := step(s, e.op)
+			if ok {
+				linearized.set(e.id)
+				k := linearized.key(next)
+				if _, dup := seen[k]; !dup {
+					seen[k] = struct{}{}
+					stack = append(stack, frame{e: e, s: s})
+					s = next
+					lift(e)
+					e = head.next
+					continue
+				}
+				linearized.clear(e.id)
+			}
+			e = e.next
+			continue
+		}
+
+		// an operation returned before it could be linearized
+		if len(stack) == 0 {
+			return false
+		}
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		s = f.s
+		linearized.clear(f.e.id)
+		unlift(f.e)
+		e = f.e.next
+	}
+	return true
+}
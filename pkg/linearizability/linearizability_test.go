@@ -0,0 +1,114 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linearizability
+
+import "testing"
+
+func put(client int, key, val string, call, ret int64) Operation {
+	return Operation{ClientID: client, Kind: Put, Key: key, Value: val, Call: call, Return: ret}
+}
+
+func get(client int, key, val string, call, ret int64) Operation {
+	return Operation{ClientID: client, Kind: Get, Key: key, Value: val, Found: val != "", Call: call, Return: ret}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []Operation
+		want bool
+	}{
+		{
+			"sequential",
+			[]Operation{put(0, "a", "1", 0, 10), get(1, "a", "1", 20, 30)},
+			true,
+		},
+		{
+			"stale read",
+			[]Operation{put(0, "a", "1", 0, 10), put(0, "a", "2", 20, 30), get(1, "a", "1", 40, 50)},
+			false,
+		},
+		{
+			"concurrent read of either value",
+			[]Operation{put(0, "a", "1", 0, 10), put(0, "a", "2", 20, 30), get(1, "a", "1", 15, 25), get(2, "a", "2", 26, 40)},
+			true,
+		},
+		{
+			"read goes back in time",
+			[]Operation{put(0, "a", "1", 0, 10), put(0, "a", "2", 20, 50), get(1, "a", "2", 25, 30), get(2, "a", "1", 35, 40)},
+			false,
+		},
+		{
+			"not found before write",
+			[]Operation{get(1, "a", "", 0, 5), put(0, "a", "1", 10, 20)},
+			true,
+		},
+		{
+			"lost write",
+			[]Operation{put(0, "a", "1", 0, 10), get(1, "a", "", 20, 30)},
+			false,
+		},
+		{
+			"deleted",
+			[]Operation{put(0, "a", "1", 0, 10), {Kind: Delete, Key: "a", Call: 20, Return: 30}, get(1, "a", "", 40, 50)},
+			true,
+		},
+		{
+			"write with unknown outcome may apply",
+			[]Operation{put(0, "a", "1", 0, 10), put(0, "a", "2", 20, Unknown), get(1, "a", "2", 40, 50)},
+			true,
+		},
+		{
+			"write with unknown outcome may not apply",
+			[]Operation{put(0, "a", "1", 0, 10), put(0, "a", "2", 20, Unknown), get(1, "a", "1", 40, 50)},
+			true,
+		},
+		{
+			"keys are independent",
+			[]Operation{put(0, "a", "1", 0, 10), put(0, "b", "1", 0, 10), get(1, "a", "1", 20, 30), get(1, "b", "", 20, 30)},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		r := Check(tt.ops)
+		if r.Linearizable != tt.want {
+			t.Fatalf("%s: expected linearizable %v, got %v (%s)", tt.name, tt.want, r.Linearizable, r)
+		}
+		if !r.Linearizable && len(r.Violations) != 1 {
+			t.Fatalf("%s: expected 1 violation, got %+v", tt.name, r.Violations)
+		}
+	}
+}
+
+func TestCheckMany(t *testing.T) {
+	// concurrent writes of 8 clients, each followed by a read of its value
+	var ops []Operation
+	for i := 0; i < 8; i++ {
+		v := string(rune('a' + i))
+		ops = append(ops, put(i, "k", v, 0, 100), get(i, "k", v, int64(100+i*10), int64(105+i*10)))
+	}
+	if r := Check(ops); r.Linearizable {
+		t.Fatalf("expected reads of different values after all writes to fail, got %s", r)
+	}
+
+	ops = ops[:0]
+	for i := 0; i < 8; i++ {
+		v := string(rune('a' + i))
+		ops = append(ops, put(i, "k", v, int64(i), int64(200+i)), get(i, "k", v, int64(i+1), int64(200+i)))
+	}
+	if r := Check(ops); !r.Linearizable {
+		t.Fatalf("expected concurrent writes and reads to be linearizable, got %s", r)
+	}
+}
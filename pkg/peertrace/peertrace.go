@@ -0,0 +1,375 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package peertrace decodes the raft messages exchanged between etcd
+// members, as written by rafthttp, and counts them per link and message
+// type, keeping a sample of the most recent ones. It turns the raft
+// protocol into something visible: votes during elections, appends and
+// heartbeats from the leader, and snapshots to lagging followers.
+package peertrace
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/pkg/types"
+	"github.com/coreos/etcd/raft/raftpb"
+)
+
+// maxMessageSize bounds the decoded message size, to stop decoding
+// a stream that is out of sync rather than allocating its garbage.
+const maxMessageSize = 512 * 1024 * 1024
+
+// Link is the direction of traffic from a member to another.
+type Link struct {
+	From types.ID
+	To   types.ID
+}
+
+// Flow is the traffic of a message type over a link.
+type Flow struct {
+	From     string
+	To       string
+	Type     string
+	Messages int64
+	// Bytes is the size of the messages on the wire.
+	Bytes int64
+}
+
+// Sample is a traced message.
+type Sample struct {
+	Time    time.Time
+	From    string
+	To      string
+	Type    string
+	Term    uint64
+	Index   uint64
+	Commit  uint64
+	Entries int
+	Bytes   int
+}
+
+type flowKey struct {
+	link Link
+	typ  raftpb.MessageType
+}
+
+// Tracer counts the traced messages, and keeps the most recent ones.
+type Tracer struct {
+	mu      sync.Mutex
+	flows   map[flowKey]*Flow
+	samples []Sample
+	next    int
+	full    bool
+
+	// active is when each link last carried anything,
+	// including the heartbeats that keep streams open
+	active map[Link]time.Time
+}
+
+// New returns a tracer keeping the last 'samples' messages.
+func New(samples int) *Tracer {
+	if samples <= 0 {
+		samples = 1
+	}
+	return &Tracer{flows: make(map[flowKey]*Flow), samples: make([]Sample, samples), active: make(map[Link]time.Time)}
+}
+
+func (t *Tracer) observe(l Link, m *raftpb.Message, size int) {
+	if m.From != 0 {
+		l.From = types.ID(m.From)
+	}
+	if m.To != 0 {
+		l.To = types.ID(m.To)
+	}
+	typ := m.Type.String()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active[l] = time.Now()
+	k := flowKey{link: l, typ: m.Type}
+	f, ok := t.flows[k]
+	if !ok {
+		f = &Flow{From: l.From.String(), To: l.To.String(), Type: typ}
+		t.flows[k] = f
+	}
+	f.Messages++
+	f.Bytes += int64(size)
+
+	t.samples[t.next] = Sample{
+		Time:    time.Now(),
+		From:    f.From,
+		To:      f.To,
+		Type:    typ,
+		Term:    m.Term,
+		Index:   m.Index,
+		Commit:  m.Commit,
+		Entries: len(m.Entries),
+		Bytes:   size,
+	}
+	t.next = (t.next + 1) % len(t.samples)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// Flows returns the traffic per link and message type, sorted by link and type.
+func (t *Tracer) Flows() []Flow {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fs := make([]Flow, 0, len(t.flows))
+	for _, f := range t.flows {
+		fs = append(fs, *f)
+	}
+	sort.Slice(fs, func(i, j int) bool {
+		if fs[i].From != fs[j].From {
+			return fs[i].From < fs[j].From
+		}
+		if fs[i].To != fs[j].To {
+			return fs[i].To < fs[j].To
+		}
+		return fs[i].Type < fs[j].Type
+	})
+	return fs
+}
+
+// Samples returns the most recent messages, oldest first.
+func (t *Tracer) Samples() []Sample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var ss []Sample
+	if t.full {
+		ss = append(ss, t.samples[t.next:]...)
+	}
+	return append(ss, t.samples[:t.next]...)
+}
+
+// touch records activity on the link without tracing a message.
+func (t *Tracer) touch(l Link) {
+	t.mu.Lock()
+	t.active[l] = time.Now()
+	t.mu.Unlock()
+}
+
+// Active returns when each link last carried a message
+// or a stream heartbeat.
+func (t *Tracer) Active() map[Link]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	act := make(map[Link]time.Time, len(t.active))
+	for l, tm := range t.active {
+		act[l] = tm
+	}
+	return act
+}
+
+// Reset discards the counts, the samples and the link activity.
+func (t *Tracer) Reset() {
+	t.mu.Lock()
+	t.flows = make(map[flowKey]*Flow)
+	t.active = make(map[Link]time.Time)
+	t.samples = make([]Sample, len(t.samples))
+	t.next, t.full = 0, false
+	t.mu.Unlock()
+}
+
+// isLinkHeartbeat returns true for the messages rafthttp sends to keep
+// streams open, which are not raft messages.
+func isLinkHeartbeat(m *raftpb.Message) bool {
+	return m.Type == raftpb.MsgHeartbeat && m.From == 0 && m.To == 0
+}
+
+// DecodeMessage traces a single message, as posted by the rafthttp pipeline.
+func (t *Tracer) DecodeMessage(l Link, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var m raftpb.Message
+	if err = m.Unmarshal(b); err != nil {
+		return err
+	}
+	t.observe(l, &m, len(b))
+	return nil
+}
+
+// DecodeSnapshot traces the message heading a snapshot, as posted by
+// rafthttp, and discards the snapshot data.
+func (t *Tracer) DecodeSnapshot(l Link, r io.Reader) error {
+	m, n, err := readMessage(r)
+	if err != nil {
+		return err
+	}
+	t.observe(l, &m, n)
+	_, err = io.Copy(ioutil.Discard, r)
+	return err
+}
+
+// DecodeStream traces the messages of a rafthttp 'message' stream,
+// each one prefixed by its size, until the reader fails.
+func (t *Tracer) DecodeStream(l Link, r io.Reader) error {
+	for {
+		m, n, err := readMessage(r)
+		if err != nil {
+			return err
+		}
+		if isLinkHeartbeat(&m) {
+			t.touch(l)
+		} else {
+			t.observe(l, &m, n)
+		}
+	}
+}
+
+func readMessage(r io.Reader) (m raftpb.Message, n int, err error) {
+	var size uint64
+	if err = binary.Read(r, binary.BigEndian, &size); err != nil {
+		return m, 0, err
+	}
+	if size > maxMessageSize {
+		return m, 0, fmt.Errorf("message of %d bytes exceeds the maximum %d", size, maxMessageSize)
+	}
+	b := make([]byte, int(size))
+	if _, err = io.ReadFull(r, b); err != nil {
+		return m, 0, err
+	}
+	err = m.Unmarshal(b)
+	return m, 8 + int(size), err
+}
+
+// msgappv2 stream message types
+const (
+	msgAppV2LinkHeartbeat uint8 = 0
+	msgAppV2AppEntries    uint8 = 1
+	msgAppV2App           uint8 = 2
+)
+
+// DecodeMsgAppV2 traces the messages of a rafthttp 'msgappv2' stream,
+// which carries the appends of the leader to a follower, until the
+// reader fails. Appends following the previous one are encoded as their
+// entries only, so their term and index are tracked from the stream.
+func (t *Tracer) DecodeMsgAppV2(l Link, r io.Reader) error {
+	var (
+		term, index uint64
+		typ         [1]byte
+		u64         [8]byte
+	)
+	readUint64 := func() (uint64, error) {
+		_, err := io.ReadFull(r, u64[:])
+		return binary.BigEndian.Uint64(u64[:]), err
+	}
+	for {
+		if _, err := io.ReadFull(r, typ[:]); err != nil {
+			return err
+		}
+		switch typ[0] {
+		case msgAppV2LinkHeartbeat:
+			t.touch(l)
+		case msgAppV2AppEntries:
+			m := raftpb.Message{Type: raftpb.MsgApp, Term: term, LogTerm: term, Index: index}
+			cnt, err := readUint64()
+			if err != nil {
+				return err
+			}
+			n := 1 + 8 + 8
+			for i := uint64(0); i < cnt; i++ {
+				size, err := readUint64()
+				if err != nil {
+					return err
+				}
+				if size > maxMessageSize {
+					return fmt.Errorf("entry of %d bytes exceeds the maximum %d", size, maxMessageSize)
+				}
+				if _, err = io.CopyN(ioutil.Discard, r, int64(size)); err != nil {
+					return err
+				}
+				n += 8 + int(size)
+				index++
+			}
+			if m.Commit, err = readUint64(); err != nil {
+				return err
+			}
+			// only the number of entries is sampled
+			m.Entries = make([]raftpb.Entry, int(cnt))
+			t.observe(l, &m, n)
+		case msgAppV2App:
+			m, n, err := readMessage(r)
+			if err != nil {
+				return err
+			}
+			term, index = m.Term, m.Index
+			if len(m.Entries) > 0 {
+				index = m.Entries[len(m.Entries)-1].Index
+			}
+			t.observe(l, &m, 1+n)
+		default:
+			return fmt.Errorf("unknown msgappv2 message type %d", typ[0])
+		}
+	}
+}
+
+// Tap returns a reader of 'rc' that also writes what is read to the
+// decode function, run in its own goroutine. Decoding stops without
+// affecting reads once it fails, and when the reader is closed.
+func Tap(rc io.ReadCloser, decode func(io.Reader) error) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		err := decode(pr)
+		if err == nil {
+			err = io.EOF
+		}
+		pr.CloseWithError(err)
+	}()
+	return &tap{ReadCloser: rc, pw: pw}
+}
+
+type tap struct {
+	io.ReadCloser
+
+	mu sync.Mutex
+	pw *io.PipeWriter
+}
+
+func (t *tap) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.mu.Lock()
+	if t.pw != nil {
+		if n > 0 {
+			if _, werr := t.pw.Write(p[:n]); werr != nil {
+				t.pw = nil
+			}
+		}
+		if err != nil && t.pw != nil {
+			t.pw.CloseWithError(err)
+			t.pw = nil
+		}
+	}
+	t.mu.Unlock()
+	return n, err
+}
+
+func (t *tap) Close() error {
+	t.mu.Lock()
+	if t.pw != nil {
+		t.pw.Close()
+		t.pw = nil
+	}
+	t.mu.Unlock()
+	return t.ReadCloser.Close()
+}
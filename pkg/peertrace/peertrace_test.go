@@ -0,0 +1,158 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peertrace
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/coreos/etcd/raft/raftpb"
+)
+
+func writeMessage(t *testing.T, w io.Writer, m raftpb.Message) {
+	b, err := m.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.Write(w, binary.BigEndian, uint64(len(b)))
+	w.Write(b)
+}
+
+func flowCounts(tr *Tracer) map[string]int64 {
+	cnts := make(map[string]int64)
+	for _, f := range tr.Flows() {
+		cnts[f.From+">"+f.To+" "+f.Type] = f.Messages
+	}
+	return cnts
+}
+
+func TestDecodeStream(t *testing.T) {
+	var buf bytes.Buffer
+	writeMessage(t, &buf, raftpb.Message{Type: raftpb.MsgHeartbeat, From: 1, To: 2, Term: 3})
+	writeMessage(t, &buf, raftpb.Message{Type: raftpb.MsgHeartbeat}) // link heartbeat
+	writeMessage(t, &buf, raftpb.Message{Type: raftpb.MsgVote, From: 1, To: 2, Term: 4})
+	writeMessage(t, &buf, raftpb.Message{Type: raftpb.MsgHeartbeat, From: 1, To: 2, Term: 4})
+
+	tr := New(2)
+	if err := tr.DecodeStream(Link{From: 1, To: 2}, &buf); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	exp := map[string]int64{"1>2 MsgHeartbeat": 2, "1>2 MsgVote": 1}
+	if cnts := flowCounts(tr); !reflect.DeepEqual(cnts, exp) {
+		t.Fatalf("expected %v, got %v", exp, cnts)
+	}
+	ss := tr.Samples()
+	if len(ss) != 2 || ss[0].Type != "MsgVote" || ss[1].Type != "MsgHeartbeat" || ss[1].Term != 4 {
+		t.Fatalf("unexpected samples %+v", ss)
+	}
+
+	tr.Reset()
+	if len(tr.Flows()) != 0 || len(tr.Samples()) != 0 {
+		t.Fatal("expected no traffic after reset")
+	}
+}
+
+func TestActive(t *testing.T) {
+	var buf bytes.Buffer
+	writeMessage(t, &buf, raftpb.Message{Type: raftpb.MsgHeartbeat}) // link heartbeat
+
+	tr := New(1)
+	if err := tr.DecodeStream(Link{From: 2, To: 3}, &buf); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	if len(tr.Flows()) != 0 {
+		t.Fatalf("expected no flows, got %+v", tr.Flows())
+	}
+	act := tr.Active()
+	if _, ok := act[Link{From: 2, To: 3}]; !ok || len(act) != 1 {
+		t.Fatalf("expected 2>3 to be active, got %v", act)
+	}
+
+	tr.Reset()
+	if len(tr.Active()) != 0 {
+		t.Fatal("expected no active links after reset")
+	}
+}
+
+func TestDecodeMsgAppV2(t *testing.T) {
+	var buf bytes.Buffer
+	// a full append, then one encoded as its entries only
+	buf.WriteByte(msgAppV2App)
+	writeMessage(t, &buf, raftpb.Message{Type: raftpb.MsgApp, From: 1, To: 2, Term: 2, LogTerm: 2, Index: 5,
+		Entries: []raftpb.Entry{{Term: 2, Index: 6}}})
+	buf.WriteByte(msgAppV2LinkHeartbeat)
+	buf.WriteByte(msgAppV2AppEntries)
+	binary.Write(&buf, binary.BigEndian, uint64(2))
+	for _, e := range []raftpb.Entry{{Term: 2, Index: 7, Data: []byte("a")}, {Term: 2, Index: 8}} {
+		b, _ := e.Marshal()
+		binary.Write(&buf, binary.BigEndian, uint64(len(b)))
+		buf.Write(b)
+	}
+	binary.Write(&buf, binary.BigEndian, uint64(7))
+
+	tr := New(10)
+	if err := tr.DecodeMsgAppV2(Link{From: 1, To: 2}, &buf); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	ss := tr.Samples()
+	if len(ss) != 2 {
+		t.Fatalf("expected 2 samples, got %+v", ss)
+	}
+	if s := ss[1]; s.Type != "MsgApp" || s.From != "1" || s.To != "2" || s.Index != 6 || s.Entries != 2 || s.Commit != 7 {
+		t.Fatalf("unexpected sample %+v", s)
+	}
+}
+
+func TestTap(t *testing.T) {
+	var buf bytes.Buffer
+	for i := 0; i < 100; i++ {
+		writeMessage(t, &buf, raftpb.Message{Type: raftpb.MsgApp, From: 2, To: 3, Index: uint64(i)})
+	}
+	exp := buf.String()
+
+	tr := New(1)
+	done := make(chan error)
+	rc := Tap(ioutil.NopCloser(&buf), func(r io.Reader) error {
+		err := tr.DecodeStream(Link{}, r)
+		done <- err
+		return err
+	})
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != exp {
+		t.Fatal("tapped reader changed the data")
+	}
+	if err = <-done; err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	if cnts := flowCounts(tr); cnts["2>3 MsgApp"] != 100 {
+		t.Fatalf("expected 100 appends, got %v", cnts)
+	}
+
+	// reads go on once decoding fails
+	rc = Tap(ioutil.NopCloser(bytes.NewBufferString("garbage")), func(r io.Reader) error {
+		return tr.DecodeMsgAppV2(Link{}, r)
+	})
+	if b, err = ioutil.ReadAll(rc); err != nil || string(b) != "garbage" {
+		t.Fatalf("unexpected read %q (%v)", b, err)
+	}
+	rc.Close()
+}
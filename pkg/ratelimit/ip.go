@@ -0,0 +1,222 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// maxIPBuckets bounds the number of client IPs tracked at once.
+var maxIPBuckets = 10000
+
+// IPLimiter limits requests per client IP with token buckets,
+// allowing 'burst' requests at once and one more every interval.
+// IPv6 clients share the bucket of their /64 prefix, since a single
+// host is usually given a whole /64.
+type IPLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	burst     int
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	last    time.Time
+}
+
+// NewIPLimiter returns a new IPLimiter.
+func NewIPLimiter(interval time.Duration, burst int) *IPLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &IPLimiter{
+		interval:  interval,
+		burst:     burst,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow returns true if the IP can make a request now, and consumes a token.
+// Otherwise, it returns a message with the time to wait.
+func (l *IPLimiter) Allow(ip string) (msg string, ok bool) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	key := bucketKey(ip)
+	b, found := l.buckets[key]
+	if !found {
+		if len(l.buckets) >= maxIPBuckets {
+			l.evictOldest()
+		}
+		b = &bucket{limiter: rate.NewLimiter(rate.Every(l.interval), l.burst)}
+		l.buckets[key] = b
+	}
+	b.last = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if d := r.DelayFrom(now); d > 0 {
+		r.CancelAt(now)
+		return fmt.Sprintf("rate limit exceeded for %s (try again after %v)", ip, roundDownDuration(d, time.Millisecond)), false
+	}
+	return OkMessage, true
+}
+
+// bucketKey returns the /64 prefix of IPv6 addresses, and
+// other addresses as they are.
+func bucketKey(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil || addr.To4() != nil {
+		return ip
+	}
+	return addr.Mask(net.CIDRMask(64, 8*net.IPv6len)).String() + "/64"
+}
+
+// sweep drops the buckets that have been idle long enough to be full,
+// since they are the same as new ones.
+func (l *IPLimiter) sweep(now time.Time) {
+	idle := l.interval * time.Duration(l.burst)
+	if now.Sub(l.lastSweep) < idle {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idle {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// evictOldest drops the least recently used bucket, so that the buckets
+// stay bounded when many addresses are seen in one idle period.
+func (l *IPLimiter) evictOldest() {
+	var (
+		oldest string
+		last   time.Time
+	)
+	for key, b := range l.buckets {
+		if last.IsZero() || b.last.Before(last) {
+			oldest, last = key, b.last
+		}
+	}
+	delete(l.buckets, oldest)
+}
+
+// Handler wraps the handler so that requests over the limit
+// get 429 (Too Many Requests).
+func (l *IPLimiter) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if msg, ok := l.Allow(ClientIP(req)); !ok {
+			http.Error(w, msg, http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// TrustedProxies are the reverse proxies whose forwarding headers are
+// trusted, as IP networks.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses IP addresses and CIDR networks.
+func ParseTrustedProxies(ss []string) (TrustedProxies, error) {
+	tp := make(TrustedProxies, 0, len(ss))
+	for _, s := range ss {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			tp = append(tp, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q (%v)", s, err)
+		}
+		tp = append(tp, n)
+	}
+	return tp, nil
+}
+
+func (tp TrustedProxies) contains(s string) bool {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+	for _, n := range tp {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP of the client. If the request comes from a
+// trusted proxy, it is the right-most address of 'X-Forwarded-For' that
+// is not a trusted proxy, since the client can set the addresses on the
+// left. Otherwise, it is the remote address, and the headers are ignored.
+func (tp TrustedProxies) ClientIP(req *http.Request) string {
+	ip := remoteIP(req)
+	if !tp.contains(ip) {
+		return ip
+	}
+	var hops []string
+	for _, v := range req.Header["X-Forwarded-For"] {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		ip = hop
+		if !tp.contains(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// ClientIP returns the remote IP of the request, ignoring forwarding
+// headers. Use TrustedProxies.ClientIP behind a reverse proxy.
+func ClientIP(req *http.Request) string {
+	return remoteIP(req)
+}
+
+func remoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIPLimiter(t *testing.T) {
+	l := NewIPLimiter(100*time.Millisecond, 2)
+
+	for i := 0; i < 2; i++ {
+		if msg, ok := l.Allow("10.0.0.1"); !ok {
+			t.Fatalf("#%d: expected ok, got %q", i, msg)
+		}
+	}
+	if msg, ok := l.Allow("10.0.0.1"); ok {
+		t.Fatalf("expected rate limit excess, got %q", msg)
+	}
+
+	// other IPs have their own buckets
+	if msg, ok := l.Allow("10.0.0.2"); !ok {
+		t.Fatalf("expected ok, got %q", msg)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if msg, ok := l.Allow("10.0.0.1"); !ok {
+		t.Fatalf("expected ok after refill, got %q", msg)
+	}
+}
+
+func TestIPLimiterIPv6Prefix(t *testing.T) {
+	l := NewIPLimiter(time.Hour, 1)
+
+	if msg, ok := l.Allow("2001:db8:1:2::1"); !ok {
+		t.Fatalf("expected ok, got %q", msg)
+	}
+	// addresses of the same /64 share the bucket
+	if msg, ok := l.Allow("2001:db8:1:2:ffff::2"); ok {
+		t.Fatalf("expected rate limit excess in the same /64, got %q", msg)
+	}
+	if msg, ok := l.Allow("2001:db8:1:3::1"); !ok {
+		t.Fatalf("expected ok in another /64, got %q", msg)
+	}
+}
+
+func TestBucketKey(t *testing.T) {
+	tests := []struct {
+		ip  string
+		key string
+	}{
+		{"10.0.0.1", "10.0.0.1"},
+		{"::ffff:10.0.0.1", "::ffff:10.0.0.1"},
+		{"2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+		{"unknown", "unknown"},
+	}
+	for i, tt := range tests {
+		if key := bucketKey(tt.ip); key != tt.key {
+			t.Errorf("#%d: expected %q, got %q", i, tt.key, key)
+		}
+	}
+}
+
+func TestIPLimiterMaxBuckets(t *testing.T) {
+	defer func(n int) { maxIPBuckets = n }(maxIPBuckets)
+	maxIPBuckets = 2
+
+	l := NewIPLimiter(time.Hour, 1)
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		if msg, ok := l.Allow(ip); !ok {
+			t.Fatalf("%s: expected ok, got %q", ip, msg)
+		}
+	}
+	if n := len(l.buckets); n != 2 {
+		t.Fatalf("expected 2 buckets, got %d", n)
+	}
+	if _, ok := l.buckets["10.0.0.1"]; ok {
+		t.Fatal("expected least recently used bucket evicted")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tp, err := ParseTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		proxies TrustedProxies
+		remote  string
+		header  http.Header
+		exp     string
+	}{
+		{nil, "10.0.0.1:1234", http.Header{}, "10.0.0.1"},
+		{nil, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.2.3.4"}}, "10.0.0.1"},
+		{nil, "10.0.0.1:1234", http.Header{"X-Real-Ip": {"1.2.3.4"}}, "10.0.0.1"},
+		{tp, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.2.3.4"}}, "1.2.3.4"},
+		// the client sets the left-most addresses
+		{tp, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"5.6.7.8, 1.2.3.4"}}, "1.2.3.4"},
+		{tp, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"5.6.7.8, 1.2.3.4, 192.168.1.1"}}, "1.2.3.4"},
+		{tp, "10.0.0.2:1234", http.Header{"X-Forwarded-For": {"1.2.3.4"}}, "10.0.0.2"},
+		{tp, "10.0.0.1:1234", http.Header{}, "10.0.0.1"},
+	}
+	for i, tt := range tests {
+		req := &http.Request{RemoteAddr: tt.remote, Header: tt.header}
+		if ip := tt.proxies.ClientIP(req); ip != tt.exp {
+			t.Errorf("#%d: expected %q, got %q", i, tt.exp, ip)
+		}
+	}
+}
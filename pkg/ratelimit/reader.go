@@ -0,0 +1,50 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// NewReader returns a reader of 'r' whose throughput in bytes per second
+// is limited by the limiter that 'limiter' returns on each read, so the
+// limit can change while reading. Reads are not limited if it returns nil.
+func NewReader(r io.Reader, limiter func() *rate.Limiter) io.Reader {
+	return &reader{r: r, limiter: limiter}
+}
+
+type reader struct {
+	r       io.Reader
+	limiter func() *rate.Limiter
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	l := r.limiter()
+	if l == nil {
+		return r.r.Read(p)
+	}
+	// never read more than the limiter allows at once
+	if b := l.Burst(); b > 0 && len(p) > b {
+		p = p[:b]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		l.WaitN(context.Background(), n)
+	}
+	return n, err
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestReader(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 300)
+
+	// 1000 bytes/s with a burst of 100 bytes takes about 200ms for 300 bytes
+	l := rate.NewLimiter(1000, 100)
+	start := time.Now()
+	b, err := ioutil.ReadAll(NewReader(bytes.NewReader(data), func() *rate.Limiter { return l }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, data) {
+		t.Fatalf("expected %d bytes, got %d", len(data), len(b))
+	}
+	if took := time.Since(start); took < 150*time.Millisecond {
+		t.Fatalf("expected limited reads, took %v", took)
+	}
+
+	start = time.Now()
+	if _, err = ioutil.ReadAll(NewReader(bytes.NewReader(data), func() *rate.Limiter { return nil })); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took > 100*time.Millisecond {
+		t.Fatalf("expected unlimited reads, took %v", took)
+	}
+}
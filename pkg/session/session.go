@@ -0,0 +1,342 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session is the state of a visitor. A new session is saved to the
+// store on its first Set, so that visitors who never write state do
+// not grow the store.
+type Session struct {
+	ID      string
+	Created time.Time
+
+	store Store
+
+	mu         sync.RWMutex
+	stored     bool
+	lastActive time.Time
+	requests   int64
+	values     map[string]string
+}
+
+// Stored returns true if the session is saved in the store.
+func (s *Session) Stored() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stored
+}
+
+// LastActive returns the time of the last request in the session.
+func (s *Session) LastActive() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastActive
+}
+
+// Requests returns the number of requests in the session.
+func (s *Session) Requests() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.requests
+}
+
+// Get returns the value of the key.
+func (s *Session) Get(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values[key]
+}
+
+// Set sets the value of the key, and saves the session.
+func (s *Session) Set(key, value string) {
+	s.mu.Lock()
+	if v, ok := s.values[key]; ok && v == value && s.stored {
+		s.mu.Unlock()
+		return
+	}
+	s.values[key] = value
+	s.stored = true
+	s.mu.Unlock()
+	s.store.Put(s)
+}
+
+// Delete deletes the key, and saves the session if it is stored.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	_, ok := s.values[key]
+	delete(s.values, key)
+	stored := s.stored
+	s.mu.Unlock()
+	if ok && stored {
+		s.store.Put(s)
+	}
+}
+
+// Values returns a copy of all values.
+func (s *Session) Values() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	vs := make(map[string]string, len(s.values))
+	for k, v := range s.values {
+		vs[k] = v
+	}
+	return vs
+}
+
+// Record is the serializable state of a session.
+type Record struct {
+	ID         string
+	Created    time.Time
+	LastActive time.Time
+	Requests   int64
+	Values     map[string]string
+}
+
+// Record returns a copy of the session state, for stores that
+// serialize sessions.
+func (s *Session) Record() Record {
+	return Record{ID: s.ID, Created: s.Created, LastActive: s.LastActive(), Requests: s.Requests(), Values: s.Values()}
+}
+
+// Restore returns the stored session of the record, which saves
+// itself to the store.
+func Restore(st Store, r Record) *Session {
+	vs := make(map[string]string, len(r.Values))
+	for k, v := range r.Values {
+		vs[k] = v
+	}
+	return &Session{
+		ID:         r.ID,
+		Created:    r.Created,
+		store:      st,
+		stored:     true,
+		lastActive: r.LastActive,
+		requests:   r.Requests,
+		values:     vs,
+	}
+}
+
+func (s *Session) touch(now time.Time) {
+	s.mu.Lock()
+	s.lastActive = now
+	s.requests++
+	stored := s.stored
+	s.mu.Unlock()
+	if stored {
+		s.store.Put(s)
+	}
+}
+
+// Config configures a Manager.
+type Config struct {
+	// CookieName is the session cookie ("session" if empty).
+	CookieName string
+	// IdleTTL expires sessions without requests for the duration,
+	// and AbsoluteTTL expires sessions that long after creation.
+	// Sessions do not expire if zero.
+	IdleTTL     time.Duration
+	AbsoluteTTL time.Duration
+	// Store keeps the sessions (in memory if nil).
+	Store Store
+	// Secure sets the Secure attribute of the cookie, for HTTPS.
+	Secure bool
+}
+
+// Manager starts and expires sessions.
+type Manager struct {
+	cfg Config
+	// secret signs session IDs and their issue time, so that the ID of
+	// a session that was never stored can be resumed until it expires,
+	// but not chosen by the client
+	secret []byte
+}
+
+const defaultCookieName = "session"
+
+// NewManager returns a new Manager.
+func NewManager(cfg Config) *Manager {
+	if cfg.CookieName == "" {
+		cfg.CookieName = defaultCookieName
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return &Manager{cfg: cfg, secret: secret}
+}
+
+// Store returns the store of the sessions.
+func (m *Manager) Store() Store {
+	return m.cfg.Store
+}
+
+func (m *Manager) expired(s *Session, now time.Time) bool {
+	if m.cfg.IdleTTL > 0 && now.Sub(s.LastActive()) > m.cfg.IdleTTL {
+		return true
+	}
+	return m.cfg.AbsoluteTTL > 0 && now.Sub(s.Created) > m.cfg.AbsoluteTTL
+}
+
+// expiredID returns true if the ID of a session that was never stored
+// has expired. Such sessions have no record of their last request, so
+// both TTLs count from the time the ID was issued.
+func (m *Manager) expiredID(issued, now time.Time) bool {
+	ttl := m.cfg.AbsoluteTTL
+	if m.cfg.IdleTTL > 0 && (ttl == 0 || m.cfg.IdleTTL < ttl) {
+		ttl = m.cfg.IdleTTL
+	}
+	return ttl > 0 && now.Sub(issued) > ttl
+}
+
+func (m *Manager) sign(payload string) string {
+	h := hmac.New(sha256.New, m.secret)
+	h.Write([]byte(payload))
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// newID returns a random ID of the form "nonce.issued.signature".
+func (m *Manager) newID(now time.Time) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	payload := hex.EncodeToString(b) + "." + strconv.FormatInt(now.UnixNano(), 16)
+	return payload + "." + m.sign(payload), nil
+}
+
+// parseID returns the issue time of the ID, or false if the ID
+// was not signed by the manager.
+func (m *Manager) parseID(id string) (time.Time, bool) {
+	i := strings.LastIndex(id, ".")
+	if i <= 0 || !hmac.Equal([]byte(id[i+1:]), []byte(m.sign(id[:i]))) {
+		return time.Time{}, false
+	}
+	j := strings.Index(id[:i], ".")
+	if j <= 0 {
+		return time.Time{}, false
+	}
+	ns, err := strconv.ParseInt(id[j+1:i], 16, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ns), true
+}
+
+// Start returns the session of the request cookie, or starts a new session
+// if there is none or it has expired, and sets the cookie on the response.
+// New sessions are stored on their first Set. A valid cookie of a session
+// that was never stored (e.g. a WebSocket request after the first page
+// load) resumes its ID until the ID expires, since the cookie cannot be
+// replaced afterwards.
+func (m *Manager) Start(w http.ResponseWriter, req *http.Request) (*Session, error) {
+	now := time.Now()
+	id, created := "", now
+	if c, err := req.Cookie(m.cfg.CookieName); err == nil {
+		if s, ok := m.cfg.Store.Get(c.Value); ok {
+			if !m.expired(s, now) {
+				s.touch(now)
+				m.setCookie(w, s)
+				return s, nil
+			}
+			m.cfg.Store.Delete(s.ID)
+		} else if issued, ok := m.parseID(c.Value); ok && !m.expiredID(issued, now) {
+			id, created = c.Value, issued
+		}
+	}
+
+	if id == "" {
+		var err error
+		if id, err = m.newID(now); err != nil {
+			return nil, err
+		}
+	}
+	s := &Session{ID: id, Created: created, store: m.cfg.Store, lastActive: now, requests: 1, values: make(map[string]string)}
+	m.setCookie(w, s)
+	return s, nil
+}
+
+func (m *Manager) setCookie(w http.ResponseWriter, s *Session) {
+	c := &http.Cookie{
+		Name:     m.cfg.CookieName,
+		Value:    s.ID,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   m.cfg.Secure,
+	}
+	if m.cfg.AbsoluteTTL > 0 {
+		c.Expires = s.Created.Add(m.cfg.AbsoluteTTL)
+	}
+	http.SetCookie(w, c)
+}
+
+// Delete ends the session.
+func (m *Manager) Delete(id string) {
+	m.cfg.Store.Delete(id)
+}
+
+// Sweep deletes expired sessions, and returns the number of deleted ones.
+func (m *Manager) Sweep() int {
+	now, n := time.Now(), 0
+	for _, s := range m.cfg.Store.List() {
+		if m.expired(s, now) {
+			m.cfg.Store.Delete(s.ID)
+			n++
+		}
+	}
+	return n
+}
+
+type key int
+
+const sessionKey key = 0
+
+// NewContext returns a context with the session.
+func NewContext(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey, s)
+}
+
+// FromContext returns the session of the context, or nil if none.
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey).(*Session)
+	return s
+}
+
+// Handler wraps the handler to start the session of each request,
+// which handlers get with FromContext.
+func (m *Manager) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s, err := m.Start(w, req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.ServeHTTP(w, req.WithContext(NewContext(req.Context(), s)))
+	})
+}
@@ -0,0 +1,232 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+	m := NewManager(Config{IdleTTL: 50 * time.Millisecond})
+
+	w := httptest.NewRecorder()
+	s1, err := m.Start(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1.Set("endpoint", "localhost:2379")
+	cookie := w.Result().Cookies()[0]
+
+	// the cookie resumes the session
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	s2, err := m.Start(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.ID != s1.ID || s2.Get("endpoint") != "localhost:2379" {
+		t.Fatalf("expected session %q to resume, got %q", s1.ID, s2.ID)
+	}
+	if n := s2.Requests(); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+
+	// idle sessions expire
+	time.Sleep(100 * time.Millisecond)
+	s3, err := m.Start(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3.ID == s1.ID {
+		t.Fatal("expected new session after idle timeout")
+	}
+	if _, ok := m.Store().Get(s1.ID); ok {
+		t.Fatal("expected expired session to be deleted")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	m := NewManager(Config{AbsoluteTTL: 50 * time.Millisecond})
+	for i := 0; i < 3; i++ {
+		s, err := m.Start(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Set("endpoint", "localhost:2379")
+	}
+	if n := m.Sweep(); n != 0 {
+		t.Fatalf("expected no expired session, got %d", n)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := m.Sweep(); n != 3 {
+		t.Fatalf("expected 3 expired sessions, got %d", n)
+	}
+}
+
+func TestStoreOnFirstSet(t *testing.T) {
+	m := NewManager(Config{})
+
+	w := httptest.NewRecorder()
+	s1, err := m.Start(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(m.Store().List()); n != 0 {
+		t.Fatalf("expected no stored session before Set, got %d", n)
+	}
+	cookie := w.Result().Cookies()[0]
+
+	// the cookie of a session that was never stored resumes its ID
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	s2, err := m.Start(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.ID != s1.ID {
+		t.Fatalf("expected session %q to resume, got %q", s1.ID, s2.ID)
+	}
+	s2.Set("endpoint", "localhost:2379")
+	if _, ok := m.Store().Get(s1.ID); !ok || !s2.Stored() {
+		t.Fatal("expected session stored after Set")
+	}
+
+	// IDs not signed by the manager are not resumed
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: defaultCookieName, Value: "0123.4567"})
+	s3, err := m.Start(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3.ID == "0123.4567" {
+		t.Fatal("expected new session ID for forged cookie")
+	}
+}
+
+func TestUnstoredExpiry(t *testing.T) {
+	m := NewManager(Config{AbsoluteTTL: 50 * time.Millisecond})
+
+	w := httptest.NewRecorder()
+	s1, err := m.Start(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(w.Result().Cookies()[0])
+
+	s2, err := m.Start(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.ID != s1.ID || !s2.Created.Equal(s1.Created) {
+		t.Fatalf("expected session %q created at %v to resume, got %q created at %v", s1.ID, s1.Created, s2.ID, s2.Created)
+	}
+
+	// the ID of a session that was never stored expires too
+	time.Sleep(100 * time.Millisecond)
+	s3, err := m.Start(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3.ID == s1.ID {
+		t.Fatal("expected new session after the ID expired")
+	}
+}
+
+// jsonStore keeps sessions serialized in JSON.
+type jsonStore struct {
+	mu      sync.Mutex
+	records map[string][]byte
+}
+
+func (js *jsonStore) Get(id string) (*Session, bool) {
+	js.mu.Lock()
+	b, ok := js.records[id]
+	js.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	var r Record
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, false
+	}
+	return Restore(js, r), true
+}
+
+func (js *jsonStore) Put(s *Session) {
+	b, err := json.Marshal(s.Record())
+	if err != nil {
+		panic(err)
+	}
+	js.mu.Lock()
+	js.records[s.ID] = b
+	js.mu.Unlock()
+}
+
+func (js *jsonStore) Delete(id string) {
+	js.mu.Lock()
+	delete(js.records, id)
+	js.mu.Unlock()
+}
+
+func (js *jsonStore) List() []*Session {
+	js.mu.Lock()
+	var ids []string
+	for id := range js.records {
+		ids = append(ids, id)
+	}
+	js.mu.Unlock()
+	var ss []*Session
+	for _, id := range ids {
+		if s, ok := js.Get(id); ok {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+func TestSerializedStore(t *testing.T) {
+	m := NewManager(Config{Store: &jsonStore{records: make(map[string][]byte)}})
+
+	w := httptest.NewRecorder()
+	s1, err := m.Start(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1.Set("endpoint", "localhost:2379")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(w.Result().Cookies()[0])
+	s2, err := m.Start(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.ID != s1.ID || !s2.Stored() || s2.Get("endpoint") != "localhost:2379" {
+		t.Fatalf("expected session %q to resume from its record, got %+v", s1.ID, s2.Record())
+	}
+	if n := s2.Requests(); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+
+	s2.Set("user", "alice")
+	if s3, ok := m.Store().Get(s1.ID); !ok || s3.Get("user") != "alice" {
+		t.Fatal("expected Set to save the restored session")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"sort"
+	"sync"
+)
+
+// Store keeps sessions by ID. Stores that serialize sessions save
+// the Record of the session in Put, and return the Restore-d session
+// of the record in Get and List.
+type Store interface {
+	// Get returns the session, or false if none.
+	Get(id string) (*Session, bool)
+	// Put saves the session.
+	Put(s *Session)
+	// Delete removes the session.
+	Delete(id string)
+	// List returns all sessions.
+	List() []*Session
+}
+
+type memoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewMemoryStore returns a Store in memory, which loses
+// sessions when the process exits.
+func NewMemoryStore() Store {
+	return &memoryStore{sessions: make(map[string]*Session)}
+}
+
+func (ms *memoryStore) Get(id string) (*Session, bool) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	s, ok := ms.sessions[id]
+	return s, ok
+}
+
+func (ms *memoryStore) Put(s *Session) {
+	ms.mu.Lock()
+	ms.sessions[s.ID] = s
+	ms.mu.Unlock()
+}
+
+func (ms *memoryStore) Delete(id string) {
+	ms.mu.Lock()
+	delete(ms.sessions, id)
+	ms.mu.Unlock()
+}
+
+func (ms *memoryStore) List() []*Session {
+	ms.mu.RLock()
+	ss := make([]*Session, 0, len(ms.sessions))
+	for _, s := range ms.sessions {
+		ss = append(ss, s)
+	}
+	ms.mu.RUnlock()
+	sort.Slice(ss, func(i, j int) bool { return ss[i].Created.Before(ss[j].Created) })
+	return ss
+}
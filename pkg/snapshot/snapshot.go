@@ -0,0 +1,136 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snapshot reads the keyspace of etcd v3 snapshot files (as saved
+// by 'etcdctl snapshot save'), and compares keyspaces, to verify backups
+// and to see the drift of a cluster after a restore. It also reads the page
+// statistics of backend files, to see how much space defragmentation frees.
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+var keyBucket = []byte("key")
+
+// revBytesLen is the length of a revision key in the key bucket, which is
+// followed by a 't' mark for deletions (see the etcd mvcc package).
+const revBytesLen = 8 + 1 + 8
+
+// Keyspace is the keys and values at the last revision of a snapshot.
+type Keyspace struct {
+	Revision int64
+	KVs      map[string]string
+}
+
+// ReadKeyspace reads the keys and values at the last revision of the
+// snapshot file, including the compacted revisions it still holds.
+func ReadKeyspace(path string) (Keyspace, error) {
+	if _, err := os.Stat(path); err != nil {
+		return Keyspace{}, err
+	}
+	db, err := bolt.Open(path, 0400, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return Keyspace{}, fmt.Errorf("failed to open snapshot %q (%v)", path, err)
+	}
+	defer db.Close()
+
+	ks := Keyspace{KVs: make(map[string]string)}
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(keyBucket)
+		if b == nil {
+			return fmt.Errorf("%q has no key bucket", path)
+		}
+		// revision keys sort by revision, so the last change of a key wins
+		return b.ForEach(func(k, v []byte) error {
+			if len(k) < revBytesLen {
+				return fmt.Errorf("invalid revision key %x", k)
+			}
+			var kv mvccpb.KeyValue
+			if err := kv.Unmarshal(v); err != nil {
+				return err
+			}
+			if kv.ModRevision > ks.Revision {
+				ks.Revision = kv.ModRevision
+			}
+			if len(k) > revBytesLen && k[revBytesLen] == 't' {
+				delete(ks.KVs, string(kv.Key))
+				return nil
+			}
+			ks.KVs[string(kv.Key)] = string(kv.Value)
+			return nil
+		})
+	})
+	if err != nil {
+		return Keyspace{}, fmt.Errorf("failed to read snapshot %q (%v)", path, err)
+	}
+	return ks, nil
+}
+
+// Change is a key that differs between two keyspaces. Before is empty
+// for added keys, and After for removed keys.
+type Change struct {
+	Key    string
+	Before string `json:",omitempty"`
+	After  string `json:",omitempty"`
+}
+
+// Diff is the difference between two keyspaces.
+type Diff struct {
+	Added     []Change
+	Removed   []Change
+	Changed   []Change
+	Unchanged int
+}
+
+// Identical is true if the keyspaces have the same keys and values.
+func (d Diff) Identical() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("%d added, %d removed, %d changed and %d unchanged keys", len(d.Added), len(d.Removed), len(d.Changed), d.Unchanged)
+}
+
+// Compare returns the keys added, removed and changed from 'before' to
+// 'after', sorted by key.
+func Compare(before, after map[string]string) Diff {
+	var d Diff
+	for k, v := range before {
+		av, ok := after[k]
+		switch {
+		case !ok:
+			d.Removed = append(d.Removed, Change{Key: k, Before: v})
+		case av != v:
+			d.Changed = append(d.Changed, Change{Key: k, Before: v, After: av})
+		default:
+			d.Unchanged++
+		}
+	}
+	for k, v := range after {
+		if _, ok := before[k]; !ok {
+			d.Added = append(d.Added, Change{Key: k, After: v})
+		}
+	}
+	for _, cs := range [][]Change{d.Added, d.Removed, d.Changed} {
+		sort.Slice(cs, func(i, j int) bool { return cs[i].Key < cs[j].Key })
+	}
+	return d
+}
@@ -0,0 +1,160 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// writeSnapshot writes the changes to the key bucket of a new database,
+// as etcd does, deleting the keys with an empty value.
+func writeSnapshot(t *testing.T, path string, changes [][2]string) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket(keyBucket)
+		if err != nil {
+			return err
+		}
+		for i, c := range changes {
+			rev := int64(i + 2)
+			k := make([]byte, revBytesLen, revBytesLen+1)
+			binary.BigEndian.PutUint64(k, uint64(rev))
+			k[8] = '_'
+			kv := mvccpb.KeyValue{Key: []byte(c[0]), Value: []byte(c[1]), ModRevision: rev}
+			if c[1] == "" {
+				k = append(k, 't')
+			}
+			v, err := kv.Marshal()
+			if err != nil {
+				return err
+			}
+			if err = b.Put(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadKeyspace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "snapshot.db")
+	writeSnapshot(t, path, [][2]string{{"a", "1"}, {"b", "1"}, {"a", "2"}, {"b", ""}, {"c", "3"}})
+	ks, err := ReadKeyspace(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := map[string]string{"a": "2", "c": "3"}; !reflect.DeepEqual(ks.KVs, exp) {
+		t.Errorf("expected %v, got %v", exp, ks.KVs)
+	}
+	if ks.Revision != 6 {
+		t.Errorf("expected revision 6, got %d", ks.Revision)
+	}
+
+	if _, err = ReadKeyspace(filepath.Join(dir, "missing.db")); err == nil {
+		t.Error("expected error for a missing snapshot")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	d := Compare(
+		map[string]string{"a": "1", "b": "1", "c": "1", "d": "1"},
+		map[string]string{"a": "1", "b": "2", "e": "1", "f": "1"},
+	)
+	exp := Diff{
+		Added:     []Change{{Key: "e", After: "1"}, {Key: "f", After: "1"}},
+		Removed:   []Change{{Key: "c", Before: "1"}, {Key: "d", Before: "1"}},
+		Changed:   []Change{{Key: "b", Before: "1", After: "2"}},
+		Unchanged: 1,
+	}
+	if !reflect.DeepEqual(d, exp) {
+		t.Errorf("expected %+v, got %+v", exp, d)
+	}
+	if d.Identical() {
+		t.Error("expected different keyspaces")
+	}
+	if !Compare(map[string]string{"a": "1"}, map[string]string{"a": "1"}).Identical() {
+		t.Error("expected identical keyspaces")
+	}
+}
+
+func TestReadBackendStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "db")
+	var changes [][2]string
+	for i := 0; i < 1000; i++ {
+		changes = append(changes, [2]string{fmt.Sprintf("key%d", i), strings.Repeat("v", 100)})
+	}
+	writeSnapshot(t, path, changes)
+
+	before, err := ReadBackendStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(before.Buckets) != 1 || before.Buckets[0].Name != "key" || before.Buckets[0].Keys != 1000 {
+		t.Fatalf("expected 1000 keys in the key bucket, got %+v", before.Buckets)
+	}
+	if before.Size != int64(before.Pages*before.PageSize) || before.FileSize < before.Size {
+		t.Errorf("expected size %d of %d pages within the file size, got %d and %d", before.Pages*before.PageSize, before.Pages, before.Size, before.FileSize)
+	}
+
+	// deleting the keys frees their pages, without shrinking the file
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Update(func(tx *bolt.Tx) error { return tx.DeleteBucket(keyBucket) }); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	after, err := ReadBackendStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size != before.Size {
+		t.Errorf("expected size %d, got %d", before.Size, after.Size)
+	}
+	if after.FreePages <= before.FreePages || after.SizeInUse >= before.SizeInUse || after.Fragmentation <= before.Fragmentation {
+		t.Errorf("expected more free pages after deleting keys, got %+v before and %+v after", before, after)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+)
+
+// BackendStats describes the pages of a bolt database file,
+// such as an etcd backend.
+type BackendStats struct {
+	// Size is the size of the pages up to the high watermark, as etcd
+	// DBSize, and FileSize the size of the file, which bolt grows ahead.
+	Size     int64
+	FileSize int64
+	PageSize int
+	// Pages is the number of pages of the database.
+	Pages int
+	// FreePages are on the freelist, to be reused by later writes,
+	// and PendingPages are freed by transactions still open.
+	FreePages     int
+	PendingPages  int
+	FreelistBytes int
+	// SizeInUse is the size without the free pages, as etcd DBSizeInUse.
+	// Defragmentation shrinks the file to about this size.
+	SizeInUse int64
+	// Fragmentation is the ratio of free and pending pages.
+	Fragmentation float64
+	Buckets       []BucketStats
+}
+
+// BucketStats describes the pages of a top-level bucket.
+type BucketStats struct {
+	Name string
+	Keys int
+	// Depth is the depth of the B+tree.
+	Depth         int
+	BranchPages   int
+	LeafPages     int
+	OverflowPages int
+	// AllocBytes are the bytes of the pages, and InuseBytes the bytes
+	// used by the data in them.
+	AllocBytes int
+	InuseBytes int
+}
+
+// ReadBackendStats reads the page statistics of the database file. The
+// freelist is only loaded by writable databases, so the file is opened
+// for writing (but not written to), and must not be open by etcd: read
+// a snapshot, or a copy of the file of a stopped member.
+func ReadBackendStats(path string) (BackendStats, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return BackendStats{}, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return BackendStats{}, err
+	}
+	defer db.Close()
+
+	st := BackendStats{FileSize: fi.Size(), PageSize: db.Info().PageSize}
+	// a rolled back writable transaction updates the freelist stats
+	tx, err := db.Begin(true)
+	if err != nil {
+		return BackendStats{}, err
+	}
+	st.Size = tx.Size()
+	st.Pages = int(st.Size / int64(st.PageSize))
+	err = tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		bs := b.Stats()
+		st.Buckets = append(st.Buckets, BucketStats{
+			Name:          string(name),
+			Keys:          bs.KeyN,
+			Depth:         bs.Depth,
+			BranchPages:   bs.BranchPageN,
+			LeafPages:     bs.LeafPageN,
+			OverflowPages: bs.BranchOverflowN + bs.LeafOverflowN,
+			AllocBytes:    bs.BranchAlloc + bs.LeafAlloc,
+			InuseBytes:    bs.BranchInuse + bs.LeafInuse,
+		})
+		return nil
+	})
+	tx.Rollback()
+	if err != nil {
+		return BackendStats{}, err
+	}
+	sort.Slice(st.Buckets, func(i, j int) bool { return st.Buckets[i].Name < st.Buckets[j].Name })
+
+	ds := db.Stats()
+	st.FreePages, st.PendingPages, st.FreelistBytes = ds.FreePageN, ds.PendingPageN, ds.FreelistInuse
+	free := int64(ds.FreePageN+ds.PendingPageN) * int64(st.PageSize)
+	st.SizeInUse = st.Size - free
+	if st.Size > 0 {
+		st.Fragmentation = float64(free) / float64(st.Size)
+	}
+	return st, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package token issues and checks the bearer tokens of the control API,
+// either static tokens or HMAC-signed tokens that expire.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Access levels of tokens.
+const (
+	// AccessRead allows reading the status and events.
+	AccessRead = "read"
+	// AccessControl allows all operations, including the ones
+	// that change the cluster.
+	AccessControl = "control"
+)
+
+// ValidAccess returns true if the access level is known.
+func ValidAccess(access string) bool {
+	return access == AccessRead || access == AccessControl
+}
+
+// Allows returns true if the token access level covers 'need'.
+func Allows(access, need string) bool {
+	return access == AccessControl || access == need
+}
+
+// NewHMAC returns a token with the access level that expires
+// at the time, signed with the secret.
+func NewHMAC(secret []byte, access string, expiry time.Time) (string, error) {
+	if !ValidAccess(access) {
+		return "", fmt.Errorf("unknown access level %q", access)
+	}
+	payload := access + "." + strconv.FormatInt(expiry.Unix(), 10)
+	return payload + "." + sign(secret, payload), nil
+}
+
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Checker checks tokens against the static tokens and the HMAC secret.
+type Checker struct {
+	// Tokens maps static tokens to their access level.
+	Tokens map[string]string
+	// HMACSecret, if not empty, accepts the tokens of NewHMAC.
+	HMACSecret []byte
+}
+
+// Enabled returns true if any token is accepted.
+func (c Checker) Enabled() bool {
+	return len(c.Tokens) > 0 || len(c.HMACSecret) > 0
+}
+
+// Validate returns an error if a static token has an unknown access level.
+func (c Checker) Validate() error {
+	for _, access := range c.Tokens {
+		if !ValidAccess(access) {
+			return fmt.Errorf("unknown access level %q", access)
+		}
+	}
+	return nil
+}
+
+// Access returns the access level of the token.
+func (c Checker) Access(token string) (string, error) {
+	for t, access := range c.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return access, nil
+		}
+	}
+	if len(c.HMACSecret) > 0 {
+		parts := strings.Split(token, ".")
+		if len(parts) == 3 && ValidAccess(parts[0]) {
+			payload := parts[0] + "." + parts[1]
+			if hmac.Equal([]byte(sign(c.HMACSecret, payload)), []byte(parts[2])) {
+				expiry, err := strconv.ParseInt(parts[1], 10, 64)
+				if err != nil {
+					return "", fmt.Errorf("invalid token expiry %q", parts[1])
+				}
+				if time.Now().Unix() > expiry {
+					return "", fmt.Errorf("token expired at %v", time.Unix(expiry, 0))
+				}
+				return parts[0], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("invalid token")
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustHMAC(t *testing.T, secret []byte, access string, expiry time.Time) string {
+	tk, err := NewHMAC(secret, access, expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tk
+}
+
+func TestAccess(t *testing.T) {
+	secret := []byte("secret")
+	c := Checker{Tokens: map[string]string{"static-read": AccessRead}, HMACSecret: secret}
+
+	valid := mustHMAC(t, secret, AccessControl, time.Now().Add(time.Hour))
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name   string
+		token  string
+		access string
+		err    string
+	}{
+		{"static", "static-read", AccessRead, ""},
+		{"valid", valid, AccessControl, ""},
+		{"read", mustHMAC(t, secret, AccessRead, time.Now().Add(time.Hour)), AccessRead, ""},
+		{"expired", mustHMAC(t, secret, AccessControl, time.Now().Add(-time.Hour)), "", "token expired"},
+		{"tampered access", AccessRead + "." + parts[1] + "." + parts[2], "", "invalid token"},
+		{"tampered expiry", parts[0] + ".9999999999." + parts[2], "", "invalid token"},
+		{"other secret", mustHMAC(t, []byte("other"), AccessControl, time.Now().Add(time.Hour)), "", "invalid token"},
+		{"wrong access", "admin." + parts[1] + "." + sign(secret, "admin."+parts[1]), "", "invalid token"},
+		{"malformed", "garbage", "", "invalid token"},
+	}
+	for _, tt := range tests {
+		access, err := c.Access(tt.token)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected error %q, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if access != tt.access {
+			t.Errorf("%s: expected access %q, got %q", tt.name, tt.access, access)
+		}
+	}
+}
+
+func TestNewHMACUnknownAccess(t *testing.T) {
+	if _, err := NewHMAC([]byte("secret"), "admin", time.Now().Add(time.Hour)); err == nil {
+		t.Fatal("expected error for unknown access level")
+	}
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		access string
+		need   string
+		exp    bool
+	}{
+		{AccessControl, AccessControl, true},
+		{AccessControl, AccessRead, true},
+		{AccessRead, AccessRead, true},
+		{AccessRead, AccessControl, false},
+	}
+	for i, tt := range tests {
+		if ok := Allows(tt.access, tt.need); ok != tt.exp {
+			t.Errorf("#%d: expected %v, got %v", i, tt.exp, ok)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wal decodes the write-ahead log of an etcd member into readable
+// entries, to show what raft persisted: the hard state, and for each entry
+// its term, index, type and a summary of the request it carries.
+package wal
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/pkg/pbutil"
+	"github.com/coreos/etcd/pkg/types"
+	"github.com/coreos/etcd/raft/raftpb"
+	etcdwal "github.com/coreos/etcd/wal"
+	"github.com/coreos/etcd/wal/walpb"
+)
+
+// Log is the decoded content of a WAL directory.
+type Log struct {
+	NodeID    string
+	ClusterID string
+	// Term, Vote and Commit are the last saved raft hard state.
+	Term    uint64
+	Vote    uint64
+	Commit  uint64
+	Entries []Entry
+}
+
+// Entry is a decoded raft entry.
+type Entry struct {
+	Term  uint64
+	Index uint64
+	// Type is "normal" or "conf-change".
+	Type string
+	// Size is the size of the entry payload in bytes.
+	Size    int
+	Summary string
+}
+
+// Read decodes the WAL files in the directory. It does not lock the files,
+// so the member must be stopped for the log to be complete.
+func Read(dir string) (Log, error) {
+	w, err := etcdwal.OpenForRead(dir, walpb.Snapshot{})
+	if err != nil {
+		return Log{}, err
+	}
+	defer w.Close()
+
+	md, st, ents, err := w.ReadAll()
+	// the log may start after a snapshot, whose entries are gone
+	if err != nil && err != etcdwal.ErrSnapshotNotFound {
+		return Log{}, err
+	}
+
+	lg := Log{Term: st.Term, Vote: st.Vote, Commit: st.Commit}
+	if md != nil {
+		var m pb.Metadata
+		pbutil.MustUnmarshal(&m, md)
+		lg.NodeID, lg.ClusterID = types.ID(m.NodeID).String(), types.ID(m.ClusterID).String()
+	}
+	lg.Entries = make([]Entry, 0, len(ents))
+	for _, e := range ents {
+		lg.Entries = append(lg.Entries, Decode(e))
+	}
+	return lg, nil
+}
+
+// Decode decodes the raft entry.
+func Decode(e raftpb.Entry) Entry {
+	ent := Entry{Term: e.Term, Index: e.Index, Type: "normal", Size: len(e.Data)}
+	if e.Type == raftpb.EntryConfChange {
+		ent.Type = "conf-change"
+		var cc raftpb.ConfChange
+		if err := cc.Unmarshal(e.Data); err != nil {
+			ent.Summary = fmt.Sprintf("invalid conf change (%v)", err)
+			return ent
+		}
+		ent.Summary = fmt.Sprintf("%s %s", strings.TrimPrefix(cc.Type.String(), "ConfChange"), types.ID(cc.NodeID))
+		return ent
+	}
+	ent.Summary = summarize(e.Data)
+	return ent
+}
+
+// summarize describes the request of a normal entry, which is either an
+// InternalRaftRequest, or a v2 Request written by older members.
+func summarize(data []byte) string {
+	if len(data) == 0 {
+		// appended by a new leader, to commit the entries of previous terms
+		return "empty (new leader)"
+	}
+	var r pb.InternalRaftRequest
+	if err := r.Unmarshal(data); err == nil {
+		if s := summarizeV3(&r); s != "" {
+			return s
+		}
+	}
+	var v2 pb.Request
+	if err := v2.Unmarshal(data); err != nil {
+		return fmt.Sprintf("unknown request (%d bytes)", len(data))
+	}
+	return summarizeV2(&v2)
+}
+
+func summarizeV3(r *pb.InternalRaftRequest) string {
+	switch {
+	case r.V2 != nil:
+		return summarizeV2(r.V2)
+	case r.Range != nil:
+		return "range " + keyRange(r.Range.Key, r.Range.RangeEnd)
+	case r.Put != nil:
+		s := fmt.Sprintf("put %q (%d bytes)", r.Put.Key, len(r.Put.Value))
+		if r.Put.Lease != 0 {
+			s += fmt.Sprintf(" with lease %x", r.Put.Lease)
+		}
+		return s
+	case r.DeleteRange != nil:
+		return "delete " + keyRange(r.DeleteRange.Key, r.DeleteRange.RangeEnd)
+	case r.Txn != nil:
+		return fmt.Sprintf("txn (%d compares, %d success ops, %d failure ops)", len(r.Txn.Compare), len(r.Txn.Success), len(r.Txn.Failure))
+	case r.Compaction != nil:
+		return fmt.Sprintf("compact to revision %d", r.Compaction.Revision)
+	case r.LeaseGrant != nil:
+		return fmt.Sprintf("grant lease %x (TTL %ds)", r.LeaseGrant.ID, r.LeaseGrant.TTL)
+	case r.LeaseRevoke != nil:
+		return fmt.Sprintf("revoke lease %x", r.LeaseRevoke.ID)
+	case r.Alarm != nil:
+		return fmt.Sprintf("alarm %s %s on %s", r.Alarm.Action, r.Alarm.Alarm, types.ID(r.Alarm.MemberID))
+	case r.AuthEnable != nil:
+		return "enable auth"
+	case r.AuthDisable != nil:
+		return "disable auth"
+	case r.Authenticate != nil:
+		return fmt.Sprintf("authenticate %q", r.Authenticate.Name)
+	case r.AuthUserAdd != nil:
+		return fmt.Sprintf("add user %q", r.AuthUserAdd.Name)
+	case r.AuthUserDelete != nil:
+		return fmt.Sprintf("delete user %q", r.AuthUserDelete.Name)
+	case r.AuthUserChangePassword != nil:
+		return fmt.Sprintf("change password of %q", r.AuthUserChangePassword.Name)
+	case r.AuthUserGrantRole != nil:
+		return fmt.Sprintf("grant role %q to %q", r.AuthUserGrantRole.Role, r.AuthUserGrantRole.User)
+	case r.AuthUserRevokeRole != nil:
+		return fmt.Sprintf("revoke role %q from %q", r.AuthUserRevokeRole.Role, r.AuthUserRevokeRole.Name)
+	case r.AuthRoleAdd != nil:
+		return fmt.Sprintf("add role %q", r.AuthRoleAdd.Name)
+	case r.AuthRoleDelete != nil:
+		return fmt.Sprintf("delete role %q", r.AuthRoleDelete.Role)
+	case r.AuthRoleGrantPermission != nil:
+		return fmt.Sprintf("grant permission to role %q", r.AuthRoleGrantPermission.Name)
+	case r.AuthRoleRevokePermission != nil:
+		return fmt.Sprintf("revoke permission from role %q", r.AuthRoleRevokePermission.Role)
+	case r.AuthUserGet != nil, r.AuthUserList != nil, r.AuthRoleGet != nil, r.AuthRoleList != nil:
+		return "auth read"
+	}
+	return ""
+}
+
+func summarizeV2(r *pb.Request) string {
+	switch r.Method {
+	case "PUT", "POST":
+		return fmt.Sprintf("v2 %s %q (%d bytes)", r.Method, r.Path, len(r.Val))
+	case "":
+		return "v2 request"
+	}
+	return fmt.Sprintf("v2 %s %q", r.Method, r.Path)
+}
+
+func keyRange(key, end []byte) string {
+	switch {
+	case len(end) == 0:
+		return fmt.Sprintf("%q", key)
+	case len(end) == 1 && end[0] == 0:
+		return fmt.Sprintf("from %q", key)
+	}
+	return fmt.Sprintf("[%q, %q)", key, end)
+}
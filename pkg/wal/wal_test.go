@@ -0,0 +1,77 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/pkg/pbutil"
+	"github.com/coreos/etcd/raft/raftpb"
+	etcdwal "github.com/coreos/etcd/wal"
+)
+
+func TestRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wal-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir = filepath.Join(dir, "wal")
+
+	md := pbutil.MustMarshal(&pb.Metadata{NodeID: 0x10, ClusterID: 0x20})
+	w, err := etcdwal.Create(dir, md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := pbutil.MustMarshal(&pb.InternalRaftRequest{Put: &pb.PutRequest{Key: []byte("foo"), Value: []byte("bar")}})
+	del := pbutil.MustMarshal(&pb.InternalRaftRequest{DeleteRange: &pb.DeleteRangeRequest{Key: []byte("a"), RangeEnd: []byte("b")}})
+	cc := pbutil.MustMarshal(&raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: 0x30})
+	ents := []raftpb.Entry{
+		{Term: 1, Index: 1, Type: raftpb.EntryConfChange, Data: cc},
+		{Term: 2, Index: 2},
+		{Term: 2, Index: 3, Data: put},
+		{Term: 2, Index: 4, Data: del},
+	}
+	if err = w.Save(raftpb.HardState{Term: 2, Vote: 0x10, Commit: 3}, ents); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	lg, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := Log{
+		NodeID:    "10",
+		ClusterID: "20",
+		Term:      2,
+		Vote:      0x10,
+		Commit:    3,
+		Entries: []Entry{
+			{Term: 1, Index: 1, Type: "conf-change", Size: len(cc), Summary: "AddNode 30"},
+			{Term: 2, Index: 2, Type: "normal", Summary: "empty (new leader)"},
+			{Term: 2, Index: 3, Type: "normal", Size: len(put), Summary: `put "foo" (3 bytes)`},
+			{Term: 2, Index: 4, Type: "normal", Size: len(del), Summary: `delete ["a", "b")`},
+		},
+	}
+	if !reflect.DeepEqual(lg, exp) {
+		t.Fatalf("expected %+v, got %+v", exp, lg)
+	}
+}
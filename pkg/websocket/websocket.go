@@ -0,0 +1,315 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Opcodes defined in RFC 6455.
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// magicGUID is used to compute 'Sec-WebSocket-Accept'.
+const magicGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// MaxMessageSize is the maximum size of a message read from peers.
+var MaxMessageSize int64 = 1 << 20
+
+// AllowedOrigins are the origins (e.g. "https://play.etcd.io") allowed to
+// connect, besides the origin of the request host. Requests without an
+// 'Origin' header do not come from browsers, and are allowed.
+var AllowedOrigins []string
+
+// WriteTimeout is the time allowed to write a message to the peer.
+var WriteTimeout = 10 * time.Second
+
+// PongWait is the time allowed to read the next frame from the peer.
+// Pings are sent at 9/10 of PongWait, so that live peers always answer
+// in time and half-open connections are closed.
+var PongWait = 60 * time.Second
+
+var (
+	// ErrBadHandshake is returned when the request is not a valid WebSocket handshake.
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	// ErrBadOrigin is returned when the request comes from a page of another origin.
+	ErrBadOrigin = errors.New("websocket: origin not allowed")
+	// ErrMessageTooLarge is returned when a peer sends a message larger than MaxMessageSize.
+	ErrMessageTooLarge = errors.New("websocket: message too large")
+)
+
+// Conn is a server-side WebSocket connection.
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	wmu sync.Mutex
+
+	// pongWait is PongWait at the time of the upgrade.
+	pongWait time.Duration
+
+	closeOnce sync.Once
+	donec     chan struct{}
+}
+
+// AcceptKey computes 'Sec-WebSocket-Accept' for the client key.
+func AcceptKey(key string) string {
+	h := sha1.New()
+	io.WriteString(h, key+magicGUID)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(name)] {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkOrigin returns true if the 'Origin' of the request is absent,
+// matches the request host, or is in AllowedOrigins, so that pages of
+// other sites cannot open connections with the cookies of the visitor.
+func checkOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, o := range AllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, req.Host)
+}
+
+// Upgrade upgrades the HTTP request to a WebSocket connection.
+// On error, it writes an HTTP error response.
+func Upgrade(w http.ResponseWriter, req *http.Request) (*Conn, error) {
+	if req.Method != http.MethodGet ||
+		!headerContains(req.Header, "Connection", "upgrade") ||
+		!headerContains(req.Header, "Upgrade", "websocket") ||
+		req.Header.Get("Sec-Websocket-Version") != "13" {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+	key := req.Header.Get("Sec-Websocket-Key")
+	if key == "" {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+	if !checkOrigin(req) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil, ErrBadOrigin
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not implement http.Hijacker")
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n"
+	if _, err = conn.Write([]byte(resp)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c := &Conn{conn: conn, br: brw.Reader, pongWait: PongWait, donec: make(chan struct{})}
+	go c.keepAlive(c.pongWait * 9 / 10)
+	return c, nil
+}
+
+// keepAlive pings the peer until the connection is closed.
+func (c *Conn) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.donec:
+			return
+		case <-ticker.C:
+			if err := c.WriteMessage(OpPing, nil); err != nil {
+				c.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// WriteMessage writes a single unfragmented message.
+func (c *Conn) WriteMessage(opcode int, data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	hdr := make([]byte, 2, 10)
+	hdr[0] = 0x80 | byte(opcode) // FIN
+	switch n := len(data); {
+	case n < 126:
+		hdr[1] = byte(n)
+	case n <= 0xFFFF:
+		hdr[1] = 126
+		hdr = hdr[:4]
+		binary.BigEndian.PutUint16(hdr[2:], uint16(n))
+	default:
+		hdr[1] = 127
+		hdr = hdr[:10]
+		binary.BigEndian.PutUint64(hdr[2:], uint64(n))
+	}
+	if err := c.conn.SetWriteDeadline(time.Now().Add(WriteTimeout)); err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(hdr); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(data)
+	return err
+}
+
+// WriteText writes a text message.
+func (c *Conn) WriteText(data []byte) error {
+	return c.WriteMessage(OpText, data)
+}
+
+// ReadMessage reads the next data message, answering pings in between.
+// It returns io.EOF when the peer closes the connection, and an error
+// when no frame (e.g. pong) arrives within PongWait.
+func (c *Conn) ReadMessage() (opcode int, data []byte, err error) {
+	for {
+		if err = c.conn.SetReadDeadline(time.Now().Add(c.pongWait)); err != nil {
+			return 0, nil, err
+		}
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case OpPing:
+			if err = c.WriteMessage(OpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			continue
+		case OpClose:
+			c.WriteMessage(OpClose, payload)
+			return 0, nil, io.EOF
+		case OpContinuation:
+			if opcode == 0 {
+				return 0, nil, fmt.Errorf("websocket: unexpected continuation frame")
+			}
+		default:
+			opcode = op
+		}
+		data = append(data, payload...)
+		if int64(len(data)) > MaxMessageSize {
+			return 0, nil, ErrMessageTooLarge
+		}
+		if fin {
+			return opcode, data, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err = io.ReadFull(c.br, hdr[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = hdr[0]&0x80 != 0
+	opcode = int(hdr[0] & 0x0F)
+	masked := hdr[1]&0x80 != 0
+
+	n := int64(hdr[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if n < 0 || n > MaxMessageSize {
+		return false, 0, nil, ErrMessageTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// SetReadDeadline sets the deadline for reading the next frame.
+// ReadMessage extends it by PongWait for every frame it reads.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// Close sends a close frame and closes the underlying connection.
+func (c *Conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.donec)
+		c.WriteMessage(OpClose, []byte{0x03, 0xE8}) // 1000, normal closure
+		err = c.conn.Close()
+	})
+	return err
+}
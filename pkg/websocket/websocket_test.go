@@ -0,0 +1,177 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// example from RFC 6455, section 1.3
+	if k := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); k != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", k)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c, err := Upgrade(w, req)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			op, data, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err = c.WriteMessage(op, data); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	conn, br := handshake(t, srv.URL)
+	defer conn.Close()
+
+	// masked client text frame
+	msg := []byte(strings.Repeat("hello", 40))
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x81, 0x80 | 126, 0, 0}
+	binary.BigEndian.PutUint16(frame[2:], uint16(len(msg)))
+	frame = append(frame, mask...)
+	for i, b := range msg {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+
+	hdr := make([]byte, 4)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		t.Fatal(err)
+	}
+	if hdr[0] != 0x81 || hdr[1] != 126 {
+		t.Fatalf("unexpected frame header %v", hdr)
+	}
+	n := binary.BigEndian.Uint16(hdr[2:])
+	got := make([]byte, n)
+	if _, err := io.ReadFull(br, got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(msg) {
+		t.Fatalf("expected %q, got %q", msg, got)
+	}
+}
+
+// handshake connects to the server and upgrades the connection.
+func handshake(t *testing.T, u string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(u, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n" +
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n"
+	if _, err = conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	if k := resp.Header.Get("Sec-Websocket-Accept"); k != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", k)
+	}
+	return conn, br
+}
+
+// TestPongWait ensures that the server pings the peer, and stops reading
+// from peers that do not answer.
+func TestPongWait(t *testing.T) {
+	defer func(d time.Duration) { PongWait = d }(PongWait)
+	PongWait = 200 * time.Millisecond
+
+	errc := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c, err := Upgrade(w, req)
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer c.Close()
+		_, _, err = c.ReadMessage()
+		errc <- err
+	}))
+	defer srv.Close()
+
+	conn, br := handshake(t, srv.URL)
+	defer conn.Close()
+
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		t.Fatal(err)
+	}
+	if hdr[0] != 0x80|OpPing {
+		t.Fatalf("expected ping frame, got %v", hdr)
+	}
+
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Fatal("expected read timeout, got no error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("took too long to reap the connection")
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	defer func(os []string) { AllowedOrigins = os }(AllowedOrigins)
+	AllowedOrigins = []string{"https://play.etcd.io"}
+
+	tests := []struct {
+		host   string
+		origin string
+		exp    bool
+	}{
+		{"localhost:2200", "", true},
+		{"localhost:2200", "http://localhost:2200", true},
+		{"localhost:2200", "https://play.etcd.io", true},
+		{"localhost:2200", "http://localhost:4200", false},
+		{"localhost:2200", "https://evil.example.com", false},
+	}
+	for i, tt := range tests {
+		req := &http.Request{Host: tt.host, Header: http.Header{}}
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if ok := checkOrigin(req); ok != tt.exp {
+			t.Errorf("#%d: expected %v, got %v", i, tt.exp, ok)
+		}
+	}
+}
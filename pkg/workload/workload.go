@@ -0,0 +1,166 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package workload generates synthetic keys and values, with uniform,
+// zipfian or sequential key distributions and fixed or variable value
+// sizes, to study hot keys and range scans with the benchmarks and when
+// filling a cluster.
+package workload
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Key distributions.
+const (
+	// Uniform picks every key with the same probability.
+	Uniform = "uniform"
+	// Zipfian picks the first keys of the key space much more often
+	// than the others, as hot keys.
+	Zipfian = "zipfian"
+	// Sequential picks the keys in order, wrapping around
+	// at the end of the key space.
+	Sequential = "sequential"
+)
+
+// DefaultZipfS is the default exponent of the zipfian distribution.
+const DefaultZipfS = 1.1
+
+// Spec defines the generated keys and values.
+type Spec struct {
+	// Distribution is Uniform (if empty), Zipfian or Sequential.
+	Distribution string
+	// KeySpace is the number of distinct keys.
+	KeySpace int
+	// KeySize is the size of the keys in bytes, after the prefix. Keys
+	// are zero-padded numbers, so they sort in the key space order.
+	KeySize int
+	Prefix  string
+	// Offset is the first key of the Sequential distribution.
+	Offset int
+	// ZipfS is the exponent of the Zipfian distribution, greater than 1.
+	// The higher, the hotter the first keys. Defaults to DefaultZipfS.
+	ZipfS float64
+	// ValueSize is the size of the values in bytes. If ValueSizeMax is
+	// greater, sizes are uniform between ValueSize and ValueSizeMax.
+	ValueSize    int
+	ValueSizeMax int
+}
+
+// Validate checks the spec.
+func (s Spec) Validate() error {
+	switch s.Distribution {
+	case "", Uniform, Zipfian, Sequential:
+	default:
+		return fmt.Errorf("unknown key distribution %q", s.Distribution)
+	}
+	if s.KeySpace <= 0 {
+		return fmt.Errorf("key space must be positive")
+	}
+	if s.KeySize <= 0 {
+		return fmt.Errorf("key size must be positive")
+	}
+	if s.Offset < 0 {
+		return fmt.Errorf("offset cannot be negative")
+	}
+	if s.ZipfS != 0 && s.ZipfS <= 1 {
+		return fmt.Errorf("zipfian exponent must be greater than 1, got %v", s.ZipfS)
+	}
+	if s.ValueSize < 0 {
+		return fmt.Errorf("value size cannot be negative")
+	}
+	if s.ValueSizeMax != 0 && s.ValueSizeMax < s.ValueSize {
+		return fmt.Errorf("maximum value size %d is less than value size %d", s.ValueSizeMax, s.ValueSize)
+	}
+	return nil
+}
+
+// Key returns the n-th key, zero-padded (or truncated) to the size.
+func Key(n, size int) string {
+	k := fmt.Sprintf("%0*d", size, n)
+	return k[len(k)-size:]
+}
+
+const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Generator generates keys and values from a random generator (e.g.
+// cluster.Cluster.NewRand), so they are reproducible from its seed.
+// It is not safe for concurrent use, as rand.Rand.
+type Generator struct {
+	spec Spec
+	rnd  *rand.Rand
+	zipf *rand.Zipf
+	next int
+	// values is sliced for the values, to avoid an allocation per value
+	values string
+}
+
+// New returns a generator of the spec.
+func New(spec Spec, rnd *rand.Rand) (*Generator, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	g := &Generator{spec: spec, rnd: rnd, next: spec.Offset}
+	if spec.Distribution == Zipfian {
+		s := spec.ZipfS
+		if s == 0 {
+			s = DefaultZipfS
+		}
+		g.zipf = rand.NewZipf(rnd, s, 1, uint64(spec.KeySpace-1))
+	}
+
+	max := spec.ValueSize
+	if spec.ValueSizeMax > max {
+		max = spec.ValueSizeMax
+	}
+	b := make([]byte, max)
+	for i := range b {
+		b[i] = letterBytes[rnd.Intn(len(letterBytes))]
+	}
+	g.values = string(b)
+	return g, nil
+}
+
+// KeyIndex returns the index of the next key in the key space.
+func (g *Generator) KeyIndex() int {
+	switch g.spec.Distribution {
+	case Zipfian:
+		return int(g.zipf.Uint64())
+	case Sequential:
+		n := g.next % g.spec.KeySpace
+		g.next++
+		return n
+	}
+	return g.rnd.Intn(g.spec.KeySpace)
+}
+
+// Key returns the next key.
+func (g *Generator) Key() string {
+	return g.spec.Prefix + Key(g.KeyIndex(), g.spec.KeySize)
+}
+
+// Value returns the next value.
+func (g *Generator) Value() string {
+	n := g.spec.ValueSize
+	if g.spec.ValueSizeMax > n {
+		n += g.rnd.Intn(g.spec.ValueSizeMax - n + 1)
+	}
+	return g.values[:n]
+}
+
+// Next returns the next key and value.
+func (g *Generator) Next() (key, value string) {
+	return g.Key(), g.Value()
+}
@@ -0,0 +1,113 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGeneratorSequential(t *testing.T) {
+	g, err := New(Spec{Distribution: Sequential, KeySpace: 3, KeySize: 4, Prefix: "/k/", Offset: 1}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, exp := range []string{"/k/0001", "/k/0002", "/k/0000", "/k/0001"} {
+		if k := g.Key(); k != exp {
+			t.Errorf("#%d: expected %q, got %q", i, exp, k)
+		}
+	}
+}
+
+func TestGeneratorUniform(t *testing.T) {
+	g, err := New(Spec{KeySpace: 10, KeySize: 2}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make([]int, 10)
+	for i := 0; i < 10000; i++ {
+		counts[g.KeyIndex()]++
+	}
+	for i, c := range counts {
+		if c < 800 || c > 1200 {
+			t.Errorf("key %d picked %d times out of 10000, expected about 1000", i, c)
+		}
+	}
+}
+
+func TestGeneratorZipfian(t *testing.T) {
+	g, err := New(Spec{Distribution: Zipfian, KeySpace: 1000, KeySize: 4}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make([]int, 1000)
+	for i := 0; i < 10000; i++ {
+		n := g.KeyIndex()
+		if n < 0 || n >= 1000 {
+			t.Fatalf("key %d out of the key space", n)
+		}
+		counts[n]++
+	}
+	if counts[0] < counts[1] || counts[1] < counts[10] || counts[0] < 10*counts[100] {
+		t.Errorf("expected hot first keys, got counts %d, %d, %d and %d of keys 0, 1, 10 and 100", counts[0], counts[1], counts[10], counts[100])
+	}
+}
+
+func TestGeneratorValue(t *testing.T) {
+	g, err := New(Spec{KeySpace: 1, KeySize: 1, ValueSize: 3, ValueSizeMax: 6}, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int]bool)
+	for i := 0; i < 1000; i++ {
+		n := len(g.Value())
+		if n < 3 || n > 6 {
+			t.Fatalf("value size %d out of [3, 6]", n)
+		}
+		seen[n] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("expected 4 value sizes, got %v", seen)
+	}
+}
+
+func TestGeneratorReproducible(t *testing.T) {
+	spec := Spec{Distribution: Zipfian, KeySpace: 100, KeySize: 3, ValueSize: 1, ValueSizeMax: 10}
+	g1, _ := New(spec, rand.New(rand.NewSource(7)))
+	g2, _ := New(spec, rand.New(rand.NewSource(7)))
+	for i := 0; i < 100; i++ {
+		k1, v1 := g1.Next()
+		k2, v2 := g2.Next()
+		if k1 != k2 || v1 != v2 {
+			t.Fatalf("#%d: expected the same keys and values from the same seed, got %q=%q and %q=%q", i, k1, v1, k2, v2)
+		}
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	for i, s := range []Spec{
+		{Distribution: "hot", KeySpace: 1, KeySize: 1},
+		{KeySpace: 0, KeySize: 1},
+		{KeySpace: 1, KeySize: 0},
+		{KeySpace: 1, KeySize: 1, Offset: -1},
+		{Distribution: Zipfian, KeySpace: 1, KeySize: 1, ZipfS: 1},
+		{KeySpace: 1, KeySize: 1, ValueSize: -1},
+		{KeySpace: 1, KeySize: 1, ValueSize: 10, ValueSizeMax: 5},
+	} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("#%d: expected error for %+v", i, s)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+)
+
+// AssertionResult is the result of an assertion.
+type AssertionResult struct {
+	Assertion string
+	Passed    bool
+	// Message explains why the assertion passed or failed.
+	Message string
+	Took    time.Duration
+}
+
+// Assertion checks the cluster state, the event log or the keyspace.
+type Assertion interface {
+	Name() string
+	Evaluate(ctx context.Context, clus *cluster.Cluster) AssertionResult
+}
+
+// assertion is an Assertion whose check returns the message of a pass, or
+// the error of a failure.
+type assertion struct {
+	name  string
+	check func(ctx context.Context, clus *cluster.Cluster) (string, error)
+}
+
+func (a assertion) Name() string { return a.name }
+
+func (a assertion) Evaluate(ctx context.Context, clus *cluster.Cluster) AssertionResult {
+	start := time.Now()
+	msg, err := a.check(ctx, clus)
+	r := AssertionResult{Assertion: a.name, Passed: err == nil, Message: msg, Took: time.Since(start)}
+	if err != nil {
+		r.Message = err.Error()
+	}
+	return r
+}
+
+// Evaluate evaluates the assertions in order.
+// It returns true if all of them pass.
+func Evaluate(ctx context.Context, clus *cluster.Cluster, as ...Assertion) ([]AssertionResult, bool) {
+	rs := make([]AssertionResult, 0, len(as))
+	passed := true
+	for _, a := range as {
+		r := a.Evaluate(ctx, clus)
+		rs = append(rs, r)
+		passed = passed && r.Passed
+	}
+	return rs, passed
+}
+
+// ExpectLeaderChange passes if another member became the leader
+// after the time, according to the leader history.
+func ExpectLeaderChange(since time.Time) Assertion {
+	return assertion{
+		name: fmt.Sprintf("leader change since %s", since.Format(time.RFC3339)),
+		check: func(ctx context.Context, clus *cluster.Cluster) (string, error) {
+			if err := clus.RefreshStatus(ctx); err != nil {
+				return "", err
+			}
+			var prev string
+			for _, ch := range clus.LeaderHistory() {
+				if !ch.Time.After(since) {
+					prev = ch.To
+					continue
+				}
+				if ch.To != "" && ch.To != prev {
+					return fmt.Sprintf("leader changed from %q to %q at term %d", prev, ch.To, ch.Term), nil
+				}
+			}
+			return "", fmt.Errorf("leader did not change from %q", prev)
+		},
+	}
+}
+
+// Writes records the acknowledged writes, to check that none is lost.
+// It is safe for concurrent use.
+type Writes struct {
+	mu  sync.Mutex
+	kvs map[string]string
+}
+
+// NewWrites returns an empty record of writes.
+func NewWrites() *Writes {
+	return &Writes{kvs: make(map[string]string)}
+}
+
+// Record records the acknowledged write of the value to the key.
+func (w *Writes) Record(key, val string) {
+	w.mu.Lock()
+	w.kvs[key] = val
+	w.mu.Unlock()
+}
+
+// Len returns the number of written keys.
+func (w *Writes) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.kvs)
+}
+
+func (w *Writes) copy() map[string]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	kvs := make(map[string]string, len(w.kvs))
+	for k, v := range w.kvs {
+		kvs[k] = v
+	}
+	return kvs
+}
+
+// maxReportedKeys bounds the keys listed in failed assertions.
+const maxReportedKeys = 10
+
+// ExpectLinearizable passes if the key-value operations recorded
+// so far by the history are linearizable.
+func ExpectLinearizable(h *cluster.History) Assertion {
+	return assertion{
+		name: "linearizable",
+		check: func(ctx context.Context, clus *cluster.Cluster) (string, error) {
+			r := h.Check()
+			if !r.Linearizable {
+				return "", errors.New(r.String())
+			}
+			return r.String(), nil
+		},
+	}
+}
+
+// ExpectNoLostWrites passes if every acknowledged write is read
+// back, with its last written value, by a linearizable read.
+func ExpectNoLostWrites(w *Writes) Assertion {
+	return assertion{
+		name: "no lost writes",
+		check: func(ctx context.Context, clus *cluster.Cluster) (string, error) {
+			kvs := w.copy()
+			idx, err := startedNode(clus, "")
+			if err != nil {
+				return "", err
+			}
+			var lost []string
+			for k, v := range kvs {
+				cctx, cancel := context.WithTimeout(ctx, requestTimeout)
+				resp, err := clus.Get(cctx, idx, k, false)
+				cancel()
+				if err != nil {
+					return "", fmt.Errorf("failed to read %q (%v)", k, err)
+				}
+				if len(resp.KeyValues) == 0 || resp.KeyValues[0].Value != v {
+					lost = append(lost, k)
+				}
+			}
+			if len(lost) > 0 {
+				sort.Strings(lost)
+				n := len(lost)
+				if n > maxReportedKeys {
+					lost = append(lost[:maxReportedKeys], "...")
+				}
+				return "", fmt.Errorf("lost %d of %d writes (%s)", n, len(kvs), strings.Join(lost, ", "))
+			}
+			return fmt.Sprintf("read back %d writes", len(kvs)), nil
+		},
+	}
+}
+
+// ExpectQuorumWithin passes once a quorum of members is healthy and
+// there is a leader, failing if it takes longer than the duration.
+func ExpectQuorumWithin(d time.Duration) Assertion {
+	return assertion{
+		name: fmt.Sprintf("quorum within %v", d),
+		check: func(ctx context.Context, clus *cluster.Cluster) (string, error) {
+			start := time.Now()
+			wctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			if err := clus.WaitHealthy(wctx, true); err != nil {
+				return "", err
+			}
+			idx, err := clus.WaitLeader(wctx)
+			if err != nil {
+				return "", err
+			}
+			st, err := clus.MemberStatus(idx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d healthy members (quorum %d) with leader %q after %v", clus.HealthyNodeN(), clus.Quorum(), st.Name, time.Since(start)), nil
+		},
+	}
+}
+
+// expectLeader passes if the leader matches 'want': a node name,
+// "!= <node>" for any other leader, "any" or "none".
+func expectLeader(want string) Assertion {
+	want = strings.TrimSpace(want)
+	return assertion{
+		name: "leader " + want,
+		check: func(ctx context.Context, clus *cluster.Cluster) (string, error) {
+			cctx, cancel := context.WithTimeout(ctx, requestTimeout)
+			name, err := leader(cctx, clus)
+			cancel()
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case want == "any":
+				if name == "" {
+					return "", fmt.Errorf("expected a leader, got none")
+				}
+			case want == "none":
+				if name != "" {
+					return "", fmt.Errorf("expected no leader, got %q", name)
+				}
+			case strings.HasPrefix(want, "!="):
+				not := strings.TrimSpace(strings.TrimPrefix(want, "!="))
+				if name == "" || name == not {
+					return "", fmt.Errorf("expected a leader other than %q, got %q", not, name)
+				}
+			default:
+				if name != want {
+					return "", fmt.Errorf("expected leader %q, got %q", want, name)
+				}
+			}
+			return fmt.Sprintf("leader is %q", name), nil
+		},
+	}
+}
+
+// expectKeys passes if there are n keys under the prefix.
+func expectKeys(prefix string, n int64) Assertion {
+	return assertion{
+		name: fmt.Sprintf("%d keys under %q", n, prefix),
+		check: func(ctx context.Context, clus *cluster.Cluster) (string, error) {
+			idx, err := startedNode(clus, "")
+			if err != nil {
+				return "", err
+			}
+			cctx, cancel := context.WithTimeout(ctx, requestTimeout)
+			resp, err := clus.Range(cctx, idx, cluster.RangeRequest{Key: prefix, Prefix: true, CountOnly: true})
+			cancel()
+			if err != nil {
+				return "", err
+			}
+			if resp.Count != n {
+				return "", fmt.Errorf("expected %d keys under %q, got %d", n, prefix, resp.Count)
+			}
+			return fmt.Sprintf("%d keys under %q", n, prefix), nil
+		},
+	}
+}
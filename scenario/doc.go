@@ -0,0 +1,57 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scenario runs scripted failure sequences against a cluster,
+// so that experiments can be repeated for teaching and regression tests.
+//
+// Scenarios are YAML (or JSON) files with a list of steps, each with
+// exactly one action:
+//
+//	name: leader-failover
+//	steps:
+//	- stop: node2
+//	- wait: 10s
+//	- put:
+//	    keys: 1000
+//	    prefix: /scenario/
+//	- restart: node2
+//	- assert:
+//	    leader: "!= node2"
+//	    within: 5s
+//	- assert:
+//	    keys: 1000
+//	    prefix: /scenario/
+//
+// Actions are 'stop', 'restart', 'kill' and 'remove' (node names), 'add'
+// (true), 'fault' ('node', 'type' and 'after', see cluster.Fault), 'inject'
+// and 'recover' ('nemesis' and 'node', see package nemesis), 'wait'
+// (a duration in Go syntax), 'put' (writes 'keys' keys under 'prefix' via
+// 'node', or any started node), and 'assert'. Assertions check the leader
+// ("node1", "!= node1", "any" or "none"), the number of keys under the
+// prefix, 'leader-changed' since the start, 'no-lost-writes' of the 'put'
+// steps, 'linearizable' key-value operations since the start, and
+// 'quorum-within' a duration, retrying until they pass or 'within'
+// elapses. The same assertions are available to tests (e.g.
+// ExpectLeaderChange), and return structured results.
+//
+// Run executes the steps in order, and stops at the first failing step.
+// Durations are scaled by the time scale of the cluster (see
+// cluster.Config.TimeScale), so accelerated clusters run scenarios faster.
+// WriteReport writes the report as JSON, JUnit XML or TAP, so scenarios can
+// run as acceptance tests in CI pipelines.
+//
+// A Recorder captures the operations performed on a cluster (e.g. through
+// the server package) as a scenario, with the pauses between them as 'wait'
+// steps, and Replay runs a saved scenario file.
+package scenario
@@ -0,0 +1,104 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+// minRecordedWait is the shortest pause between operations recorded as a
+// 'wait' step. Pauses are rounded to it.
+const minRecordedWait = 100 * time.Millisecond
+
+// Recorder records operations as a scenario. It is safe for concurrent use.
+type Recorder struct {
+	mu   sync.Mutex
+	sc   Scenario
+	path string
+	// last is when the previous operation completed
+	last time.Time
+}
+
+// NewRecorder returns a recorder of the scenario with the name. If 'path'
+// is not empty, the scenario is saved to the file after every operation.
+func NewRecorder(name, path string) *Recorder {
+	return &Recorder{sc: Scenario{Name: name}, path: path}
+}
+
+// Record records the operation that started at the time and has completed,
+// preceded by a 'wait' step for the pause since the previous operation.
+func (r *Recorder) Record(start time.Time, s Step) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.last.IsZero() {
+		if pause := start.Sub(r.last).Round(minRecordedWait); pause >= minRecordedWait {
+			r.sc.Steps = append(r.sc.Steps, Step{Wait: Duration(pause)})
+		}
+	}
+	r.sc.Steps = append(r.sc.Steps, s)
+	r.last = time.Now()
+
+	if r.path == "" {
+		return nil
+	}
+	return r.save(r.path)
+}
+
+// Scenario returns the recorded scenario.
+func (r *Recorder) Scenario() Scenario {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sc := r.sc
+	sc.Steps = append([]Step(nil), r.sc.Steps...)
+	return sc
+}
+
+// Reset discards the recorded operations.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.sc.Steps, r.last = nil, time.Time{}
+	r.mu.Unlock()
+}
+
+// Save writes the recorded scenario to the YAML file.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.save(path)
+}
+
+func (r *Recorder) save(path string) error {
+	b, err := yaml.Marshal(r.sc)
+	if err != nil {
+		return err
+	}
+	// write and rename, so the file is always a complete scenario
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
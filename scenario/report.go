@@ -0,0 +1,175 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Report formats.
+const (
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+	FormatTAP   = "tap"
+)
+
+// WriteReport writes the report in the format, so scenario runs can be
+// checked by CI pipelines.
+func WriteReport(w io.Writer, rp Report, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(rp)
+	case FormatJUnit:
+		return rp.WriteJUnit(w)
+	case FormatTAP:
+		return rp.WriteTAP(w)
+	}
+	return fmt.Errorf("unknown report format %q (expected %q, %q or %q)", format, FormatJSON, FormatJUnit, FormatTAP)
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as a JUnit XML test suite,
+// with a test case per step.
+func (rp Report) WriteJUnit(w io.Writer) error {
+	s := junitSuite{Name: rp.Name, Tests: len(rp.Steps), Time: seconds(rp.Took.Seconds())}
+	for _, r := range rp.Steps {
+		c := junitCase{
+			Name:      fmt.Sprintf("%d: %s", r.Step, r.Action),
+			ClassName: "scenario." + rp.Name,
+			Time:      seconds(r.Took.Seconds()),
+			SystemOut: strings.Join(assertionLines(r), "\n"),
+		}
+		switch {
+		case r.Skipped:
+			s.Skipped++
+			c.Skipped = &struct{}{}
+		case !r.Success:
+			s.Failures++
+			c.Failure = &junitFailure{Message: r.Error, Text: r.Error}
+		}
+		s.Cases = append(s.Cases, c)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{s}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteTAP writes the report in the Test Anything Protocol (version 13),
+// with a test point per step, and the errors and assertions as YAML.
+func (rp Report) WriteTAP(w io.Writer) error {
+	lines := []string{"TAP version 13", fmt.Sprintf("1..%d", len(rp.Steps))}
+	if rp.Name != "" {
+		lines = append(lines, "# "+rp.Name)
+	}
+	for _, r := range rp.Steps {
+		desc := strings.Replace(r.Action, "#", "\\#", -1)
+		switch {
+		case r.Skipped:
+			lines = append(lines, fmt.Sprintf("ok %d - %s # SKIP previous step failed", r.Step, desc))
+			continue
+		case r.Success:
+			lines = append(lines, fmt.Sprintf("ok %d - %s", r.Step, desc))
+		default:
+			lines = append(lines, fmt.Sprintf("not ok %d - %s", r.Step, desc))
+		}
+
+		as := assertionLines(r)
+		if r.Success && len(as) == 0 {
+			continue
+		}
+		lines = append(lines, "  ---")
+		if r.Error != "" {
+			lines = append(lines, "  message: "+quote(r.Error))
+		}
+		lines = append(lines, fmt.Sprintf("  duration_ms: %d", r.Took.Nanoseconds()/1e6))
+		if len(as) > 0 {
+			lines = append(lines, "  assertions:")
+			for _, a := range as {
+				lines = append(lines, "    - "+quote(a))
+			}
+		}
+		lines = append(lines, "  ...")
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// assertionLines returns a line per assertion of the step.
+func assertionLines(r StepResult) []string {
+	var ls []string
+	for _, a := range r.Assertions {
+		status := "passed"
+		if !a.Passed {
+			status = "failed"
+		}
+		ls = append(ls, fmt.Sprintf("%s %s: %s", a.Assertion, status, a.Message))
+	}
+	return ls
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
+
+// quote quotes the string as a YAML (and JSON) string.
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,325 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+	"github.com/coreos/etcdlabs/nemesis"
+	"github.com/coreos/etcdlabs/pkg/linearizability"
+
+	"github.com/golang/glog"
+)
+
+// requestTimeout bounds each request to the cluster.
+var requestTimeout = 5 * time.Second
+
+// assertRetryInterval is the interval between attempts of an assertion.
+var assertRetryInterval = 200 * time.Millisecond
+
+// StepResult is the result of a step.
+type StepResult struct {
+	// Step is the step number, starting at 1.
+	Step    int
+	Action  string
+	Success bool
+	// Skipped is true for the steps after the first failing step.
+	Skipped bool `json:",omitempty"`
+	Error   string
+	Took    time.Duration
+	// Assertions are the results of an 'assert' step.
+	Assertions []AssertionResult `json:",omitempty"`
+}
+
+// Report is the result of a scenario. Steps after
+// the first failing step are not run, and are skipped.
+type Report struct {
+	Name    string
+	Success bool
+	Steps   []StepResult
+	// History is the linearizability check of the key-value
+	// operations during the scenario.
+	History linearizability.Result
+	Took    time.Duration
+}
+
+// Replay runs the scenario file (e.g. saved by a Recorder) against the cluster.
+func Replay(ctx context.Context, path string, clus *cluster.Cluster) (Report, error) {
+	sc, err := Load(path)
+	if err != nil {
+		return Report{}, err
+	}
+	return Run(ctx, clus, sc), nil
+}
+
+// Run runs the scenario against the cluster.
+func Run(ctx context.Context, clus *cluster.Cluster, sc Scenario) Report {
+	start := time.Now()
+	rp := Report{Name: sc.Name}
+	if err := sc.Validate(); err != nil {
+		rp.Steps = append(rp.Steps, StepResult{Action: "validate", Error: err.Error()})
+		return rp
+	}
+
+	h := clus.RecordHistory()
+	defer h.Stop()
+
+	rs := &runState{start: start, writes: NewWrites(), history: h, timeScale: clus.TimeScale()}
+	rp.Success = true
+	for i, s := range sc.Steps {
+		glog.Infof("scenario %q: step %d (%s)", sc.Name, i+1, s)
+		now := time.Now()
+		results, err := runStep(ctx, clus, s, rs)
+		r := StepResult{Step: i + 1, Action: s.String(), Success: err == nil, Took: time.Since(now), Assertions: results}
+		rp.Steps = append(rp.Steps, r)
+		if err != nil {
+			glog.Warningf("scenario %q: step %d (%s) failed (%v)", sc.Name, i+1, s, err)
+			rp.Steps[i].Error = err.Error()
+			rp.Success = false
+			for j := i + 1; j < len(sc.Steps); j++ {
+				rp.Steps = append(rp.Steps, StepResult{Step: j + 1, Action: sc.Steps[j].String(), Skipped: true})
+			}
+			break
+		}
+	}
+	h.Stop()
+	rp.History = h.Check()
+	if !rp.History.Linearizable {
+		glog.Warningf("scenario %q: %s", sc.Name, rp.History)
+	}
+	rp.Took = time.Since(start)
+	return rp
+}
+
+// assertions returns the assertions of the step.
+func (a Assert) assertions(rs *runState) []Assertion {
+	var as []Assertion
+	if a.Leader != "" {
+		as = append(as, expectLeader(a.Leader))
+	}
+	if a.Keys != nil {
+		as = append(as, expectKeys(prefixOr(a.Prefix), *a.Keys))
+	}
+	if a.LeaderChanged {
+		as = append(as, ExpectLeaderChange(rs.start))
+	}
+	if a.NoLostWrites {
+		as = append(as, ExpectNoLostWrites(rs.writes))
+	}
+	if a.Linearizable {
+		as = append(as, ExpectLinearizable(rs.history))
+	}
+	if a.QuorumWithin > 0 {
+		as = append(as, ExpectQuorumWithin(rs.scale(a.QuorumWithin)))
+	}
+	return as
+}
+
+// runState is the state of a running scenario.
+type runState struct {
+	start time.Time
+	// writes are the writes acknowledged to put steps
+	writes *Writes
+	// history records the key-value operations since the start
+	history *cluster.History
+	// timeScale scales waits, as the cluster timing (see cluster.Config.TimeScale)
+	timeScale float64
+}
+
+func (rs *runState) scale(d Duration) time.Duration {
+	if rs.timeScale == 0 {
+		return time.Duration(d)
+	}
+	return time.Duration(float64(d) * rs.timeScale)
+}
+
+func nodeIndex(clus *cluster.Cluster, name string) (int, error) {
+	idx := clus.FindIndexByName(name)
+	if idx == -1 {
+		return -1, fmt.Errorf("unknown node %q", name)
+	}
+	return idx, nil
+}
+
+func runStep(ctx context.Context, clus *cluster.Cluster, s Step, rs *runState) ([]AssertionResult, error) {
+	if s.Assert == nil {
+		return nil, runAction(ctx, clus, s, rs)
+	}
+
+	as := s.Assert.assertions(rs)
+	deadline := time.Now().Add(rs.scale(s.Assert.Within))
+	for {
+		results, passed := Evaluate(ctx, clus, as...)
+		if passed {
+			return results, nil
+		}
+		if !time.Now().Before(deadline) {
+			var failed []string
+			for _, r := range results {
+				if !r.Passed {
+					failed = append(failed, r.Message)
+				}
+			}
+			return results, fmt.Errorf("%s", strings.Join(failed, "; "))
+		}
+		select {
+		case <-time.After(assertRetryInterval):
+		case <-ctx.Done():
+			return results, ctx.Err()
+		}
+	}
+}
+
+func runAction(ctx context.Context, clus *cluster.Cluster, s Step, rs *runState) error {
+	switch {
+	case s.Stop != "":
+		idx, err := nodeIndex(clus, s.Stop)
+		if err != nil {
+			return err
+		}
+		stopped, err := clus.IsStopped(idx)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			return fmt.Errorf("%q is already stopped", s.Stop)
+		}
+		return clus.Stop(idx)
+
+	case s.Restart != "":
+		idx, err := nodeIndex(clus, s.Restart)
+		if err != nil {
+			return err
+		}
+		stopped, err := clus.IsStopped(idx)
+		if err != nil {
+			return err
+		}
+		if !stopped {
+			return fmt.Errorf("%q is already started", s.Restart)
+		}
+		return clus.Restart(idx)
+
+	case s.Kill != "":
+		idx, err := nodeIndex(clus, s.Kill)
+		if err != nil {
+			return err
+		}
+		return clus.Kill(idx)
+
+	case s.Add:
+		return clus.Add(ctx)
+
+	case s.Remove != "":
+		idx, err := nodeIndex(clus, s.Remove)
+		if err != nil {
+			return err
+		}
+		return clus.Remove(ctx, idx)
+
+	case s.Fault != nil:
+		return clus.InjectFault(cluster.Fault{Node: s.Fault.Node, Type: s.Fault.Type, After: rs.scale(s.Fault.After)})
+
+	case s.Inject != nil:
+		n, idx, err := nemesisNode(clus, *s.Inject)
+		if err != nil {
+			return err
+		}
+		return n.Inject(ctx, clus, idx)
+
+	case s.Recover != nil:
+		n, idx, err := nemesisNode(clus, *s.Recover)
+		if err != nil {
+			return err
+		}
+		return n.Recover(ctx, clus, idx)
+
+	case s.Wait > 0:
+		select {
+		case <-time.After(rs.scale(s.Wait)):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+	case s.Put != nil:
+		return put(ctx, clus, *s.Put, rs.writes)
+	}
+	return fmt.Errorf("empty step")
+}
+
+// startedNode returns the index of the node, or of any started node.
+// nemesisNode returns the nemesis and the node of the step.
+func nemesisNode(clus *cluster.Cluster, s Nemesis) (nemesis.Nemesis, int, error) {
+	n, err := nemesis.Get(s.Name)
+	if err != nil {
+		return nil, -1, err
+	}
+	idx, err := nodeIndex(clus, s.Node)
+	if err != nil {
+		return nil, -1, err
+	}
+	return n, idx, nil
+}
+
+func startedNode(clus *cluster.Cluster, name string) (int, error) {
+	if name != "" {
+		return nodeIndex(clus, name)
+	}
+	for i := 0; i < clus.Size(); i++ {
+		if stopped, err := clus.IsStopped(i); err == nil && !stopped {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no started node")
+}
+
+// put writes the keys, recording the acknowledged writes.
+func put(ctx context.Context, clus *cluster.Cluster, p Put, w *Writes) error {
+	idx, err := startedNode(clus, p.Node)
+	if err != nil {
+		return err
+	}
+	prefix := prefixOr(p.Prefix)
+	for i := 0; i < p.Keys; i++ {
+		cctx, cancel := context.WithTimeout(ctx, requestTimeout)
+		k, v := fmt.Sprintf("%s%d", prefix, i), fmt.Sprintf("value-%d", i)
+		_, err = clus.Put(cctx, idx, k, v)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("put %d of %d keys (%v)", i, p.Keys, err)
+		}
+		w.Record(k, v)
+	}
+	return nil
+}
+
+// leader returns the name of the started leader, or "" if there is none.
+func leader(ctx context.Context, clus *cluster.Cluster) (string, error) {
+	if err := clus.RefreshStatus(ctx); err != nil {
+		return "", err
+	}
+	for _, st := range clus.AllMemberStatus() {
+		if st.IsLeader && st.State != clusterpb.StoppedMemberStatus {
+			return st.Name, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcdlabs/nemesis"
+
+	"github.com/ghodss/yaml"
+)
+
+// DefaultPrefix is the key prefix of 'put' steps and key assertions.
+const DefaultPrefix = "/scenario/"
+
+// Duration is a time.Duration in Go syntax (e.g. "10s") in scenario files.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"10s\" (%v)", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Scenario is a sequence of steps.
+type Scenario struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Steps       []Step `json:"steps"`
+}
+
+// Step is a single action. Exactly one field is set.
+type Step struct {
+	Stop    string   `json:"stop,omitempty"`
+	Restart string   `json:"restart,omitempty"`
+	Kill    string   `json:"kill,omitempty"`
+	Add     bool     `json:"add,omitempty"`
+	Remove  string   `json:"remove,omitempty"`
+	Fault   *Fault   `json:"fault,omitempty"`
+	Inject  *Nemesis `json:"inject,omitempty"`
+	Recover *Nemesis `json:"recover,omitempty"`
+	Wait    Duration `json:"wait,omitempty"`
+	Put     *Put     `json:"put,omitempty"`
+	Assert  *Assert  `json:"assert,omitempty"`
+}
+
+// Fault schedules a fault on a node (see cluster.Fault).
+type Fault struct {
+	Node string `json:"node"`
+	// Type is "stop", "kill" or "slow".
+	Type  string   `json:"type"`
+	After Duration `json:"after,omitempty"`
+}
+
+// Nemesis injects a failure mode into a node, or recovers the node
+// from it (see package nemesis).
+type Nemesis struct {
+	Name string `json:"nemesis"`
+	Node string `json:"node"`
+}
+
+// Put writes keys.
+type Put struct {
+	Keys int `json:"keys"`
+	// Prefix defaults to DefaultPrefix.
+	Prefix string `json:"prefix,omitempty"`
+	// Node defaults to any started node.
+	Node string `json:"node,omitempty"`
+}
+
+// Assert checks the cluster state.
+type Assert struct {
+	// Leader is the expected leader: a node name, "!= <node>"
+	// for any other leader, "any" or "none".
+	Leader string `json:"leader,omitempty"`
+	// Keys is the expected number of keys under Prefix.
+	Keys *int64 `json:"keys,omitempty"`
+	// Prefix defaults to DefaultPrefix.
+	Prefix string `json:"prefix,omitempty"`
+	// LeaderChanged expects another leader since the scenario started
+	// (see ExpectLeaderChange).
+	LeaderChanged bool `json:"leader-changed,omitempty"`
+	// NoLostWrites expects every write acknowledged to a 'put' step
+	// to be read back (see ExpectNoLostWrites).
+	NoLostWrites bool `json:"no-lost-writes,omitempty"`
+	// Linearizable expects the key-value operations since the scenario
+	// started to be linearizable (see ExpectLinearizable).
+	Linearizable bool `json:"linearizable,omitempty"`
+	// QuorumWithin expects a healthy quorum and a leader within
+	// the duration (see ExpectQuorumWithin).
+	QuorumWithin Duration `json:"quorum-within,omitempty"`
+	// Within retries the assertions until they pass, or the duration elapses.
+	Within Duration `json:"within,omitempty"`
+}
+
+func (s Step) String() string {
+	switch {
+	case s.Stop != "":
+		return "stop " + s.Stop
+	case s.Restart != "":
+		return "restart " + s.Restart
+	case s.Kill != "":
+		return "kill " + s.Kill
+	case s.Add:
+		return "add a member"
+	case s.Remove != "":
+		return "remove " + s.Remove
+	case s.Fault != nil:
+		return fmt.Sprintf("fault %q on %s in %v", s.Fault.Type, s.Fault.Node, time.Duration(s.Fault.After))
+	case s.Inject != nil:
+		return fmt.Sprintf("inject %q into %s", s.Inject.Name, s.Inject.Node)
+	case s.Recover != nil:
+		return fmt.Sprintf("recover %s from %q", s.Recover.Node, s.Recover.Name)
+	case s.Wait > 0:
+		return "wait " + time.Duration(s.Wait).String()
+	case s.Put != nil:
+		via := "any node"
+		if s.Put.Node != "" {
+			via = s.Put.Node
+		}
+		return fmt.Sprintf("put %d keys under %q via %s", s.Put.Keys, prefixOr(s.Put.Prefix), via)
+	case s.Assert != nil:
+		var conds []string
+		if s.Assert.Leader != "" {
+			conds = append(conds, "leader "+s.Assert.Leader)
+		}
+		if s.Assert.Keys != nil {
+			conds = append(conds, fmt.Sprintf("%d keys under %q", *s.Assert.Keys, prefixOr(s.Assert.Prefix)))
+		}
+		if s.Assert.LeaderChanged {
+			conds = append(conds, "leader changed")
+		}
+		if s.Assert.NoLostWrites {
+			conds = append(conds, "no lost writes")
+		}
+		if s.Assert.Linearizable {
+			conds = append(conds, "linearizable")
+		}
+		if s.Assert.QuorumWithin > 0 {
+			conds = append(conds, "quorum within "+time.Duration(s.Assert.QuorumWithin).String())
+		}
+		desc := "assert " + strings.Join(conds, ", ")
+		if s.Assert.Within > 0 {
+			desc += " within " + time.Duration(s.Assert.Within).String()
+		}
+		return desc
+	}
+	return "empty step"
+}
+
+func prefixOr(prefix string) string {
+	if prefix == "" {
+		return DefaultPrefix
+	}
+	return prefix
+}
+
+func (s Step) validate() error {
+	n := 0
+	for _, set := range []bool{s.Stop != "", s.Restart != "", s.Kill != "", s.Add, s.Remove != "", s.Fault != nil, s.Inject != nil, s.Recover != nil, s.Wait != 0, s.Put != nil, s.Assert != nil} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("expected exactly one action, got %d", n)
+	}
+	for _, n := range []*Nemesis{s.Inject, s.Recover} {
+		if n == nil {
+			continue
+		}
+		if _, err := nemesis.Get(n.Name); err != nil {
+			return err
+		}
+		if n.Node == "" {
+			return fmt.Errorf("nemesis %q has no node", n.Name)
+		}
+	}
+	switch {
+	case s.Fault != nil && s.Fault.After < 0:
+		return fmt.Errorf("negative fault delay %v", time.Duration(s.Fault.After))
+	case s.Wait < 0:
+		return fmt.Errorf("negative wait %v", time.Duration(s.Wait))
+	case s.Put != nil && s.Put.Keys <= 0:
+		return fmt.Errorf("put needs a positive number of keys, got %d", s.Put.Keys)
+	case s.Assert != nil && len(s.Assert.assertions(&runState{})) == 0:
+		return fmt.Errorf("assert needs 'leader', 'keys', 'leader-changed', 'no-lost-writes', 'linearizable' or 'quorum-within'")
+	}
+	return nil
+}
+
+// Validate checks that every step has exactly one valid action.
+func (sc Scenario) Validate() error {
+	if len(sc.Steps) == 0 {
+		return fmt.Errorf("scenario %q has no steps", sc.Name)
+	}
+	for i, s := range sc.Steps {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("step %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Parse parses a YAML or JSON scenario.
+func Parse(b []byte) (Scenario, error) {
+	var sc Scenario
+	if err := yaml.Unmarshal(b, &sc); err != nil {
+		return Scenario{}, err
+	}
+	return sc, sc.Validate()
+}
+
+// Load reads a scenario file.
+func Load(path string) (Scenario, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	sc, err := Parse(b)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("failed to parse %q (%v)", path, err)
+	}
+	return sc, nil
+}
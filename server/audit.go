@@ -0,0 +1,162 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcdlabs/pkg/audit"
+	"github.com/coreos/etcdlabs/pkg/token"
+
+	"github.com/golang/glog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// maxAuditBody bounds the request body kept in audit entries.
+const maxAuditBody = 4096
+
+// caller identifies the bearer token in audit entries, without its secret.
+func caller(tk string) string {
+	if tk == "" {
+		return "anonymous"
+	}
+	if parts := strings.Split(tk, "."); len(parts) == 3 && token.ValidAccess(parts[0]) && len(parts[2]) >= 8 {
+		return "hmac:" + parts[0] + ":" + parts[2][:8]
+	}
+	h := sha256.Sum256([]byte(tk))
+	return "token:" + hex.EncodeToString(h[:4])
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// auditWriter records the status and error of a response.
+type auditWriter struct {
+	http.ResponseWriter
+	code int
+	err  string
+}
+
+func (aw *auditWriter) WriteHeader(code int) {
+	aw.code = code
+	aw.ResponseWriter.WriteHeader(code)
+}
+
+// auditHTTP starts auditing the request, returning the writer to serve it
+// with, and the function to record the entry with once it is served.
+func (s *Server) auditHTTP(w http.ResponseWriter, req *http.Request) (*auditWriter, func()) {
+	start := time.Now()
+	var body string
+	if req.Body != nil {
+		b, _ := ioutil.ReadAll(io.LimitReader(req.Body, maxAuditBody))
+		req.Body = readCloser{io.MultiReader(bytes.NewReader(b), req.Body), req.Body}
+		body = strings.TrimSpace(string(b))
+	}
+	aw := &auditWriter{ResponseWriter: w, code: http.StatusOK}
+	return aw, func() {
+		e := audit.Entry{
+			Time:     start,
+			Who:      caller(bearerToken(req)),
+			Remote:   s.proxies.ClientIP(req),
+			Protocol: "http",
+			Method:   req.Method,
+			Target:   req.URL.RequestURI(),
+			Request:  body,
+			Success:  aw.code < 400,
+			Status:   fmt.Sprintf("%d %s", aw.code, http.StatusText(aw.code)),
+			Error:    aw.err,
+			Took:     time.Since(start),
+		}
+		if err := s.cfg.Audit.Append(e); err != nil {
+			glog.Warningf("failed to append audit entry (%v)", err)
+		}
+	}
+}
+
+// auditRPC records the gRPC request that returned the error.
+func (cfg Config) auditRPC(ctx context.Context, method string, req interface{}, start time.Time, err error) {
+	e := audit.Entry{
+		Time:     start,
+		Who:      caller(rpcToken(ctx)),
+		Protocol: "grpc",
+		Method:   method,
+		Target:   method,
+		Request:  fmt.Sprint(req),
+		Success:  err == nil,
+		Status:   grpc.Code(err).String(),
+		Took:     time.Since(start),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		e.Remote = p.Addr.String()
+	}
+	if err != nil {
+		e.Error = grpc.ErrorDesc(err)
+	}
+	if aerr := cfg.Audit.Append(e); aerr != nil {
+		glog.Warningf("failed to append audit entry (%v)", aerr)
+	}
+}
+
+// auditLog serves '/v1/audit', with the optional 'who', 'target' (e.g.
+// "node3"), 'since' and 'until' (RFC 3339), 'failed' and 'last' queries.
+// The log reveals who changed the cluster, so it needs AccessControl.
+func (s *Server) auditLog(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	if err := s.authorize(req, AccessControl); err != nil {
+		return nil, err
+	}
+
+	vs := req.URL.Query()
+	q := audit.Query{Who: vs.Get("who"), Target: vs.Get("target")}
+	var err error
+	for k, t := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := vs.Get(k); v != "" {
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				return nil, errorf(http.StatusBadRequest, "invalid '%s' %q", k, v)
+			}
+		}
+	}
+	if v := vs.Get("failed"); v != "" {
+		if q.Failed, err = strconv.ParseBool(v); err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid 'failed' %q", v)
+		}
+	}
+	if v := vs.Get("last"); v != "" {
+		if q.Last, err = strconv.Atoi(v); err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid 'last' %q", v)
+		}
+	}
+
+	es, err := s.cfg.Audit.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	return AuditResponse{Result: Result{Success: true}, Entries: es}, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcdlabs/pkg/token"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// Access levels of API tokens (see package token).
+const (
+	AccessRead    = token.AccessRead
+	AccessControl = token.AccessControl
+)
+
+// tokens returns the checker of the configured tokens.
+func (cfg Config) tokens() token.Checker {
+	return token.Checker{Tokens: cfg.Tokens, HMACSecret: cfg.HMACSecret}
+}
+
+func (cfg Config) validate() error {
+	return cfg.tokens().Validate()
+}
+
+// bearerToken returns the bearer token of the request, if any.
+func bearerToken(req *http.Request) string {
+	h := req.Header.Get("Authorization")
+	if !strings.HasPrefix(h, "Bearer ") {
+		return ""
+	}
+	return strings.TrimPrefix(h, "Bearer ")
+}
+
+// authorize checks the bearer token of the request for the access level.
+func (s *Server) authorize(req *http.Request, need string) error {
+	tc := s.cfg.tokens()
+	if !tc.Enabled() {
+		return nil
+	}
+	tk := bearerToken(req)
+	if tk == "" {
+		return errorf(http.StatusUnauthorized, "missing bearer token")
+	}
+	access, err := tc.Access(tk)
+	if err != nil {
+		return errorf(http.StatusUnauthorized, "%v", err)
+	}
+	if !token.Allows(access, need) {
+		return errorf(http.StatusForbidden, "%q access is required", need)
+	}
+	return nil
+}
+
+// controlMethods are the gRPC methods that change the cluster.
+var controlMethods = map[string]bool{
+	"/clusterpb.ClusterControl/StartNode":   true,
+	"/clusterpb.ClusterControl/StopNode":    true,
+	"/clusterpb.ClusterControl/InjectFault": true,
+}
+
+// rpcToken returns the bearer token of the gRPC request, if any.
+func rpcToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vs := md["authorization"]; len(vs) > 0 {
+		return strings.TrimPrefix(vs[0], "Bearer ")
+	}
+	return ""
+}
+
+func (cfg Config) authorizeRPC(ctx context.Context, method string) error {
+	tc := cfg.tokens()
+	if !tc.Enabled() {
+		return nil
+	}
+	tk := rpcToken(ctx)
+	if tk == "" {
+		return grpc.Errorf(codes.Unauthenticated, "missing bearer token")
+	}
+	access, err := tc.Access(tk)
+	if err != nil {
+		return grpc.Errorf(codes.Unauthenticated, "%v", err)
+	}
+	need := AccessRead
+	if controlMethods[method] {
+		need = AccessControl
+	}
+	if !token.Allows(access, need) {
+		return grpc.Errorf(codes.PermissionDenied, "%q access is required", need)
+	}
+	return nil
+}
+
+// ServerOptions returns the gRPC server options that check the bearer
+// tokens in the 'authorization' metadata of the control plane requests,
+// and record the requests that change the cluster to Config.Audit.
+func (cfg Config) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+			if cfg.Audit != nil && controlMethods[info.FullMethod] {
+				defer func(start time.Time) { cfg.auditRPC(ctx, info.FullMethod, req, start, err) }(time.Now())
+			}
+			if err = cfg.authorizeRPC(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := cfg.authorizeRPC(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
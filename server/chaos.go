@@ -0,0 +1,60 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/coreos/etcdlabs/chaos"
+)
+
+// chaosCalendar serves '/v1/chaos'. GET lists the entries of the chaos
+// calendar, and POST adds a chaos.Entry (replacing any of the same name).
+func (s *Server) chaosCalendar(req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case http.MethodGet:
+		return ChaosResponse{Result: Result{Success: true}, Entries: s.cfg.Chaos.Entries()}, nil
+
+	case http.MethodPost:
+		var e chaos.Entry
+		if err := json.NewDecoder(req.Body).Decode(&e); err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid chaos entry (%v)", err)
+		}
+		defer req.Body.Close()
+		if err := e.Validate(); err != nil {
+			return nil, errorf(http.StatusBadRequest, "%v", err)
+		}
+		if err := s.cfg.Chaos.Add(e); err != nil {
+			return nil, err
+		}
+		return Result{Success: true, Result: fmt.Sprintf("scheduled %q (%s %s at %q)", e.Name, e.Action, e.Target, e.Schedule)}, nil
+	}
+	return nil, errMethodNotAllowed
+}
+
+// chaosEntry serves '/v1/chaos/{name}', whose DELETE removes the entry.
+func (s *Server) chaosEntry(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodDelete {
+		return nil, errMethodNotAllowed
+	}
+	name := strings.TrimPrefix(req.URL.Path, "/v1/chaos/")
+	if err := s.cfg.Chaos.Remove(name); err != nil {
+		return nil, errorf(http.StatusNotFound, "%v", err)
+	}
+	return Result{Success: true, Result: fmt.Sprintf("removed %q", name)}, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server exposes cluster operations over a REST API,
+// so a cluster can be driven remotely.
+//
+//	GET    /v1/status                      cluster and member status
+//	GET    /v1/state                       configuration, status and events in one document
+//	POST   /v1/members                     add a member
+//	DELETE /v1/members/{name}              remove the member
+//	POST   /v1/members/{name}/stop         stop the member
+//	POST   /v1/members/{name}/restart      restart the member
+//	POST   /v1/members/{name}/kill         kill the member
+//	GET    /v1/members/{name}/health       probe the member health
+//	GET    /v1/members/{name}/backend      backend page statistics (bbolt)
+//	GET    /v1/members/{name}/catchup      log replay or snapshot after restart
+//	GET    /v1/members/{name}/wal          decoded WAL of a stopped member
+//	POST   /v1/faults                      inject a fault (FaultRequest)
+//	GET    /v1/events                      recent cluster events
+//	GET    /v1/leases                      active leases with TTLs and keys
+//	GET    /v1/raft/progress               replication progress tracked by the leader
+//	GET    /v1/raft/traffic                raft message flow (see cluster.Config.PeerProxyRootPort)
+//	DELETE /v1/raft/traffic                discard the traced messages
+//	POST   /v1/raft/timeline               timeline of a write (TimelineRequest)
+//	POST   /v1/raft/election               force an election, timed from the event log
+//	POST   /v1/fixture                     load keys (JSON lines of cluster.FixtureEntry)
+//	GET    /v1/keyspace                    dump keys ('prefix', 'format' json or csv)
+//	POST   /v1/keyspace                    import a dump ('format' json or csv)
+//	GET    /v1/keyspace/stats              key count, value bytes and top prefixes
+//	GET    /v1/audit                       audited requests (see Config.Audit)
+//	GET    /v1/recording                   recorded scenario (see Config.Recorder)
+//	DELETE /v1/recording                   discard the recorded operations
+//	POST   /v1/snapshots                   save a snapshot (see Config.SnapshotDir)
+//	GET    /v1/snapshots/diff              compare snapshots, or a snapshot and the cluster
+//	GET    /v1/chaos                       chaos calendar (see Config.Chaos)
+//	POST   /v1/chaos                       schedule a recurring failure (chaos.Entry)
+//	DELETE /v1/chaos/{name}                remove the calendar entry
+//	GET    /api/spec                       OpenAPI (Swagger 2.0) specification
+//	GET    /healthz                        liveness of the server
+//	GET    /readyz                         readiness (a quorum of members is healthy)
+//
+// Responses are JSON with 'Success' and 'Result' fields, as in the
+// playground backend, and member statuses are clusterpb.MemberStatus,
+// as the frontend reads them.
+//
+// The same operations are served over gRPC by the clusterpb.ClusterControl
+// service (see RegisterControl).
+//
+// Requests carry bearer tokens (see Config.Tokens and token.NewHMAC) in the
+// 'Authorization' header, or the 'authorization' gRPC metadata when the
+// gRPC server is created with Config.ServerOptions.
+//
+// Server.Shutdown (or Server.ShutdownOnSignal, on SIGTERM) stops the server
+// gracefully: new requests are rejected with 503, in-flight requests are
+// drained, status streams are canceled, and the cluster is shut down.
+package server
@@ -0,0 +1,48 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// fixtureTimeout bounds the load of a fixture.
+var fixtureTimeout = time.Minute
+
+// fixture serves '/v1/fixture', whose POST loads the request body as a
+// fixture (JSON lines of cluster.FixtureEntry).
+func (s *Server) fixture(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodPost {
+		return nil, errMethodNotAllowed
+	}
+	if err := s.allowControl(req); err != nil {
+		return nil, err
+	}
+	defer req.Body.Close()
+
+	ctx, cancel := context.WithTimeout(req.Context(), fixtureTimeout)
+	defer cancel()
+	r, err := s.clus.LoadFixtureFrom(ctx, req.Body, "request from "+req.RemoteAddr)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	return FixtureResponse{
+		Result:  Result{Success: true, Result: fmt.Sprintf("loaded %d keys with %d leases", r.Keys, r.Leases)},
+		Fixture: r,
+	}, nil
+}
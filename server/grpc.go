@@ -0,0 +1,167 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster"
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+	"github.com/coreos/etcdlabs/pkg/ratelimit"
+	"github.com/coreos/etcdlabs/scenario"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+)
+
+// minStatusInterval is the shortest interval between the statuses of a
+// stream, so that clients cannot make the server send statuses in a busy loop.
+var minStatusInterval = 100 * time.Millisecond
+
+// controlServer implements clusterpb.ClusterControlServer over a cluster.
+type controlServer struct {
+	clus *cluster.Cluster
+	cfg  Config
+	// limiter, if not nil, limits the requests that change the cluster
+	// per client IP, shared with the REST API
+	limiter *ratelimit.IPLimiter
+	// stopc, if not nil, cancels status streams when closed
+	stopc <-chan struct{}
+}
+
+// NewControlServer returns the gRPC control plane of the cluster.
+func NewControlServer(clus *cluster.Cluster) clusterpb.ClusterControlServer {
+	return &controlServer{clus: clus}
+}
+
+// RegisterControl registers the control plane of the cluster to the gRPC server.
+func RegisterControl(gs *grpc.Server, clus *cluster.Cluster) {
+	clusterpb.RegisterClusterControlServer(gs, NewControlServer(clus))
+}
+
+// allowControl checks the rate limit of requests that change the cluster.
+func (cs *controlServer) allowControl(ctx context.Context) error {
+	if cs.limiter == nil {
+		return nil
+	}
+	ip := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	if msg, ok := cs.limiter.Allow(ip); !ok {
+		return grpc.Errorf(codes.ResourceExhausted, "%s", msg)
+	}
+	return nil
+}
+
+// lifecycleRPCError maps the errors of StopByNameCtx and RestartByNameCtx
+// to gRPC errors.
+func lifecycleRPCError(name string, err error) error {
+	switch err.(type) {
+	case *cluster.UnknownNodeError:
+		return grpc.Errorf(codes.NotFound, "unknown member %q", name)
+	case *cluster.ErrRateLimited:
+		return grpc.Errorf(codes.ResourceExhausted, "%q: %v", name, err)
+	}
+	switch err {
+	case cluster.ErrAlreadyStarted, cluster.ErrAlreadyStopped:
+		return grpc.Errorf(codes.FailedPrecondition, "%q: %v", name, err)
+	case context.Canceled:
+		return grpc.Errorf(codes.Canceled, "%v", err)
+	case context.DeadlineExceeded:
+		return grpc.Errorf(codes.DeadlineExceeded, "%v", err)
+	}
+	return grpc.Errorf(codes.Internal, "%v", err)
+}
+
+func (cs *controlServer) StartNode(ctx context.Context, r *clusterpb.NodeRequest) (*clusterpb.NodeResponse, error) {
+	if err := cs.allowControl(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if err := cs.clus.RestartByNameCtx(ctx, r.Name); err != nil {
+		return nil, lifecycleRPCError(r.Name, err)
+	}
+	cs.cfg.record(start, scenario.Step{Restart: r.Name})
+	return &clusterpb.NodeResponse{Result: fmt.Sprintf("restarted %q", r.Name)}, nil
+}
+
+func (cs *controlServer) StopNode(ctx context.Context, r *clusterpb.NodeRequest) (*clusterpb.NodeResponse, error) {
+	if err := cs.allowControl(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if err := cs.clus.StopByNameCtx(ctx, r.Name); err != nil {
+		return nil, lifecycleRPCError(r.Name, err)
+	}
+	cs.cfg.record(start, scenario.Step{Stop: r.Name})
+	return &clusterpb.NodeResponse{Result: fmt.Sprintf("stopped %q", r.Name)}, nil
+}
+
+func (cs *controlServer) Status(r *clusterpb.StatusRequest, stream clusterpb.ClusterControl_StatusServer) error {
+	interval := time.Duration(r.IntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = cs.clus.StatusInterval()
+	}
+	if interval < minStatusInterval {
+		interval = minStatusInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		st := &clusterpb.ClusterStatus{
+			ClusterSize: int64(cs.clus.Size()),
+			Quorum:      int64(cs.clus.Quorum()),
+			Active:      int64(cs.clus.ActiveNodeN()),
+			Members:     cs.clus.AllMemberStatus(),
+		}
+		if err := stream.Send(st); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-cs.clus.StopNotify():
+			return grpc.Errorf(codes.Unavailable, "cluster is shut down")
+		case <-cs.stopc:
+			return grpc.Errorf(codes.Unavailable, "server is shutting down")
+		}
+	}
+}
+
+func (cs *controlServer) InjectFault(ctx context.Context, r *clusterpb.FaultRequest) (*clusterpb.NodeResponse, error) {
+	if r.AfterMs < 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "negative fault delay %dms", r.AfterMs)
+	}
+	if err := cs.allowControl(ctx); err != nil {
+		return nil, err
+	}
+	f := cluster.Fault{Node: r.Node, Type: r.Type, After: time.Duration(r.AfterMs) * time.Millisecond}
+	start := time.Now()
+	if err := cs.clus.InjectFault(f); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	cs.cfg.record(start, scenario.Step{Fault: &scenario.Fault{Node: f.Node, Type: f.Type, After: scenario.Duration(f.After)}})
+	return &clusterpb.NodeResponse{Result: fmt.Sprintf("%q on %q armed in %v", f.Type, f.Node, f.After)}, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/coreos/etcdlabs/cluster"
+
+	"github.com/golang/glog"
+)
+
+// keyspace serves '/v1/keyspace?prefix=P&format=F' (JSON lines if 'format'
+// is not set). GET dumps the keys under the prefix (see
+// cluster.Cluster.DumpKeyspace), and POST imports a dump from the body.
+func (s *Server) keyspace(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		handlerFunc(s.importKeyspace).ServeHTTP(w, req)
+		return
+	}
+	if req.Method != http.MethodGet {
+		writeError(w, req, errMethodNotAllowed)
+		return
+	}
+
+	format := keyspaceFormat(req)
+	ctx, cancel := context.WithTimeout(req.Context(), fixtureTimeout)
+	defer cancel()
+	// buffered, so that errors are reported with their status code
+	var buf bytes.Buffer
+	if _, err := s.clus.DumpKeyspace(ctx, &buf, req.URL.Query().Get("prefix"), format); err != nil {
+		writeError(w, req, errorf(http.StatusBadRequest, "%v", err))
+		return
+	}
+	switch format {
+	case cluster.DumpCSV:
+		w.Header().Set("Content-Type", "text/csv")
+	default:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	if _, err := buf.WriteTo(w); err != nil {
+		glog.Warningf("failed to write keyspace (%v)", err)
+	}
+}
+
+func (s *Server) importKeyspace(req *http.Request) (interface{}, error) {
+	if err := s.allowControl(req); err != nil {
+		return nil, err
+	}
+	defer req.Body.Close()
+
+	ctx, cancel := context.WithTimeout(req.Context(), fixtureTimeout)
+	defer cancel()
+	r, err := s.clus.ImportKeyspace(ctx, req.Body, keyspaceFormat(req), "request from "+req.RemoteAddr)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	return FixtureResponse{
+		Result:  Result{Success: true, Result: fmt.Sprintf("imported %d keys with %d leases", r.Keys, r.Leases)},
+		Fixture: r,
+	}, nil
+}
+
+// keyspaceStats serves '/v1/keyspace/stats?top=N', the statistics of the
+// keyspace with the top N prefixes (see cluster.Cluster.KeyspaceStats).
+func (s *Server) keyspaceStats(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	top := 0
+	if v := req.URL.Query().Get("top"); v != "" {
+		var err error
+		if top, err = strconv.Atoi(v); err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid 'top' %q", v)
+		}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), fixtureTimeout)
+	defer cancel()
+	st, err := s.clus.KeyspaceStats(ctx, top)
+	if err != nil {
+		return nil, err
+	}
+	return KeyspaceStatsResponse{
+		Result:   Result{Success: true, Result: fmt.Sprintf("%d keys (%d value bytes) at revision %d", st.Keys, st.ValueBytes, st.Revision)},
+		Keyspace: st,
+	}, nil
+}
+
+func keyspaceFormat(req *http.Request) string {
+	if f := req.URL.Query().Get("format"); f != "" {
+		return f
+	}
+	return cluster.DumpJSON
+}
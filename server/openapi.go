@@ -0,0 +1,209 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcdlabs/chaos"
+	"github.com/coreos/etcdlabs/scenario"
+)
+
+// route describes an API route for the OpenAPI specification.
+type route struct {
+	method  string
+	path    string
+	summary string
+	// request and response are zero values of the JSON bodies
+	request  interface{}
+	response interface{}
+}
+
+// routes are the routes served by Server.
+var routes = []route{
+	{http.MethodGet, "/v1/status", "Returns the cluster and member status.", nil, StatusResponse{}},
+	{http.MethodGet, "/v1/state", "Returns the configuration, status and events of the cluster and members.", nil, StateResponse{}},
+	{http.MethodPost, "/v1/members", "Adds a member.", nil, Result{}},
+	{http.MethodDelete, "/v1/members/{name}", "Removes the member.", nil, Result{}},
+	{http.MethodPost, "/v1/members/{name}/stop", "Stops the member.", nil, Result{}},
+	{http.MethodPost, "/v1/members/{name}/restart", "Restarts the stopped member.", nil, Result{}},
+	{http.MethodPost, "/v1/members/{name}/kill", "Kills the member without a graceful shutdown.", nil, Result{}},
+	{http.MethodGet, "/v1/members/{name}/health", "Probes the member health with a linearizable read.", nil, HealthResponse{}},
+	{http.MethodGet, "/v1/members/{name}/backend", "Returns the page statistics of the member backend database.", nil, BackendResponse{}},
+	{http.MethodGet, "/v1/members/{name}/catchup", "Returns whether the member caught up by log replay or snapshot after its last restart.", nil, CatchUpResponse{}},
+	{http.MethodGet, "/v1/members/{name}/wal", "Decodes the write-ahead log of a stopped member.", nil, WALResponse{}},
+	{http.MethodPost, "/v1/faults", "Schedules a fault on a member.", FaultRequest{}, Result{}},
+	{http.MethodPost, "/v1/fixture", "Loads the request body as a fixture (JSON lines of key, value and lease TTL).", nil, FixtureResponse{}},
+	{http.MethodGet, "/v1/keyspace", "Dumps the keys under 'prefix' as JSON lines, or CSV if 'format' is 'csv'.", nil, ""},
+	{http.MethodPost, "/v1/keyspace", "Imports a keyspace dump ('format' is 'json' or 'csv').", nil, FixtureResponse{}},
+	{http.MethodGet, "/v1/keyspace/stats", "Returns the key count, value bytes, revision span and top prefixes ('top' query) of the keyspace.", nil, KeyspaceStatsResponse{}},
+	{http.MethodGet, "/v1/leases", "Lists the active leases with their remaining TTL and attached keys ('node' query picks the member).", nil, LeasesResponse{}},
+	{http.MethodGet, "/v1/raft/progress", "Returns the match and next index and the state of each member, as tracked by the leader.", nil, RaftProgressResponse{}},
+	{http.MethodGet, "/v1/raft/traffic", "Returns the raft messages per link and type, traced by the peer proxies.", nil, PeerTrafficResponse{}},
+	{http.MethodDelete, "/v1/raft/traffic", "Discards the traced raft messages.", nil, Result{}},
+	{http.MethodPost, "/v1/raft/timeline", "Writes a key, and returns the proposal, commit and apply times on each member.", TimelineRequest{}, TimelineResponse{}},
+	{http.MethodPost, "/v1/raft/election", "Moves the leadership to a random follower, and returns the election duration.", nil, ElectionResponse{}},
+	{http.MethodGet, "/v1/events", "Returns recent cluster events ('last' query limits them).", nil, EventsResponse{}},
+	{http.MethodGet, "/v1/audit", "Queries the audit log ('who', 'target', 'since', 'until', 'failed' and 'last' queries); needs control access.", nil, AuditResponse{}},
+	{http.MethodGet, "/v1/recording", "Returns the recorded operations as a replayable scenario.", nil, scenario.Scenario{}},
+	{http.MethodDelete, "/v1/recording", "Discards the recorded operations.", nil, Result{}},
+	{http.MethodPost, "/v1/snapshots", "Saves a snapshot of a member.", SnapshotRequest{}, Result{}},
+	{http.MethodGet, "/v1/snapshots/diff", "Compares the keyspaces of the 'before' and 'after' snapshots, or of 'before' and the cluster if 'after' is not set.", nil, SnapshotDiffResponse{}},
+	{http.MethodGet, "/v1/chaos", "Lists the chaos calendar entries.", nil, ChaosResponse{}},
+	{http.MethodPost, "/v1/chaos", "Schedules a recurring failure (replacing the entry of the same name).", chaos.Entry{}, Result{}},
+	{http.MethodDelete, "/v1/chaos/{name}", "Removes the chaos calendar entry.", nil, Result{}},
+	{http.MethodGet, healthzPath, "Liveness probe of the server.", nil, Result{}},
+	{http.MethodGet, readyzPath, "Readiness probe; fails with 503 unless a quorum of members is healthy.", nil, Result{}},
+}
+
+// schema is an OpenAPI (Swagger 2.0) schema object.
+type schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaOf returns the schema of the type as encoded by encoding/json,
+// adding named structs to the definitions.
+func schemaOf(t reflect.Type, defs map[string]*schema) *schema {
+	switch t {
+	case timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &schema{Type: "integer", Format: "int64"}
+	}
+	if t.Kind() != reflect.Struct && t.Kind() != reflect.Ptr && t.Implements(marshalerType) {
+		// e.g. durations in Go syntax
+		return &schema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), defs)
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: schemaOf(t.Elem(), defs)}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), defs)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, defs)
+		}
+		name := t.String() // e.g. "cluster.HealthResponse"
+		if _, ok := defs[name]; !ok {
+			defs[name] = nil // breaks recursion
+			defs[name] = structSchema(t, defs)
+		}
+		return &schema{Ref: "#/definitions/" + name}
+	}
+	return &schema{}
+}
+
+func structSchema(t reflect.Type, defs map[string]*schema) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue // unexported
+		}
+		name := f.Name
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		if f.Anonymous && f.Tag.Get("json") == "" && f.Type.Kind() == reflect.Struct {
+			// embedded fields are flattened
+			for k, v := range structSchema(f.Type, defs).Properties {
+				s.Properties[k] = v
+			}
+			continue
+		}
+		s.Properties[name] = schemaOf(f.Type, defs)
+	}
+	return s
+}
+
+// spec returns the OpenAPI (Swagger 2.0) specification of the API.
+func spec() map[string]interface{} {
+	defs := make(map[string]*schema)
+	paths := make(map[string]map[string]interface{})
+	for _, r := range routes {
+		op := map[string]interface{}{
+			"summary":  r.summary,
+			"produces": []string{"application/json"},
+			"responses": map[string]interface{}{
+				"200":     map[string]interface{}{"description": "OK", "schema": schemaOf(reflect.TypeOf(r.response), defs)},
+				"default": map[string]interface{}{"description": "Error", "schema": schemaOf(reflect.TypeOf(Result{}), defs)},
+			},
+		}
+		var params []interface{}
+		if strings.Contains(r.path, "{name}") {
+			params = append(params, map[string]interface{}{"name": "name", "in": "path", "required": true, "type": "string"})
+		}
+		if r.request != nil {
+			params = append(params, map[string]interface{}{"name": "body", "in": "body", "required": true, "schema": schemaOf(reflect.TypeOf(r.request), defs)})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if paths[r.path] == nil {
+			paths[r.path] = make(map[string]interface{})
+		}
+		paths[r.path][strings.ToLower(r.method)] = op
+	}
+	return map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"title":   "etcdlabs cluster control API",
+			"version": "v1",
+		},
+		"securityDefinitions": map[string]interface{}{
+			"bearer": map[string]interface{}{"type": "apiKey", "name": "Authorization", "in": "header"},
+		},
+		"security":    []interface{}{map[string][]string{"bearer": {}}},
+		"paths":       paths,
+		"definitions": defs,
+	}
+}
+
+// specHandler serves the OpenAPI specification.
+func specHandler(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	return spec(), nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Probe paths for liveness and readiness checks (e.g. Kubernetes probes).
+// They report the health of this server, and whether the cluster it
+// manages has quorum, rather than the health of a single etcd member.
+// They are not authenticated.
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+)
+
+// healthz reports whether the server is serving requests.
+func (s *Server) healthz(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	return Result{Success: true, Result: "OK"}, nil
+}
+
+// readyz reports whether a quorum of members is healthy,
+// so that the cluster accepts writes.
+func (s *Server) readyz(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	select {
+	case <-s.clus.StopNotify():
+		return nil, errorf(http.StatusServiceUnavailable, "cluster is shut down")
+	default:
+	}
+	healthy, quorum := s.clus.HealthyNodeN(), s.clus.Quorum()
+	if healthy < quorum {
+		return nil, errorf(http.StatusServiceUnavailable, "no quorum (%d healthy members, quorum %d)", healthy, quorum)
+	}
+	return Result{Success: true, Result: fmt.Sprintf("%d healthy members (quorum %d)", healthy, quorum)}, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/coreos/etcdlabs/scenario"
+
+	"github.com/golang/glog"
+)
+
+// record records the operation to Config.Recorder, if any.
+func (cfg Config) record(start time.Time, s scenario.Step) {
+	if cfg.Recorder == nil {
+		return
+	}
+	if err := cfg.Recorder.Record(start, s); err != nil {
+		glog.Warningf("failed to record %q (%v)", s, err)
+	}
+}
+
+// recording serves '/v1/recording'. GET returns the recorded scenario,
+// which can be saved and replayed (JSON is valid YAML), and DELETE
+// discards the recorded operations.
+func (s *Server) recording(req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case http.MethodGet:
+		return s.cfg.Recorder.Scenario(), nil
+	case http.MethodDelete:
+		s.cfg.Recorder.Reset()
+		return Result{Success: true, Result: "discarded recorded operations"}, nil
+	}
+	return nil, errMethodNotAllowed
+}
@@ -0,0 +1,585 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcdlabs/chaos"
+	"github.com/coreos/etcdlabs/cluster"
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+	"github.com/coreos/etcdlabs/pkg/audit"
+	"github.com/coreos/etcdlabs/pkg/ratelimit"
+	"github.com/coreos/etcdlabs/scenario"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc"
+)
+
+// Config configures a Server.
+type Config struct {
+	// ControlRateInterval and ControlRateBurst limit the requests that
+	// change the cluster (add, remove, stop, restart, kill and faults)
+	// per client IP, to 'burst' requests at once and one more every
+	// interval. Requests are not limited if the interval is zero.
+	ControlRateInterval time.Duration
+	ControlRateBurst    int
+
+	// TrustedProxies are the IP addresses or CIDR networks of the reverse
+	// proxies in front of the server. The client IP of their requests is
+	// taken from 'X-Forwarded-For'; the header is ignored otherwise.
+	TrustedProxies []string
+
+	// Tokens maps static bearer tokens to their access level (AccessRead
+	// or AccessControl). HMACSecret accepts tokens from token.NewHMAC.
+	// Requests are not authenticated if neither is set, so set them
+	// before exposing the API beyond localhost. GET requests need
+	// AccessRead, and others need AccessControl.
+	Tokens     map[string]string
+	HMACSecret []byte
+
+	// Audit, if not nil, records the requests that change the cluster
+	// (over HTTP and over gRPC with ServerOptions), including rejected
+	// ones, and serves them at '/v1/audit'. The caller closes it.
+	Audit *audit.Log
+
+	// Recorder, if not nil, records the successful operations that change
+	// the cluster as a replayable scenario (see scenario.Replay), served
+	// at '/v1/recording'.
+	Recorder *scenario.Recorder
+
+	// Chaos, if not nil, is the chaos calendar of the cluster, managed at
+	// '/v1/chaos'. The caller closes it.
+	Chaos *chaos.Calendar
+
+	// SnapshotDir, if not empty, holds the snapshots saved at
+	// '/v1/snapshots' and compared at '/v1/snapshots/diff'.
+	SnapshotDir string
+}
+
+// Server serves the REST API of a cluster.
+type Server struct {
+	clus *cluster.Cluster
+	cfg  Config
+	mux  *http.ServeMux
+
+	controlLimiter *ratelimit.IPLimiter
+	proxies        ratelimit.TrustedProxies
+
+	mu         sync.Mutex
+	draining   bool
+	inflight   sync.WaitGroup
+	httpServer *http.Server
+	grpcServer *grpc.Server
+
+	// stopc is closed to cancel streams on shutdown
+	stopc        chan struct{}
+	donec        chan struct{}
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// New returns a Server of the cluster. Serve it with ListenAndServe,
+// or with an http.Server, and stop it with Shutdown.
+func New(clus *cluster.Cluster, cfg Config) (*Server, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	s := &Server{
+		clus:  clus,
+		cfg:   cfg,
+		mux:   http.NewServeMux(),
+		stopc: make(chan struct{}),
+		donec: make(chan struct{}),
+	}
+	proxies, err := ratelimit.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	s.proxies = proxies
+	if cfg.ControlRateInterval > 0 {
+		s.controlLimiter = ratelimit.NewIPLimiter(cfg.ControlRateInterval, cfg.ControlRateBurst)
+	}
+	s.mux.Handle("/v1/status", handlerFunc(s.status))
+	s.mux.Handle("/v1/state", handlerFunc(s.state))
+	s.mux.Handle("/v1/members", handlerFunc(s.members))
+	s.mux.Handle("/v1/members/", handlerFunc(s.member))
+	s.mux.Handle("/v1/faults", handlerFunc(s.faults))
+	s.mux.Handle("/v1/events", handlerFunc(s.events))
+	s.mux.Handle("/v1/leases", handlerFunc(s.leases))
+	s.mux.Handle("/v1/raft/progress", handlerFunc(s.raftProgress))
+	s.mux.Handle("/v1/raft/traffic", handlerFunc(s.raftTraffic))
+	s.mux.Handle("/v1/raft/election", handlerFunc(s.raftElection))
+	s.mux.Handle("/v1/raft/timeline", handlerFunc(s.raftTimeline))
+	s.mux.Handle("/v1/fixture", handlerFunc(s.fixture))
+	s.mux.HandleFunc("/v1/keyspace", s.keyspace)
+	s.mux.Handle("/v1/keyspace/stats", handlerFunc(s.keyspaceStats))
+	if cfg.Audit != nil {
+		s.mux.Handle("/v1/audit", handlerFunc(s.auditLog))
+	}
+	if cfg.Recorder != nil {
+		s.mux.Handle("/v1/recording", handlerFunc(s.recording))
+	}
+	if cfg.SnapshotDir != "" {
+		s.mux.Handle("/v1/snapshots", handlerFunc(s.snapshots))
+		s.mux.Handle("/v1/snapshots/diff", handlerFunc(s.snapshotDiff))
+	}
+	if cfg.Chaos != nil {
+		s.mux.Handle("/v1/chaos", handlerFunc(s.chaosCalendar))
+		s.mux.Handle("/v1/chaos/", handlerFunc(s.chaosEntry))
+	}
+	s.mux.Handle(specPath, handlerFunc(specHandler))
+	s.mux.Handle(healthzPath, handlerFunc(s.healthz))
+	s.mux.Handle(readyzPath, handlerFunc(s.readyz))
+	return s, nil
+}
+
+// specPath serves the OpenAPI specification, without authentication.
+const specPath = "/api/spec"
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !s.enter() {
+		writeError(w, req, errShuttingDown)
+		return
+	}
+	defer s.inflight.Done()
+
+	switch req.URL.Path {
+	case specPath, healthzPath, readyzPath:
+		s.mux.ServeHTTP(w, req)
+		return
+	}
+	if s.cfg.Audit != nil && req.Method != http.MethodGet {
+		aw, record := s.auditHTTP(w, req)
+		defer record()
+		w = aw
+	}
+	need := AccessControl
+	if req.Method == http.MethodGet {
+		need = AccessRead
+	}
+	if err := s.authorize(req, need); err != nil {
+		writeError(w, req, err)
+		return
+	}
+	s.mux.ServeHTTP(w, req)
+}
+
+// requestTimeout bounds operations that talk to the cluster.
+var requestTimeout = 5 * time.Second
+
+// httpError is an error with its HTTP status code.
+type httpError struct {
+	code int
+	msg  string
+}
+
+func (e httpError) Error() string { return e.msg }
+
+func errorf(code int, format string, args ...interface{}) error {
+	return httpError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+var errMethodNotAllowed = errorf(http.StatusMethodNotAllowed, "method not allowed")
+
+// allowControl checks the rate limit of requests that change the cluster.
+func (s *Server) allowControl(req *http.Request) error {
+	if s.controlLimiter == nil {
+		return nil
+	}
+	if msg, ok := s.controlLimiter.Allow(s.proxies.ClientIP(req)); !ok {
+		return errorf(http.StatusTooManyRequests, "%s", msg)
+	}
+	return nil
+}
+
+// handlerFunc returns a JSON response, or an error written as a
+// Result with the error status code (500 if it is not an httpError).
+type handlerFunc func(req *http.Request) (interface{}, error)
+
+func (f handlerFunc) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	resp, err := f(req)
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		glog.Warningf("failed to write response (%v)", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, req *http.Request, err error) {
+	code := http.StatusInternalServerError
+	if he, ok := err.(httpError); ok {
+		code = he.code
+	}
+	glog.Warningf("%s %s failed (%v)", req.Method, req.URL.Path, err)
+	if aw, ok := w.(*auditWriter); ok {
+		aw.err = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err = json.NewEncoder(w).Encode(Result{Success: false, Result: err.Error()}); err != nil {
+		glog.Warningf("failed to write response (%v)", err)
+	}
+}
+
+func (s *Server) status(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	return StatusResponse{
+		Result:       Result{Success: true},
+		Size:         s.clus.Size(),
+		Quorum:       s.clus.Quorum(),
+		Active:       s.clus.ActiveNodeN(),
+		Members:      s.clus.AllMemberStatus(),
+		Tolerance:    s.clus.FailureTolerance(),
+		Connectivity: s.clus.Connectivity(),
+		Seed:         s.clus.Seed(),
+	}, nil
+}
+
+// state serves '/v1/state', the whole cluster state in one response.
+func (s *Server) state(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	return StateResponse{Result: Result{Success: true}, State: s.clus.State()}, nil
+}
+
+func (s *Server) members(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodPost {
+		return nil, errMethodNotAllowed
+	}
+	if err := s.allowControl(req); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if err := s.clus.Add(req.Context()); err != nil {
+		return nil, err
+	}
+	s.cfg.record(start, scenario.Step{Add: true})
+	return Result{Success: true, Result: fmt.Sprintf("added member (cluster size %d)", s.clus.Size())}, nil
+}
+
+// member serves '/v1/members/{name}' and '/v1/members/{name}/{action}'.
+func (s *Server) member(req *http.Request) (interface{}, error) {
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/v1/members/"), "/")
+	if len(parts) > 2 || parts[0] == "" {
+		return nil, errorf(http.StatusNotFound, "unknown path %q", req.URL.Path)
+	}
+	// resolve the node by name in each operation, since a concurrent
+	// Add or Remove can shift the node indexes
+	name := parts[0]
+	if s.clus.FindIndexByName(name) == -1 {
+		return nil, errorf(http.StatusNotFound, "unknown member %q", name)
+	}
+
+	if len(parts) == 1 {
+		if req.Method != http.MethodDelete {
+			return nil, errMethodNotAllowed
+		}
+		if err := s.allowControl(req); err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		if err := s.clus.RemoveByName(req.Context(), name); err != nil {
+			return nil, lifecycleError(name, err)
+		}
+		s.cfg.record(start, scenario.Step{Remove: name})
+		return Result{Success: true, Result: fmt.Sprintf("removed %q", name)}, nil
+	}
+
+	action := parts[1]
+	if action == "health" {
+		if req.Method != http.MethodGet {
+			return nil, errMethodNotAllowed
+		}
+		ctx, cancel := context.WithTimeout(req.Context(), requestTimeout)
+		defer cancel()
+		h, err := s.clus.HealthByName(ctx, name)
+		if err != nil {
+			return nil, lifecycleError(name, err)
+		}
+		return HealthResponse{Result: Result{Success: true, Result: fmt.Sprintf("%q healthy: %v (took %v)", name, h.Healthy, h.Took)}, Health: h}, nil
+	}
+	if action == "backend" {
+		if req.Method != http.MethodGet {
+			return nil, errMethodNotAllowed
+		}
+		ctx, cancel := context.WithTimeout(req.Context(), snapshotTimeout)
+		defer cancel()
+		st, err := s.clus.BackendStatsByName(ctx, name)
+		if err != nil {
+			return nil, lifecycleError(name, err)
+		}
+		return BackendResponse{Result: Result{Success: true, Result: fmt.Sprintf("%q backend is %.1f%% fragmented", name, 100*st.Fragmentation)}, Backend: st}, nil
+	}
+	if action == "catchup" {
+		if req.Method != http.MethodGet {
+			return nil, errMethodNotAllowed
+		}
+		cu, ok := s.clus.CatchUpByName(name)
+		if !ok {
+			return nil, errorf(http.StatusNotFound, "%q has not caught up since a restart", name)
+		}
+		return CatchUpResponse{Result: Result{Success: true, Result: fmt.Sprintf("%q caught up by %s in %v", name, cu.Method, cu.Duration)}, CatchUp: cu}, nil
+	}
+	if action == "wal" {
+		if req.Method != http.MethodGet {
+			return nil, errMethodNotAllowed
+		}
+		st, err := s.clus.MemberStatusByName(name)
+		if err != nil {
+			return nil, lifecycleError(name, err)
+		}
+		if st.State != clusterpb.StoppedMemberStatus {
+			return nil, errorf(http.StatusConflict, "%q must be stopped to read its WAL", name)
+		}
+		lg, err := s.clus.ReadWALByName(name)
+		if err != nil {
+			return nil, err
+		}
+		return WALResponse{Result: Result{Success: true, Result: fmt.Sprintf("%q WAL has %d entries (term %d, commit %d)", name, len(lg.Entries), lg.Term, lg.Commit)}, WAL: lg}, nil
+	}
+
+	if req.Method != http.MethodPost {
+		return nil, errMethodNotAllowed
+	}
+	switch action {
+	case "stop", "restart", "kill":
+	default:
+		return nil, errorf(http.StatusNotFound, "unknown action %q", action)
+	}
+	if err := s.allowControl(req); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), requestTimeout)
+	defer cancel()
+	start := time.Now()
+	switch action {
+	case "stop":
+		if err := s.clus.StopByNameCtx(ctx, name); err != nil {
+			return nil, lifecycleError(name, err)
+		}
+		s.cfg.record(start, scenario.Step{Stop: name})
+	case "restart":
+		if err := s.clus.RestartByNameCtx(ctx, name); err != nil {
+			return nil, lifecycleError(name, err)
+		}
+		s.cfg.record(start, scenario.Step{Restart: name})
+	case "kill":
+		if err := s.clus.KillByName(name); err != nil {
+			return nil, lifecycleError(name, err)
+		}
+		s.cfg.record(start, scenario.Step{Kill: name})
+	}
+	return Result{Success: true, Result: fmt.Sprintf("%s %q", action, name)}, nil
+}
+
+// lifecycleError maps the errors of the member operations to HTTP errors.
+func lifecycleError(name string, err error) error {
+	if _, ok := err.(*cluster.UnknownNodeError); ok {
+		return errorf(http.StatusNotFound, "unknown member %q", name)
+	}
+	if err == cluster.ErrAlreadyStopped || err == cluster.ErrAlreadyStarted {
+		return errorf(http.StatusConflict, "%q: %v", name, err)
+	}
+	if _, ok := err.(*cluster.ErrRateLimited); ok {
+		return errorf(http.StatusTooManyRequests, "%q: %v", name, err)
+	}
+	return err
+}
+
+func (s *Server) faults(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodPost {
+		return nil, errMethodNotAllowed
+	}
+	if err := s.allowControl(req); err != nil {
+		return nil, err
+	}
+	var freq FaultRequest
+	if err := json.NewDecoder(req.Body).Decode(&freq); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid fault request (%v)", err)
+	}
+	defer req.Body.Close()
+
+	f := cluster.Fault{Node: freq.Node, Type: freq.Type}
+	if freq.After != "" {
+		d, err := time.ParseDuration(freq.After)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid fault delay %q (%v)", freq.After, err)
+		}
+		f.After = d
+	}
+	start := time.Now()
+	if err := s.clus.InjectFault(f); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	s.cfg.record(start, scenario.Step{Fault: &scenario.Fault{Node: f.Node, Type: f.Type, After: scenario.Duration(f.After)}})
+	return Result{Success: true, Result: fmt.Sprintf("%q on %q armed in %v", f.Type, f.Node, f.After)}, nil
+}
+
+// leases serves '/v1/leases?node=NAME', the active leases read through
+// the node (the first started member if 'node' is not set).
+func (s *Server) leases(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	idx := -1
+	if name := req.URL.Query().Get("node"); name != "" {
+		if idx = s.clus.FindIndexByName(name); idx == -1 {
+			return nil, errorf(http.StatusNotFound, "unknown member %q", name)
+		}
+	} else {
+		for i := 0; i < s.clus.Size(); i++ {
+			if stopped, err := s.clus.IsStopped(i); err == nil && !stopped {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return nil, errorf(http.StatusServiceUnavailable, "no started member")
+		}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), requestTimeout)
+	defer cancel()
+	ls, err := s.clus.Leases(ctx, idx)
+	if err != nil {
+		return nil, err
+	}
+	return LeasesResponse{Result: Result{Success: true, Result: fmt.Sprintf("%d active leases", len(ls))}, Leases: ls}, nil
+}
+
+// raftProgress serves '/v1/raft/progress', the replication progress
+// of the members as tracked by the leader.
+func (s *Server) raftProgress(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	rp, err := s.clus.RaftProgress()
+	if err != nil {
+		return nil, errorf(http.StatusServiceUnavailable, "%v", err)
+	}
+	return RaftProgressResponse{Result: Result{Success: true, Result: fmt.Sprintf("leader %q at commit %d (term %d)", rp.Leader, rp.Commit, rp.Term)}, Progress: rp}, nil
+}
+
+// raftTraffic serves '/v1/raft/traffic', the raft messages traced by
+// the peer proxies on GET, which DELETE discards.
+func (s *Server) raftTraffic(req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case http.MethodGet:
+		pt, err := s.clus.PeerTraffic()
+		if err != nil {
+			return nil, errorf(http.StatusNotFound, "%v", err)
+		}
+		return PeerTrafficResponse{Result: Result{Success: true, Result: fmt.Sprintf("%d message flows", len(pt.Flows))}, Traffic: pt}, nil
+	case http.MethodDelete:
+		if err := s.allowControl(req); err != nil {
+			return nil, err
+		}
+		if err := s.clus.ResetPeerTraffic(); err != nil {
+			return nil, errorf(http.StatusNotFound, "%v", err)
+		}
+		return Result{Success: true, Result: "discarded the traced messages"}, nil
+	}
+	return nil, errMethodNotAllowed
+}
+
+// raftElection serves '/v1/raft/election', which moves the leadership
+// to a random follower and returns how long the election took.
+func (s *Server) raftElection(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodPost {
+		return nil, errMethodNotAllowed
+	}
+	if err := s.allowControl(req); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), requestTimeout)
+	defer cancel()
+	el, err := s.clus.ForceElection(ctx)
+	if err != nil {
+		return nil, errorf(http.StatusServiceUnavailable, "%v", err)
+	}
+	return ElectionResponse{Result: Result{Success: true, Result: fmt.Sprintf("moved leader from %q to %q in %v", el.From, el.To, el.Duration)}, Election: el}, nil
+}
+
+// raftTimeline serves '/v1/raft/timeline', which writes the key and
+// returns where the time went, from the proposal to the apply on each member.
+func (s *Server) raftTimeline(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodPost {
+		return nil, errMethodNotAllowed
+	}
+	if err := s.allowControl(req); err != nil {
+		return nil, err
+	}
+	var treq TimelineRequest
+	if err := json.NewDecoder(req.Body).Decode(&treq); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid timeline request (%v)", err)
+	}
+	defer req.Body.Close()
+	if treq.Key == "" {
+		return nil, errorf(http.StatusBadRequest, "empty key")
+	}
+
+	idx := -1
+	if treq.Node != "" {
+		if idx = s.clus.FindIndexByName(treq.Node); idx == -1 {
+			return nil, errorf(http.StatusNotFound, "unknown member %q", treq.Node)
+		}
+	} else {
+		for i := 0; i < s.clus.Size(); i++ {
+			if stopped, err := s.clus.IsStopped(i); err == nil && !stopped {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return nil, errorf(http.StatusServiceUnavailable, "no started member")
+		}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), requestTimeout)
+	defer cancel()
+	tl, err := s.clus.TracePut(ctx, idx, treq.Key, treq.Value)
+	if err != nil {
+		return nil, err
+	}
+	return TimelineResponse{Result: Result{Success: true, Result: fmt.Sprintf("wrote %q at revision %d through %q (took %v)", tl.Key, tl.Revision, tl.Node, tl.Took)}, Timeline: tl}, nil
+}
+
+// events serves '/v1/events?last=N' (all kept events if 'last' is not set).
+func (s *Server) events(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	n := 0
+	if v := req.URL.Query().Get("last"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid 'last' %q", v)
+		}
+	}
+	return EventsResponse{Result: Result{Success: true}, Events: s.clus.Events(n)}, nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc"
+)
+
+var errShuttingDown = errorf(http.StatusServiceUnavailable, "server is shutting down")
+
+// enter registers an in-flight request, or returns false
+// if the server is shutting down.
+func (s *Server) enter() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.inflight.Add(1)
+	return true
+}
+
+// ListenAndServe serves the REST API on 'addr', and the gRPC control plane
+// on 'grpcAddr' unless it is empty, until the server is shut down.
+// It returns nil once Shutdown completes.
+func (s *Server) ListenAndServe(addr, grpcAddr string) error {
+	hs := &http.Server{Addr: addr, Handler: s}
+
+	var (
+		gs *grpc.Server
+		gl net.Listener
+	)
+	if grpcAddr != "" {
+		var err error
+		if gl, err = net.Listen("tcp", grpcAddr); err != nil {
+			return err
+		}
+		gs = grpc.NewServer(s.cfg.ServerOptions()...)
+		clusterpb.RegisterClusterControlServer(gs, &controlServer{clus: s.clus, cfg: s.cfg, limiter: s.controlLimiter, stopc: s.stopc})
+	}
+
+	s.mu.Lock()
+	if s.draining {
+		s.mu.Unlock()
+		if gl != nil {
+			gl.Close()
+		}
+		return errShuttingDown
+	}
+	s.httpServer, s.grpcServer = hs, gs
+	s.mu.Unlock()
+
+	if gs != nil {
+		go func() {
+			if err := gs.Serve(gl); err != nil {
+				glog.Warningf("gRPC server on %s stopped (%v)", grpcAddr, err)
+			}
+		}()
+	}
+	err := hs.ListenAndServe()
+	if err == http.ErrServerClosed {
+		<-s.donec
+		return nil
+	}
+	if gs != nil {
+		gs.Stop()
+	}
+	return err
+}
+
+// Shutdown stops the server and then the cluster, in order:
+// new requests are rejected, in-flight requests are drained,
+// status streams are canceled, and the cluster is shut down,
+// removing its data directories. If 'ctx' is done before the
+// requests are drained, the remaining ones are abandoned, and
+// the cluster is still shut down. It is safe to call more than once.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		s.shutdownErr = s.shutdown(ctx)
+		close(s.donec)
+	})
+	<-s.donec
+	return s.shutdownErr
+}
+
+func (s *Server) shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.draining = true
+	hs, gs := s.httpServer, s.grpcServer
+	s.mu.Unlock()
+
+	glog.Info("shutting down server (rejecting new requests)")
+	var err error
+	if hs != nil {
+		// closes the listener, and waits for active connections
+		err = hs.Shutdown(ctx)
+	}
+
+	// handlers served by other http.Servers
+	drained := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		glog.Info("drained in-flight requests")
+	case <-ctx.Done():
+		glog.Warningf("abandoning in-flight requests (%v)", ctx.Err())
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+
+	glog.Info("canceling status streams")
+	close(s.stopc)
+	if gs != nil {
+		stopped := make(chan struct{})
+		go func() {
+			gs.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			gs.Stop()
+		}
+	}
+
+	glog.Info("shutting down cluster")
+	s.clus.Shutdown()
+	glog.Info("shut down server")
+	return err
+}
+
+// ShutdownOnSignal shuts down the server when the process receives
+// SIGTERM or SIGINT, waiting up to 'timeout' for in-flight requests.
+func (s *Server) ShutdownOnSignal(timeout time.Duration) {
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		defer signal.Stop(sc)
+		select {
+		case sig := <-sc:
+			glog.Infof("shutting down server with signal %q", sig)
+		case <-s.donec:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := s.Shutdown(ctx); err != nil {
+			glog.Warningf("shutdown error (%v)", err)
+		}
+	}()
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcdlabs/pkg/snapshot"
+)
+
+// snapshotTimeout bounds saving and comparing snapshots.
+var snapshotTimeout = time.Minute
+
+// snapshotPath returns the path of the named snapshot in Config.SnapshotDir.
+func (s *Server) snapshotPath(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", errorf(http.StatusBadRequest, "invalid snapshot name %q", name)
+	}
+	return filepath.Join(s.cfg.SnapshotDir, name), nil
+}
+
+// snapshots serves '/v1/snapshots', whose POST saves a snapshot of a member
+// (SnapshotRequest) in Config.SnapshotDir.
+func (s *Server) snapshots(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodPost {
+		return nil, errMethodNotAllowed
+	}
+	if err := s.allowControl(req); err != nil {
+		return nil, err
+	}
+	var sreq SnapshotRequest
+	if err := json.NewDecoder(req.Body).Decode(&sreq); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid snapshot request (%v)", err)
+	}
+	defer req.Body.Close()
+
+	idx := s.clus.FindIndexByName(sreq.Node)
+	if idx == -1 {
+		return nil, errorf(http.StatusNotFound, "unknown member %q", sreq.Node)
+	}
+	path, err := s.snapshotPath(sreq.Name)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), snapshotTimeout)
+	defer cancel()
+	n, err := s.clus.SaveSnapshot(ctx, idx, path)
+	if err != nil {
+		return nil, err
+	}
+	return Result{Success: true, Result: fmt.Sprintf("saved snapshot %q of %q (%d bytes)", sreq.Name, sreq.Node, n)}, nil
+}
+
+// snapshotDiff serves '/v1/snapshots/diff?before=A&after=B', comparing the
+// keyspaces of two snapshots, or of a snapshot and the cluster if 'after'
+// is not set.
+func (s *Server) snapshotDiff(req *http.Request) (interface{}, error) {
+	if req.Method != http.MethodGet {
+		return nil, errMethodNotAllowed
+	}
+	vs := req.URL.Query()
+	before, err := s.snapshotPath(vs.Get("before"))
+	if err != nil {
+		return nil, err
+	}
+
+	var d snapshot.Diff
+	if vs.Get("after") == "" {
+		ctx, cancel := context.WithTimeout(req.Context(), snapshotTimeout)
+		defer cancel()
+		if d, err = s.clus.CompareSnapshot(ctx, before); err != nil {
+			return nil, errorf(http.StatusBadRequest, "%v", err)
+		}
+	} else {
+		after, err := s.snapshotPath(vs.Get("after"))
+		if err != nil {
+			return nil, err
+		}
+		kb, err := snapshot.ReadKeyspace(before)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "%v", err)
+		}
+		ka, err := snapshot.ReadKeyspace(after)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "%v", err)
+		}
+		d = snapshot.Compare(kb.KVs, ka.KVs)
+	}
+	return SnapshotDiffResponse{Result: Result{Success: true, Result: d.String()}, Diff: d}, nil
+}
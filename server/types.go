@@ -0,0 +1,167 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/coreos/etcdlabs/chaos"
+	"github.com/coreos/etcdlabs/cluster"
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+	"github.com/coreos/etcdlabs/pkg/audit"
+	"github.com/coreos/etcdlabs/pkg/snapshot"
+	"github.com/coreos/etcdlabs/pkg/wal"
+)
+
+// Result is the response of operations with no other output.
+type Result struct {
+	Success bool
+	Result  string
+}
+
+// StatusResponse is the response of '/v1/status'.
+type StatusResponse struct {
+	Result
+	Size    int
+	Quorum  int
+	Active  int
+	Members []clusterpb.MemberStatus
+	// Tolerance is how many more member failures the cluster can survive.
+	Tolerance cluster.FailureTolerance
+	// Connectivity is the peer reachability matrix of the members.
+	Connectivity cluster.Connectivity
+	// Seed is the random seed of the cluster (see cluster.Config.Seed).
+	Seed int64
+}
+
+// StateResponse is the response of '/v1/state'.
+type StateResponse struct {
+	Result
+	State cluster.State
+}
+
+// HealthResponse is the response of '/v1/members/{name}/health'.
+type HealthResponse struct {
+	Result
+	Health cluster.HealthResponse
+}
+
+// BackendResponse is the response of '/v1/members/{name}/backend'.
+type BackendResponse struct {
+	Result
+	Backend snapshot.BackendStats
+}
+
+// CatchUpResponse is the response of '/v1/members/{name}/catchup'.
+type CatchUpResponse struct {
+	Result
+	CatchUp cluster.CatchUp
+}
+
+// WALResponse is the response of '/v1/members/{name}/wal'.
+type WALResponse struct {
+	Result
+	WAL wal.Log
+}
+
+// KeyspaceStatsResponse is the response of '/v1/keyspace/stats'.
+type KeyspaceStatsResponse struct {
+	Result
+	Keyspace cluster.KeyspaceStats
+}
+
+// LeasesResponse is the response of '/v1/leases'.
+type LeasesResponse struct {
+	Result
+	Leases []cluster.LeaseInfo
+}
+
+// RaftProgressResponse is the response of '/v1/raft/progress'.
+type RaftProgressResponse struct {
+	Result
+	Progress cluster.RaftProgress
+}
+
+// PeerTrafficResponse is the response of '/v1/raft/traffic'.
+type PeerTrafficResponse struct {
+	Result
+	Traffic cluster.PeerTraffic
+}
+
+// ElectionResponse is the response of '/v1/raft/election'.
+type ElectionResponse struct {
+	Result
+	Election cluster.ForcedElection
+}
+
+// TimelineResponse is the response of '/v1/raft/timeline'.
+type TimelineResponse struct {
+	Result
+	Timeline cluster.WriteTimeline
+}
+
+// FaultRequest is the request of '/v1/faults'.
+type FaultRequest struct {
+	// Node is the node name (e.g. "node1").
+	Node string
+	// Type is "stop", "kill" or "slow".
+	Type string
+	// After is the delay in Go syntax (e.g. "5s"), immediate if empty.
+	After string
+}
+
+// TimelineRequest is the request of '/v1/raft/timeline'.
+type TimelineRequest struct {
+	// Node is the node name to write through, the first started member if empty.
+	Node  string
+	Key   string
+	Value string
+}
+
+// FixtureResponse is the response of '/v1/fixture'.
+type FixtureResponse struct {
+	Result
+	Fixture cluster.FixtureResult
+}
+
+// SnapshotRequest is the request of '/v1/snapshots'.
+type SnapshotRequest struct {
+	// Node is the member to take the snapshot from (e.g. "node1").
+	Node string
+	// Name is the file name of the snapshot in Config.SnapshotDir.
+	Name string
+}
+
+// SnapshotDiffResponse is the response of '/v1/snapshots/diff'.
+type SnapshotDiffResponse struct {
+	Result
+	Diff snapshot.Diff
+}
+
+// EventsResponse is the response of '/v1/events'.
+type EventsResponse struct {
+	Result
+	Events []cluster.Event
+}
+
+// ChaosResponse is the response of '/v1/chaos'.
+type ChaosResponse struct {
+	Result
+	Entries []chaos.Status
+}
+
+// AuditResponse is the response of '/v1/audit'.
+type AuditResponse struct {
+	Result
+	Entries []audit.Entry
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tutorial
+
+import "fmt"
+
+// HasLeader passes when a started member is the leader.
+func HasLeader() Check {
+	return func(start, now State) error {
+		if now.Leader() == "" {
+			return fmt.Errorf("no member is the leader yet")
+		}
+		return nil
+	}
+}
+
+// LeaderStopped passes when the leader at the start of the step is stopped.
+func LeaderStopped() Check {
+	return func(start, now State) error {
+		lead := start.Leader()
+		if lead == "" {
+			return fmt.Errorf("there was no leader to stop")
+		}
+		if !now.Stopped(lead) {
+			return fmt.Errorf("the leader %q is still running", lead)
+		}
+		return nil
+	}
+}
+
+// NewLeaderElected passes when a started member other than the
+// stopped members at the start of the step is the leader.
+func NewLeaderElected() Check {
+	return func(start, now State) error {
+		lead := now.Leader()
+		if lead == "" {
+			return fmt.Errorf("no member is the leader yet (election in progress?)")
+		}
+		if start.Stopped(lead) {
+			return fmt.Errorf("%q was stopped, expected another leader", lead)
+		}
+		return nil
+	}
+}
+
+// QuorumLost passes when fewer members than a quorum are started.
+func QuorumLost() Check {
+	return func(start, now State) error {
+		if a, q := now.Active(), now.Quorum(); a >= q {
+			return fmt.Errorf("%d members are started (quorum %d), stop %d more", a, q, a-q+1)
+		}
+		return nil
+	}
+}
+
+// QuorumRestored passes when a quorum of members is started and there is a leader.
+func QuorumRestored() Check {
+	return func(start, now State) error {
+		if a, q := now.Active(), now.Quorum(); a < q {
+			return fmt.Errorf("%d members are started (quorum %d), restart %d more", a, q, q-a)
+		}
+		if now.Leader() == "" {
+			return fmt.Errorf("quorum is back, waiting for a leader")
+		}
+		return nil
+	}
+}
+
+// AllStarted passes when every member is started.
+func AllStarted() Check {
+	return func(start, now State) error {
+		if a := now.Active(); a < len(now.Members) {
+			return fmt.Errorf("%d of %d members are stopped", len(now.Members)-a, len(now.Members))
+		}
+		return nil
+	}
+}
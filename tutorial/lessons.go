@@ -0,0 +1,73 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tutorial
+
+// DefaultLessons are the built-in lessons.
+var DefaultLessons = []Lesson{
+	{
+		ID:    "leader-election",
+		Title: "Kill the leader and watch re-election",
+		Steps: []Step{
+			{
+				Title:       "Find the leader",
+				Action:      "Look at the member statuses, and find the member whose state is 'Leader'.",
+				Explanation: "Every write goes through the leader, which replicates it to the followers.",
+				Check:       HasLeader(),
+			},
+			{
+				Title:       "Stop the leader",
+				Action:      "Stop the member that is the leader.",
+				Explanation: "The followers stop hearing heartbeats from the leader. Once the election timeout passes, they start an election.",
+				Check:       LeaderStopped(),
+			},
+			{
+				Title:       "Watch the re-election",
+				Action:      "Wait until one of the remaining members becomes the leader.",
+				Explanation: "A follower became a candidate, won the votes of a quorum, and is the new leader of a new term.",
+				Check:       NewLeaderElected(),
+			},
+			{
+				Title:       "Restart the old leader",
+				Action:      "Restart the stopped member.",
+				Explanation: "The old leader rejoins as a follower of the new term, and catches up with the writes it missed.",
+				Check:       AllStarted(),
+			},
+		},
+	},
+	{
+		ID:    "quorum-loss",
+		Title: "Lose and restore quorum",
+		Steps: []Step{
+			{
+				Title:       "Lose quorum",
+				Action:      "Stop members until fewer than a quorum (a majority) are running.",
+				Explanation: "Without a quorum, no leader can be elected and writes cannot be committed, so the cluster is unavailable.",
+				Check:       QuorumLost(),
+			},
+			{
+				Title:       "Restore quorum",
+				Action:      "Restart members until a quorum is running.",
+				Explanation: "A majority of members can elect a leader again, and the cluster serves writes.",
+				Check:       QuorumRestored(),
+			},
+			{
+				Title:       "Restart every member",
+				Action:      "Restart the remaining stopped members.",
+				Explanation: "Every member is back, so the cluster tolerates the failure of a minority again.",
+				Check:       AllStarted(),
+			},
+		},
+	},
+}
@@ -0,0 +1,200 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tutorial serves guided lessons on a cluster. Each lesson is a
+// sequence of steps with an explanation, the action the user is expected
+// to take, and a check of the cluster state that verifies the step.
+package tutorial
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+)
+
+// State is a snapshot of the cluster state.
+type State struct {
+	Time    time.Time
+	Members []clusterpb.MemberStatus
+}
+
+// NewState returns the state of the members
+// (e.g. from cluster.Cluster.AllMemberStatus).
+func NewState(members []clusterpb.MemberStatus) State {
+	return State{Time: time.Now(), Members: members}
+}
+
+// Leader returns the name of the started leader, or "" if there is none.
+func (st State) Leader() string {
+	for _, m := range st.Members {
+		if m.IsLeader && m.State != clusterpb.StoppedMemberStatus {
+			return m.Name
+		}
+	}
+	return ""
+}
+
+// Stopped returns true if the member is stopped.
+func (st State) Stopped(name string) bool {
+	for _, m := range st.Members {
+		if m.Name == name {
+			return m.State == clusterpb.StoppedMemberStatus
+		}
+	}
+	return false
+}
+
+// Active returns the number of started members.
+func (st State) Active() (n int) {
+	for _, m := range st.Members {
+		if m.State != clusterpb.StoppedMemberStatus {
+			n++
+		}
+	}
+	return n
+}
+
+// Quorum returns the size of quorum.
+func (st State) Quorum() int {
+	return len(st.Members)/2 + 1
+}
+
+// Check verifies a step, given the state when the step began and the
+// current state. It returns an error that hints at what is missing.
+type Check func(start, now State) error
+
+// Step is a step of a lesson.
+type Step struct {
+	Title string
+	// Explanation is shown once the step passes.
+	Explanation string
+	// Action is what the user is expected to do.
+	Action string
+	Check  Check `json:"-"`
+}
+
+// Lesson is an ordered sequence of steps.
+type Lesson struct {
+	ID    string
+	Title string
+	Steps []Step
+}
+
+// Progress is the progress of a user through a lesson.
+type Progress struct {
+	Lesson string
+	// Step is the index of the current step.
+	Step int
+	// Start is the state when the current step began.
+	Start State
+	Done  bool
+}
+
+// Result is the result of verifying a step.
+type Result struct {
+	Progress Progress
+	Passed   bool
+	// Message explains why the step passed or failed.
+	Message string
+	// Next is the next step, if the lesson is not done.
+	Next *Step `json:",omitempty"`
+}
+
+// Engine serves lessons.
+type Engine struct {
+	lessons []Lesson
+	index   map[string]int
+}
+
+// NewEngine returns an engine with the lessons (DefaultLessons if none).
+func NewEngine(lessons ...Lesson) (*Engine, error) {
+	if len(lessons) == 0 {
+		lessons = DefaultLessons
+	}
+	e := &Engine{lessons: lessons, index: make(map[string]int)}
+	for i, l := range lessons {
+		if _, ok := e.index[l.ID]; ok {
+			return nil, fmt.Errorf("duplicate lesson %q", l.ID)
+		}
+		if len(l.Steps) == 0 {
+			return nil, fmt.Errorf("lesson %q has no steps", l.ID)
+		}
+		for j, s := range l.Steps {
+			if s.Check == nil {
+				return nil, fmt.Errorf("step %d of lesson %q has no check", j+1, l.ID)
+			}
+		}
+		e.index[l.ID] = i
+	}
+	return e, nil
+}
+
+// Lessons returns the lessons.
+func (e *Engine) Lessons() []Lesson {
+	return e.lessons
+}
+
+// Lesson returns the lesson with the ID.
+func (e *Engine) Lesson(id string) (Lesson, error) {
+	i, ok := e.index[id]
+	if !ok {
+		return Lesson{}, fmt.Errorf("unknown lesson %q", id)
+	}
+	return e.lessons[i], nil
+}
+
+// Start starts the lesson at the cluster state.
+func (e *Engine) Start(id string, st State) (Result, error) {
+	l, err := e.Lesson(id)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Progress: Progress{Lesson: id, Start: st},
+		Message:  fmt.Sprintf("started %q", l.Title),
+		Next:     &l.Steps[0],
+	}, nil
+}
+
+// Verify checks the current step of the progress against the cluster
+// state, and advances to the next step if it passes.
+func (e *Engine) Verify(p Progress, now State) (Result, error) {
+	l, err := e.Lesson(p.Lesson)
+	if err != nil {
+		return Result{}, err
+	}
+	if p.Done {
+		return Result{Progress: p, Passed: true, Message: fmt.Sprintf("%q is done", l.Title)}, nil
+	}
+	if p.Step < 0 || p.Step >= len(l.Steps) {
+		return Result{}, fmt.Errorf("lesson %q has no step %d", p.Lesson, p.Step+1)
+	}
+
+	s := l.Steps[p.Step]
+	if err = s.Check(p.Start, now); err != nil {
+		return Result{Progress: p, Message: err.Error(), Next: &l.Steps[p.Step]}, nil
+	}
+
+	r := Result{Passed: true, Message: s.Explanation}
+	p.Step++
+	p.Start = now
+	if p.Step == len(l.Steps) {
+		p.Done = true
+	} else {
+		r.Next = &l.Steps[p.Step]
+	}
+	r.Progress = p
+	return r, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tutorial
+
+import (
+	"testing"
+
+	"github.com/coreos/etcdlabs/cluster/clusterpb"
+)
+
+func state(leader string, stopped ...string) State {
+	var ms []clusterpb.MemberStatus
+	for _, name := range []string{"node1", "node2", "node3"} {
+		m := clusterpb.MemberStatus{Name: name, State: clusterpb.FollowerMemberStatus}
+		if name == leader {
+			m.IsLeader, m.State = true, clusterpb.LeaderMemberStatus
+		}
+		for _, s := range stopped {
+			if name == s {
+				m.IsLeader, m.State = false, clusterpb.StoppedMemberStatus
+			}
+		}
+		ms = append(ms, m)
+	}
+	return NewState(ms)
+}
+
+func TestLeaderElection(t *testing.T) {
+	e, err := NewEngine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := e.Start("leader-election", state("node1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		now  State
+		pass bool
+	}{
+		{state(""), false},
+		{state("node1"), true},
+		{state("node1", "node2"), false}, // stopped a follower
+		{state("", "node1"), true},
+		{state("", "node1"), false}, // election in progress
+		{state("node2", "node1"), true},
+		{state("node2", "node1"), false},
+		{state("node2"), true},
+	}
+	for i, s := range steps {
+		if r, err = e.Verify(r.Progress, s.now); err != nil {
+			t.Fatal(err)
+		}
+		if r.Passed != s.pass {
+			t.Fatalf("#%d: expected passed %v, got %v (%s)", i, s.pass, r.Passed, r.Message)
+		}
+	}
+	if !r.Progress.Done || r.Next != nil {
+		t.Fatalf("expected the lesson to be done, got %+v", r.Progress)
+	}
+}
+
+func TestQuorumChecks(t *testing.T) {
+	if err := QuorumLost()(State{}, state("node1", "node2")); err == nil {
+		t.Fatal("expected quorum with 2 of 3 members")
+	}
+	if err := QuorumLost()(State{}, state("", "node2", "node3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := QuorumRestored()(State{}, state("", "node3")); err == nil {
+		t.Fatal("expected no leader to fail")
+	}
+	if err := QuorumRestored()(State{}, state("node1", "node3")); err != nil {
+		t.Fatal(err)
+	}
+}